@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// FCMSender Firebase Cloud Messaging でプッシュ通知を送る
+type FCMSender struct {
+	client *messaging.Client
+}
+
+// NewFCMSender 認証と同じ Firebase アプリで Messaging クライアントを作る
+func NewFCMSender(fa *FirebaseAdapter) (*FCMSender, error) {
+	if fa == nil || fa.App == nil {
+		return nil, fmt.Errorf("firebase app is not initialized")
+	}
+	client, err := fa.App.Messaging(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &FCMSender{client: client}, nil
+}
+
+func (s *FCMSender) Name() string {
+	return "fcm"
+}
+
+// Send 端末に通知を送る。失効したトークンは ErrInvalidPushToken を返す
+func (s *FCMSender) Send(ctx context.Context, msg PushMessage) error {
+	_, err := s.client.Send(ctx, &messaging.Message{
+		Token: msg.Token,
+		Notification: &messaging.Notification{
+			Title: msg.Title,
+			Body:  msg.Body,
+		},
+		Data: msg.Data,
+	})
+	if err != nil && (messaging.IsUnregistered(err) || messaging.IsSenderIDMismatch(err)) {
+		return fmt.Errorf("%w: %v", ErrInvalidPushToken, err)
+	}
+	return err
+}
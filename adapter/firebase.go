@@ -11,6 +11,7 @@ import (
 )
 
 type FirebaseAdapter struct {
+	App        *firebase.App
 	AuthClient *auth.Client
 }
 
@@ -45,7 +46,7 @@ func NewFirebaseAdapter() *FirebaseAdapter {
 		log.Fatalf("Firebase Auth初期化エラー: %v", err)
 	}
 
-	return &FirebaseAdapter{AuthClient: authClient}
+	return &FirebaseAdapter{App: app, AuthClient: authClient}
 }
 
 // VerifyToken はIDトークンを検証し、トークン情報を返す
@@ -0,0 +1,53 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogPushSender ローカル開発用。プッシュ通知を送らずにログかファイルへ書き出す
+type LogPushSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogPushSender path が空ならログに、指定があればそのファイルに JSON Lines で追記する
+func NewLogPushSender(path string) *LogPushSender {
+	return &LogPushSender{path: path}
+}
+
+func (s *LogPushSender) Name() string {
+	return "log"
+}
+
+func (s *LogPushSender) Send(ctx context.Context, msg PushMessage) error {
+	line, err := json.Marshal(struct {
+		SentAt string            `json:"sent_at"`
+		Token  string            `json:"token"`
+		Title  string            `json:"title"`
+		Body   string            `json:"body"`
+		Data   map[string]string `json:"data,omitempty"`
+	}{time.Now().Format(time.RFC3339), msg.Token, msg.Title, msg.Body, msg.Data})
+	if err != nil {
+		return err
+	}
+	if s.path == "" {
+		log.Printf("[push] %s", line)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open push log: %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\n", line)
+	return err
+}
@@ -0,0 +1,44 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+)
+
+// ErrInvalidPushToken 端末トークンが失効・不正で、今後も届かない
+var ErrInvalidPushToken = errors.New("push token is no longer valid")
+
+// PushMessage 端末1台に送るプッシュ通知
+type PushMessage struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushSender プッシュ通知の送信先。PUSH_TRANSPORT で切り替える
+type PushSender interface {
+	Name() string
+	Send(ctx context.Context, msg PushMessage) error
+}
+
+// NewPushSender PUSH_TRANSPORT に応じた送信先を返す
+// fcm（既定）: Firebase Cloud Messaging / log: ログに出力（PUSH_LOG_FILE を指定するとJSON Linesでファイルに追記）
+func NewPushSender(fa *FirebaseAdapter) PushSender {
+	switch transport := os.Getenv("PUSH_TRANSPORT"); transport {
+	case "log":
+		return NewLogPushSender(os.Getenv("PUSH_LOG_FILE"))
+	case "", "fcm":
+		sender, err := NewFCMSender(fa)
+		if err != nil {
+			log.Printf("FCM初期化エラー、ログ出力に切り替えます: %v", err)
+			return NewLogPushSender(os.Getenv("PUSH_LOG_FILE"))
+		}
+		return sender
+	default:
+		log.Printf("不明な PUSH_TRANSPORT です、ログ出力に切り替えます: %s", transport)
+		return NewLogPushSender(os.Getenv("PUSH_LOG_FILE"))
+	}
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -8,16 +9,18 @@ import (
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/requests"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"github.com/trihackathon/api/utils"
 	"gorm.io/gorm"
 )
 
 type ActivityController struct {
-	db *gorm.DB
+	db           *gorm.DB
+	nudgeService *service.NudgeService
 }
 
-func NewActivityController(db *gorm.DB) *ActivityController {
-	return &ActivityController{db: db}
+func NewActivityController(db *gorm.DB, nudgeService *service.NudgeService) *ActivityController {
+	return &ActivityController{db: db, nudgeService: nudgeService}
 }
 
 // StartRunning ランニング開始
@@ -198,6 +201,14 @@ func (ctrl *ActivityController) FinishRunning(c echo.Context) error {
 		})
 	}
 
+	// 今日まだ運動していないチームメイトに声をかける（失敗しても完了処理は成功させる）
+	if _, err := ctrl.nudgeService.AfterActivity(activity, now); err != nil {
+		log.Printf("[FinishRunning] failed to nudge teammates: %v", err)
+	}
+	if err := service.EmitActivityCompleted(ctrl.db, activity); err != nil {
+		log.Printf("[FinishRunning] failed to emit webhook: %v", err)
+	}
+
 	// GPSポイントも含めてレスポンス
 	return c.JSON(http.StatusOK, toActivityResponse(activity, allPoints))
 }
@@ -496,14 +507,6 @@ func (ctrl *ActivityController) PostActivityReview(c echo.Context) error {
 		})
 	}
 
-	// 自分のアクティビティにはレビューできない
-	if activity.UserID == uid {
-		return c.JSON(http.StatusForbidden, response.ErrorResponse{
-			Error:   "cannot_review_own",
-			Message: "自分のアクティビティにはレビューできません",
-		})
-	}
-
 	// チームメンバー確認
 	if activity.TeamID == nil {
 		return c.JSON(http.StatusForbidden, response.ErrorResponse{
@@ -520,63 +523,58 @@ func (ctrl *ActivityController) PostActivityReview(c echo.Context) error {
 		})
 	}
 
-	// 既存レビューチェック（あればUPDATE、なければINSERT）
-	var existingReview models.ActivityReview
-	err := ctrl.db.Where("activity_id = ? AND reviewer_id = ?", activityId, uid).First(&existingReview).Error
-
-	if err == nil {
-		// 既存レビューを更新
-		existingReview.Status = req.Status
-		existingReview.Comment = req.Comment
-		if err := ctrl.db.Save(&existingReview).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-				Error:   "update_failed",
-				Message: "レビューの更新に失敗しました",
-			})
-		}
-	} else {
-		// 新規レビューを作成
-		existingReview = models.ActivityReview{
-			ID:         utils.GenerateULID(),
-			ActivityID: activityId,
-			ReviewerID: uid,
-			Status:     req.Status,
-			Comment:    req.Comment,
-		}
-		if err := ctrl.db.Create(&existingReview).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-				Error:   "create_failed",
-				Message: "レビューの作成に失敗しました",
-			})
-		}
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", *activity.TeamID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
 	}
 
-	// Activity の review_status を更新
-	if req.Status == "rejected" {
-		ctrl.db.Model(&activity).Update("review_status", "rejected")
-	} else {
-		// approved の場合、他に rejected がなければ approved に
-		var rejectedCount int64
-		ctrl.db.Model(&models.ActivityReview{}).
-			Where("activity_id = ? AND status = ?", activityId, "rejected").
-			Count(&rejectedCount)
-		if rejectedCount == 0 {
-			ctrl.db.Model(&activity).Update("review_status", "approved")
-		}
+	var review *models.ActivityReview
+	var tally service.ReviewTally
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		review, tally, err = service.RecordReview(tx, team, &activity, uid, service.ReviewInput{
+			Status:         req.Status,
+			ReasonCategory: req.ReasonCategory,
+			Comment:        req.Comment,
+		}, time.Now())
+		return err
+	})
+	if errResp := reviewErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
 	}
 
 	// レスポンス用にレビュアー情報を取得
 	var reviewer models.User
 	ctrl.db.First(&reviewer, "id = ?", uid)
 
+	if err := service.EmitTeamEvent(ctrl.db, *activity.TeamID, "review.posted", map[string]interface{}{
+		"review_id":        review.ID,
+		"activity_id":      activity.ID,
+		"activity_user_id": activity.UserID,
+		"reviewer_id":      uid,
+		"reviewer_name":    reviewer.Name,
+		"status":           review.Status,
+		"reason_category":  review.ReasonCategory,
+		"comment":          review.Comment,
+		"review_status":    activity.ReviewStatus,
+	}); err != nil {
+		log.Printf("[PostActivityReview] failed to emit webhook: %v", err)
+	}
+
+	tallyResp := toReviewTallyResponse(team, activity, tally, nil, time.Now())
 	return c.JSON(http.StatusOK, response.ActivityReviewResponse{
-		ID:           existingReview.ID,
-		ActivityID:   existingReview.ActivityID,
-		ReviewerID:   existingReview.ReviewerID,
-		ReviewerName: reviewer.Name,
-		Status:       existingReview.Status,
-		Comment:      existingReview.Comment,
-		CreatedAt:    existingReview.CreatedAt.Format(time.RFC3339),
+		ID:             review.ID,
+		ActivityID:     review.ActivityID,
+		ReviewerID:     review.ReviewerID,
+		ReviewerName:   reviewer.Name,
+		Status:         review.Status,
+		ReasonCategory: review.ReasonCategory,
+		Comment:        review.Comment,
+		Tally:          &tallyResp,
+		CreatedAt:      review.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -624,13 +622,14 @@ func (ctrl *ActivityController) GetActivityReviews(c echo.Context) error {
 	responses := make([]response.ActivityReviewResponse, len(reviews))
 	for i, review := range reviews {
 		responses[i] = response.ActivityReviewResponse{
-			ID:           review.ID,
-			ActivityID:   review.ActivityID,
-			ReviewerID:   review.ReviewerID,
-			ReviewerName: review.Reviewer.Name,
-			Status:       review.Status,
-			Comment:      review.Comment,
-			CreatedAt:    review.CreatedAt.Format(time.RFC3339),
+			ID:             review.ID,
+			ActivityID:     review.ActivityID,
+			ReviewerID:     review.ReviewerID,
+			ReviewerName:   review.Reviewer.Name,
+			Status:         review.Status,
+			ReasonCategory: review.ReasonCategory,
+			Comment:        review.Comment,
+			CreatedAt:      review.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
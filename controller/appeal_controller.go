@@ -0,0 +1,458 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+)
+
+type AppealController struct {
+	db            *gorm.DB
+	appealService *service.AppealService
+}
+
+func NewAppealController(db *gorm.DB, appealService *service.AppealService) *AppealController {
+	return &AppealController{db: db, appealService: appealService}
+}
+
+// FileAppeal 否認に異議を申し立てる
+// @Summary      否認に異議を申し立てる
+// @Description  否認された自分のアクティビティに説明か証拠URLを添えて異議を申し立て、本人以外のメンバーの投票を始める。申し立てはアクティビティの翌週の終わりまで、1つのアクティビティに1回だけ。週次評価でHPが尽きて解散・最終週を終えて完走したチームは、その最後に評価された週のアクティビティに限り申し立てられる（投票や人数不足で解散したチームは不可）。投票期間は APPEAL_VOTING_HOURS（既定72時間）で、チームの review_quorum に届けば決着する
+// @Tags         appeals
+// @Accept       json
+// @Produce      json
+// @Param        activityId  path      string                      true  "アクティビティID"
+// @Param        body        body      requests.FileAppealRequest  true  "申し立ての内容"
+// @Success      201         {object}  response.ActivityAppealResponse
+// @Failure      400         {object}  response.ErrorResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Failure      409         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/appeal [post]
+// @Security     BearerAuth
+func (ctrl *AppealController) FileAppeal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	req := new(requests.FileAppealRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	activity, team, errResp := ctrl.findActivityTeam(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var appeal *models.ActivityAppeal
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		appeal, err = ctrl.appealService.FileAppeal(tx, team, activity, uid, req.Explanation, req.EvidenceURL, time.Now())
+		return err
+	})
+	if errResp := appealErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	resp, err := ctrl.appealResponse(team, *appeal)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "異議申し立ての取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// GetAppeal 異議申し立ての詳細
+// @Summary      異議申し立ての詳細
+// @Description  アクティビティへの異議申し立てと、投票・集計を返す
+// @Tags         appeals
+// @Produce      json
+// @Param        activityId  path      string  true  "アクティビティID"
+// @Success      200         {object}  response.ActivityAppealResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/appeal [get]
+// @Security     BearerAuth
+func (ctrl *AppealController) GetAppeal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	activity, team, errResp := ctrl.findActivityTeam(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	appeal, err := service.AppealFor(ctrl.db, activity.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "異議申し立ての取得に失敗しました",
+		})
+	}
+	if appeal == nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "appeal_not_found",
+			Message: "異議申し立てが見つかりません",
+		})
+	}
+
+	resp, err := ctrl.appealResponse(team, *appeal)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "異議申し立ての取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// VoteAppeal 異議申し立てに投票
+// @Summary      異議申し立てに投票
+// @Description  アクティビティを認めるか（approve）を投票する。投票し直すと上書きされる。認める票が review_quorum に届けば否認を取り消し、評価済みの週なら週次評価とチームHPを評価し直す
+// @Tags         appeals
+// @Accept       json
+// @Produce      json
+// @Param        activityId  path      string                      true  "アクティビティID"
+// @Param        body        body      requests.AppealVoteRequest  true  "投票"
+// @Success      200         {object}  response.ActivityAppealResponse
+// @Failure      400         {object}  response.ErrorResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Failure      409         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/appeal/votes [post]
+// @Security     BearerAuth
+func (ctrl *AppealController) VoteAppeal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	req := new(requests.AppealVoteRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	if len([]rune(req.Comment)) > service.MaxCommentLength {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("comment は%d文字以内で入力してください", service.MaxCommentLength),
+		})
+	}
+
+	activity, team, errResp := ctrl.findActivityTeam(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	appeal, err := service.AppealFor(ctrl.db, activity.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "異議申し立ての取得に失敗しました",
+		})
+	}
+	if appeal == nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "appeal_not_found",
+			Message: "異議申し立てが見つかりません",
+		})
+	}
+
+	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return ctrl.appealService.Vote(tx, team, appeal, uid, req.Approve, req.Comment, time.Now())
+	})
+	if errResp := appealErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	resp, err := ctrl.appealResponse(team, *appeal)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "異議申し立ての取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetTeamAppeals チームの異議申し立て一覧
+// @Summary      チームの異議申し立て一覧
+// @Description  チームの異議申し立てを新しい順に返す。status（open / overturned / upheld）で絞り込める
+// @Tags         appeals
+// @Produce      json
+// @Param        teamId  path      string  true   "チームID"
+// @Param        status  query     string  false  "open / overturned / upheld"
+// @Success      200     {array}   response.ActivityAppealResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/appeals [get]
+// @Security     BearerAuth
+func (ctrl *AppealController) GetTeamAppeals(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	query := ctrl.db.Where("team_id = ?", teamId)
+	if status := c.QueryParam("status"); status != "" {
+		if status != "open" && status != "overturned" && status != "upheld" {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "status は open / overturned / upheld のいずれかを指定してください",
+			})
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var appeals []models.ActivityAppeal
+	if err := query.Order("created_at DESC").Find(&appeals).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "異議申し立ての取得に失敗しました",
+		})
+	}
+
+	result := make([]response.ActivityAppealResponse, len(appeals))
+	for i, a := range appeals {
+		resp, err := ctrl.appealResponse(team, a)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Error:   "fetch_failed",
+				Message: "異議申し立ての取得に失敗しました",
+			})
+		}
+		result[i] = resp
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetEvaluationAdjustments 週次評価の修正履歴
+// @Summary      週次評価の修正履歴
+// @Description  異議申し立てが通って評価済みの週を評価し直した記録（変更前後の達成判定・合計・HP）を新しい順に返す
+// @Tags         evaluations
+// @Produce      json
+// @Param        teamId  path      string  true   "チームID"
+// @Param        week    query     int     false  "週番号で絞り込む"
+// @Success      200     {array}   response.EvaluationAdjustmentResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/evaluations/adjustments [get]
+// @Security     BearerAuth
+func (ctrl *AppealController) GetEvaluationAdjustments(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	query := ctrl.db.Where("team_id = ?", teamId)
+	if w := c.QueryParam("week"); w != "" {
+		week, err := strconv.Atoi(w)
+		if err != nil || week < 1 {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "week は1以上の整数で指定してください",
+			})
+		}
+		query = query.Where("week_number = ?", week)
+	}
+
+	var adjustments []models.EvaluationAdjustment
+	if err := query.Order("created_at DESC").Find(&adjustments).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "評価の修正履歴の取得に失敗しました",
+		})
+	}
+
+	result := make([]response.EvaluationAdjustmentResponse, len(adjustments))
+	for i, a := range adjustments {
+		result[i] = response.NewEvaluationAdjustmentResponse(a)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// findActivityTeam アクティビティと、その所属チームを取得する。チームのメンバーでなければエラー
+func (ctrl *AppealController) findActivityTeam(activityId, uid string) (models.Activity, models.Team, *errorReply) {
+	var activity models.Activity
+	if err := ctrl.db.First(&activity, "id = ?", activityId).Error; err != nil {
+		return activity, models.Team{}, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "activity_not_found",
+			Message: "アクティビティが見つかりません",
+		}}
+	}
+	if activity.TeamID == nil {
+		return activity, models.Team{}, &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "no_team",
+			Message: "チームに紐づいていないアクティビティです",
+		}}
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", *activity.TeamID, uid).Error; err != nil {
+		return activity, models.Team{}, &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		}}
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", *activity.TeamID).Error; err != nil {
+		return activity, team, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		}}
+	}
+	return activity, team, nil
+}
+
+// appealResponse 申し立てに投票と集計を添えてレスポンスにする
+func (ctrl *AppealController) appealResponse(team models.Team, appeal models.ActivityAppeal) (response.ActivityAppealResponse, error) {
+	tally, err := service.TallyAppeal(ctrl.db, team, appeal)
+	if err != nil {
+		return response.ActivityAppealResponse{}, err
+	}
+
+	var owner models.User
+	ctrl.db.Select("name").First(&owner, "id = ?", appeal.UserID)
+
+	var votes []models.AppealVote
+	if err := ctrl.db.Preload("User").Where("appeal_id = ?", appeal.ID).Order("created_at ASC").Find(&votes).Error; err != nil {
+		return response.ActivityAppealResponse{}, err
+	}
+	voteResponses := make([]response.AppealVoteResponse, len(votes))
+	for i, v := range votes {
+		voteResponses[i] = response.AppealVoteResponse{
+			UserID:    v.UserID,
+			UserName:  v.User.Name,
+			Approve:   v.Approve,
+			Comment:   v.Comment,
+			CreatedAt: v.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	var resolvedAt *string
+	if appeal.ResolvedAt != nil {
+		s := appeal.ResolvedAt.Format(time.RFC3339)
+		resolvedAt = &s
+	}
+
+	return response.ActivityAppealResponse{
+		ID:            appeal.ID,
+		ActivityID:    appeal.ActivityID,
+		TeamID:        appeal.TeamID,
+		UserID:        appeal.UserID,
+		UserName:      owner.Name,
+		WeekNumber:    appeal.WeekNumber,
+		Explanation:   appeal.Explanation,
+		EvidenceURL:   appeal.EvidenceURL,
+		Status:        appeal.Status,
+		Resolution:    appeal.Resolution,
+		Recalculated:  appeal.Recalculated,
+		EligibleCount: tally.Eligible,
+		Quorum:        tally.Quorum,
+		Approvals:     tally.Approvals,
+		Rejections:    tally.Rejections,
+		Votes:         voteResponses,
+		ExpiresAt:     appeal.ExpiresAt.Format(time.RFC3339),
+		ResolvedAt:    resolvedAt,
+		CreatedAt:     appeal.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+func appealErrorResponse(err error) *errorReply {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrNotActivityOwner):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_activity_owner",
+			Message: "自分のアクティビティのみ異議を申し立てられます",
+		}}
+	case errors.Is(err, service.ErrVoteOwnAppeal):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "cannot_vote_own_appeal",
+			Message: "自分の申し立てには投票できません",
+		}}
+	case errors.Is(err, service.ErrMemberNotFound):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		}}
+	case errors.Is(err, service.ErrAppealNeedsExplanation):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "explanation か evidence_url のどちらかを入力してください",
+		}}
+	case errors.Is(err, service.ErrAppealTooLong):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("explanation は%d文字以内で入力してください", service.MaxAppealExplanationLength),
+		}}
+	case errors.Is(err, service.ErrInvalidEvidenceURL):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "evidence_url は http または https のURLで指定してください",
+		}}
+	case errors.Is(err, service.ErrActivityNotRejected):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "activity_not_rejected",
+			Message: "否認されたアクティビティのみ異議を申し立てられます",
+		}}
+	case errors.Is(err, service.ErrAppealExists):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "appeal_exists",
+			Message: "このアクティビティには既に異議を申し立てています",
+		}}
+	case errors.Is(err, service.ErrAppealWindowClosed):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "appeal_window_closed",
+			Message: "このアクティビティへの異議申し立ての期限は過ぎています",
+		}}
+	case errors.Is(err, service.ErrNoAppealVoters):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "no_voters",
+			Message: "投票できるメンバーがいません",
+		}}
+	case errors.Is(err, service.ErrAppealClosed):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "appeal_closed",
+			Message: "この異議申し立ての投票は締め切られています",
+		}}
+	}
+	return &errorReply{http.StatusInternalServerError, response.ErrorResponse{
+		Error:   "appeal_failed",
+		Message: "異議申し立ての処理に失敗しました",
+	}}
+}
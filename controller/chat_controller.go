@@ -0,0 +1,338 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+)
+
+const (
+	// chatHeartbeatInterval SSE接続を保つためのコメントを送る間隔。送るたびにまだメンバーかも確かめる
+	chatHeartbeatInterval = 25 * time.Second
+	// chatReplayPageSize 再接続時に Last-Event-ID から送り直すとき、1回に取得する件数
+	chatReplayPageSize = 200
+)
+
+type ChatController struct {
+	db          *gorm.DB
+	chatService *service.ChatService
+}
+
+func NewChatController(db *gorm.DB, chatService *service.ChatService) *ChatController {
+	return &ChatController{db: db, chatService: chatService}
+}
+
+// member チームのメンバーを取得する
+func (ctrl *ChatController) member(teamId, uid string) (*models.TeamMember, *errorReply) {
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return nil, &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		}}
+	}
+	return &member, nil
+}
+
+// GetMessages チャットの履歴
+// @Summary      チャットの履歴
+// @Description  チームチャットのメッセージを古い順に返す。before を指定するとそれより前、after を指定するとそれより後のメッセージ（どちらもメッセージID）。省略すると最新の limit 件
+// @Tags         chat
+// @Produce      json
+// @Param        teamId  path      string  true   "チームID"
+// @Param        before  query     string  false  "このメッセージIDより前"
+// @Param        after   query     string  false  "このメッセージIDより後"
+// @Param        limit   query     int     false  "取得件数（1〜200、省略時50）"
+// @Success      200     {object}  response.ChatMessageListResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/chat/messages [get]
+// @Security     BearerAuth
+func (ctrl *ChatController) GetMessages(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if _, errResp := ctrl.member(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	before, after := c.QueryParam("before"), c.QueryParam("after")
+	if before != "" && after != "" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_cursor",
+			Message: "before と after は同時に指定できません",
+		})
+	}
+	limit := service.DefaultChatPageSize
+	if v, err := strconv.Atoi(c.QueryParam("limit")); err == nil && v >= 1 && v <= service.MaxChatPageSize {
+		limit = v
+	}
+
+	messages, hasMore, err := ctrl.chatService.History(teamId, before, after, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "メッセージの取得に失敗しました",
+		})
+	}
+
+	result := make([]response.ChatMessageResponse, len(messages))
+	for i, m := range messages {
+		result[i] = response.NewChatMessageResponse(m)
+	}
+	var next *string
+	if hasMore && len(messages) > 0 {
+		cursor := messages[0].ID
+		if after != "" {
+			cursor = messages[len(messages)-1].ID
+		}
+		next = &cursor
+	}
+
+	return c.JSON(http.StatusOK, response.ChatMessageListResponse{
+		TeamID:     teamId,
+		Messages:   result,
+		HasMore:    hasMore,
+		NextCursor: next,
+	})
+}
+
+// PostMessage チャットに投稿
+// @Summary      チャットに投稿
+// @Description  チームチャットにメッセージを投稿し、接続中のメンバーに配信する。forming / active のチームでのみ投稿できる。禁止語を含むメッセージや短時間の連投（1分あたり CHAT_RATE_LIMIT 件、既定20件）は拒否される
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                           true  "チームID"
+// @Param        body    body      requests.PostChatMessageRequest  true  "メッセージ"
+// @Success      201     {object}  response.ChatMessageResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Failure      422     {object}  response.ErrorResponse
+// @Failure      429     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/chat/messages [post]
+// @Security     BearerAuth
+func (ctrl *ChatController) PostMessage(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if _, errResp := ctrl.member(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	req := new(requests.PostChatMessageRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	msg, err := ctrl.chatService.PostMessage(team, uid, req.Body)
+	if errResp := chatErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return c.JSON(http.StatusCreated, response.NewChatMessageResponse(*msg))
+}
+
+// DeleteMessage チャットのメッセージを削除
+// @Summary      チャットのメッセージを削除
+// @Description  自分のメッセージ、またはリーダーならチームの誰のメッセージでも削除できる。本文は空になり、接続中のメンバーに message.deleted が配信される
+// @Tags         chat
+// @Produce      json
+// @Param        teamId     path      string  true  "チームID"
+// @Param        messageId  path      string  true  "メッセージID"
+// @Success      200        {object}  response.ChatMessageResponse
+// @Failure      403        {object}  response.ErrorResponse
+// @Failure      404        {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/chat/messages/{messageId} [delete]
+// @Security     BearerAuth
+func (ctrl *ChatController) DeleteMessage(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	member, errResp := ctrl.member(teamId, uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	msg, err := ctrl.chatService.DeleteMessage(teamId, c.Param("messageId"), *member)
+	if errResp := chatErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return c.JSON(http.StatusOK, response.NewChatMessageResponse(*msg))
+}
+
+// StreamMessages チャットのリアルタイム配信
+// @Summary      チャットのリアルタイム配信（SSE）
+// @Description  text/event-stream で message.created / message.deleted を配信する。data は ChatMessageResponse の JSON。EventSource はヘッダーを付けられないため、Accept: text/event-stream の接続に限り ?access_token= でも認証できる。再接続時は Last-Event-ID ヘッダー（または last_event_id クエリ）以降のメッセージをすべて送り直す。その間に削除されたメッセージは message.deleted で送る。チームを抜けると event: closed を送って切断する
+// @Tags         chat
+// @Produce      text/event-stream
+// @Param        teamId         path   string  true   "チームID"
+// @Param        access_token   query  string  false  "Authorization ヘッダーの代わりのIDトークン"
+// @Param        last_event_id  query  string  false  "このメッセージIDより後を送り直す"
+// @Success      200
+// @Failure      403  {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/chat/stream [get]
+// @Security     BearerAuth
+func (ctrl *ChatController) StreamMessages(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if _, errResp := ctrl.member(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	// 送り直しと購読開始の間に投稿されたメッセージを取りこぼさないよう、先に購読する
+	events, unsubscribe := ctrl.chatService.Subscribe(teamId)
+	defer unsubscribe()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	lastSent := c.Request().Header.Get("Last-Event-ID")
+	if lastSent == "" {
+		lastSent = c.QueryParam("last_event_id")
+	}
+	if lastSent != "" {
+		for hasMore := true; hasMore; {
+			var missed []models.ChatMessage
+			var err error
+			missed, hasMore, err = ctrl.chatService.History(teamId, "", lastSent, chatReplayPageSize)
+			if err != nil {
+				return nil
+			}
+			for _, m := range missed {
+				eventType := "message.created"
+				if m.DeletedAt != nil {
+					eventType = "message.deleted"
+				}
+				if err := writeChatEvent(res, eventType, m); err != nil {
+					return nil
+				}
+				lastSent = m.ID
+			}
+		}
+	}
+	fmt.Fprint(res, ": connected\n\n")
+	res.Flush()
+
+	ticker := time.NewTicker(chatHeartbeatInterval)
+	defer ticker.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				// 配信が追いつかず切断された。クライアントは Last-Event-ID で再接続する
+				return nil
+			}
+			if event.Type == "message.created" && event.Message.ID <= lastSent {
+				continue
+			}
+			if err := writeChatEvent(res, event.Type, event.Message); err != nil {
+				return nil
+			}
+			if event.Type == "message.created" {
+				lastSent = event.Message.ID
+			}
+		case <-ticker.C:
+			if _, errResp := ctrl.member(teamId, uid); errResp != nil {
+				fmt.Fprint(res, "event: closed\ndata: {\"reason\":\"not_team_member\"}\n\n")
+				res.Flush()
+				return nil
+			}
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// writeChatEvent SSEのイベントを1件書き込む。message.created にはメッセージIDを id として付け、再接続時の Last-Event-ID にする
+func writeChatEvent(res *echo.Response, eventType string, msg models.ChatMessage) error {
+	data, err := json.Marshal(response.NewChatMessageResponse(msg))
+	if err != nil {
+		return err
+	}
+	if eventType == "message.created" {
+		if _, err := fmt.Fprintf(res, "id: %s\n", msg.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", eventType, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+func chatErrorResponse(err error) *errorReply {
+	var rejected *service.ChatRejectedError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &rejected):
+		if rejected.Reason == "rate_limited" {
+			return &errorReply{http.StatusTooManyRequests, response.ErrorResponse{
+				Error:   "rate_limited",
+				Message: "短時間に投稿しすぎています。しばらくしてから投稿してください",
+			}}
+		}
+		return &errorReply{http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "message_rejected",
+			Message: "このメッセージは投稿できません",
+		}}
+	case errors.Is(err, service.ErrInvalidChatMessage):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_message",
+			Message: fmt.Sprintf("body は1〜%d文字で入力してください", service.MaxChatMessageLength),
+		}}
+	case errors.Is(err, service.ErrChatClosed):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "chat_closed",
+			Message: "終了したチームのチャットには投稿できません",
+		}}
+	case errors.Is(err, service.ErrChatMessageNotFound):
+		return &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "message_not_found",
+			Message: "メッセージが見つかりません",
+		}}
+	case errors.Is(err, service.ErrChatMessageDeleted):
+		return &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "message_not_found",
+			Message: "メッセージは既に削除されています",
+		}}
+	case errors.Is(err, service.ErrChatDeleteForbidden):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_message_author",
+			Message: "自分のメッセージ、またはリーダーのみ削除できます",
+		}}
+	}
+	return &errorReply{http.StatusInternalServerError, response.ErrorResponse{
+		Error:   "chat_failed",
+		Message: "チャットの処理に失敗しました",
+	}}
+}
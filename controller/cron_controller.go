@@ -3,6 +3,7 @@ package controller
 import (
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/trihackathon/api/response"
@@ -10,11 +11,42 @@ import (
 )
 
 type CronController struct {
-	evaluationService *service.EvaluationService
+	evaluationService   *service.EvaluationService
+	inviteService       *service.InviteService
+	matchmakingService  *service.MatchmakingService
+	proposalService     *service.ProposalService
+	cleanupService      *service.TeamCleanupService
+	notificationService *service.NotificationService
+	nudgeService        *service.NudgeService
+	webhookService      *service.WebhookService
+	appealService       *service.AppealService
 }
 
-func NewCronController(evaluationService *service.EvaluationService) *CronController {
-	return &CronController{evaluationService: evaluationService}
+func NewCronController(evaluationService *service.EvaluationService, inviteService *service.InviteService, matchmakingService *service.MatchmakingService, proposalService *service.ProposalService, cleanupService *service.TeamCleanupService, notificationService *service.NotificationService, nudgeService *service.NudgeService, webhookService *service.WebhookService, appealService *service.AppealService) *CronController {
+	return &CronController{
+		evaluationService:   evaluationService,
+		inviteService:       inviteService,
+		matchmakingService:  matchmakingService,
+		proposalService:     proposalService,
+		cleanupService:      cleanupService,
+		notificationService: notificationService,
+		nudgeService:        nudgeService,
+		webhookService:      webhookService,
+		appealService:       appealService,
+	}
+}
+
+// TeamCleanupResult チームクリーンアップの実行結果
+type TeamCleanupResult struct {
+	FormingExpiry    *service.FormingExpiryResult    `json:"forming_expiry"`
+	DisbandedCleanup *service.DisbandedCleanupResult `json:"disbanded_cleanup"`
+}
+
+// authorized X-Cron-Secret ヘッダーを検証する
+func (ctrl *CronController) authorized(c echo.Context) bool {
+	secret := c.Request().Header.Get("X-Cron-Secret")
+	expectedSecret := os.Getenv("CRON_SECRET")
+	return expectedSecret != "" && secret == expectedSecret
 }
 
 // RunWeeklyEvaluation 週次評価実行
@@ -29,9 +61,7 @@ func NewCronController(evaluationService *service.EvaluationService) *CronContro
 // @Router       /cron/weekly-evaluation [post]
 func (ctrl *CronController) RunWeeklyEvaluation(c echo.Context) error {
 	// APIキー認証
-	secret := c.Request().Header.Get("X-Cron-Secret")
-	expectedSecret := os.Getenv("CRON_SECRET")
-	if expectedSecret == "" || secret != expectedSecret {
+	if !ctrl.authorized(c) {
 		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
 			Error:   "unauthorized",
 			Message: "Invalid or missing cron secret",
@@ -48,3 +78,242 @@ func (ctrl *CronController) RunWeeklyEvaluation(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, result)
 }
+
+// PurgeInviteCodes 期限切れ招待コード削除
+// @Summary      期限切れ招待コード削除
+// @Description  有効期限切れ・失効から24時間以上経過した招待コードと、不要になった参加試行制限を削除する
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.PurgeResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/purge-invite-codes [post]
+func (ctrl *CronController) PurgeInviteCodes(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.inviteService.PurgeExpiredCodes()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "purge_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// RunMatchmaking マッチング実行
+// @Summary      マッチング実行
+// @Description  マッチング待ちのユーザーを条件の近い3人ずつformingチームにまとめる
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.MatchmakingResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/matchmaking [post]
+func (ctrl *CronController) RunMatchmaking(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.matchmakingService.RunMatchmaking()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "matchmaking_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// ExpireProposals 期限切れ提案の締め切り
+// @Summary      期限切れ提案の締め切り
+// @Description  投票期限を過ぎた解散・設定変更の提案を締め切り、期限切れになった提案の投票を削除する
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.ProposalExpiryResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/expire-proposals [post]
+func (ctrl *CronController) ExpireProposals(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.proposalService.ExpireProposals()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "expire_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// RunTeamCleanup チームクリーンアップ
+// @Summary      チームクリーンアップ
+// @Description  期限（FORMING_TEAM_TTL_HOURS、既定7日）までに開始しなかったformingチームをexpiredにしてメンバーに通知し、解散から DISBANDED_TEAM_RETENTION_HOURS（既定14日）が経ったチームに残ったメンバーと投票を削除する
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  TeamCleanupResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/team-cleanup [post]
+func (ctrl *CronController) RunTeamCleanup(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	expiry, err := ctrl.cleanupService.ExpireFormingTeams()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "cleanup_failed",
+			Message: err.Error(),
+		})
+	}
+	cleanup, err := ctrl.cleanupService.CleanupDisbandedTeams()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "cleanup_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, TeamCleanupResult{FormingExpiry: expiry, DisbandedCleanup: cleanup})
+}
+
+// DispatchNotifications プッシュ通知の配信
+// @Summary      プッシュ通知の配信
+// @Description  送信待ちのプッシュ通知を PUSH_TRANSPORT（fcm / log）で送る。失敗したものは1分から倍々で最大1時間まで間隔を空けて再送し、PUSH_MAX_ATTEMPTS 回（既定5回）失敗したら諦める。失効した端末トークンは削除する
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.OutboxDispatchResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/dispatch-notifications [post]
+func (ctrl *CronController) DispatchNotifications(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.notificationService.DispatchOutbox()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "dispatch_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// RunNudges ナッジ（運動の声かけ）
+// @Summary      ナッジ（運動の声かけ）
+// @Description  activeチームのメンバーのうち、週の経過に対して進捗が遅れている人と、苦手な曜日（過去8週間で運動が途切れがちな曜日）にまだ運動していない人に声をかける。最終日に遅れているメンバーがいればチームメイトにも応援を頼む。朝型夜型に応じた静かな時間帯は送らず、同じルールは1日1回、全体で1日 NUDGE_DAILY_LIMIT 回（既定2回）まで。1時間ごとの実行を想定
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.NudgeResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/nudges [post]
+func (ctrl *CronController) RunNudges(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.nudgeService.RunNudges(time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "nudge_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// DispatchWebhooks Webhookの配信
+// @Summary      Webhookの配信
+// @Description  送信待ちのWebhookを送る。2xx 以外の応答・タイムアウトは30秒から倍々で最大6時間まで間隔を空けて再送し、WEBHOOK_MAX_ATTEMPTS 回（既定8回）失敗したら諦める。諦めた配信が続いたWebhookは無効にする
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.WebhookDispatchResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/dispatch-webhooks [post]
+func (ctrl *CronController) DispatchWebhooks(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.webhookService.DispatchDeliveries()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "dispatch_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// ResolveAppeals 期限切れの異議申し立ての締め切り
+// @Summary      期限切れの異議申し立ての締め切り
+// @Description  投票期限を過ぎた異議申し立てを締め切る。認める票が review_quorum に届いていなければ否認のままになる
+// @Tags         cron
+// @Produce      json
+// @Param        X-Cron-Secret  header  string  true  "Cronシークレットキー"
+// @Success      200  {object}  service.AppealExpiryResult
+// @Failure      401  {object}  response.ErrorResponse
+// @Failure      500  {object}  response.ErrorResponse
+// @Router       /cron/resolve-appeals [post]
+func (ctrl *CronController) ResolveAppeals(c echo.Context) error {
+	if !ctrl.authorized(c) {
+		return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Invalid or missing cron secret",
+		})
+	}
+
+	result, err := ctrl.appealService.ExpireAppeals()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "resolve_failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -3,25 +3,52 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/trihackathon/api/adapter"
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/requests"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"gorm.io/gorm"
 )
 
 type DebugController struct {
-	fa *adapter.FirebaseAdapter
-	db *gorm.DB
+	fa             *adapter.FirebaseAdapter
+	db             *gorm.DB
+	cleanupService *service.TeamCleanupService
+
+	// 開発用のWebhook受信口が受け取った最新 webhookReceiverLimit 件
+	receivedMu sync.Mutex
+	received   []ReceivedWebhook
+}
+
+// webhookReceiverLimit 開発用のWebhook受信口が保持する件数
+const webhookReceiverLimit = 50
+
+// webhookTimestampTolerance 署名の時刻がこれより古い・新しいWebhookはリプレイとみなす
+const webhookTimestampTolerance = 5 * time.Minute
+
+// ReceivedWebhook 開発用のWebhook受信口が受け取った1件
+type ReceivedWebhook struct {
+	ReceivedAt     time.Time       `json:"received_at"`
+	WebhookID      string          `json:"webhook_id"`
+	DeliveryID     string          `json:"delivery_id"`
+	Event          string          `json:"event"`
+	SignatureValid bool            `json:"signature_valid"`
+	Error          string          `json:"error,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
 }
 
-func NewDebugController(fa *adapter.FirebaseAdapter, db *gorm.DB) *DebugController {
-	return &DebugController{fa: fa, db: db}
+func NewDebugController(fa *adapter.FirebaseAdapter, db *gorm.DB, cleanupService *service.TeamCleanupService) *DebugController {
+	return &DebugController{fa: fa, db: db, cleanupService: cleanupService}
 }
 
 // @Summary Health check
@@ -151,7 +178,7 @@ func exchangeCustomTokenForIDToken(customToken, apiKey string) (string, error) {
 
 // CleanupDisbandedTeams 解散済みチームのメンバーと投票を削除
 // @Summary 解散済みチームのクリーンアップ
-// @Description 解散済み（disbanded）チームのteam_membersとdisband_votesレコードを削除（開発環境専用）
+// @Description 解散済み（disbanded）チームに残ったteam_membersとteam_proposal_votesレコードを削除（解散から DISBANDED_TEAM_RETENTION_HOURS 経過したチームのみ。開発環境専用。本番は /cron/team-cleanup で定期実行）
 // @Tags debug
 // @Produce json
 // @Success 200 {object} map[string]interface{}
@@ -165,63 +192,28 @@ func (ctrl *DebugController) CleanupDisbandedTeams(ctx echo.Context) error {
 		})
 	}
 
-	// 解散済みチームのIDを取得
-	var disbandedTeams []models.Team
-	if err := ctrl.db.Where("status = ?", "disbanded").Find(&disbandedTeams).Error; err != nil {
+	result, err := ctrl.cleanupService.CleanupDisbandedTeams()
+	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error:   "query_failed",
-			Message: fmt.Sprintf("チームの取得に失敗: %v", err),
+			Error:   "cleanup_failed",
+			Message: fmt.Sprintf("クリーンアップに失敗: %v", err),
 		})
 	}
 
-	teamIDs := make([]string, len(disbandedTeams))
-	for i, team := range disbandedTeams {
-		teamIDs[i] = team.ID
-	}
-
-	if len(teamIDs) == 0 {
+	if result.CleanedTeams == 0 {
 		return ctx.JSON(http.StatusOK, map[string]interface{}{
-			"message":         "解散済みチームはありません",
+			"message":         "クリーンアップが必要な解散済みチームはありません",
 			"deleted_votes":   0,
 			"deleted_members": 0,
 		})
 	}
 
-	// トランザクションでクリーンアップ
-	var deletedVotes int64
-	var deletedMembers int64
-
-	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
-		// 解散投票を削除
-		result := tx.Where("team_id IN ?", teamIDs).Delete(&models.DisbandVote{})
-		if result.Error != nil {
-			return result.Error
-		}
-		deletedVotes = result.RowsAffected
-
-		// チームメンバーを削除
-		result = tx.Where("team_id IN ?", teamIDs).Delete(&models.TeamMember{})
-		if result.Error != nil {
-			return result.Error
-		}
-		deletedMembers = result.RowsAffected
-
-		return nil
-	})
-
-	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error:   "cleanup_failed",
-			Message: fmt.Sprintf("クリーンアップに失敗: %v", err),
-		})
-	}
-
 	return ctx.JSON(http.StatusOK, map[string]interface{}{
 		"message":         "クリーンアップ完了",
-		"disbanded_teams": len(teamIDs),
-		"deleted_votes":   deletedVotes,
-		"deleted_members": deletedMembers,
-		"team_ids":        teamIDs,
+		"disbanded_teams": result.CleanedTeams,
+		"deleted_votes":   result.DeletedVotes,
+		"deleted_members": result.DeletedMembers,
+		"team_ids":        result.TeamIDs,
 	})
 }
 
@@ -295,3 +287,96 @@ func (ctrl *DebugController) GetUserTeamStatus(ctx echo.Context) error {
 		"can_create_new_team": len(activeMembers) == 0,
 	})
 }
+
+// ReceiveWebhook 開発用のWebhook受信口
+// @Summary Webhook受信口（開発用）
+// @Description チームのWebhookの送信先に http://localhost:8080/debug/webhook-receiver を登録すると、ここで受け取って X-Webhook-Signature を検証する。署名が正しくないか時刻が5分以上ずれていれば401を返す（開発環境専用）
+// @Tags debug
+// @Accept json
+// @Produce json
+// @Param X-Webhook-Id header string true "Webhook ID"
+// @Param X-Webhook-Timestamp header string true "送信時刻（Unix秒）"
+// @Param X-Webhook-Signature header string true "sha256=署名"
+// @Success 200 {object} ReceivedWebhook
+// @Failure 401 {object} ReceivedWebhook
+// @Failure 403 {object} response.ErrorResponse
+// @Router /debug/webhook-receiver [post]
+func (ctrl *DebugController) ReceiveWebhook(ctx echo.Context) error {
+	if os.Getenv("FLAVOR") != "dev" {
+		return ctx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "forbidden",
+			Message: "このエンドポイントは開発環境でのみ使用できます",
+		})
+	}
+
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "本文を読み込めません",
+		})
+	}
+
+	header := ctx.Request().Header
+	received := ReceivedWebhook{
+		ReceivedAt: time.Now(),
+		WebhookID:  header.Get(service.WebhookIDHeader),
+		DeliveryID: header.Get(service.WebhookDeliveryHeader),
+		Event:      header.Get(service.WebhookEventHeader),
+	}
+	if json.Valid(body) {
+		received.Payload = body
+	}
+
+	timestamp := header.Get(service.WebhookTimestampHeader)
+	var webhook models.TeamWebhook
+	if err := ctrl.db.First(&webhook, "id = ?", received.WebhookID).Error; err != nil {
+		received.Error = "unknown webhook"
+	} else if sec, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		received.Error = "invalid timestamp"
+	} else if d := time.Since(time.Unix(sec, 0)); d > webhookTimestampTolerance || d < -webhookTimestampTolerance {
+		received.Error = "timestamp out of tolerance"
+	} else if !service.VerifyWebhookSignature(webhook.Secret, timestamp, body, header.Get(service.WebhookSignatureHeader)) {
+		received.Error = "signature mismatch"
+	} else {
+		received.SignatureValid = true
+	}
+
+	ctrl.receivedMu.Lock()
+	ctrl.received = append(ctrl.received, received)
+	if len(ctrl.received) > webhookReceiverLimit {
+		ctrl.received = ctrl.received[len(ctrl.received)-webhookReceiverLimit:]
+	}
+	ctrl.receivedMu.Unlock()
+
+	if !received.SignatureValid {
+		return ctx.JSON(http.StatusUnauthorized, received)
+	}
+	return ctx.JSON(http.StatusOK, received)
+}
+
+// GetReceivedWebhooks 開発用のWebhook受信口が受け取った一覧
+// @Summary Webhook受信口の受信履歴（開発用）
+// @Description /debug/webhook-receiver が受け取った最新50件を新しい順に返す。サーバーを再起動すると消える（開発環境専用）
+// @Tags debug
+// @Produce json
+// @Success 200 {array} ReceivedWebhook
+// @Failure 403 {object} response.ErrorResponse
+// @Router /debug/webhook-receiver [get]
+func (ctrl *DebugController) GetReceivedWebhooks(ctx echo.Context) error {
+	if os.Getenv("FLAVOR") != "dev" {
+		return ctx.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "forbidden",
+			Message: "このエンドポイントは開発環境でのみ使用できます",
+		})
+	}
+
+	ctrl.receivedMu.Lock()
+	result := make([]ReceivedWebhook, len(ctrl.received))
+	for i, r := range ctrl.received {
+		result[len(result)-1-i] = r
+	}
+	ctrl.receivedMu.Unlock()
+
+	return ctx.JSON(http.StatusOK, result)
+}
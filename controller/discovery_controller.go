@@ -0,0 +1,440 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDirectoryLimit = 20
+	maxDirectoryLimit     = 50
+)
+
+type DiscoveryController struct {
+	db                *gorm.DB
+	membershipService *service.MembershipService
+}
+
+func NewDiscoveryController(db *gorm.DB, membershipService *service.MembershipService) *DiscoveryController {
+	return &DiscoveryController{db: db, membershipService: membershipService}
+}
+
+// GetTeamDirectory 公開チーム一覧
+// @Summary      公開チーム一覧
+// @Description  メンバー募集中（forming）かつ公開設定のチームを返す。chronotypeを指定すると、全メンバーがその朝型夜型またはbothのチームに絞り込む
+// @Tags         discovery
+// @Produce      json
+// @Param        exercise_type  query     string  false  "running / gym"
+// @Param        strictness     query     string  false  "normal / strict / relaxed"
+// @Param        chronotype     query     string  false  "morning / night / both"
+// @Param        limit          query     int     false  "取得件数（デフォルト20、最大50）"
+// @Success      200            {object}  response.TeamDirectoryResponse
+// @Failure      400            {object}  response.ErrorResponse
+// @Router       /api/teams/directory [get]
+// @Security     BearerAuth
+func (ctrl *DiscoveryController) GetTeamDirectory(c echo.Context) error {
+	limit := defaultDirectoryLimit
+	if l := c.QueryParam("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 || v > maxDirectoryLimit {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "limit は1〜50の範囲で指定してください",
+			})
+		}
+		limit = v
+	}
+	chronotype := c.QueryParam("chronotype")
+	if chronotype != "" && chronotype != "morning" && chronotype != "night" && chronotype != "both" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "chronotype は morning / night / both のいずれかを指定してください",
+		})
+	}
+
+	query := ctrl.db.Where("status = ? AND discoverable = ?", "forming", true)
+	if et := c.QueryParam("exercise_type"); et != "" {
+		query = query.Where("exercise_type = ?", et)
+	}
+	if st := c.QueryParam("strictness"); st != "" {
+		query = query.Where("strictness = ?", st)
+	}
+
+	var teams []models.Team
+	if err := query.Preload("Members.User").Order("created_at DESC").Find(&teams).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "チーム一覧の取得に失敗しました",
+		})
+	}
+
+	entries := []response.TeamDirectoryEntry{}
+	for _, team := range teams {
+		if len(entries) >= limit {
+			break
+		}
+		if len(team.Members) >= team.MaxMembers {
+			continue
+		}
+
+		compatible := true
+		chronotypes := make([]string, len(team.Members))
+		for i, m := range team.Members {
+			chronotypes[i] = m.User.Chronotype
+			if chronotype != "" && chronotype != "both" && m.User.Chronotype != chronotype && m.User.Chronotype != "both" {
+				compatible = false
+			}
+		}
+		if !compatible {
+			continue
+		}
+
+		entry := response.TeamDirectoryEntry{
+			TeamID:         team.ID,
+			Name:           team.Name,
+			ExerciseType:   team.ExerciseType,
+			Strictness:     team.Strictness,
+			MemberCount:    len(team.Members),
+			MinMembers:     team.MinMembers,
+			MaxMembers:     team.MaxMembers,
+			ChallengeWeeks: team.ChallengeWeeks,
+			Chronotypes:    chronotypes,
+			CreatedAt:      team.CreatedAt.Format(time.RFC3339),
+		}
+		var goal models.Goal
+		if err := ctrl.db.First(&goal, "team_id = ?", team.ID).Error; err == nil {
+			gr := newGoalResponse(goal)
+			entry.Goal = &gr
+		}
+		entries = append(entries, entry)
+	}
+
+	return c.JSON(http.StatusOK, response.TeamDirectoryResponse{Teams: entries})
+}
+
+// CreateJoinRequest 参加申請
+// @Summary      参加申請
+// @Description  公開チームに参加申請を送る。リーダーが承認するとチームに参加する
+// @Tags         discovery
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                             true  "チームID"
+// @Param        body    body      requests.CreateJoinRequestRequest  false  "メッセージ"
+// @Success      201     {object}  response.JoinRequestResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Failure      422     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/join-requests [post]
+// @Security     BearerAuth
+func (ctrl *DiscoveryController) CreateJoinRequest(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	req := new(requests.CreateJoinRequestRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	if len([]rune(req.Message)) > 200 {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "message は200文字以内で入力してください",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ? AND discoverable = ?", teamId, true).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+	if team.Status != "forming" {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_forming",
+			Message: "チームはメンバー募集中ではありません",
+		})
+	}
+
+	var user models.User
+	if err := ctrl.db.First(&user, "id = ?", uid).Error; err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "user_not_registered",
+			Message: "プロフィールが未登録です。先にアカウント設定（新規登録）を完了してください",
+		})
+	}
+
+	var existingCount int64
+	ctrl.db.Model(&models.TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id").
+		Where("team_members.user_id = ? AND teams.status IN ?", uid, []string{"forming", "active"}).
+		Count(&existingCount)
+	if existingCount > 0 {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_in_team",
+			Message: "既にアクティブなチームに所属しています",
+		})
+	}
+
+	var pendingCount int64
+	ctrl.db.Model(&models.JoinRequest{}).Where("team_id = ? AND user_id = ? AND status = ?", teamId, uid, "pending").Count(&pendingCount)
+	if pendingCount > 0 {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_requested",
+			Message: "既にこのチームに参加申請しています",
+		})
+	}
+
+	var memberCount int64
+	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+	if int(memberCount) >= team.MaxMembers {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_full",
+			Message: "チームは満員です",
+		})
+	}
+
+	joinRequest := models.JoinRequest{
+		ID:      utils.GenerateULID(),
+		TeamID:  teamId,
+		UserID:  uid,
+		Message: req.Message,
+		Status:  "pending",
+	}
+	if err := ctrl.db.Create(&joinRequest).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "参加申請に失敗しました",
+		})
+	}
+	joinRequest.User = user
+
+	return c.JSON(http.StatusCreated, response.NewJoinRequestResponse(joinRequest))
+}
+
+// GetJoinRequests 参加申請一覧
+// @Summary      参加申請一覧
+// @Description  チームへの未処理の参加申請を返す（リーダーのみ）
+// @Tags         discovery
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {array}   response.JoinRequestResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/join-requests [get]
+// @Security     BearerAuth
+func (ctrl *DiscoveryController) GetJoinRequests(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	if !ctrl.isLeader(teamId, uid) {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみ参加申請を確認できます",
+		})
+	}
+
+	var joinRequests []models.JoinRequest
+	ctrl.db.Preload("User").Where("team_id = ? AND status = ?", teamId, "pending").Order("created_at ASC").Find(&joinRequests)
+
+	result := make([]response.JoinRequestResponse, len(joinRequests))
+	for i, jr := range joinRequests {
+		result[i] = response.NewJoinRequestResponse(jr)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// ApproveJoinRequest 参加申請を承認
+// @Summary      参加申請を承認
+// @Description  参加申請を承認し、申請者をチームに追加する（リーダーのみ）。申請者の他チームへの申請は取り消される
+// @Tags         discovery
+// @Produce      json
+// @Param        teamId     path      string  true  "チームID"
+// @Param        requestId  path      string  true  "参加申請ID"
+// @Success      200        {object}  response.JoinRequestResponse
+// @Failure      403        {object}  response.ErrorResponse
+// @Failure      404        {object}  response.ErrorResponse
+// @Failure      409        {object}  response.ErrorResponse
+// @Failure      422        {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/join-requests/{requestId}/approve [post]
+// @Security     BearerAuth
+func (ctrl *DiscoveryController) ApproveJoinRequest(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	if !ctrl.isLeader(teamId, uid) {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみ参加申請を承認できます",
+		})
+	}
+
+	joinRequest, errResp := ctrl.findPendingRequest(teamId, c.Param("requestId"))
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+	if team.Status != "forming" {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_forming",
+			Message: "チームはメンバー募集中ではありません",
+		})
+	}
+
+	now := time.Now()
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := ctrl.membershipService.AddMember(tx, team, joinRequest.UserID, "member"); err != nil {
+			return err
+		}
+		if err := tx.Model(joinRequest).Updates(map[string]interface{}{
+			"status":     "approved",
+			"decided_by": uid,
+			"decided_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		// 他チームへの未処理の申請は取り消す
+		return tx.Model(&models.JoinRequest{}).
+			Where("user_id = ? AND status = ? AND id <> ?", joinRequest.UserID, "pending", joinRequest.ID).
+			Update("status", "cancelled").Error
+	})
+	if errors.Is(err, service.ErrAlreadyInTeam) {
+		ctrl.db.Model(joinRequest).Update("status", "cancelled")
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_in_team",
+			Message: "申請者は既に別のチームに所属しています",
+		})
+	}
+	if errors.Is(err, service.ErrTeamFull) {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_full",
+			Message: "チームは満員です",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "approve_failed",
+			Message: "参加申請の承認に失敗しました",
+		})
+	}
+
+	joinRequest.Status = "approved"
+	joinRequest.DecidedBy = &uid
+	joinRequest.DecidedAt = &now
+	return c.JSON(http.StatusOK, response.NewJoinRequestResponse(*joinRequest))
+}
+
+// RejectJoinRequest 参加申請を却下
+// @Summary      参加申請を却下
+// @Description  参加申請を却下する（リーダーのみ）
+// @Tags         discovery
+// @Produce      json
+// @Param        teamId     path      string  true  "チームID"
+// @Param        requestId  path      string  true  "参加申請ID"
+// @Success      200        {object}  response.JoinRequestResponse
+// @Failure      403        {object}  response.ErrorResponse
+// @Failure      404        {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/join-requests/{requestId}/reject [post]
+// @Security     BearerAuth
+func (ctrl *DiscoveryController) RejectJoinRequest(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	if !ctrl.isLeader(teamId, uid) {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみ参加申請を却下できます",
+		})
+	}
+
+	joinRequest, errResp := ctrl.findPendingRequest(teamId, c.Param("requestId"))
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	now := time.Now()
+	if err := ctrl.db.Model(joinRequest).Updates(map[string]interface{}{
+		"status":     "rejected",
+		"decided_by": uid,
+		"decided_at": now,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "reject_failed",
+			Message: "参加申請の却下に失敗しました",
+		})
+	}
+
+	joinRequest.Status = "rejected"
+	joinRequest.DecidedBy = &uid
+	joinRequest.DecidedAt = &now
+	return c.JSON(http.StatusOK, response.NewJoinRequestResponse(*joinRequest))
+}
+
+// CancelJoinRequest 参加申請を取り消し
+// @Summary      参加申請を取り消し
+// @Description  自分の未処理の参加申請を取り消す
+// @Tags         discovery
+// @Produce      json
+// @Param        teamId     path  string  true  "チームID"
+// @Param        requestId  path  string  true  "参加申請ID"
+// @Success      204
+// @Failure      404  {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/join-requests/{requestId} [delete]
+// @Security     BearerAuth
+func (ctrl *DiscoveryController) CancelJoinRequest(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	result := ctrl.db.Model(&models.JoinRequest{}).
+		Where("id = ? AND team_id = ? AND user_id = ? AND status = ?", c.Param("requestId"), c.Param("teamId"), uid, "pending").
+		Update("status", "cancelled")
+	if result.Error != nil || result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "request_not_found",
+			Message: "参加申請が見つかりません",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (ctrl *DiscoveryController) isLeader(teamID, uid string) bool {
+	var member models.TeamMember
+	err := ctrl.db.Where("team_id = ? AND user_id = ?", teamID, uid).First(&member).Error
+	return err == nil && member.Role == "leader"
+}
+
+func (ctrl *DiscoveryController) findPendingRequest(teamID, requestID string) (*models.JoinRequest, *errorReply) {
+	var joinRequest models.JoinRequest
+	if err := ctrl.db.Preload("User").First(&joinRequest, "id = ? AND team_id = ?", requestID, teamID).Error; err != nil {
+		return nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "request_not_found",
+			Message: "参加申請が見つかりません",
+		}}
+	}
+	if joinRequest.Status != "pending" {
+		return nil, &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "request_already_decided",
+			Message: "この参加申請は既に処理されています",
+		}}
+	}
+	return &joinRequest, nil
+}
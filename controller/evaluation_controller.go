@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,6 +8,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"gorm.io/gorm"
 )
 
@@ -71,6 +71,8 @@ func (ctrl *EvaluationController) GetEvaluations(c echo.Context) error {
 			TotalVisits:      e.TotalVisits,
 			TotalDurationMin: e.TotalDurationMin,
 			HPChange:         e.HPChange,
+			GoalVersion:      e.GoalVersion,
+			Frozen:           e.Frozen,
 			EvaluatedAt:      e.EvaluatedAt.Format(time.RFC3339),
 		}
 	}
@@ -119,18 +121,14 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 		})
 	}
 
-	// 目標取得
-	var goal models.Goal
-	ctrl.db.First(&goal, "team_id = ?", teamId)
-
-	// 今週の期間
-	weekStart := team.StartedAt.AddDate(0, 0, (team.CurrentWeek-1)*7)
-	weekEnd := weekStart.AddDate(0, 0, 7)
-	now := time.Now()
-	daysRemaining := int(math.Ceil(weekEnd.Sub(now).Hours() / 24))
-	if daysRemaining < 0 {
-		daysRemaining = 0
-	}
+	// 目標取得（今週に適用されている版）
+	goal, _, _ := service.GoalForWeek(ctrl.db, teamId, team.CurrentWeek)
+
+	// 今週の期間（チームの開始ルールで区切る）
+	weekStart, weekEnd, _ := service.WeekWindow(team, team.CurrentWeek)
+	// 残り日数はチームの暦で数える（今日を含む）
+	loc := service.TeamLocation(team)
+	daysRemaining := service.DaysRemaining(team, team.CurrentWeek, time.Now())
 
 	// メンバー一覧
 	var members []models.TeamMember
@@ -138,6 +136,9 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 
 	var memberProgresses []response.CurrentWeekMemberProgress
 	for _, m := range members {
+		memberGoal, override, _ := service.MemberGoalForWeek(ctrl.db, goal, m.UserID, team.CurrentWeek)
+		freeze, _ := service.FrozenWeek(ctrl.db, teamId, m.UserID, team.CurrentWeek)
+
 		// 今週のアクティビティ取得
 		var activities []models.Activity
 		ctrl.db.Where("user_id = ? AND team_id = ? AND status = ? AND started_at >= ? AND started_at < ?",
@@ -154,8 +155,8 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 			totalDuration += a.DurationMin
 			if a.ExerciseType == "gym" {
 				totalVisits++
-				if goal.TargetMinDurationMin != nil {
-					if a.DurationMin >= *goal.TargetMinDurationMin {
+				if memberGoal.TargetMinDurationMin != nil {
+					if a.DurationMin >= *memberGoal.TargetMinDurationMin {
 						qualifiedVisits++
 					}
 				} else {
@@ -164,7 +165,7 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 			}
 			actSummaries = append(actSummaries, response.WeekActivitySummary{
 				ID:          a.ID,
-				Date:        a.StartedAt.Format("2006-01-02"),
+				Date:        a.StartedAt.In(loc).Format("2006-01-02"),
 				DistanceKM:  a.DistanceKM,
 				DurationMin: a.DurationMin,
 			})
@@ -173,22 +174,24 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 			actSummaries = []response.WeekActivitySummary{}
 		}
 
-		// 有効目標 = ベース目標 × メンバーの倍率（前週未達成時は1.5倍ペナルティ）
+		// 有効目標 = ベース目標 × メンバーの倍率（前週未達成時は1.5倍ペナルティ） × 短い週の按分率
 		multiplier := m.TargetMultiplier
 		if multiplier <= 0 {
 			multiplier = 1.0
 		}
+		proration := service.TargetProration(team, m.JoinedAt, team.CurrentWeek)
+		effectiveMultiplier := multiplier * proration
 
 		var progressPercent float64
 		switch team.ExerciseType {
 		case "running":
-			if goal.TargetDistanceKM != nil && *goal.TargetDistanceKM > 0 {
-				effectiveTarget := *goal.TargetDistanceKM * multiplier
+			if memberGoal.TargetDistanceKM != nil && *memberGoal.TargetDistanceKM > 0 {
+				effectiveTarget := *memberGoal.TargetDistanceKM * effectiveMultiplier
 				progressPercent = (totalDist / effectiveTarget) * 100
 			}
 		case "gym":
-			if goal.TargetVisitsPerWeek != nil && *goal.TargetVisitsPerWeek > 0 {
-				effectiveTarget := float64(*goal.TargetVisitsPerWeek) * multiplier
+			if memberGoal.TargetVisitsPerWeek != nil && *memberGoal.TargetVisitsPerWeek > 0 {
+				effectiveTarget := float64(*memberGoal.TargetVisitsPerWeek) * effectiveMultiplier
 				progressPercent = (float64(qualifiedVisits) / effectiveTarget) * 100
 			}
 		}
@@ -196,7 +199,8 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 			progressPercent = 100
 		}
 
-		onTrack := progressPercent >= 100 || (daysRemaining > 0 && progressPercent > 0)
+		// フリーズした週は評価されないため達成扱いで表示する
+		onTrack := freeze != nil || progressPercent >= 100 || (daysRemaining > 0 && progressPercent > 0)
 
 		memberProgresses = append(memberProgresses, response.CurrentWeekMemberProgress{
 			UserID:                m.UserID,
@@ -208,6 +212,9 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 			TargetProgressPercent: progressPercent,
 			OnTrack:               onTrack,
 			TargetMultiplier:      multiplier,
+			TargetProration:       proration,
+			IndividualGoal:        override != nil,
+			Frozen:                freeze != nil,
 			ActivitiesThisWeek:    actSummaries,
 		})
 	}
@@ -219,8 +226,9 @@ func (ctrl *EvaluationController) GetCurrentWeekEvaluation(c echo.Context) error
 	return c.JSON(http.StatusOK, response.CurrentWeekEvaluationResponse{
 		TeamID:        teamId,
 		WeekNumber:    team.CurrentWeek,
-		WeekStart:     weekStart.Format(time.RFC3339),
-		WeekEnd:       weekEnd.Add(-time.Second).Format(time.RFC3339),
+		StartPolicy:   team.StartPolicy,
+		WeekStart:     weekStart.In(loc).Format(time.RFC3339),
+		WeekEnd:       weekEnd.Add(-time.Second).In(loc).Format(time.RFC3339),
 		DaysRemaining: daysRemaining,
 		Members:       memberProgresses,
 	})
@@ -0,0 +1,560 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+)
+
+type FeedController struct {
+	db *gorm.DB
+}
+
+func NewFeedController(db *gorm.DB) *FeedController {
+	return &FeedController{db: db}
+}
+
+// requireMember チームのメンバーでなければエラーを返す
+func (ctrl *FeedController) requireMember(teamId, uid string) *errorReply {
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		}}
+	}
+	return nil
+}
+
+// teamActivity チームのアクティビティを取得する。チームに属さないアクティビティや他のチームのものは扱えない
+func (ctrl *FeedController) teamActivity(activityId, uid string) (*models.Activity, *errorReply) {
+	var activity models.Activity
+	if err := ctrl.db.First(&activity, "id = ?", activityId).Error; err != nil {
+		return nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "activity_not_found",
+			Message: "アクティビティが見つかりません",
+		}}
+	}
+	if activity.TeamID == nil {
+		return nil, feedErrorResponse(service.ErrActivityNotInTeam)
+	}
+	if errResp := ctrl.requireMember(*activity.TeamID, uid); errResp != nil {
+		return nil, errResp
+	}
+	return &activity, nil
+}
+
+// GetFeed チームフィード
+// @Summary      チームフィード
+// @Description  チームのアクティビティ・週次評価・HP変動・メンバーの出入りを新しい順に返す。アクティビティにはリアクションとコメント数が付く。unread は最後に既読にした時点（一度も既読にしていなければ参加日時）より後の他のメンバーの項目
+// @Tags         feed
+// @Produce      json
+// @Param        teamId  path      string  true   "チームID"
+// @Param        before  query     string  false  "この日時より前の項目を返す（前回の next_cursor）"
+// @Param        limit   query     int     false  "取得件数（1〜100、省略時30）"
+// @Success      200     {object}  response.FeedResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/feed [get]
+// @Security     BearerAuth
+func (ctrl *FeedController) GetFeed(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireMember(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	before := time.Now()
+	if v := c.QueryParam("before"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_cursor",
+				Message: "before はRFC3339形式の日時で指定してください",
+			})
+		}
+		before = t
+	}
+	limit := service.DefaultFeedLimit
+	if v, err := strconv.Atoi(c.QueryParam("limit")); err == nil && v >= 1 && v <= service.MaxFeedLimit {
+		limit = v
+	}
+
+	items, err := service.BuildFeed(ctrl.db, teamId, uid, before, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "フィードの取得に失敗しました",
+		})
+	}
+	unread, err := service.CountFeedUnread(ctrl.db, teamId, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "フィードの取得に失敗しました",
+		})
+	}
+
+	result := make([]response.FeedItemResponse, len(items))
+	for i, item := range items {
+		result[i] = toFeedItemResponse(item)
+	}
+	var next *string
+	if len(items) == limit {
+		cursor := items[len(items)-1].OccurredAt.UTC().Format(time.RFC3339Nano)
+		next = &cursor
+	}
+
+	return c.JSON(http.StatusOK, response.FeedResponse{
+		TeamID:     teamId,
+		Items:      result,
+		NextCursor: next,
+		Unread:     toFeedUnreadResponse(teamId, unread),
+	})
+}
+
+// GetFeedUnread フィードの未読数
+// @Summary      フィードの未読数
+// @Description  最後に既読にした時点より後に他のメンバーが追加したフィードの項目とコメントの数を返す
+// @Tags         feed
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.FeedUnreadResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/feed/unread [get]
+// @Security     BearerAuth
+func (ctrl *FeedController) GetFeedUnread(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireMember(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	unread, err := service.CountFeedUnread(ctrl.db, teamId, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "未読数の取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, toFeedUnreadResponse(teamId, unread))
+}
+
+// MarkFeedRead フィードを既読にする
+// @Summary      フィードを既読にする
+// @Description  read_at（省略時は現在時刻）までのフィードの項目とコメントを既読にする。既読位置は戻らない
+// @Tags         feed
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                        true   "チームID"
+// @Param        body    body      requests.MarkFeedReadRequest  false  "既読にする日時"
+// @Success      200     {object}  response.FeedUnreadResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/feed/read [post]
+// @Security     BearerAuth
+func (ctrl *FeedController) MarkFeedRead(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireMember(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	req := new(requests.MarkFeedReadRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	now := time.Now()
+	readAt := now
+	if req.ReadAt != nil {
+		t, err := time.Parse(time.RFC3339Nano, *req.ReadAt)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "read_at はRFC3339形式の日時で指定してください",
+			})
+		}
+		// 未来の日時で既読にすると、その後の項目が未読にならなくなる
+		if t.Before(now) {
+			readAt = t
+		}
+	}
+
+	if _, err := service.MarkFeedRead(ctrl.db, teamId, uid, readAt); err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "update_failed",
+			Message: "既読の更新に失敗しました",
+		})
+	}
+	unread, err := service.CountFeedUnread(ctrl.db, teamId, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "未読数の取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, toFeedUnreadResponse(teamId, unread))
+}
+
+// GetReactions アクティビティのリアクション一覧
+// @Summary      アクティビティのリアクション一覧
+// @Description  チームメイトのアクティビティに付いた絵文字リアクションを絵文字ごとにまとめて返す
+// @Tags         feed
+// @Produce      json
+// @Param        activityId  path      string  true  "アクティビティID"
+// @Success      200         {object}  response.ActivityReactionsResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/reactions [get]
+// @Security     BearerAuth
+func (ctrl *FeedController) GetReactions(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activity, errResp := ctrl.teamActivity(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return ctrl.reactionsJSON(c, activity.ID, uid)
+}
+
+// AddReaction アクティビティにリアクションする
+// @Summary      アクティビティにリアクションする
+// @Description  チームメイトのアクティビティに絵文字リアクションを付ける（👍 / 🔥 / 💪 / 👏 / 🎉 / 😮）。同じ絵文字を付け直しても1つのまま
+// @Tags         feed
+// @Accept       json
+// @Produce      json
+// @Param        activityId  path      string                       true  "アクティビティID"
+// @Param        body        body      requests.AddReactionRequest  true  "絵文字"
+// @Success      200         {object}  response.ActivityReactionsResponse
+// @Failure      400         {object}  response.ErrorResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/reactions [post]
+// @Security     BearerAuth
+func (ctrl *FeedController) AddReaction(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activity, errResp := ctrl.teamActivity(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	req := new(requests.AddReactionRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	if errResp := feedErrorResponse(service.AddReaction(ctrl.db, *activity, uid, strings.TrimSpace(req.Emoji))); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return ctrl.reactionsJSON(c, activity.ID, uid)
+}
+
+// RemoveReaction アクティビティのリアクションを外す
+// @Summary      アクティビティのリアクションを外す
+// @Description  自分が付けた絵文字リアクションを外す
+// @Tags         feed
+// @Produce      json
+// @Param        activityId  path      string  true  "アクティビティID"
+// @Param        emoji       query     string  true  "外す絵文字"
+// @Success      200         {object}  response.ActivityReactionsResponse
+// @Failure      400         {object}  response.ErrorResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/reactions [delete]
+// @Security     BearerAuth
+func (ctrl *FeedController) RemoveReaction(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activity, errResp := ctrl.teamActivity(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	if errResp := feedErrorResponse(service.RemoveReaction(ctrl.db, *activity, uid, c.QueryParam("emoji"))); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return ctrl.reactionsJSON(c, activity.ID, uid)
+}
+
+func (ctrl *FeedController) reactionsJSON(c echo.Context, activityId, uid string) error {
+	summaries, err := service.ReactionSummaries(ctrl.db, []string{activityId}, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "リアクションの取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, response.ActivityReactionsResponse{
+		ActivityID: activityId,
+		Reactions:  toReactionSummaryResponses(summaries[activityId]),
+	})
+}
+
+// GetComments アクティビティのコメント一覧
+// @Summary      アクティビティのコメント一覧
+// @Description  アクティビティへのコメントを古い順に、返信をスレッドごとにまとめて返す。削除されたコメントは返信が残っていれば本文を空にして返す
+// @Tags         feed
+// @Produce      json
+// @Param        activityId  path      string  true  "アクティビティID"
+// @Success      200         {object}  response.ActivityCommentListResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/comments [get]
+// @Security     BearerAuth
+func (ctrl *FeedController) GetComments(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activity, errResp := ctrl.teamActivity(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var comments []models.ActivityComment
+	if err := ctrl.db.Preload("User").
+		Where("activity_id = ?", activity.ID).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "コメントの取得に失敗しました",
+		})
+	}
+
+	threads := []response.ActivityCommentResponse{}
+	index := map[string]int{}
+	count := 0
+	for _, comment := range comments {
+		if comment.DeletedAt == nil {
+			count++
+		}
+		res := response.NewActivityCommentResponse(comment)
+		if comment.ParentID == nil {
+			index[comment.ID] = len(threads)
+			threads = append(threads, res)
+			continue
+		}
+		if i, ok := index[*comment.ParentID]; ok && comment.DeletedAt == nil {
+			threads[i].Replies = append(threads[i].Replies, res)
+		}
+	}
+	// 返信のない削除済みコメントは表示しない
+	visible := make([]response.ActivityCommentResponse, 0, len(threads))
+	for _, t := range threads {
+		if t.Deleted && len(t.Replies) == 0 {
+			continue
+		}
+		visible = append(visible, t)
+	}
+
+	return c.JSON(http.StatusOK, response.ActivityCommentListResponse{
+		ActivityID:   activity.ID,
+		CommentCount: count,
+		Comments:     visible,
+	})
+}
+
+// PostComment アクティビティにコメントする
+// @Summary      アクティビティにコメントする
+// @Description  チームメイトのアクティビティにコメントする。parent_id を指定すると返信になり、返信への返信は最初のコメントのスレッドにまとまる。アクティビティの持ち主と返信先のコメントを書いた人に通知する
+// @Tags         feed
+// @Accept       json
+// @Produce      json
+// @Param        activityId  path      string                               true  "アクティビティID"
+// @Param        body        body      requests.PostActivityCommentRequest  true  "コメント"
+// @Success      201         {object}  response.ActivityCommentResponse
+// @Failure      400         {object}  response.ErrorResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/comments [post]
+// @Security     BearerAuth
+func (ctrl *FeedController) PostComment(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activity, errResp := ctrl.teamActivity(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	req := new(requests.PostActivityCommentRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	var comment *models.ActivityComment
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		comment, err = service.PostComment(tx, *activity, uid, req.Body, req.ParentID)
+		return err
+	})
+	if errResp := feedErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return c.JSON(http.StatusCreated, response.NewActivityCommentResponse(*comment))
+}
+
+// DeleteComment コメントを削除する
+// @Summary      コメントを削除する
+// @Description  自分のコメントを削除する。返信のスレッドは残る
+// @Tags         feed
+// @Param        activityId  path  string  true  "アクティビティID"
+// @Param        commentId   path  string  true  "コメントID"
+// @Success      204
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/comments/{commentId} [delete]
+// @Security     BearerAuth
+func (ctrl *FeedController) DeleteComment(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activity, errResp := ctrl.teamActivity(c.Param("activityId"), uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var comment models.ActivityComment
+	if err := ctrl.db.First(&comment, "id = ? AND activity_id = ?", c.Param("commentId"), activity.ID).Error; err != nil {
+		errResp := feedErrorResponse(service.ErrCommentNotFound)
+		return c.JSON(errResp.status, errResp.body)
+	}
+	if errResp := feedErrorResponse(service.DeleteComment(ctrl.db, &comment, uid, time.Now())); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func toReactionSummaryResponses(summaries []service.ReactionSummary) []response.ReactionSummaryResponse {
+	result := make([]response.ReactionSummaryResponse, len(summaries))
+	for i, s := range summaries {
+		result[i] = response.ReactionSummaryResponse{
+			Emoji:       s.Emoji,
+			Count:       s.Count,
+			UserIDs:     s.UserIDs,
+			ReactedByMe: s.ReactedByMe,
+		}
+	}
+	return result
+}
+
+func toFeedUnreadResponse(teamId string, unread service.FeedUnread) response.FeedUnreadResponse {
+	var lastReadAt *string
+	if unread.LastReadAt != nil {
+		s := unread.LastReadAt.Format(time.RFC3339)
+		lastReadAt = &s
+	}
+	return response.FeedUnreadResponse{
+		TeamID:     teamId,
+		LastReadAt: lastReadAt,
+		Items:      unread.Items,
+		Comments:   unread.Comments,
+		MyComments: unread.MyComments,
+	}
+}
+
+func toFeedItemResponse(item service.FeedItem) response.FeedItemResponse {
+	res := response.FeedItemResponse{
+		ID:         item.ID,
+		Type:       item.Type,
+		OccurredAt: item.OccurredAt.Format(time.RFC3339),
+		Unread:     item.Unread,
+	}
+	switch {
+	case item.Activity != nil:
+		res.Activity = &response.FeedActivityResponse{
+			Activity:           toActivityResponse(item.Activity.Activity, nil),
+			Reactions:          toReactionSummaryResponses(item.Activity.Reactions),
+			CommentCount:       item.Activity.CommentCount,
+			UnreadCommentCount: item.Activity.UnreadCommentCount,
+		}
+	case item.Evaluation != nil:
+		e := item.Evaluation
+		members := make([]response.HPChangeEntry, len(e.Members))
+		for i, m := range e.Members {
+			members[i] = response.HPChangeEntry{
+				UserID:    m.UserID,
+				UserName:  m.User.Name,
+				HPChange:  m.HPChange,
+				TargetMet: m.TargetMet,
+				Frozen:    m.Frozen,
+			}
+		}
+		res.Evaluation = &response.FeedEvaluationResponse{
+			WeekNumber:     e.WeekNumber,
+			EvaluatedAt:    e.EvaluatedAt.Format(time.RFC3339),
+			EvaluatedCount: e.EvaluatedCount,
+			MetCount:       e.MetCount,
+			FrozenCount:    e.FrozenCount,
+			HPChange:       e.HPChange,
+			Members:        members,
+		}
+	case item.HPEvent != nil:
+		res.HPChange = &response.FeedHPChangeResponse{
+			Reason:     item.HPEvent.Reason,
+			UserID:     item.HPEvent.UserID,
+			UserName:   item.HPEvent.User.Name,
+			HPChange:   item.HPEvent.HPChange,
+			HPAfter:    item.HPEvent.HPAfter,
+			WeekNumber: item.HPEvent.WeekNumber,
+		}
+	case item.Membership != nil:
+		res.Membership = &response.FeedMembershipResponse{
+			Type:     item.Membership.Type,
+			UserID:   item.Membership.UserID,
+			UserName: item.Membership.User.Name,
+			ActorID:  item.Membership.ActorID,
+		}
+	}
+	return res
+}
+
+func feedErrorResponse(err error) *errorReply {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrActivityNotInTeam):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "activity_not_in_team",
+			Message: "チームに記録されたアクティビティではありません",
+		}}
+	case errors.Is(err, service.ErrInvalidReaction):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_emoji",
+			Message: "emoji には " + strings.Join(service.ReactionEmojis, " / ") + " のいずれかを指定してください",
+		}}
+	case errors.Is(err, service.ErrInvalidComment):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_comment",
+			Message: fmt.Sprintf("body は1〜%d文字で入力してください", service.MaxCommentLength),
+		}}
+	case errors.Is(err, service.ErrCommentNotFound):
+		return &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "comment_not_found",
+			Message: "コメントが見つかりません",
+		}}
+	case errors.Is(err, service.ErrNotCommentAuthor):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_comment_author",
+			Message: "自分のコメントのみ削除できます",
+		}}
+	case errors.Is(err, service.ErrCommentAlreadyGone):
+		return &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "comment_not_found",
+			Message: "コメントは既に削除されています",
+		}}
+	}
+	return &errorReply{http.StatusInternalServerError, response.ErrorResponse{
+		Error:   "internal_error",
+		Message: "処理に失敗しました",
+	}}
+}
@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+)
+
+// maxFreezeReasonLength フリーズ理由の最大文字数
+const maxFreezeReasonLength = 200
+
+type FreezeController struct {
+	db *gorm.DB
+}
+
+func NewFreezeController(db *gorm.DB) *FreezeController {
+	return &FreezeController{db: db}
+}
+
+// GetFreezes フリーズ一覧
+// @Summary      フリーズ一覧
+// @Description  チームメンバーがフリーズした週（予約中・使用済み）と、自分のフリーズトークンの残高を返す
+// @Tags         freezes
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.WeekFreezeListResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/freezes [get]
+// @Security     BearerAuth
+func (ctrl *FreezeController) GetFreezes(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	balance, err := service.FreezeBalanceFor(ctrl.db, team, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "internal_error",
+			Message: "フリーズトークンの取得に失敗しました",
+		})
+	}
+
+	var freezes []models.WeekFreeze
+	ctrl.db.Preload("User").
+		Where("team_id = ? AND status IN ?", teamId, []string{"scheduled", "used"}).
+		Order("week_number ASC, created_at ASC").
+		Find(&freezes)
+
+	result := make([]response.WeekFreezeResponse, len(freezes))
+	for i, f := range freezes {
+		result[i] = response.NewWeekFreezeResponse(f, service.WeekStart(team, f.WeekNumber))
+	}
+
+	return c.JSON(http.StatusOK, response.WeekFreezeListResponse{
+		TeamID:            teamId,
+		CurrentWeek:       team.CurrentWeek,
+		NextFreezableWeek: service.NextFreezableWeek(team, time.Now()),
+		MyTokens: response.FreezeBalanceResponse{
+			Allowance: balance.Allowance,
+			Earned:    balance.Earned,
+			Used:      balance.Used,
+			Remaining: balance.Remaining,
+		},
+		Freezes: result,
+	})
+}
+
+// CreateFreeze フリーズを予約
+// @Summary      フリーズを予約
+// @Description  フリーズトークンを1つ使い、まだ始まっていない週の週次評価を免除する。フリーズした週は目標未達成でもHPが減らず、全員達成ボーナスの判定からも外れる。チームメンバーに通知される
+// @Tags         freezes
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                            true  "チームID"
+// @Param        body    body      requests.CreateWeekFreezeRequest  true  "フリーズする週"
+// @Success      201     {object}  response.WeekFreezeResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/freezes [post]
+// @Security     BearerAuth
+func (ctrl *FreezeController) CreateFreeze(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	req := new(requests.CreateWeekFreezeRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	if utf8.RuneCountInString(req.Reason) > maxFreezeReasonLength {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("reason は%d文字以内で入力してください", maxFreezeReasonLength),
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+	if team.Status != "forming" && team.Status != "active" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームではフリーズできません",
+		})
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.Preload("User").First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	now := time.Now()
+	week := service.NextFreezableWeek(team, now)
+	if req.WeekNumber != nil {
+		week = *req.WeekNumber
+	}
+
+	var freeze *models.WeekFreeze
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		freeze, err = service.ScheduleFreeze(tx, team, uid, week, req.Reason, now)
+		if err != nil {
+			return err
+		}
+
+		return service.NotifyTeam(tx, team.ID, uid, "week_frozen", map[string]string{
+			"member": member.User.Name,
+			"week":   strconv.Itoa(week),
+		})
+	})
+	if errResp := freezeErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	freeze.User = member.User
+	return c.JSON(http.StatusCreated, response.NewWeekFreezeResponse(*freeze, service.WeekStart(team, freeze.WeekNumber)))
+}
+
+// CancelFreeze フリーズを取り消す
+// @Summary      フリーズを取り消す
+// @Description  自分が予約したフリーズを取り消し、トークンを戻す。週が始まった後は取り消せない
+// @Tags         freezes
+// @Produce      json
+// @Param        teamId    path      string  true  "チームID"
+// @Param        freezeId  path      string  true  "フリーズID"
+// @Success      200       {object}  response.WeekFreezeResponse
+// @Failure      403       {object}  response.ErrorResponse
+// @Failure      404       {object}  response.ErrorResponse
+// @Failure      409       {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/freezes/{freezeId} [delete]
+// @Security     BearerAuth
+func (ctrl *FreezeController) CancelFreeze(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	freezeId := c.Param("freezeId")
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	var freeze models.WeekFreeze
+	if err := ctrl.db.Preload("User").First(&freeze, "id = ? AND team_id = ? AND status <> ?", freezeId, teamId, "cancelled").Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "freeze_not_found",
+			Message: "フリーズが見つかりません",
+		})
+	}
+	if freeze.UserID != uid {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_freeze_owner",
+			Message: "自分のフリーズのみ取り消せます",
+		})
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return service.CancelFreeze(tx, team, &freeze, time.Now())
+	})
+	if errResp := freezeErrorResponse(err); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	return c.JSON(http.StatusOK, response.NewWeekFreezeResponse(freeze, service.WeekStart(team, freeze.WeekNumber)))
+}
+
+func freezeErrorResponse(err error) *errorReply {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrMemberNotFound):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		}}
+	case errors.Is(err, service.ErrFreezeWeekOutOfRange):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_week",
+			Message: "チャレンジ期間内の週を指定してください",
+		}}
+	case errors.Is(err, service.ErrFreezeWeekStarted):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "week_already_started",
+			Message: "既に始まった週はフリーズの予約・取り消しができません",
+		}}
+	case errors.Is(err, service.ErrFreezeAlreadyScheduled):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "already_frozen",
+			Message: "この週は既にフリーズしています",
+		}}
+	case errors.Is(err, service.ErrNoFreezeTokens):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "no_freeze_tokens",
+			Message: "フリーズトークンが残っていません",
+		}}
+	}
+	return &errorReply{http.StatusInternalServerError, response.ErrorResponse{
+		Error:   "freeze_failed",
+		Message: "フリーズの処理に失敗しました",
+	}}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/requests"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"gorm.io/gorm"
 )
 
@@ -22,7 +23,7 @@ func NewGoalController(db *gorm.DB) *GoalController {
 
 // CreateGoal 目標設定
 // @Summary      目標設定
-// @Description  チームの目標を設定する。リーダーのみ設定可能。チームメンバーが3人揃った状態でのみ設定可能。目標設定完了でチームstatusをactiveに変更。
+// @Description  チームの目標を設定する。リーダーのみ設定可能。メンバー募集中でも設定でき、チャレンジ開始は /api/teams/{teamId}/start で行う。
 // @Tags         goals
 // @Accept       json
 // @Produce      json
@@ -71,9 +72,12 @@ func (ctrl *GoalController) CreateGoal(c echo.Context) error {
 		})
 	}
 
-	// メンバー数を確認（チーム結成前でも目標は設定可能）
-	var memberCount int64
-	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+	if msg := validateGoal(team.ExerciseType, *req, goalComplete); msg != "" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: msg,
+		})
+	}
 
 	// 既に目標が存在するか確認
 	var existingGoal models.Goal
@@ -93,23 +97,8 @@ func (ctrl *GoalController) CreateGoal(c echo.Context) error {
 		TargetMinDurationMin: req.TargetMinDurationMin,
 	}
 
-	// トランザクションで目標作成 + チームステータス更新（3人揃っている場合のみ）
 	if err := ctrl.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(&goal).Error; err != nil {
-			return err
-		}
-		// 3人揃っている場合のみチームをactiveに
-		if memberCount >= 3 {
-			now := time.Now()
-			if err := tx.Model(&team).Updates(map[string]interface{}{
-				"status":       "active",
-				"started_at":   now,
-				"current_week": 1,
-			}).Error; err != nil {
-				return err
-			}
-		}
-		return nil
+		return service.CreateGoal(tx, &goal, &uid, "manual")
 	}); err != nil {
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Error:   "create_failed",
@@ -122,7 +111,7 @@ func (ctrl *GoalController) CreateGoal(c echo.Context) error {
 
 // GetGoal 目標取得
 // @Summary      目標取得
-// @Description  チームの目標を取得する
+// @Description  チームの目標を取得する。今週の評価に使われる目標を返し、翌週から適用される変更があれば pending に入る
 // @Tags         goals
 // @Produce      json
 // @Param        teamId  path      string  true  "チームID"
@@ -144,6 +133,14 @@ func (ctrl *GoalController) GetGoal(c echo.Context) error {
 		})
 	}
 
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
 	var goal models.Goal
 	if err := ctrl.db.First(&goal, "team_id = ?", teamId).Error; err != nil {
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
@@ -152,18 +149,172 @@ func (ctrl *GoalController) GetGoal(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, newGoalResponse(goal))
+	return c.JSON(http.StatusOK, ctrl.buildCurrentGoalResponse(team, goal))
+}
+
+// GetGoalHistory 目標の変更履歴
+// @Summary      目標の変更履歴
+// @Description  チーム目標の版を新しい順に返す。各版は effective_from_week 以降の週の評価に使われる
+// @Tags         goals
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.GoalHistoryResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/goal/history [get]
+// @Security     BearerAuth
+func (ctrl *GoalController) GetGoalHistory(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	// メンバー確認
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	var goal models.Goal
+	if err := ctrl.db.First(&goal, "team_id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "goal_not_found",
+			Message: "目標が設定されていません",
+		})
+	}
+
+	var versions []models.GoalVersion
+	ctrl.db.Where("team_id = ?", teamId).Order("version DESC").Find(&versions)
+	// 版管理前の目標は現在の値を版1として扱う
+	if len(versions) == 0 {
+		versions = []models.GoalVersion{{
+			ID:                   goal.ID,
+			GoalID:               goal.ID,
+			TeamID:               goal.TeamID,
+			Version:              1,
+			EffectiveFromWeek:    1,
+			TargetDistanceKM:     goal.TargetDistanceKM,
+			TargetVisitsPerWeek:  goal.TargetVisitsPerWeek,
+			TargetMinDurationMin: goal.TargetMinDurationMin,
+			Source:               "initial",
+			CreatedAt:            goal.CreatedAt,
+		}}
+	}
+
+	_, inEffect, _ := service.GoalForWeek(ctrl.db, teamId, team.CurrentWeek)
+	result := make([]response.GoalVersionResponse, len(versions))
+	for i, v := range versions {
+		result[i] = response.GoalVersionResponse{
+			ID:                   v.ID,
+			Version:              v.Version,
+			EffectiveFromWeek:    v.EffectiveFromWeek,
+			TargetDistanceKM:     v.TargetDistanceKM,
+			TargetVisitsPerWeek:  v.TargetVisitsPerWeek,
+			TargetMinDurationMin: v.TargetMinDurationMin,
+			ChangedBy:            v.ChangedBy,
+			Source:               v.Source,
+			InEffect:             (inEffect != nil && inEffect.ID == v.ID) || (inEffect == nil && len(versions) == 1),
+			CreatedAt:            v.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return c.JSON(http.StatusOK, response.GoalHistoryResponse{
+		TeamID:      teamId,
+		CurrentWeek: team.CurrentWeek,
+		Versions:    result,
+	})
+}
+
+// GetGoalSuggestions 目標の提案
+// @Summary      目標の提案
+// @Description  メンバーの直近のアクティビティと過去シーズンの週次評価から、控えめ・標準・挑戦的な目標と、チーム全員が1週間で達成できる推定確率を返す。履歴が少ない場合は初心者向けの既定値を返す（basis=default）
+// @Tags         goals
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.GoalSuggestionsResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/goal/suggestions [get]
+// @Security     BearerAuth
+func (ctrl *GoalController) GetGoalSuggestions(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	// メンバー確認
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	result, err := service.SuggestGoals(ctrl.db, team, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "internal_error",
+			Message: "目標の提案に失敗しました",
+		})
+	}
+
+	suggestions := make([]response.GoalSuggestionResponse, len(result.Suggestions))
+	for i, s := range result.Suggestions {
+		members := make([]response.MemberSuccessRateResponse, len(s.Members))
+		for j, m := range s.Members {
+			members[j] = response.MemberSuccessRateResponse{
+				UserID:      m.UserID,
+				UserName:    m.UserName,
+				Samples:     m.Samples,
+				SuccessRate: m.SuccessRate,
+			}
+		}
+		suggestions[i] = response.GoalSuggestionResponse{
+			Level:                s.Level,
+			TargetDistanceKM:     s.TargetDistanceKM,
+			TargetVisitsPerWeek:  s.TargetVisitsPerWeek,
+			TargetMinDurationMin: s.TargetMinDurationMin,
+			EstimatedSuccessRate: s.EstimatedSuccessRate,
+			Members:              members,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response.GoalSuggestionsResponse{
+		TeamID:          team.ID,
+		ExerciseType:    team.ExerciseType,
+		Basis:           result.Basis,
+		MembersAnalyzed: result.MembersAnalyzed,
+		WeeksAnalyzed:   result.WeeksAnalyzed,
+		Suggestions:     suggestions,
+	})
 }
 
 // UpdateGoal 目標更新
 // @Summary      目標更新
-// @Description  チームの目標を更新する。リーダーのみ更新可能。
+// @Description  チームの目標を更新する。リーダーのみ更新可能。変更は新しい版として記録され、activeチームでは翌週の評価から適用される（今週は変更前の目標で評価）。
 // @Tags         goals
 // @Accept       json
 // @Produce      json
 // @Param        teamId  path      string                    true  "チームID"
 // @Param        body    body      requests.CreateGoalRequest  true  "更新する目標情報"
 // @Success      200     {object}  response.GoalResponse
+// @Failure      400     {object}  response.ErrorResponse
 // @Failure      403     {object}  response.ErrorResponse
 // @Failure      404     {object}  response.ErrorResponse
 // @Router       /api/teams/{teamId}/goal [put]
@@ -195,6 +346,21 @@ func (ctrl *GoalController) UpdateGoal(c echo.Context) error {
 		})
 	}
 
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	if msg := validateGoal(team.ExerciseType, *req, goalComplete); msg != "" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: msg,
+		})
+	}
+
 	var goal models.Goal
 	if err := ctrl.db.First(&goal, "team_id = ?", teamId).Error; err != nil {
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
@@ -207,14 +373,73 @@ func (ctrl *GoalController) UpdateGoal(c echo.Context) error {
 	goal.TargetVisitsPerWeek = req.TargetVisitsPerWeek
 	goal.TargetMinDurationMin = req.TargetMinDurationMin
 
-	if err := ctrl.db.Save(&goal).Error; err != nil {
+	if err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return service.RecordGoalChange(tx, &goal, service.NextGoalWeek(team), &uid, "manual")
+	}); err != nil {
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Error:   "update_failed",
 			Message: "目標の更新に失敗しました",
 		})
 	}
 
-	return c.JSON(http.StatusOK, newGoalResponse(goal))
+	ctrl.db.First(&goal, "id = ?", goal.ID)
+	return c.JSON(http.StatusOK, ctrl.buildCurrentGoalResponse(team, goal))
+}
+
+// goalRequirement 目標の値のうち省略できないもの
+type goalRequirement int
+
+const (
+	// goalComplete チーム目標・再戦の目標: running は距離、gym は回数が必須
+	goalComplete goalRequirement = iota
+	// goalOverride 個別目標: gym は回数か時間のどちらかがあればよい
+	goalOverride
+	// goalPartial 設定変更の提案: 変更する値だけを指定する
+	goalPartial
+)
+
+// validateGoal 目標の値をチームの運動種別に応じて検証し、不正な場合はメッセージを返す
+// チーム目標・個別目標・設定変更の提案・再戦の目標はすべてここで検証する
+func validateGoal(exerciseType string, req requests.CreateGoalRequest, requirement goalRequirement) string {
+	switch exerciseType {
+	case "running":
+		if req.TargetVisitsPerWeek != nil || req.TargetMinDurationMin != nil {
+			return "running チームでは target_distance_km のみ指定できます"
+		}
+		if (req.TargetDistanceKM == nil && requirement != goalPartial) ||
+			(req.TargetDistanceKM != nil && *req.TargetDistanceKM <= 0) {
+			return "target_distance_km に0より大きい値を指定してください"
+		}
+	case "gym":
+		if req.TargetDistanceKM != nil {
+			return "gym チームでは target_visits_per_week と target_min_duration_min のみ指定できます"
+		}
+		if requirement == goalOverride && req.TargetVisitsPerWeek == nil && req.TargetMinDurationMin == nil {
+			return "target_visits_per_week または target_min_duration_min を指定してください"
+		}
+		if (req.TargetVisitsPerWeek == nil && requirement == goalComplete) ||
+			(req.TargetVisitsPerWeek != nil && *req.TargetVisitsPerWeek <= 0) {
+			return "target_visits_per_week に1以上の値を指定してください"
+		}
+		if req.TargetMinDurationMin != nil && *req.TargetMinDurationMin < 0 {
+			return "target_min_duration_min は0以上を指定してください"
+		}
+	}
+	return ""
+}
+
+// buildCurrentGoalResponse 今週適用されている目標を返し、最新の版がまだ適用前なら pending に入れる
+func (ctrl *GoalController) buildCurrentGoalResponse(team models.Team, latest models.Goal) response.GoalResponse {
+	current, _, err := service.GoalForWeek(ctrl.db, team.ID, team.CurrentWeek)
+	if err != nil {
+		return newGoalResponse(latest)
+	}
+	resp := newGoalResponse(current)
+	if latest.Version > current.Version {
+		pending := newGoalResponse(latest)
+		resp.Pending = &pending
+	}
+	return resp
 }
 
 func newGoalResponse(goal models.Goal) response.GoalResponse {
@@ -225,6 +450,8 @@ func newGoalResponse(goal models.Goal) response.GoalResponse {
 		TargetDistanceKM:     goal.TargetDistanceKM,
 		TargetVisitsPerWeek:  goal.TargetVisitsPerWeek,
 		TargetMinDurationMin: goal.TargetMinDurationMin,
+		Version:              goal.Version,
+		EffectiveFromWeek:    goal.EffectiveFromWeek,
 		CreatedAt:            goal.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:            goal.UpdatedAt.Format(time.RFC3339),
 	}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -8,16 +9,18 @@ import (
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/requests"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"github.com/trihackathon/api/utils"
 	"gorm.io/gorm"
 )
 
 type GymController struct {
-	db *gorm.DB
+	db           *gorm.DB
+	nudgeService *service.NudgeService
 }
 
-func NewGymController(db *gorm.DB) *GymController {
-	return &GymController{db: db}
+func NewGymController(db *gorm.DB, nudgeService *service.NudgeService) *GymController {
+	return &GymController{db: db, nudgeService: nudgeService}
 }
 
 // CreateGymLocation ジム位置登録
@@ -214,7 +217,7 @@ func (ctrl *GymController) GymCheckin(c echo.Context) error {
 	}
 
 	// デバッグ: リクエスト内容をログ出力
-	c.Logger().Infof("チェックインリクエスト: gym_location_id=%s, lat=%f, lon=%f", 
+	c.Logger().Infof("チェックインリクエスト: gym_location_id=%s, lat=%f, lon=%f",
 		req.GymLocationID, req.Latitude, req.Longitude)
 
 	// バリデーション
@@ -254,7 +257,7 @@ func (ctrl *GymController) GymCheckin(c echo.Context) error {
 		Where("team_members.user_id = ?", uid).
 		Where("teams.status IN ?", []string{"forming", "active"}).
 		First(&team).Error
-	
+
 	if err == nil {
 		// チームが見つかった場合、検証を行う
 		// チームがactiveか確認
@@ -272,7 +275,7 @@ func (ctrl *GymController) GymCheckin(c echo.Context) error {
 				Message: "チームの運動タイプがジムではありません",
 			})
 		}
-		
+
 		teamID = &team.ID
 	} else if err != gorm.ErrRecordNotFound {
 		// チームが見つからない以外のエラー
@@ -440,6 +443,14 @@ func (ctrl *GymController) GymCheckout(c echo.Context) error {
 		})
 	}
 
+	// 今日まだ運動していないチームメイトに声をかける（失敗してもチェックアウトは成功させる）
+	if _, err := ctrl.nudgeService.AfterActivity(activity, now); err != nil {
+		log.Printf("[GymCheckout] failed to nudge teammates: %v", err)
+	}
+	if err := service.EmitActivityCompleted(ctrl.db, activity); err != nil {
+		log.Printf("[GymCheckout] failed to emit webhook: %v", err)
+	}
+
 	// ジム位置名を取得
 	var gymLocationName *string
 	if activity.GymLocationID != nil {
@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,25 +16,48 @@ import (
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/requests"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"github.com/trihackathon/api/utils"
 	"gorm.io/gorm"
 )
 
+var (
+	errCodeExhausted = errors.New("invite code exhausted")
+)
+
+const (
+	defaultInviteExpiryHours = 24
+	maxInviteExpiryHours     = 168
+	defaultQRScale           = 8
+	maxQRScale               = 32
+)
+
 type InviteController struct {
-	db *gorm.DB
+	db                *gorm.DB
+	inviteService     *service.InviteService
+	membershipService *service.MembershipService
+}
+
+func NewInviteController(db *gorm.DB, inviteService *service.InviteService, membershipService *service.MembershipService) *InviteController {
+	return &InviteController{db: db, inviteService: inviteService, membershipService: membershipService}
 }
 
-func NewInviteController(db *gorm.DB) *InviteController {
-	return &InviteController{db: db}
+// invalidCodeResponse 存在しない・期限切れ・失効・使用上限のいずれも同じ応答にして、コードの有無を推測させない
+var invalidCodeResponse = response.ErrorResponse{
+	Error:   "invalid_code",
+	Message: "招待コードが無効です",
 }
 
 // CreateInviteCode 招待コード生成
 // @Summary      招待コード生成
-// @Description  6桁の英数大文字の招待コードを生成する。有効期限は24時間。チーム状態がformingかつメンバー3人未満の場合のみ発行可能。
+// @Description  6桁の英数大文字の招待コードを生成する。有効期限はexpires_in_hours（1〜168、デフォルト24時間）、使用回数はmax_uses（省略で無制限）で指定できる。チーム状態がformingかつメンバーがmax_members未満の場合のみ発行可能。
 // @Tags         invite
+// @Accept       json
 // @Produce      json
-// @Param        teamId  path      string  true  "チームID"
+// @Param        teamId  path      string                            true   "チームID"
+// @Param        body    body      requests.CreateInviteCodeRequest  false  "有効期限・使用回数"
 // @Success      201     {object}  response.InviteCodeResponse
+// @Failure      400     {object}  response.ErrorResponse
 // @Failure      403     {object}  response.ErrorResponse
 // @Failure      422     {object}  response.ErrorResponse
 // @Router       /api/teams/{teamId}/invite [post]
@@ -38,6 +66,30 @@ func (ctrl *InviteController) CreateInviteCode(c echo.Context) error {
 	uid := c.Get("uid").(string)
 	teamId := c.Param("teamId")
 
+	req := new(requests.CreateInviteCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	expiresInHours := defaultInviteExpiryHours
+	if req.ExpiresInHours != nil {
+		expiresInHours = *req.ExpiresInHours
+	}
+	if expiresInHours < 1 || expiresInHours > maxInviteExpiryHours {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("expires_in_hours は1〜%dの範囲で指定してください", maxInviteExpiryHours),
+		})
+	}
+	if req.MaxUses != nil && *req.MaxUses < 1 {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "max_uses は1以上で指定してください",
+		})
+	}
+
 	// チーム存在確認
 	var team models.Team
 	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
@@ -67,7 +119,7 @@ func (ctrl *InviteController) CreateInviteCode(c echo.Context) error {
 	// メンバー数確認
 	var memberCount int64
 	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
-	if memberCount >= 3 {
+	if int(memberCount) >= team.MaxMembers {
 		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
 			Error:   "team_full",
 			Message: "チームは満員です",
@@ -79,7 +131,8 @@ func (ctrl *InviteController) CreateInviteCode(c echo.Context) error {
 		Code:      code,
 		TeamID:    teamId,
 		CreatedBy: uid,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: time.Now().Add(time.Duration(expiresInHours) * time.Hour),
+		MaxUses:   req.MaxUses,
 	}
 
 	if err := ctrl.db.Create(&inviteCode).Error; err != nil {
@@ -96,12 +149,225 @@ func (ctrl *InviteController) CreateInviteCode(c echo.Context) error {
 		ExerciseType:       team.ExerciseType,
 		ExpiresAt:          inviteCode.ExpiresAt.Format(time.RFC3339),
 		CurrentMemberCount: int(memberCount),
+		MaxMemberCount:     team.MaxMembers,
+		MaxUses:            inviteCode.MaxUses,
+		QRCodeURL:          fmt.Sprintf("/api/teams/%s/invites/%s/qr", team.ID, code),
+	})
+}
+
+// ListInviteCodes 招待コード一覧
+// @Summary      招待コード一覧
+// @Description  チームの招待コードを状態（active / expired / revoked / exhausted）と使用履歴付きで返す
+// @Tags         invite
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.InviteCodeListResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/invites [get]
+// @Security     BearerAuth
+func (ctrl *InviteController) ListInviteCodes(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	var codes []models.InviteCode
+	ctrl.db.Where("team_id = ?", teamId).Order("created_at DESC").Find(&codes)
+
+	var uses []models.InviteCodeUse
+	ctrl.db.Preload("User").Where("team_id = ?", teamId).Order("used_at ASC").Find(&uses)
+	usesByCode := make(map[string][]models.InviteCodeUse)
+	for _, u := range uses {
+		usesByCode[u.Code] = append(usesByCode[u.Code], u)
+	}
+
+	now := time.Now()
+	invites := make([]response.InviteCodeDetailResponse, len(codes))
+	for i, code := range codes {
+		invites[i] = response.NewInviteCodeDetailResponse(code, usesByCode[code.Code], now)
+	}
+
+	return c.JSON(http.StatusOK, response.InviteCodeListResponse{
+		TeamID:  teamId,
+		Invites: invites,
 	})
 }
 
+// RevokeInviteCode 招待コード失効
+// @Summary      招待コード失効
+// @Description  招待コードを失効させる。発行者またはリーダーのみ実行可能
+// @Tags         invite
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Param        code    path      string  true  "招待コード"
+// @Success      200     {object}  response.InviteCodeDetailResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/invites/{code} [delete]
+// @Security     BearerAuth
+func (ctrl *InviteController) RevokeInviteCode(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	var inviteCode models.InviteCode
+	if err := ctrl.db.Where("code = ? AND team_id = ?", c.Param("code"), teamId).First(&inviteCode).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "code_not_found",
+			Message: "招待コードが見つかりません",
+		})
+	}
+
+	if inviteCode.CreatedBy != uid && member.Role != "leader" {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_allowed",
+			Message: "招待コードを失効できるのは発行者かリーダーのみです",
+		})
+	}
+
+	if inviteCode.RevokedAt == nil {
+		now := time.Now()
+		if err := ctrl.db.Model(&inviteCode).Update("revoked_at", now).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Error:   "revoke_failed",
+				Message: "招待コードの失効に失敗しました",
+			})
+		}
+		inviteCode.RevokedAt = &now
+	}
+
+	var uses []models.InviteCodeUse
+	ctrl.db.Preload("User").Where("code = ? AND team_id = ?", inviteCode.Code, teamId).Order("used_at ASC").Find(&uses)
+
+	return c.JSON(http.StatusOK, response.NewInviteCodeDetailResponse(inviteCode, uses, time.Now()))
+}
+
+// GetInviteQRCode 招待QRコード
+// @Summary      招待QRコード
+// @Description  招待コードの参加用ディープリンク（INVITE_LINK_BASE_URL?code=XXXXXX）をQRコード画像で返す
+// @Tags         invite
+// @Produce      png
+// @Produce      image/svg+xml
+// @Param        teamId  path      string  true   "チームID"
+// @Param        code    path      string  true   "招待コード"
+// @Param        format  query     string  false  "png / svg（デフォルト png）"
+// @Param        scale   query     int     false  "1モジュールあたりのピクセル数（1〜32、デフォルト8）"
+// @Success      200     {file}    binary
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/invites/{code}/qr [get]
+// @Security     BearerAuth
+func (ctrl *InviteController) GetInviteQRCode(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "format は png / svg のいずれかを指定してください",
+		})
+	}
+	scale := defaultQRScale
+	if s := c.QueryParam("scale"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > maxQRScale {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: fmt.Sprintf("scale は1〜%dの範囲で指定してください", maxQRScale),
+			})
+		}
+		scale = v
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	var inviteCode models.InviteCode
+	if err := ctrl.db.Where("code = ? AND team_id = ?", c.Param("code"), teamId).First(&inviteCode).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "code_not_found",
+			Message: "招待コードが見つかりません",
+		})
+	}
+
+	qr, err := utils.EncodeQR(inviteJoinLink(inviteCode.Code))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "qr_failed",
+			Message: "QRコードの生成に失敗しました",
+		})
+	}
+
+	if format == "svg" {
+		return c.Blob(http.StatusOK, "image/svg+xml", []byte(qr.SVG(scale)))
+	}
+	img, err := qr.PNG(scale)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "qr_failed",
+			Message: "QRコードの生成に失敗しました",
+		})
+	}
+	return c.Blob(http.StatusOK, "image/png", img)
+}
+
+// rejectInvalidCode 失敗を記録して無効コードの統一応答を返す。今回の失敗でロックされた場合は429を返す
+func (ctrl *InviteController) rejectInvalidCode(c echo.Context, uid, ip, code, reason string) error {
+	err := ctrl.inviteService.RecordJoinFailure(uid, ip, fmt.Sprintf("code=%s reason=%s", code, reason))
+	var lockedErr *service.JoinLockedError
+	if errors.As(err, &lockedErr) {
+		return tooManyJoinAttempts(c, lockedErr)
+	}
+	if err != nil {
+		log.Printf("[JoinTeam] failed to record join failure: %v", err)
+	}
+	return c.JSON(http.StatusNotFound, invalidCodeResponse)
+}
+
+func tooManyJoinAttempts(c echo.Context, lockedErr *service.JoinLockedError) error {
+	retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+		Error:   "too_many_attempts",
+		Message: fmt.Sprintf("試行回数が多すぎます。%d秒後に再度お試しください", retryAfter),
+	})
+}
+
+// inviteJoinLink 招待コードの参加用ディープリンクを返す
+func inviteJoinLink(code string) string {
+	base := os.Getenv("INVITE_LINK_BASE_URL")
+	if base == "" {
+		base = "trihackathon://join"
+	}
+	return base + "?code=" + url.QueryEscape(code)
+}
+
 // JoinTeam 招待コードでチーム参加
 // @Summary      招待コードでチーム参加
-// @Description  招待コードを使用してチームに参加する。3人揃った場合team_readyがtrueになる。
+// @Description  招待コードを使用してチームに参加する。min_members人揃った場合team_readyがtrueになる（チャレンジ開始はリーダーが /start で行う）。無効なコード（存在しない・期限切れ・失効・使用上限）はすべて invalid_code を返す。失敗が続くとユーザー・IP単位で一定時間ロックされ、429とRetry-Afterを返す。
 // @Tags         invite
 // @Accept       json
 // @Produce      json
@@ -110,8 +376,8 @@ func (ctrl *InviteController) CreateInviteCode(c echo.Context) error {
 // @Failure      400   {object}  response.ErrorResponse
 // @Failure      404   {object}  response.ErrorResponse
 // @Failure      409   {object}  response.ErrorResponse
-// @Failure      410   {object}  response.ErrorResponse
 // @Failure      422   {object}  response.ErrorResponse
+// @Failure      429   {object}  response.ErrorResponse
 // @Router       /api/teams/join [post]
 // @Security     BearerAuth
 func (ctrl *InviteController) JoinTeam(c echo.Context) error {
@@ -125,20 +391,44 @@ func (ctrl *InviteController) JoinTeam(c echo.Context) error {
 		})
 	}
 
-	// コード存在確認
+	// 試行制限チェック
+	ip := c.RealIP()
+	var lockedErr *service.JoinLockedError
+	if err := ctrl.inviteService.CheckJoinThrottle(uid, ip); errors.As(err, &lockedErr) {
+		return tooManyJoinAttempts(c, lockedErr)
+	} else if err != nil {
+		log.Printf("[JoinTeam] throttle check error: %v", err)
+	}
+
+	// コード存在・有効期限・失効・使用回数チェック
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
 	var inviteCode models.InviteCode
-	if err := ctrl.db.First(&inviteCode, "code = ?", req.Code).Error; err != nil {
+	reason := ""
+	if err := ctrl.db.First(&inviteCode, "code = ?", code).Error; err != nil {
+		reason = "not_found"
+	} else if time.Now().After(inviteCode.ExpiresAt) {
+		reason = "expired"
+	} else if inviteCode.RevokedAt != nil {
+		reason = "revoked"
+	} else if inviteCode.MaxUses != nil && inviteCode.UseCount >= *inviteCode.MaxUses {
+		reason = "exhausted"
+	}
+	if reason != "" {
+		return ctrl.rejectInvalidCode(c, uid, ip, code, reason)
+	}
+
+	// 参加先チームがメンバー募集中か確認
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", inviteCode.TeamID).Error; err != nil {
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
-			Error:   "code_not_found",
-			Message: "招待コードが見つかりません",
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
 		})
 	}
-
-	// 有効期限チェック
-	if time.Now().After(inviteCode.ExpiresAt) {
-		return c.JSON(http.StatusGone, response.ErrorResponse{
-			Error:   "code_expired",
-			Message: "招待コードの有効期限が切れています",
+	if team.Status != "forming" {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_forming",
+			Message: "チームはメンバー募集中ではありません",
 		})
 	}
 
@@ -158,7 +448,7 @@ func (ctrl *InviteController) JoinTeam(c echo.Context) error {
 	// チーム満員チェック
 	var memberCount int64
 	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", inviteCode.TeamID).Count(&memberCount)
-	if memberCount >= 3 {
+	if int(memberCount) >= team.MaxMembers {
 		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
 			Error:   "team_full",
 			Message: "チームは満員です",
@@ -174,46 +464,61 @@ func (ctrl *InviteController) JoinTeam(c echo.Context) error {
 		})
 	}
 
-	// メンバー追加とチームステータス更新をトランザクションで実行
-	var team models.Team
+	// メンバー追加をトランザクションで実行
 	var members []models.TeamMember
 	var teamReady bool
 
 	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
-		// メンバー追加
-		memberID := utils.GenerateULID()
-		member := models.TeamMember{
-			ID:     memberID,
-			TeamID: inviteCode.TeamID,
-			UserID: uid,
-			Role:   "member",
-		}
-		if err := tx.Create(&member).Error; err != nil {
+		// メンバー追加（同時参加で上限を超えた場合はロールバック。参加の通知・Webhookもここで送る）
+		if _, err := ctrl.membershipService.AddMember(tx, team, uid, "member"); err != nil {
 			return err
 		}
-
-		// メンバー数を確認
 		var memberCount int64
 		if err := tx.Model(&models.TeamMember{}).Where("team_id = ?", inviteCode.TeamID).Count(&memberCount).Error; err != nil {
 			return err
 		}
 
-		// 3人揃ったらステータスをactiveに更新（started_at, current_weekも設定）
-		if memberCount >= 3 {
-			now := time.Now()
-			if err := tx.Model(&models.Team{}).Where("id = ?", inviteCode.TeamID).Updates(map[string]interface{}{
-				"status":       "active",
-				"started_at":   now,
-				"current_week": 1,
-			}).Error; err != nil {
-				return err
-			}
-			teamReady = true
+		// 使用回数を加算（同時使用で上限を超えた場合はロールバック）
+		res := tx.Model(&models.InviteCode{}).
+			Where("code = ? AND revoked_at IS NULL AND (max_uses IS NULL OR use_count < max_uses)", inviteCode.Code).
+			UpdateColumn("use_count", gorm.Expr("use_count + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errCodeExhausted
+		}
+		use := models.InviteCodeUse{
+			ID:     utils.GenerateULID(),
+			Code:   inviteCode.Code,
+			TeamID: inviteCode.TeamID,
+			UserID: uid,
 		}
+		if err := tx.Create(&use).Error; err != nil {
+			return err
+		}
+
+		// 最少人数が揃ったらリーダーがチャレンジを開始できる
+		teamReady = int(memberCount) >= team.MinMembers
 
 		return nil
 	})
 
+	if errors.Is(err, service.ErrAlreadyInTeam) {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_in_team",
+			Message: "既にアクティブなチームに所属しています",
+		})
+	}
+	if errors.Is(err, service.ErrTeamFull) {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_full",
+			Message: "チームは満員です",
+		})
+	}
+	if errors.Is(err, errCodeExhausted) {
+		return ctrl.rejectInvalidCode(c, uid, ip, code, "exhausted")
+	}
 	if err != nil {
 		log.Printf("[JoinTeam] transaction error: %v", err)
 		// ユーザーが存在しない場合のエラーメッセージを改善
@@ -236,6 +541,10 @@ func (ctrl *InviteController) JoinTeam(c echo.Context) error {
 		})
 	}
 
+	if err := ctrl.inviteService.ResetJoinThrottle(uid); err != nil {
+		log.Printf("[JoinTeam] throttle reset error: %v", err)
+	}
+
 	// レスポンス構築
 	ctrl.db.First(&team, "id = ?", inviteCode.TeamID)
 	ctrl.db.Preload("User").Where("team_id = ?", team.ID).Find(&members)
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+type MatchmakingController struct {
+	db                 *gorm.DB
+	matchmakingService *service.MatchmakingService
+}
+
+func NewMatchmakingController(db *gorm.DB, matchmakingService *service.MatchmakingService) *MatchmakingController {
+	return &MatchmakingController{db: db, matchmakingService: matchmakingService}
+}
+
+// JoinMatchmaking マッチング申し込み
+// @Summary      マッチング申し込み
+// @Description  チーム未所属のユーザーがマッチング待ちに入る。運動種別・厳しさ・朝型夜型・目標レベルが近いユーザーが3人揃うとformingチームが作成される
+// @Tags         matchmaking
+// @Accept       json
+// @Produce      json
+// @Param        body  body      requests.MatchmakingRequest  true  "マッチング条件"
+// @Success      201   {object}  response.MatchmakingTicketResponse
+// @Failure      400   {object}  response.ErrorResponse
+// @Failure      409   {object}  response.ErrorResponse
+// @Failure      422   {object}  response.ErrorResponse
+// @Router       /api/matchmaking [post]
+// @Security     BearerAuth
+func (ctrl *MatchmakingController) JoinMatchmaking(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	req := new(requests.MatchmakingRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	if req.Strictness == "" {
+		req.Strictness = "normal"
+	}
+	if req.Strictness != "normal" && req.Strictness != "strict" && req.Strictness != "relaxed" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "strictness は normal / strict / relaxed のいずれかを指定してください",
+		})
+	}
+
+	var targetLevel float64
+	switch req.ExerciseType {
+	case "running":
+		if req.TargetDistanceKM == nil || *req.TargetDistanceKM <= 0 {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "running の場合は target_distance_km を指定してください",
+			})
+		}
+		targetLevel = *req.TargetDistanceKM
+	case "gym":
+		if req.TargetVisitsPerWeek == nil || *req.TargetVisitsPerWeek <= 0 {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "gym の場合は target_visits_per_week を指定してください",
+			})
+		}
+		targetLevel = float64(*req.TargetVisitsPerWeek)
+	default:
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "exercise_type は running または gym を指定してください",
+		})
+	}
+
+	var user models.User
+	if err := ctrl.db.First(&user, "id = ?", uid).Error; err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "user_not_registered",
+			Message: "プロフィールが未登録です。先にアカウント設定（新規登録）を完了してください",
+		})
+	}
+
+	var existingCount int64
+	ctrl.db.Model(&models.TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id").
+		Where("team_members.user_id = ? AND teams.status IN ?", uid, []string{"forming", "active"}).
+		Count(&existingCount)
+	if existingCount > 0 {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_in_team",
+			Message: "既にアクティブなチームに所属しています",
+		})
+	}
+
+	var waitingCount int64
+	ctrl.db.Model(&models.MatchmakingTicket{}).Where("user_id = ? AND status = ?", uid, "waiting").Count(&waitingCount)
+	if waitingCount > 0 {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_waiting",
+			Message: "既にマッチング待ちです",
+		})
+	}
+
+	ticket := models.MatchmakingTicket{
+		ID:           utils.GenerateULID(),
+		UserID:       uid,
+		ExerciseType: req.ExerciseType,
+		Strictness:   req.Strictness,
+		Chronotype:   user.Chronotype,
+		TargetLevel:  targetLevel,
+		Status:       "waiting",
+	}
+	if err := ctrl.db.Create(&ticket).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "マッチングの申し込みに失敗しました",
+		})
+	}
+
+	// 条件の合うユーザーが既に待っていればすぐにチームを作る
+	if _, err := ctrl.matchmakingService.RunMatchmaking(); err != nil {
+		log.Printf("[JoinMatchmaking] matchmaking error: %v", err)
+	}
+	ctrl.db.First(&ticket, "id = ?", ticket.ID)
+
+	return c.JSON(http.StatusCreated, response.NewMatchmakingTicketResponse(ticket))
+}
+
+// GetMyMatchmaking マッチング状況を取得
+// @Summary      マッチング状況を取得
+// @Description  自分の最新のマッチング申し込みの状態を返す。matchedの場合はteam_idに作成されたチームが入る
+// @Tags         matchmaking
+// @Produce      json
+// @Success      200  {object}  response.MatchmakingTicketResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Router       /api/matchmaking/me [get]
+// @Security     BearerAuth
+func (ctrl *MatchmakingController) GetMyMatchmaking(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	var ticket models.MatchmakingTicket
+	if err := ctrl.db.Where("user_id = ?", uid).Order("created_at DESC").First(&ticket).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "ticket_not_found",
+			Message: "マッチングの申し込みがありません",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.NewMatchmakingTicketResponse(ticket))
+}
+
+// CancelMatchmaking マッチングを取り消し
+// @Summary      マッチングを取り消し
+// @Description  マッチング待ちを取り消す
+// @Tags         matchmaking
+// @Success      204
+// @Failure      404  {object}  response.ErrorResponse
+// @Router       /api/matchmaking/me [delete]
+// @Security     BearerAuth
+func (ctrl *MatchmakingController) CancelMatchmaking(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	result := ctrl.db.Model(&models.MatchmakingTicket{}).
+		Where("user_id = ? AND status = ?", uid, "waiting").
+		Update("status", "cancelled")
+	if result.Error != nil || result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "ticket_not_found",
+			Message: "マッチング待ちの申し込みがありません",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
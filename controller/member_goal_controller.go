@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+type MemberGoalController struct {
+	db *gorm.DB
+}
+
+func NewMemberGoalController(db *gorm.DB) *MemberGoalController {
+	return &MemberGoalController{db: db}
+}
+
+// ProposeMemberGoal メンバー個別目標を提案
+// @Summary      メンバー個別目標を提案
+// @Description  リーダーがメンバーに個別の目標を提案する。本人が承認すると、activeチームでは翌週の評価から適用される。リーダー自身の個別目標は提案と同時に承認される。省略した目標値はチーム目標を使う
+// @Tags         goals
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                            true  "チームID"
+// @Param        body    body      requests.CreateMemberGoalRequest  true  "個別目標"
+// @Success      201     {object}  response.MemberGoalResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/member-goals [post]
+// @Security     BearerAuth
+func (ctrl *MemberGoalController) ProposeMemberGoal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	req := new(requests.CreateMemberGoalRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+	if team.Status != "forming" && team.Status != "active" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームでは個別目標を設定できません",
+		})
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+	if member.Role != "leader" {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみ個別目標を提案できます",
+		})
+	}
+
+	var target models.TeamMember
+	if err := ctrl.db.First(&target, "team_id = ? AND user_id = ?", teamId, req.UserID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "member_not_found",
+			Message: "対象のメンバーが見つかりません",
+		})
+	}
+
+	if msg := validateGoal(team.ExerciseType, req.CreateGoalRequest, goalOverride); msg != "" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: msg,
+		})
+	}
+
+	override := models.MemberGoalOverride{
+		ID:                   utils.GenerateULID(),
+		TeamID:               teamId,
+		UserID:               req.UserID,
+		ProposedBy:           uid,
+		Status:               "proposed",
+		TargetDistanceKM:     req.TargetDistanceKM,
+		TargetVisitsPerWeek:  req.TargetVisitsPerWeek,
+		TargetMinDurationMin: req.TargetMinDurationMin,
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		// 未回答の提案は新しい提案で置き換える
+		if err := tx.Model(&models.MemberGoalOverride{}).
+			Where("team_id = ? AND user_id = ? AND status = ?", teamId, req.UserID, "proposed").
+			Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&override).Error; err != nil {
+			return err
+		}
+		if req.UserID == uid {
+			return service.AcceptMemberGoal(tx, team, &override)
+		}
+		return service.Notify(tx, req.UserID, "member_goal_proposed", map[string]string{"team": team.Name}, &team.ID)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "個別目標の提案に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, response.NewMemberGoalResponse(override))
+}
+
+// GetMemberGoals メンバー個別目標一覧
+// @Summary      メンバー個別目標一覧
+// @Description  チームの個別目標を返す。省略時は提案中（proposed）と適用中（accepted）のみ
+// @Tags         goals
+// @Produce      json
+// @Param        teamId  path      string  true   "チームID"
+// @Param        status  query     string  false  "proposed / accepted / declined / cancelled / superseded / ended"
+// @Success      200     {array}   response.MemberGoalResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/member-goals [get]
+// @Security     BearerAuth
+func (ctrl *MemberGoalController) GetMemberGoals(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	query := ctrl.db.Where("team_id = ?", teamId)
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{"proposed", "accepted"})
+	}
+	var overrides []models.MemberGoalOverride
+	query.Order("created_at DESC").Find(&overrides)
+
+	result := make([]response.MemberGoalResponse, len(overrides))
+	for i, o := range overrides {
+		result[i] = response.NewMemberGoalResponse(o)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// AcceptMemberGoal 個別目標を承認
+// @Summary      個別目標を承認
+// @Description  自分宛ての個別目標を承認する。activeチームでは翌週の評価から適用され、それまでの個別目標は今週までで終了する
+// @Tags         goals
+// @Produce      json
+// @Param        teamId        path      string  true  "チームID"
+// @Param        memberGoalId  path      string  true  "個別目標ID"
+// @Success      200           {object}  response.MemberGoalResponse
+// @Failure      403           {object}  response.ErrorResponse
+// @Failure      404           {object}  response.ErrorResponse
+// @Failure      409           {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/member-goals/{memberGoalId}/accept [post]
+// @Security     BearerAuth
+func (ctrl *MemberGoalController) AcceptMemberGoal(c echo.Context) error {
+	override, team, errResp := ctrl.findOwnProposal(c)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	if err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return service.AcceptMemberGoal(tx, *team, override)
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "accept_failed",
+			Message: "個別目標の承認に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.NewMemberGoalResponse(*override))
+}
+
+// DeclineMemberGoal 個別目標を辞退
+// @Summary      個別目標を辞退
+// @Tags         goals
+// @Produce      json
+// @Param        teamId        path      string  true  "チームID"
+// @Param        memberGoalId  path      string  true  "個別目標ID"
+// @Success      200           {object}  response.MemberGoalResponse
+// @Failure      403           {object}  response.ErrorResponse
+// @Failure      404           {object}  response.ErrorResponse
+// @Failure      409           {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/member-goals/{memberGoalId}/decline [post]
+// @Security     BearerAuth
+func (ctrl *MemberGoalController) DeclineMemberGoal(c echo.Context) error {
+	override, _, errResp := ctrl.findOwnProposal(c)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	now := time.Now()
+	if err := ctrl.db.Model(override).Updates(map[string]interface{}{
+		"status":       "declined",
+		"responded_at": now,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "decline_failed",
+			Message: "個別目標の辞退に失敗しました",
+		})
+	}
+	override.Status = "declined"
+	override.RespondedAt = &now
+
+	return c.JSON(http.StatusOK, response.NewMemberGoalResponse(*override))
+}
+
+// DeleteMemberGoal 個別目標を取り消す
+// @Summary      個別目標を取り消す
+// @Description  提案中の個別目標はリーダーが取り下げられる。適用中の個別目標はリーダーまたは本人が終了でき、activeチームでは翌週からチーム目標に戻る
+// @Tags         goals
+// @Produce      json
+// @Param        teamId        path      string  true  "チームID"
+// @Param        memberGoalId  path      string  true  "個別目標ID"
+// @Success      200           {object}  response.MemberGoalResponse
+// @Failure      403           {object}  response.ErrorResponse
+// @Failure      404           {object}  response.ErrorResponse
+// @Failure      409           {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/member-goals/{memberGoalId} [delete]
+// @Security     BearerAuth
+func (ctrl *MemberGoalController) DeleteMemberGoal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	var override models.MemberGoalOverride
+	if err := ctrl.db.First(&override, "id = ? AND team_id = ?", c.Param("memberGoalId"), teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "member_goal_not_found",
+			Message: "個別目標が見つかりません",
+		})
+	}
+
+	isLeader := member.Role == "leader"
+	var err error
+	switch override.Status {
+	case "proposed":
+		if !isLeader {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Error:   "not_leader",
+				Message: "提案中の個別目標を取り下げられるのはリーダーのみです",
+			})
+		}
+		override.Status = "cancelled"
+		err = ctrl.db.Model(&override).Update("status", override.Status).Error
+	case "accepted":
+		if !isLeader && override.UserID != uid {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Error:   "forbidden",
+				Message: "個別目標を終了できるのはリーダーか本人のみです",
+			})
+		}
+		err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+			return service.EndMemberGoal(tx, team, &override)
+		})
+	default:
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "member_goal_closed",
+			Message: "この個別目標は既に終了しています",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "delete_failed",
+			Message: "個別目標の取り消しに失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.NewMemberGoalResponse(override))
+}
+
+// findOwnProposal 自分宛ての提案中の個別目標を取得する
+func (ctrl *MemberGoalController) findOwnProposal(c echo.Context) (*models.MemberGoalOverride, *models.Team, *errorReply) {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var override models.MemberGoalOverride
+	if err := ctrl.db.First(&override, "id = ? AND team_id = ?", c.Param("memberGoalId"), teamId).Error; err != nil {
+		return nil, nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "member_goal_not_found",
+			Message: "個別目標が見つかりません",
+		}}
+	}
+	if override.UserID != uid {
+		return nil, nil, &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "forbidden",
+			Message: "自分宛ての個別目標のみ回答できます",
+		}}
+	}
+	if override.Status != "proposed" {
+		return nil, nil, &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "member_goal_closed",
+			Message: "この個別目標は既に回答済みか取り下げられています",
+		}}
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return nil, nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		}}
+	}
+	if team.Status != "forming" && team.Status != "active" {
+		return nil, nil, &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームでは個別目標を設定できません",
+		}}
+	}
+	return &override, &team, nil
+}
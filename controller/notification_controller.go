@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDeviceTokenLength 端末トークンの最大長
+const maxDeviceTokenLength = 4096
+
+type NotificationController struct {
+	db *gorm.DB
+}
+
+func NewNotificationController(db *gorm.DB) *NotificationController {
+	return &NotificationController{db: db}
+}
+
+// GetNotifications 自分の通知一覧
+// @Summary      自分の通知一覧
+// @Description  アプリ内通知を新しい順に返す。unread_only=true で未読のみ
+// @Tags         notifications
+// @Produce      json
+// @Param        unread_only  query     bool  false  "未読のみ"
+// @Param        limit        query     int   false  "取得件数（1〜100、省略時50）"
+// @Success      200          {object}  response.NotificationListResponse
+// @Router       /api/notifications [get]
+// @Security     BearerAuth
+func (ctrl *NotificationController) GetNotifications(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	limit := 50
+	if v, err := strconv.Atoi(c.QueryParam("limit")); err == nil && v >= 1 && v <= 100 {
+		limit = v
+	}
+
+	query := ctrl.db.Where("user_id = ?", uid)
+	if c.QueryParam("unread_only") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	var notifications []models.Notification
+	query.Order("created_at DESC").Limit(limit).Find(&notifications)
+
+	var unreadCount int64
+	ctrl.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", uid).Count(&unreadCount)
+
+	result := make([]response.NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		result[i] = response.NewNotificationResponse(n)
+	}
+	return c.JSON(http.StatusOK, response.NotificationListResponse{
+		Notifications: result,
+		UnreadCount:   unreadCount,
+	})
+}
+
+// MarkNotificationRead 通知を既読にする
+// @Summary      通知を既読にする
+// @Tags         notifications
+// @Produce      json
+// @Param        notificationId  path      string  true  "通知ID"
+// @Success      200             {object}  response.NotificationResponse
+// @Failure      404             {object}  response.ErrorResponse
+// @Router       /api/notifications/{notificationId}/read [post]
+// @Security     BearerAuth
+func (ctrl *NotificationController) MarkNotificationRead(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	var notification models.Notification
+	if err := ctrl.db.First(&notification, "id = ? AND user_id = ?", c.Param("notificationId"), uid).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "notification_not_found",
+			Message: "通知が見つかりません",
+		})
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		ctrl.db.Model(&notification).Update("read_at", now)
+		notification.ReadAt = &now
+	}
+
+	return c.JSON(http.StatusOK, response.NewNotificationResponse(notification))
+}
+
+// MarkAllNotificationsRead すべての通知を既読にする
+// @Summary      すべての通知を既読にする
+// @Tags         notifications
+// @Success      204
+// @Router       /api/notifications/read-all [post]
+// @Security     BearerAuth
+func (ctrl *NotificationController) MarkAllNotificationsRead(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	if err := ctrl.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", uid).
+		Update("read_at", time.Now()).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "update_failed",
+			Message: "通知の更新に失敗しました",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegisterDevice プッシュ通知の端末登録
+// @Summary      プッシュ通知の端末登録
+// @Description  FCMの登録トークンを登録する。同じトークンが登録済みなら最終利用日時を更新し、別のユーザーの登録だった場合は自分に付け替える
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        body  body      requests.RegisterDeviceRequest  true  "端末トークン"
+// @Success      200   {object}  response.DeviceTokenResponse
+// @Success      201   {object}  response.DeviceTokenResponse
+// @Failure      400   {object}  response.ErrorResponse
+// @Router       /api/devices [post]
+// @Security     BearerAuth
+func (ctrl *NotificationController) RegisterDevice(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	req := new(requests.RegisterDeviceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" || len(req.Token) > maxDeviceTokenLength {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "token を指定してください",
+		})
+	}
+
+	var device *models.DeviceToken
+	var created bool
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		device, created, err = service.RegisterDevice(tx, uid, req.Token, req.Platform)
+		return err
+	})
+	if errors.Is(err, service.ErrInvalidPlatform) {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_platform",
+			Message: "platform は ios / android / web のいずれかを指定してください",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "register_failed",
+			Message: "端末の登録に失敗しました",
+		})
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, response.NewDeviceTokenResponse(*device))
+}
+
+// DeleteDevice プッシュ通知の端末登録解除
+// @Summary      プッシュ通知の端末登録解除
+// @Description  ログアウト時などに端末の登録を削除する。未送信のプッシュ通知も取り消される
+// @Tags         notifications
+// @Param        deviceId  path  string  true  "端末ID"
+// @Success      204
+// @Failure      404  {object}  response.ErrorResponse
+// @Router       /api/devices/{deviceId} [delete]
+// @Security     BearerAuth
+func (ctrl *NotificationController) DeleteDevice(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	var device models.DeviceToken
+	if err := ctrl.db.First(&device, "id = ? AND user_id = ?", c.Param("deviceId"), uid).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "device_not_found",
+			Message: "端末が見つかりません",
+		})
+	}
+
+	if err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return service.UnregisterDevice(tx, device)
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "delete_failed",
+			Message: "端末の登録解除に失敗しました",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetNotificationPreferences 通知設定の取得
+// @Summary      通知設定の取得
+// @Description  通知の言語、プッシュ通知のオン・オフ、プッシュ通知しないカテゴリと登録済みの端末を返す
+// @Tags         notifications
+// @Produce      json
+// @Success      200  {object}  response.NotificationPreferenceResponse
+// @Router       /api/notification-preferences [get]
+// @Security     BearerAuth
+func (ctrl *NotificationController) GetNotificationPreferences(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	pref, err := service.NotificationPreferenceFor(ctrl.db, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "internal_error",
+			Message: "通知設定の取得に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, ctrl.preferenceResponse(uid, pref))
+}
+
+// UpdateNotificationPreferences 通知設定の更新
+// @Summary      通知設定の更新
+// @Description  通知の言語（ja / en）、プッシュ通知のオン・オフ、プッシュ通知しないカテゴリを変更する。アプリ内通知はカテゴリに関わらず作成される
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        body  body      requests.UpdateNotificationPreferenceRequest  true  "通知設定"
+// @Success      200   {object}  response.NotificationPreferenceResponse
+// @Failure      400   {object}  response.ErrorResponse
+// @Router       /api/notification-preferences [put]
+// @Security     BearerAuth
+func (ctrl *NotificationController) UpdateNotificationPreferences(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	req := new(requests.UpdateNotificationPreferenceRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Locale != nil {
+		if !service.ValidNotificationLocale(*req.Locale) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_locale",
+				Message: "locale は ja / en のいずれかを指定してください",
+			})
+		}
+		updates["locale"] = *req.Locale
+	}
+	if req.PushEnabled != nil {
+		updates["push_enabled"] = *req.PushEnabled
+	}
+	if req.MutedCategories != nil {
+		seen := map[string]bool{}
+		muted := []string{}
+		for _, category := range *req.MutedCategories {
+			if !service.ValidNotificationCategory(category) {
+				return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+					Error:   "invalid_category",
+					Message: fmt.Sprintf("不明なカテゴリです: %s", category),
+				})
+			}
+			if !seen[category] {
+				seen[category] = true
+				muted = append(muted, category)
+			}
+		}
+		updates["muted_categories"] = strings.Join(muted, ",")
+	}
+
+	var pref models.NotificationPreference
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		// 既定値の行を作ってから更新する（false を指定しても列の既定値 true にならないように）
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.NotificationPreference{UserID: uid, Locale: "ja", PushEnabled: true}).Error; err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&models.NotificationPreference{}).Where("user_id = ?", uid).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.First(&pref, "user_id = ?", uid).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "update_failed",
+			Message: "通知設定の更新に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, ctrl.preferenceResponse(uid, pref))
+}
+
+func (ctrl *NotificationController) preferenceResponse(uid string, pref models.NotificationPreference) response.NotificationPreferenceResponse {
+	var devices []models.DeviceToken
+	ctrl.db.Where("user_id = ?", uid).Order("last_seen_at DESC").Find(&devices)
+	deviceResponses := make([]response.DeviceTokenResponse, len(devices))
+	for i, d := range devices {
+		deviceResponses[i] = response.NewDeviceTokenResponse(d)
+	}
+	return response.NotificationPreferenceResponse{
+		Locale:          pref.Locale,
+		PushEnabled:     pref.PushEnabled,
+		MutedCategories: service.MutedCategories(pref),
+		Categories:      service.NotificationCategories,
+		Devices:         deviceResponses,
+	}
+}
@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strings"
 	"time"
@@ -9,22 +11,25 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"github.com/trihackathon/api/utils"
 	"gorm.io/gorm"
 )
 
 type PredictionController struct {
-	db *gorm.DB
+	db                *gorm.DB
+	predictionService *service.PredictionService
 }
 
-func NewPredictionController(db *gorm.DB) *PredictionController {
-	return &PredictionController{db: db}
+func NewPredictionController(db *gorm.DB, predictionService *service.PredictionService) *PredictionController {
+	return &PredictionController{db: db, predictionService: predictionService}
 }
 
 var dayNames = [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"}
 
 // GetMyPrediction 自分の失敗予測
 // @Summary      自分の失敗予測
-// @Description  過去のアクティビティデータから曜日別の成功率を算出し、危険な曜日を警告する。成功率40%未満の曜日を「危険」と判定。
+// @Description  参加中（active）のチームについて、今週の残りの各日に運動できる確率を曜日・時刻の習慣と朝型夜型・連続達成・目標までの残り・チームメイトの活動から予測し、今週の目標を達成できない確率とおすすめの時間帯を返す。daily_stats は過去8週間の曜日別の実績で、成功率40%未満の曜日を「危険」と判定する
 // @Tags         predictions
 // @Produce      json
 // @Success      200  {object}  response.PredictionResponse
@@ -35,97 +40,207 @@ var dayNames = [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "
 func (ctrl *PredictionController) GetMyPrediction(c echo.Context) error {
 	uid := c.Get("uid").(string)
 
-	// ユーザーがチームに所属しているか確認
-	var teamMember models.TeamMember
-	if err := ctrl.db.Preload("Team").First(&teamMember, "user_id = ?", uid).Error; err != nil {
+	prediction, err := ctrl.predictionService.PredictForUser(uid, time.Now())
+	if errors.Is(err, service.ErrNoActiveTeam) {
+		// 開始前のチームに所属している場合は区別して返す
+		var count int64
+		ctrl.db.Model(&models.TeamMember{}).
+			Joins("JOIN teams ON teams.id = team_members.team_id").
+			Where("team_members.user_id = ? AND teams.status = ?", uid, "forming").
+			Count(&count)
+		if count > 0 {
+			return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+				Error:   "team_not_active",
+				Message: "チームがまだアクティブになっていません",
+			})
+		}
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
 			Error:   "no_team",
 			Message: "チームに所属していません",
 		})
 	}
-
-	team := teamMember.Team
-	if team.StartedAt == nil {
-		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
-			Error:   "team_not_active",
-			Message: "チームがまだアクティブになっていません",
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "internal_error",
+			Message: "予測の計算に失敗しました",
 		})
 	}
 
-	// 過去4週間のアクティビティを取得
-	analysisPeriodWeeks := 4
-	since := time.Now().AddDate(0, 0, -analysisPeriodWeeks*7)
-
-	var activities []models.Activity
-	ctrl.db.Where("user_id = ? AND status = ? AND started_at >= ?",
-		uid, "completed", since).Find(&activities)
-
-	// 曜日ごとの集計
-	// dayActivity[曜日] = アクティビティがあった日数
-	// dayTotal[曜日] = その曜日の合計日数
-	dayActivity := [7]int{}
-	dayTotal := [7]int{}
-
-	// 分析期間中の各日を走査
-	now := time.Now()
-	for d := since; d.Before(now); d = d.AddDate(0, 0, 1) {
-		dow := int(d.Weekday())
-		dayTotal[dow]++
-	}
-
-	// アクティビティがあった日をカウント（同じ日に複数あっても1回）
-	activityDays := make(map[string]bool)
-	for _, a := range activities {
-		key := a.StartedAt.Format("2006-01-02")
-		activityDays[key] = true
-	}
-	for dateStr := range activityDays {
-		t, err := time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			continue
-		}
-		dow := int(t.Weekday())
-		dayActivity[dow]++
-	}
+	// 日時はユーザーのタイムゾーンで返す
+	loc := utils.LoadLocation(service.UserTimezone(ctrl.db, uid))
 
 	var dailyStats []response.DailyStat
 	var dangerDays []string
-
-	for dow := 0; dow < 7; dow++ {
-		var successRate float64
-		if dayTotal[dow] > 0 {
-			successRate = float64(dayActivity[dow]) / float64(dayTotal[dow])
-		}
-		// データ不足の場合（アクティビティ数が2未満）は危険扱いしない
-		isDanger := dayActivity[dow] >= 2 && successRate < 0.4
+	for _, w := range prediction.Weekdays {
+		isDanger := w.Weak()
 		if isDanger {
-			dangerDays = append(dangerDays, dayNames[dow])
+			dangerDays = append(dangerDays, dayNames[w.DayOfWeek])
 		}
-
 		dailyStats = append(dailyStats, response.DailyStat{
-			DayOfWeek:     dow,
-			DayName:       dayNames[dow],
-			SuccessRate:   successRate,
-			ActivityCount: dayActivity[dow],
+			DayOfWeek:     w.DayOfWeek,
+			DayName:       dayNames[w.DayOfWeek],
+			SuccessRate:   w.SuccessRate,
+			ActivityCount: w.ActiveDays,
 			IsDanger:      isDanger,
 		})
 	}
-
 	if dangerDays == nil {
 		dangerDays = []string{}
 	}
 
-	recommendation := "素晴らしい！全曜日でバランスよく運動できています。"
-	if len(dangerDays) > 0 {
-		recommendation = fmt.Sprintf("%sが危険です。%sは運動をサボりやすい傾向があります。",
-			strings.Join(dangerDays, "と"), strings.Join(dangerDays, "と"))
+	days := make([]response.DayPredictionResponse, len(prediction.Days))
+	for i, d := range prediction.Days {
+		factors := d.Factors
+		if factors == nil {
+			factors = []string{}
+		}
+		days[i] = response.DayPredictionResponse{
+			Date:                d.Date.Format("2006-01-02"),
+			DayOfWeek:           d.DayOfWeek,
+			DayName:             dayNames[d.DayOfWeek],
+			ActivityProbability: d.Probability,
+			Factors:             factors,
+		}
+	}
+	slots := make([]response.SuggestedSlotResponse, len(prediction.Slots))
+	for i, s := range prediction.Slots {
+		slots[i] = response.SuggestedSlotResponse{
+			Start:               s.Start.In(loc).Format(time.RFC3339),
+			End:                 s.End.In(loc).Format(time.RFC3339),
+			ActivityProbability: s.Probability,
+			Reason:              s.Reason,
+		}
 	}
 
 	return c.JSON(http.StatusOK, response.PredictionResponse{
 		UserID:              uid,
-		AnalysisPeriodWeeks: analysisPeriodWeeks,
+		TeamID:              prediction.Team.ID,
+		WeekNumber:          prediction.WeekNumber,
+		WeekStart:           prediction.WeekStart.In(loc).Format(time.RFC3339),
+		WeekEnd:             prediction.WeekEnd.In(loc).Format(time.RFC3339),
+		AnalysisPeriodWeeks: service.PredictionHistoryWeeks,
+		TargetAmount:        math.Round(prediction.Progress.Target*100) / 100,
+		AchievedAmount:      math.Round(prediction.Progress.Achieved*100) / 100,
+		RemainingAmount:     math.Round(prediction.Progress.Remaining()*100) / 100,
+		NeededDays:          prediction.NeededDays,
+		Frozen:              prediction.Progress.Frozen,
+		MissProbability:     prediction.MissProbability,
+		StreakWeeks:         prediction.StreakWeeks,
+		DaysSinceLast:       prediction.DaysSinceLast,
+		TeammatesActiveRate: math.Round(prediction.TeammatesActive*100) / 100,
 		DailyStats:          dailyStats,
 		DangerDays:          dangerDays,
-		Recommendation:      recommendation,
+		Days:                days,
+		SuggestedSlots:      slots,
+		Recommendation:      predictionRecommendation(prediction, dangerDays),
+	})
+}
+
+// predictionRecommendation 予測結果から一言のアドバイスを作る
+func predictionRecommendation(p *service.Prediction, dangerDays []string) string {
+	switch {
+	case p.Progress.Frozen:
+		return "今週はフリーズ中です。無理せず休みましょう。"
+	case p.Progress.Remaining() <= 0:
+		return "今週の目標は達成済みです！この調子で続けましょう。"
+	case len(p.Slots) > 0 && p.MissProbability >= 0.5:
+		s := p.Slots[0]
+		return fmt.Sprintf("このままだと今週の目標を達成できない可能性が%.0f%%あります。%s %sからの運動がおすすめです。",
+			p.MissProbability*100, dayNames[int(s.Start.Weekday())], s.Start.Format("15:04"))
+	case len(dangerDays) > 0:
+		return fmt.Sprintf("%sが危険です。%sは運動をサボりやすい傾向があります。",
+			strings.Join(dangerDays, "と"), strings.Join(dangerDays, "と"))
+	}
+	return "素晴らしい！全曜日でバランスよく運動できています。"
+}
+
+// GetTeamForecast チームの失敗予測
+// @Summary      チームの失敗予測
+// @Description  メンバーごとの今週の進捗と過去の達成率から、今週の目標を達成する確率（リスクの高い順）、今週の評価でのHP変動の期待値、HPが尽きて解散するまでの週数の確率分布を返す。来週以降は過去の達成率で推定する
+// @Tags         predictions
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.TeamForecastResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      422     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/forecast [get]
+// @Security     BearerAuth
+func (ctrl *PredictionController) GetTeamForecast(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	forecast, err := ctrl.predictionService.ForecastTeam(team, time.Now())
+	if errors.Is(err, service.ErrNoActiveTeam) {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_active",
+			Message: "チームがまだアクティブになっていません",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "internal_error",
+			Message: "予測の計算に失敗しました",
+		})
+	}
+
+	loc := service.TeamLocation(team)
+	members := make([]response.MemberForecastResponse, len(forecast.Members))
+	for i, f := range forecast.Members {
+		members[i] = response.MemberForecastResponse{
+			UserID:           f.Member.UserID,
+			UserName:         f.Member.User.Name,
+			TargetAmount:     math.Round(f.Progress.Target*100) / 100,
+			AchievedAmount:   math.Round(f.Progress.Achieved*100) / 100,
+			RemainingAmount:  math.Round(f.Progress.Remaining()*100) / 100,
+			Frozen:           f.Progress.Frozen,
+			MeetProbability:  f.MeetProbability,
+			HistoricalRate:   f.HistoricalRate,
+			EvaluatedWeeks:   f.EvaluatedWeeks,
+			ExpectedHPChange: f.ExpectedHPChange,
+			Risk:             f.Risk,
+		}
+	}
+	disbandWeeks := make([]response.DisbandWeekResponse, len(forecast.DisbandWeeks))
+	for i, d := range forecast.DisbandWeeks {
+		disbandWeeks[i] = response.DisbandWeekResponse{
+			WeeksFromNow: d.WeeksFromNow,
+			WeekNumber:   d.WeekNumber,
+			Probability:  d.Probability,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response.TeamForecastResponse{
+		TeamID:              team.ID,
+		WeekNumber:          forecast.WeekNumber,
+		WeekStart:           forecast.WeekStart.In(loc).Format(time.RFC3339),
+		WeekEnd:             forecast.WeekEnd.In(loc).Format(time.RFC3339),
+		CurrentHP:           team.CurrentHP,
+		MaxHP:               team.MaxHP,
+		AllMetProbability:   forecast.AllMetProbability,
+		ExpectedHPChange:    forecast.ExpectedHPChange,
+		ExpectedHP:          forecast.ExpectedHP,
+		DisbandProbability:  forecast.DisbandProbability,
+		SurvivalProbability: forecast.SurvivalProbability,
+		HorizonWeeks:        forecast.HorizonWeeks,
+		SeasonEndsInHorizon: forecast.SeasonEndsInHorizon,
+		Members:             members,
+		DisbandWeeks:        disbandWeeks,
 	})
 }
@@ -0,0 +1,467 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+)
+
+type ProposalController struct {
+	db              *gorm.DB
+	proposalService *service.ProposalService
+}
+
+func NewProposalController(db *gorm.DB, proposalService *service.ProposalService) *ProposalController {
+	return &ProposalController{db: db, proposalService: proposalService}
+}
+
+// CreateProposal チームへの提案を作成
+// @Summary      チームへの提案を作成
+// @Description  解散（disband）・除名（remove_member）・設定変更（change_settings）を提案する。提案者は自動的に賛成票を入れる。設定変更はメンバーの過半数で可決し、activeチームでは翌週の評価から反映される。設定変更と解散の投票期限は既定で72時間。解散投票が不成立になった後はクールダウン中の再提案に429を返す
+// @Tags         proposals
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                          true  "チームID"
+// @Param        body    body      requests.CreateProposalRequest  true  "提案内容"
+// @Success      201     {object}  response.TeamProposalResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Failure      429     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/proposals [post]
+// @Security     BearerAuth
+func (ctrl *ProposalController) CreateProposal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	req := new(requests.CreateProposalRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+	if team.Status != "forming" && team.Status != "active" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームは提案できる状態ではありません",
+		})
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	proposal := models.TeamProposal{Type: req.Type, ProposedBy: uid}
+	duplicate := ctrl.db.Where("team_id = ? AND type = ? AND status = ?", teamId, req.Type, "open")
+
+	switch req.Type {
+	case "disband":
+	case "remove_member":
+		if member.Role != "leader" {
+			return c.JSON(http.StatusForbidden, response.ErrorResponse{
+				Error:   "not_leader",
+				Message: "除名はリーダーのみ提案できます",
+			})
+		}
+		if req.TargetUserID == nil || *req.TargetUserID == uid {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "target_user_id に自分以外のメンバーを指定してください",
+			})
+		}
+		var target models.TeamMember
+		if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, *req.TargetUserID).First(&target).Error; err != nil {
+			return c.JSON(http.StatusNotFound, response.ErrorResponse{
+				Error:   "member_not_found",
+				Message: "対象のメンバーが見つかりません",
+			})
+		}
+		proposal.TargetUserID = req.TargetUserID
+		duplicate = duplicate.Where("target_user_id = ?", *req.TargetUserID)
+	case "change_settings":
+		if msg := ctrl.validateSettingsChange(team, req); msg != "" {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: msg,
+			})
+		}
+		proposal.NewStrictness = req.Strictness
+		proposal.NewChallengeWeeks = req.ChallengeWeeks
+		proposal.NewTargetDistanceKM = req.TargetDistanceKM
+		proposal.NewTargetVisitsPerWeek = req.TargetVisitsPerWeek
+		proposal.NewTargetMinDurationMin = req.TargetMinDurationMin
+		proposal.NewDisbandThreshold = req.DisbandThreshold
+		proposal.NewReviewQuorum = req.ReviewQuorum
+		proposal.NewReviewWindowHours = req.ReviewWindowHours
+	default:
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "type は disband / remove_member / change_settings のいずれかを指定してください",
+		})
+	}
+
+	var existing models.TeamProposal
+	if err := duplicate.First(&existing).Error; err == nil {
+		ctrl.proposalService.Refresh(ctrl.db, &existing)
+		if existing.Status == "open" {
+			return c.JSON(http.StatusConflict, response.ErrorResponse{
+				Error:   "proposal_already_open",
+				Message: "同じ種類の提案が投票中です",
+			})
+		}
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return ctrl.proposalService.CreateProposal(tx, team, &proposal)
+	})
+	var cooldownErr *service.ProposalCooldownError
+	if errors.As(err, &cooldownErr) {
+		return disbandVoteCooldown(c, cooldownErr)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "提案の作成に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, buildProposalResponse(ctrl.db, proposal))
+}
+
+// GetProposals チームの提案一覧
+// @Summary      チームの提案一覧
+// @Description  チームの提案を新しい順に返す。statusで絞り込める
+// @Tags         proposals
+// @Produce      json
+// @Param        teamId  path      string  true   "チームID"
+// @Param        status  query     string  false  "open / passed / applied / rejected / expired / cancelled"
+// @Success      200     {array}   response.TeamProposalResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/proposals [get]
+// @Security     BearerAuth
+func (ctrl *ProposalController) GetProposals(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	query := ctrl.db.Where("team_id = ?", teamId)
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var proposals []models.TeamProposal
+	query.Order("created_at DESC").Limit(50).Find(&proposals)
+
+	result := make([]response.TeamProposalResponse, len(proposals))
+	for i := range proposals {
+		ctrl.proposalService.Refresh(ctrl.db, &proposals[i])
+		result[i] = buildProposalResponse(ctrl.db, proposals[i])
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetProposal 提案の詳細
+// @Summary      提案の詳細
+// @Tags         proposals
+// @Produce      json
+// @Param        teamId      path      string  true  "チームID"
+// @Param        proposalId  path      string  true  "提案ID"
+// @Success      200         {object}  response.TeamProposalResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/proposals/{proposalId} [get]
+// @Security     BearerAuth
+func (ctrl *ProposalController) GetProposal(c echo.Context) error {
+	proposal, errResp := ctrl.findProposal(c)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	ctrl.proposalService.Refresh(ctrl.db, proposal)
+	return c.JSON(http.StatusOK, buildProposalResponse(ctrl.db, *proposal))
+}
+
+// VoteProposal 提案に投票
+// @Summary      提案に投票
+// @Description  提案に賛成（approve=true）または反対（approve=false）を投票する。必要数の賛成で可決、可決が不可能になった時点で否決となる
+// @Tags         proposals
+// @Accept       json
+// @Produce      json
+// @Param        teamId      path      string                        true  "チームID"
+// @Param        proposalId  path      string                        true  "提案ID"
+// @Param        body        body      requests.ProposalVoteRequest  true  "投票"
+// @Success      200         {object}  response.TeamProposalResponse
+// @Failure      400         {object}  response.ErrorResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Failure      409         {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/proposals/{proposalId}/vote [post]
+// @Security     BearerAuth
+func (ctrl *ProposalController) VoteProposal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	req := new(requests.ProposalVoteRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	proposal, errResp := ctrl.findProposal(c)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return ctrl.proposalService.Vote(tx, proposal, uid, req.Approve)
+	})
+	if resp := proposalErrorResponse(err); resp != nil {
+		return c.JSON(resp.status, resp.body)
+	}
+
+	return c.JSON(http.StatusOK, buildProposalResponse(ctrl.db, *proposal))
+}
+
+// WithdrawProposalVote 提案への投票を取り消す
+// @Summary      提案への投票を取り消す
+// @Tags         proposals
+// @Produce      json
+// @Param        teamId      path      string  true  "チームID"
+// @Param        proposalId  path      string  true  "提案ID"
+// @Success      200         {object}  response.TeamProposalResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Failure      409         {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/proposals/{proposalId}/vote [delete]
+// @Security     BearerAuth
+func (ctrl *ProposalController) WithdrawProposalVote(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	proposal, errResp := ctrl.findProposal(c)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return ctrl.proposalService.WithdrawVote(tx, proposal, uid)
+	})
+	if resp := proposalErrorResponse(err); resp != nil {
+		return c.JSON(resp.status, resp.body)
+	}
+
+	return c.JSON(http.StatusOK, buildProposalResponse(ctrl.db, *proposal))
+}
+
+// CancelProposal 提案を取り下げる
+// @Summary      提案を取り下げる
+// @Description  投票中の提案を取り下げる（提案者のみ）
+// @Tags         proposals
+// @Produce      json
+// @Param        teamId      path      string  true  "チームID"
+// @Param        proposalId  path      string  true  "提案ID"
+// @Success      200         {object}  response.TeamProposalResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Failure      409         {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/proposals/{proposalId} [delete]
+// @Security     BearerAuth
+func (ctrl *ProposalController) CancelProposal(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	proposal, errResp := ctrl.findProposal(c)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	if proposal.ProposedBy != uid {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_proposer",
+			Message: "提案を取り下げられるのは提案者のみです",
+		})
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return ctrl.proposalService.Cancel(tx, proposal)
+	})
+	if resp := proposalErrorResponse(err); resp != nil {
+		return c.JSON(resp.status, resp.body)
+	}
+
+	return c.JSON(http.StatusOK, buildProposalResponse(ctrl.db, *proposal))
+}
+
+// findProposal パスの提案を取得し、リクエストしたユーザーがチームのメンバーか確認する
+func (ctrl *ProposalController) findProposal(c echo.Context) (*models.TeamProposal, *errorReply) {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return nil, &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		}}
+	}
+
+	var proposal models.TeamProposal
+	if err := ctrl.db.First(&proposal, "id = ? AND team_id = ?", c.Param("proposalId"), teamId).Error; err != nil {
+		return nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "proposal_not_found",
+			Message: "提案が見つかりません",
+		}}
+	}
+	return &proposal, nil
+}
+
+// validateSettingsChange 設定変更の内容を検証し、不正な場合はメッセージを返す
+func (ctrl *ProposalController) validateSettingsChange(team models.Team, req *requests.CreateProposalRequest) string {
+	if req.Strictness == nil && req.ChallengeWeeks == nil && req.TargetDistanceKM == nil &&
+		req.TargetVisitsPerWeek == nil && req.TargetMinDurationMin == nil && req.DisbandThreshold == nil &&
+		req.ReviewQuorum == nil && req.ReviewWindowHours == nil {
+		return "変更する設定を1つ以上指定してください"
+	}
+	if req.Strictness != nil && *req.Strictness != "normal" && *req.Strictness != "strict" && *req.Strictness != "relaxed" {
+		return "strictness は normal / strict / relaxed のいずれかを指定してください"
+	}
+	if req.DisbandThreshold != nil && *req.DisbandThreshold != "unanimous" && *req.DisbandThreshold != "majority_when_inactive" {
+		return "disband_threshold は unanimous または majority_when_inactive を指定してください"
+	}
+	if req.ReviewQuorum != nil && !service.ValidReviewQuorum(*req.ReviewQuorum) {
+		return "review_quorum は majority / any / all のいずれかを指定してください"
+	}
+	if req.ReviewWindowHours != nil && (*req.ReviewWindowHours < 1 || *req.ReviewWindowHours > service.MaxReviewWindowHours) {
+		return fmt.Sprintf("review_window_hours は 1〜%d の範囲で指定してください", service.MaxReviewWindowHours)
+	}
+	if req.ChallengeWeeks != nil {
+		minWeeks := 1
+		if team.Status == "active" {
+			// 反映は翌週からなので、それより前に終わる期間には変更できない
+			minWeeks = team.CurrentWeek + 1
+		}
+		if *req.ChallengeWeeks < minWeeks || *req.ChallengeWeeks > maxChallengeWeeks {
+			return fmt.Sprintf("challenge_weeks は %d〜%d の範囲で指定してください", minWeeks, maxChallengeWeeks)
+		}
+	}
+	return validateGoal(team.ExerciseType, requests.CreateGoalRequest{
+		TargetDistanceKM:     req.TargetDistanceKM,
+		TargetVisitsPerWeek:  req.TargetVisitsPerWeek,
+		TargetMinDurationMin: req.TargetMinDurationMin,
+	}, goalPartial)
+}
+
+func proposalErrorResponse(err error) *errorReply {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrProposalNotOpen):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "proposal_closed",
+			Message: "この提案は投票を締め切っています",
+		}}
+	case errors.Is(err, service.ErrAlreadyVoted):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "already_voted",
+			Message: "既に投票しています",
+		}}
+	case errors.Is(err, service.ErrNotEligible):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_eligible",
+			Message: "この提案には投票できません",
+		}}
+	case errors.Is(err, service.ErrVoteNotFound):
+		return &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "vote_not_found",
+			Message: "投票が見つかりません",
+		}}
+	}
+	return &errorReply{http.StatusInternalServerError, response.ErrorResponse{
+		Error:   "proposal_failed",
+		Message: "提案の処理に失敗しました",
+	}}
+}
+
+// buildProposalResponse 提案と現在の投票集計からレスポンスを構築する
+func buildProposalResponse(db *gorm.DB, p models.TeamProposal) response.TeamProposalResponse {
+	tally, _ := service.TallyProposal(db, p)
+
+	var votes []models.TeamProposalVote
+	db.Where("proposal_id = ?", p.ID).Order("created_at ASC").Find(&votes)
+	voteResponses := make([]response.ProposalVoteResponse, len(votes))
+	for i, v := range votes {
+		voteResponses[i] = response.ProposalVoteResponse{
+			UserID:    v.UserID,
+			Approve:   v.Approve,
+			CreatedAt: v.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	var changes *response.ProposalChanges
+	if p.Type == "change_settings" {
+		changes = &response.ProposalChanges{
+			Strictness:           p.NewStrictness,
+			ChallengeWeeks:       p.NewChallengeWeeks,
+			TargetDistanceKM:     p.NewTargetDistanceKM,
+			TargetVisitsPerWeek:  p.NewTargetVisitsPerWeek,
+			TargetMinDurationMin: p.NewTargetMinDurationMin,
+			DisbandThreshold:     p.NewDisbandThreshold,
+			ReviewQuorum:         p.NewReviewQuorum,
+			ReviewWindowHours:    p.NewReviewWindowHours,
+		}
+	}
+
+	var expiresAt, resolvedAt *string
+	if p.ExpiresAt != nil {
+		s := p.ExpiresAt.Format(time.RFC3339)
+		expiresAt = &s
+	}
+	if p.ResolvedAt != nil {
+		s := p.ResolvedAt.Format(time.RFC3339)
+		resolvedAt = &s
+	}
+
+	return response.TeamProposalResponse{
+		ID:            p.ID,
+		TeamID:        p.TeamID,
+		Type:          p.Type,
+		ProposedBy:    p.ProposedBy,
+		TargetUserID:  p.TargetUserID,
+		Changes:       changes,
+		Status:        p.Status,
+		EligibleCount: tally.EligibleCount,
+		RequiredCount: tally.RequiredCount,
+		ApproveCount:  tally.ApproveCount,
+		RejectCount:   tally.RejectCount,
+		Votes:         voteResponses,
+		ApplyFromWeek: p.ApplyFromWeek,
+		ExpiresAt:     expiresAt,
+		ResolvedAt:    resolvedAt,
+		CreatedAt:     p.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,412 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+type RematchController struct {
+	db                *gorm.DB
+	membershipService *service.MembershipService
+}
+
+func NewRematchController(db *gorm.DB, membershipService *service.MembershipService) *RematchController {
+	return &RematchController{db: db, membershipService: membershipService}
+}
+
+// ProposeRematch 再戦を提案
+// @Summary      再戦を提案
+// @Description  終了したチーム（completed / disbanded）と同じメンバー・設定で新しいチームを作成する。提案者がリーダーとなり、他のメンバーは /rematch/confirm で参加する。goal_modeで目標の引き継ぎ方を指定する（carry_over / auto / adjust）。
+// @Tags         rematch
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                   true  "前回のチームID"
+// @Param        body    body      requests.RematchRequest  true  "再戦設定"
+// @Success      201     {object}  response.RematchResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Failure      422     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/rematch [post]
+// @Security     BearerAuth
+func (ctrl *RematchController) ProposeRematch(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	req := new(requests.RematchRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	if req.GoalMode == "" {
+		req.GoalMode = "carry_over"
+	}
+	if req.GoalMode != "carry_over" && req.GoalMode != "auto" && req.GoalMode != "adjust" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "goal_mode は carry_over / auto / adjust のいずれかを指定してください",
+		})
+	}
+
+	var prevTeam models.Team
+	if err := ctrl.db.First(&prevTeam, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	summary, errResp := ctrl.findFinishedSeason(prevTeam, uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	// 同じチームからの再戦は1つまで
+	var existing models.Team
+	if err := ctrl.db.Where("previous_team_id = ? AND status IN ?", teamId, []string{"forming", "active"}).
+		First(&existing).Error; err == nil {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "rematch_already_exists",
+			Message: "既に再戦チームが作成されています。/rematch/confirm から参加してください",
+		})
+	}
+
+	// 前シーズン最終週に使われていた目標を引き継ぐ
+	prevGoal, _, err := service.GoalForWeek(ctrl.db, teamId, prevTeam.CurrentWeek)
+	if err != nil && req.GoalMode != "adjust" {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "goal_not_found",
+			Message: "前回の目標が見つからないため goal_mode=adjust で目標を指定してください",
+		})
+	}
+
+	goal := models.Goal{
+		ID:           utils.GenerateULID(),
+		ExerciseType: prevTeam.ExerciseType,
+	}
+	switch req.GoalMode {
+	case "carry_over":
+		goal.TargetDistanceKM = prevGoal.TargetDistanceKM
+		goal.TargetVisitsPerWeek = prevGoal.TargetVisitsPerWeek
+		goal.TargetMinDurationMin = prevGoal.TargetMinDurationMin
+	case "auto":
+		adjustGoalFromSeason(&goal, prevGoal, *summary)
+	case "adjust":
+		if msg := validateGoal(prevTeam.ExerciseType, requests.CreateGoalRequest{
+			TargetDistanceKM:     req.TargetDistanceKM,
+			TargetVisitsPerWeek:  req.TargetVisitsPerWeek,
+			TargetMinDurationMin: req.TargetMinDurationMin,
+		}, goalComplete); msg != "" {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "goal_mode=adjust: " + msg,
+			})
+		}
+		goal.TargetDistanceKM = req.TargetDistanceKM
+		goal.TargetVisitsPerWeek = req.TargetVisitsPerWeek
+		goal.TargetMinDurationMin = req.TargetMinDurationMin
+	}
+
+	name := req.Name
+	if name == "" {
+		name = prevTeam.Name
+	}
+	// 無期限だった旧チームからの再戦は既定の期間で始める
+	challengeWeeks := prevTeam.ChallengeWeeks
+	if challengeWeeks < 1 || challengeWeeks > maxChallengeWeeks {
+		challengeWeeks = defaultChallengeWeeks
+	}
+	if req.ChallengeWeeks != nil {
+		if *req.ChallengeWeeks < 1 || *req.ChallengeWeeks > maxChallengeWeeks {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_challenge_weeks",
+				Message: fmt.Sprintf("challenge_weeks は 1〜%d の範囲で指定してください", maxChallengeWeeks),
+			})
+		}
+		challengeWeeks = *req.ChallengeWeeks
+	}
+
+	// 前シーズン終了時のメンバー数に合わせて定員を確保する
+	finalMembers := 0
+	for _, st := range summary.Members {
+		if st.InTeamAtEnd {
+			finalMembers++
+		}
+	}
+	maxMembers := prevTeam.MaxMembers
+	if finalMembers > maxMembers {
+		maxMembers = finalMembers
+	}
+	if maxMembers > teamMembersUpperLimit {
+		maxMembers = teamMembersUpperLimit
+	}
+
+	prevTeamID := prevTeam.ID
+	team := models.Team{
+		ID:               utils.GenerateULID(),
+		Name:             name,
+		ExerciseType:     prevTeam.ExerciseType,
+		Strictness:       prevTeam.Strictness,
+		Status:           "forming",
+		MaxHP:            prevTeam.MaxHP,
+		CurrentHP:        prevTeam.MaxHP,
+		CurrentWeek:      0,
+		MinMembers:       prevTeam.MinMembers,
+		MaxMembers:       maxMembers,
+		ChallengeWeeks:   challengeWeeks,
+		PreviousTeamID:   &prevTeamID,
+		DisbandThreshold: prevTeam.DisbandThreshold,
+		// フリーズトークンはシーズンごとに配り直す
+		FreezeTokensPerMember: prevTeam.FreezeTokensPerMember,
+		StartPolicy:           prevTeam.StartPolicy,
+		Timezone:              prevTeam.Timezone,
+		ReviewQuorum:          prevTeam.ReviewQuorum,
+		ReviewWindowHours:     prevTeam.ReviewWindowHours,
+	}
+	goal.TeamID = team.ID
+
+	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		if _, err := ctrl.membershipService.AddMember(tx, team, uid, "leader"); err != nil {
+			return err
+		}
+		return service.CreateGoal(tx, &goal, &uid, "rematch")
+	})
+	if errors.Is(err, service.ErrAlreadyInTeam) {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_in_team",
+			Message: "既にアクティブなチームに所属しています",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "再戦チームの作成に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, ctrl.buildRematchResponse(team, *summary))
+}
+
+// ConfirmRematch 再戦に参加
+// @Summary      再戦に参加
+// @Description  前シーズンのメンバーが再戦チームへの参加を確定する
+// @Tags         rematch
+// @Produce      json
+// @Param        teamId  path      string  true  "前回のチームID"
+// @Success      200     {object}  response.RematchResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Failure      422     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/rematch/confirm [post]
+// @Security     BearerAuth
+func (ctrl *RematchController) ConfirmRematch(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var prevTeam models.Team
+	if err := ctrl.db.First(&prevTeam, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	summary, errResp := ctrl.findFinishedSeason(prevTeam, uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var team models.Team
+	if err := ctrl.db.Where("previous_team_id = ? AND status IN ?", teamId, []string{"forming", "active"}).
+		First(&team).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "rematch_not_found",
+			Message: "再戦チームがまだ作成されていません",
+		})
+	}
+	if team.Status != "forming" {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_forming",
+			Message: "再戦チームは既にチャレンジを開始しています",
+		})
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		_, err := ctrl.membershipService.AddMember(tx, team, uid, "member")
+		return err
+	})
+	if errors.Is(err, service.ErrAlreadyInTeam) {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_in_team",
+			Message: "既にアクティブなチームに所属しています",
+		})
+	}
+	if errors.Is(err, service.ErrTeamFull) {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_full",
+			Message: "チームは満員です",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "join_failed",
+			Message: "再戦チームへの参加に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, ctrl.buildRematchResponse(team, *summary))
+}
+
+// GetRematch 再戦状況を取得
+// @Summary      再戦状況を取得
+// @Description  再戦チームと、前シーズンのメンバーの参加状況を返す
+// @Tags         rematch
+// @Produce      json
+// @Param        teamId  path      string  true  "前回のチームID"
+// @Success      200     {object}  response.RematchResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/rematch [get]
+// @Security     BearerAuth
+func (ctrl *RematchController) GetRematch(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var prevTeam models.Team
+	if err := ctrl.db.First(&prevTeam, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	summary, errResp := ctrl.findFinishedSeason(prevTeam, uid)
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var team models.Team
+	if err := ctrl.db.Where("previous_team_id = ?", teamId).Order("created_at DESC").First(&team).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "rematch_not_found",
+			Message: "再戦チームがまだ作成されていません",
+		})
+	}
+
+	return c.JSON(http.StatusOK, ctrl.buildRematchResponse(team, *summary))
+}
+
+type errorReply struct {
+	status int
+	body   response.ErrorResponse
+}
+
+// findFinishedSeason 終了済みチームのサマリーを取得し、ユーザーがシーズン終了時のメンバーか確認する
+// 途中で脱退・除名されたメンバーは再戦に参加できない
+func (ctrl *RematchController) findFinishedSeason(team models.Team, uid string) (*models.SeasonSummary, *errorReply) {
+	if team.Status != "completed" && team.Status != "disbanded" {
+		return nil, &errorReply{http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "season_not_finished",
+			Message: "チャレンジが終了したチームのみ再戦できます",
+		}}
+	}
+
+	var summary models.SeasonSummary
+	if err := ctrl.db.Preload("Members").First(&summary, "team_id = ?", team.ID).Error; err != nil {
+		return nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "summary_not_found",
+			Message: "シーズン結果が見つかりません",
+		}}
+	}
+
+	for _, st := range summary.Members {
+		if st.UserID == uid && st.InTeamAtEnd {
+			return &summary, nil
+		}
+	}
+	return nil, &errorReply{http.StatusForbidden, response.ErrorResponse{
+		Error:   "not_team_member",
+		Message: "このチームのメンバーではありません",
+	}}
+}
+
+func (ctrl *RematchController) buildRematchResponse(team models.Team, summary models.SeasonSummary) response.RematchResponse {
+	var members []models.TeamMember
+	ctrl.db.Preload("User").Where("team_id = ?", team.ID).Find(&members)
+
+	var goal models.Goal
+	var goalPtr *models.Goal
+	if err := ctrl.db.First(&goal, "team_id = ?", team.ID).Error; err == nil {
+		goalPtr = &goal
+	}
+
+	joined := make(map[string]bool, len(members))
+	confirmed := make([]string, 0, len(members))
+	for _, m := range members {
+		joined[m.UserID] = true
+		confirmed = append(confirmed, m.UserID)
+	}
+	pending := []string{}
+	for _, st := range summary.Members {
+		if st.InTeamAtEnd && !joined[st.UserID] {
+			pending = append(pending, st.UserID)
+		}
+	}
+
+	return response.RematchResponse{
+		PreviousTeamID: summary.TeamID,
+		Team:           response.NewTeamResponse(team, members, goalPtr),
+		ConfirmedUsers: confirmed,
+		PendingUsers:   pending,
+	}
+}
+
+// adjustGoalFromSeason 前シーズンの達成率から目標を調整する
+// 達成率90%以上で完走→1.1倍 / 解散または達成率50%未満→0.8倍 / それ以外は据え置き
+func adjustGoalFromSeason(goal *models.Goal, prev models.Goal, summary models.SeasonSummary) {
+	var met, evaluated int
+	for _, st := range summary.Members {
+		met += st.WeeksMet
+		evaluated += st.WeeksEvaluated
+	}
+	rate := 0.0
+	if evaluated > 0 {
+		rate = float64(met) / float64(evaluated)
+	}
+
+	factor := 1.0
+	switch {
+	case summary.Result == "completed" && rate >= 0.9:
+		factor = 1.1
+	case summary.Result == "disbanded" || rate < 0.5:
+		factor = 0.8
+	}
+
+	if prev.TargetDistanceKM != nil {
+		// 0.5km単位に丸める
+		dist := math.Max(0.5, math.Round(*prev.TargetDistanceKM*factor*2)/2)
+		goal.TargetDistanceKM = &dist
+	}
+	if prev.TargetVisitsPerWeek != nil {
+		visits := int(math.Max(1, math.Round(float64(*prev.TargetVisitsPerWeek)*factor)))
+		goal.TargetVisitsPerWeek = &visits
+	}
+	goal.TargetMinDurationMin = prev.TargetMinDurationMin
+}
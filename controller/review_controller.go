@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"gorm.io/gorm"
+)
+
+type ReviewController struct {
+	db *gorm.DB
+}
+
+func NewReviewController(db *gorm.DB) *ReviewController {
+	return &ReviewController{db: db}
+}
+
+// GetReviewStatus アクティビティのレビュー集計
+// @Summary      アクティビティのレビュー集計
+// @Description  チームの review_quorum に対する承認・否認の票数と、レビューの受付期限を返す。週次評価で確定した後は確定結果も返す
+// @Tags         reviews
+// @Produce      json
+// @Param        activityId  path      string  true  "アクティビティID"
+// @Success      200         {object}  response.ReviewTallyResponse
+// @Failure      403         {object}  response.ErrorResponse
+// @Failure      404         {object}  response.ErrorResponse
+// @Router       /api/activities/{activityId}/review-status [get]
+// @Security     BearerAuth
+func (ctrl *ReviewController) GetReviewStatus(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	activityId := c.Param("activityId")
+
+	var activity models.Activity
+	if err := ctrl.db.First(&activity, "id = ?", activityId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "activity_not_found",
+			Message: "アクティビティが見つかりません",
+		})
+	}
+	if activity.TeamID == nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "no_team",
+			Message: "チームに紐づいていないアクティビティです",
+		})
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", *activity.TeamID, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", *activity.TeamID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	tally, err := service.TallyReviews(ctrl.db, team, activity)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "レビューの集計に失敗しました",
+		})
+	}
+	outcome, err := service.ReviewOutcomeFor(ctrl.db, activity.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "レビュー結果の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, toReviewTallyResponse(team, activity, tally, outcome, time.Now()))
+}
+
+// GetReviewerStats レビュアー統計
+// @Summary      レビュアー統計
+// @Description  メンバーごとに、結果が確定したアクティビティへのレビュー数・参加率・否認の内訳と、否認がそのまま確定したか覆ったかを返す
+// @Tags         reviews
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.ReviewerStatsResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/review-stats [get]
+// @Security     BearerAuth
+func (ctrl *ReviewController) GetReviewerStats(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	stats, err := service.ReviewerStats(ctrl.db, teamId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "レビュアー統計の取得に失敗しました",
+		})
+	}
+
+	reviewers := make([]response.ReviewerStatResponse, len(stats))
+	for i, s := range stats {
+		var rate float64
+		if s.Eligible > 0 {
+			rate = float64(s.Reviews) / float64(s.Eligible)
+			if rate > 1 {
+				rate = 1
+			}
+		}
+		reviewers[i] = response.ReviewerStatResponse{
+			UserID:            s.UserID,
+			UserName:          s.UserName,
+			EligibleCount:     s.Eligible,
+			Reviews:           s.Reviews,
+			ParticipationRate: rate,
+			Approvals:         s.Approvals,
+			Rejections:        s.Rejections,
+			Upheld:            s.Upheld,
+			Overturned:        s.Overturned,
+			ReasonBreakdown:   s.ReasonBreakdown,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response.ReviewerStatsResponse{
+		TeamID:            team.ID,
+		ReviewQuorum:      team.ReviewQuorum,
+		ReviewWindowHours: team.ReviewWindowHours,
+		Reviewers:         reviewers,
+	})
+}
+
+// toReviewTallyResponse 集計と確定結果をレスポンスにまとめる
+func toReviewTallyResponse(team models.Team, activity models.Activity, tally service.ReviewTally, outcome *models.ReviewOutcome, now time.Time) response.ReviewTallyResponse {
+	resp := response.ReviewTallyResponse{
+		ActivityID:       activity.ID,
+		ReviewStatus:     activity.ReviewStatus,
+		Policy:           tally.Policy,
+		EligibleCount:    tally.Eligible,
+		Quorum:           tally.Quorum,
+		Approvals:        tally.Approvals,
+		Rejections:       tally.Rejections,
+		ReasonCategories: tally.Reasons,
+		WindowOpen:       outcome == nil,
+	}
+	if resp.ReasonCategories == nil {
+		resp.ReasonCategories = []string{}
+	}
+	if deadline := service.ReviewDeadline(team, activity); deadline != nil {
+		s := deadline.Format(time.RFC3339)
+		resp.Deadline = &s
+		resp.WindowOpen = resp.WindowOpen && now.Before(*deadline)
+	}
+	if outcome != nil {
+		// 確定時点の集計を返す。その後のメンバーの出入りで数え直さない
+		resp.Outcome = &outcome.Outcome
+		resp.Resolution = &outcome.Resolution
+		resp.Policy = outcome.Policy
+		resp.EligibleCount = outcome.EligibleCount
+		resp.Quorum = outcome.Quorum
+		resp.Approvals = outcome.Approvals
+		resp.Rejections = outcome.Rejections
+		resp.ReasonCategories = []string{}
+		if outcome.ReasonCategories != "" {
+			resp.ReasonCategories = strings.Split(outcome.ReasonCategories, ",")
+		}
+	}
+	return resp
+}
+
+func reviewErrorResponse(err error) *errorReply {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrReviewOwnActivity):
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "cannot_review_own",
+			Message: "自分のアクティビティにはレビューできません",
+		}}
+	case errors.Is(err, service.ErrActivityNotCompleted):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "activity_not_completed",
+			Message: "完了したアクティビティのみレビューできます",
+		}}
+	case errors.Is(err, service.ErrInvalidRejectionReason):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_reason_category",
+			Message: "否認する場合は reason_category に gps_anomaly / not_exercise / duplicate / wrong_location / too_short / other のいずれかを指定してください",
+		}}
+	case errors.Is(err, service.ErrRejectionNeedsComment):
+		return &errorReply{http.StatusBadRequest, response.ErrorResponse{
+			Error:   "comment_required",
+			Message: "reason_category が other の場合はコメントを入力してください",
+		}}
+	case errors.Is(err, service.ErrActivityUnderAppeal):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "under_appeal",
+			Message: "異議申し立て中のアクティビティにはレビューできません。申し立てに投票してください",
+		}}
+	case errors.Is(err, service.ErrReviewWindowClosed):
+		return &errorReply{http.StatusConflict, response.ErrorResponse{
+			Error:   "review_window_closed",
+			Message: "このアクティビティのレビュー受付は終了しました",
+		}}
+	}
+	return &errorReply{http.StatusInternalServerError, response.ErrorResponse{
+		Error:   "review_failed",
+		Message: "レビューの保存に失敗しました",
+	}}
+}
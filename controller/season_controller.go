@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/response"
+	"gorm.io/gorm"
+)
+
+type SeasonController struct {
+	db *gorm.DB
+}
+
+func NewSeasonController(db *gorm.DB) *SeasonController {
+	return &SeasonController{db: db}
+}
+
+// GetTeamSummary シーズン結果取得
+// @Summary      シーズン結果取得
+// @Description  completed / disbanded になったチームの最終HP、メンバー別成績（達成週数・連続達成など）を取得する。シーズン参加者のみアクセス可能。
+// @Tags         seasons
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.SeasonSummaryResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/summary [get]
+// @Security     BearerAuth
+func (ctrl *SeasonController) GetTeamSummary(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var summary models.SeasonSummary
+	if err := ctrl.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("weeks_met DESC, total_distance_km DESC")
+	}).Preload("Members.User").First(&summary, "team_id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "summary_not_found",
+			Message: "シーズン結果が見つかりません（チャレンジが終了していません）",
+		})
+	}
+
+	// シーズン参加者か確認（解散チームはメンバーが削除されているため成績から判定）
+	participated := false
+	for _, st := range summary.Members {
+		if st.UserID == uid {
+			participated = true
+			break
+		}
+	}
+	if !participated {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.NewSeasonSummaryResponse(summary))
+}
+
+// GetMyTeamHistory 過去チーム履歴
+// @Summary      過去チーム履歴
+// @Description  自分が参加した終了済みチームの一覧と自分の成績を新しい順に返す
+// @Tags         seasons
+// @Produce      json
+// @Success      200  {array}   response.TeamHistoryEntry
+// @Router       /api/users/me/teams/history [get]
+// @Security     BearerAuth
+func (ctrl *SeasonController) GetMyTeamHistory(c echo.Context) error {
+	uid := c.Get("uid").(string)
+
+	var stats []models.SeasonMemberStat
+	if err := ctrl.db.Preload("User").Where("user_id = ?", uid).Find(&stats).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "チーム履歴の取得に失敗しました",
+		})
+	}
+	if len(stats) == 0 {
+		return c.JSON(http.StatusOK, []response.TeamHistoryEntry{})
+	}
+
+	statBySummary := make(map[string]models.SeasonMemberStat, len(stats))
+	summaryIDs := make([]string, len(stats))
+	for i, st := range stats {
+		statBySummary[st.SummaryID] = st
+		summaryIDs[i] = st.SummaryID
+	}
+
+	var summaries []models.SeasonSummary
+	ctrl.db.Where("id IN ?", summaryIDs).Order("ended_at DESC").Find(&summaries)
+
+	entries := make([]response.TeamHistoryEntry, len(summaries))
+	for i, s := range summaries {
+		var startedAt *string
+		if s.StartedAt != nil {
+			str := s.StartedAt.Format(time.RFC3339)
+			startedAt = &str
+		}
+		entries[i] = response.TeamHistoryEntry{
+			TeamID:         s.TeamID,
+			TeamName:       s.TeamName,
+			ExerciseType:   s.ExerciseType,
+			Result:         s.Result,
+			FinalHP:        s.FinalHP,
+			MaxHP:          s.MaxHP,
+			WeeksPlayed:    s.WeeksPlayed,
+			PreviousTeamID: s.PreviousTeamID,
+			StartedAt:      startedAt,
+			EndedAt:        s.EndedAt.Format(time.RFC3339),
+			MyStats:        response.NewSeasonMemberStatResponse(statBySummary[s.ID]),
+		}
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
@@ -1,27 +1,52 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/adapter"
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/requests"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"github.com/trihackathon/api/utils"
 	"gorm.io/gorm"
 )
 
+// チーム人数の既定値と設定可能な範囲
+const (
+	defaultTeamMembers    = 3
+	teamMembersLowerLimit = 2
+	teamMembersUpperLimit = 10
+
+	defaultChallengeWeeks = 4
+	maxChallengeWeeks     = 52
+
+	defaultFreezeTokens = 1
+	maxFreezeTokens     = 3
+)
+
 type TeamController struct {
-	db *gorm.DB
+	db                *gorm.DB
+	r2                *adapter.R2Adapter
+	membershipService *service.MembershipService
+	proposalService   *service.ProposalService
 }
 
-func NewTeamController(db *gorm.DB) *TeamController {
-	return &TeamController{db: db}
+func NewTeamController(db *gorm.DB, r2 *adapter.R2Adapter, membershipService *service.MembershipService, proposalService *service.ProposalService) *TeamController {
+	return &TeamController{db: db, r2: r2, membershipService: membershipService, proposalService: proposalService}
 }
 
 // CreateTeam チーム作成
 // @Summary      チーム作成
-// @Description  チームを作成し、作成者をリーダーとしてメンバーに追加する。1ユーザーが同時に参加できるアクティブチームは1つのみ。
+// @Description  チームを作成し、作成者をリーダーとしてメンバーに追加する。1ユーザーが同時に参加できるアクティブチームは1つのみ。min_members/max_membersで人数（2〜10人）、challenge_weeksでチャレンジ期間（1〜52週、省略時は4週）を設定できる。無期限のチームは作成できない。
 // @Tags         teams
 // @Accept       json
 // @Produce      json
@@ -58,6 +83,99 @@ func (ctrl *TeamController) CreateTeam(c echo.Context) error {
 	if req.Strictness == "" {
 		req.Strictness = "normal"
 	}
+	if req.DisbandThreshold == "" {
+		req.DisbandThreshold = "majority_when_inactive"
+	}
+	if req.DisbandThreshold != "unanimous" && req.DisbandThreshold != "majority_when_inactive" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "disband_threshold は unanimous または majority_when_inactive を指定してください",
+		})
+	}
+
+	// 人数設定（省略時は3人チーム）
+	minMembers := defaultTeamMembers
+	if req.MinMembers != nil {
+		minMembers = *req.MinMembers
+	}
+	maxMembers := defaultTeamMembers
+	if req.MaxMembers != nil {
+		maxMembers = *req.MaxMembers
+	} else if maxMembers < minMembers {
+		maxMembers = minMembers
+	}
+	if req.MinMembers == nil && minMembers > maxMembers {
+		minMembers = maxMembers
+	}
+	if minMembers < teamMembersLowerLimit || maxMembers > teamMembersUpperLimit || minMembers > maxMembers {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("min_members と max_members は %d〜%d の範囲で min_members ≦ max_members となるよう指定してください", teamMembersLowerLimit, teamMembersUpperLimit),
+		})
+	}
+
+	if req.StartPolicy == "" {
+		req.StartPolicy = "midnight"
+	}
+	if !service.ValidStartPolicy(req.StartPolicy) {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "start_policy は midnight または monday を指定してください",
+		})
+	}
+
+	// タイムゾーン（省略時はリーダーのタイムゾーン）
+	if req.Timezone == "" {
+		req.Timezone = service.UserTimezone(ctrl.db, uid)
+	}
+	if !utils.ValidTimezone(req.Timezone) {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "timezone は Asia/Tokyo のようなIANAタイムゾーン名で指定してください",
+		})
+	}
+
+	freezeTokens := defaultFreezeTokens
+	if req.FreezeTokens != nil {
+		freezeTokens = *req.FreezeTokens
+	}
+	if freezeTokens < 1 || freezeTokens > maxFreezeTokens {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("freeze_tokens_per_member は 1〜%d の範囲で指定してください", maxFreezeTokens),
+		})
+	}
+
+	if req.ReviewQuorum == "" {
+		req.ReviewQuorum = "majority"
+	}
+	if !service.ValidReviewQuorum(req.ReviewQuorum) {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "review_quorum は majority / any / all のいずれかを指定してください",
+		})
+	}
+	reviewWindowHours := service.DefaultReviewWindowHours
+	if req.ReviewWindowHours != nil {
+		reviewWindowHours = *req.ReviewWindowHours
+	}
+	if reviewWindowHours < 1 || reviewWindowHours > service.MaxReviewWindowHours {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("review_window_hours は 1〜%d の範囲で指定してください", service.MaxReviewWindowHours),
+		})
+	}
+
+	challengeWeeks := defaultChallengeWeeks
+	if req.ChallengeWeeks != nil {
+		challengeWeeks = *req.ChallengeWeeks
+	}
+	if challengeWeeks < 1 || challengeWeeks > maxChallengeWeeks {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_challenge_weeks",
+			Message: fmt.Sprintf("challenge_weeks は 1〜%d の範囲で指定してください（0 の無期限は指定できません）", maxChallengeWeeks),
+		})
+	}
 
 	// ユーザーが既にアクティブチームに所属しているか確認
 	var existingMember models.TeamMember
@@ -76,14 +194,24 @@ func (ctrl *TeamController) CreateTeam(c echo.Context) error {
 	memberID := utils.GenerateULID()
 
 	team := models.Team{
-		ID:           teamID,
-		Name:         req.Name,
-		ExerciseType: req.ExerciseType,
-		Strictness:   req.Strictness,
-		Status:       "forming",
-		MaxHP:        100,
-		CurrentHP:    100,
-		CurrentWeek:  0,
+		ID:                    teamID,
+		Name:                  req.Name,
+		ExerciseType:          req.ExerciseType,
+		Strictness:            req.Strictness,
+		Status:                "forming",
+		MaxHP:                 100,
+		CurrentHP:             100,
+		CurrentWeek:           0,
+		MinMembers:            minMembers,
+		MaxMembers:            maxMembers,
+		ChallengeWeeks:        challengeWeeks,
+		Discoverable:          req.Discoverable,
+		DisbandThreshold:      req.DisbandThreshold,
+		FreezeTokensPerMember: freezeTokens,
+		StartPolicy:           req.StartPolicy,
+		Timezone:              req.Timezone,
+		ReviewQuorum:          req.ReviewQuorum,
+		ReviewWindowHours:     reviewWindowHours,
 	}
 
 	member := models.TeamMember{
@@ -101,7 +229,7 @@ func (ctrl *TeamController) CreateTeam(c echo.Context) error {
 		if err := tx.Create(&member).Error; err != nil {
 			return err
 		}
-		return nil
+		return service.RecordMembershipEvent(tx, teamID, uid, "joined", nil)
 	}); err != nil {
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
 			Error:   "create_failed",
@@ -206,8 +334,24 @@ func (ctrl *TeamController) GetTeam(c echo.Context) error {
 	return c.JSON(http.StatusOK, response.NewTeamResponse(team, members, goalPtr))
 }
 
-// VoteDisband 解散に投票
-func (ctrl *TeamController) VoteDisband(c echo.Context) error {
+// UpdateTeam チーム情報を更新
+// @Summary      チーム情報を更新
+// @Description  チーム名・アイコン画像・週の区切り方とタイムゾーン（開始前のみ）を更新する（リーダーのみ）。厳しさ・目標・チャレンジ期間など勝敗に関わる設定は提案と投票（/proposals）で変更する
+// @Tags         teams
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        teamId        path      string  true   "チームID"
+// @Param        name          formData  string  false  "チーム名"
+// @Param        avatar        formData  file    false  "チームアイコン画像"
+// @Param        start_policy  formData  string  false  "週の区切り方（midnight / monday）。チャレンジ開始前のみ変更可"
+// @Param        timezone      formData  string  false  "週の区切りに使うIANAタイムゾーン。チャレンジ開始前のみ変更可"
+// @Success      200           {object}  response.TeamResponse
+// @Failure      400           {object}  response.ErrorResponse
+// @Failure      403           {object}  response.ErrorResponse
+// @Failure      404           {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId} [patch]
+// @Security     BearerAuth
+func (ctrl *TeamController) UpdateTeam(c echo.Context) error {
 	uid := c.Get("uid").(string)
 	teamId := c.Param("teamId")
 
@@ -219,14 +363,145 @@ func (ctrl *TeamController) VoteDisband(c echo.Context) error {
 		})
 	}
 
-	if team.Status != "forming" && team.Status != "active" {
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+	if member.Role != "leader" {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみチーム情報を更新できます",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if name := strings.TrimSpace(c.FormValue("name")); name != "" {
+		if len([]rune(name)) > 50 {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "name は50文字以内で入力してください",
+			})
+		}
+		updates["name"] = name
+	}
+
+	if policy := c.FormValue("start_policy"); policy != "" {
+		if !service.ValidStartPolicy(policy) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "start_policy は midnight または monday を指定してください",
+			})
+		}
+		if team.Status != "forming" {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_team_status",
+				Message: "週の区切り方はチャレンジ開始前のみ変更できます",
+			})
+		}
+		updates["start_policy"] = policy
+	}
+	if timezone := c.FormValue("timezone"); timezone != "" {
+		if !utils.ValidTimezone(timezone) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "timezone は Asia/Tokyo のようなIANAタイムゾーン名で指定してください",
+			})
+		}
+		if team.Status != "forming" {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_team_status",
+				Message: "タイムゾーンはチャレンジ開始前のみ変更できます",
+			})
+		}
+		updates["timezone"] = timezone
+	}
+
+	// アイコン更新
+	file, err := c.FormFile("avatar")
+	if err == nil && file != nil {
+		src, err := file.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_file",
+				Message: "ファイルの読み込みに失敗しました",
+			})
+		}
+		defer src.Close()
+
+		// 古いアイコンを削除
+		if team.AvatarURL != "" {
+			if oldKey := extractR2Key(team.AvatarURL); oldKey != "" {
+				_ = ctrl.r2.Delete(c.Request().Context(), oldKey)
+			}
+		}
+
+		ext := strings.ToLower(filepath.Ext(file.Filename))
+		key := fmt.Sprintf("avatars/teams/%s/%s%s", teamId, utils.GenerateULID(), ext)
+		url, err := ctrl.r2.Upload(c.Request().Context(), key, src, file.Header.Get("Content-Type"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Error:   "upload_failed",
+				Message: "チームアイコンのアップロードに失敗しました",
+			})
+		}
+		updates["avatar_url"] = url
+	}
+
+	if len(updates) == 0 {
 		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error:   "invalid_team_status",
-			Message: "このチームは解散投票できる状態ではありません",
+			Error:   "invalid_request",
+			Message: "name・avatar・start_policy・timezone のいずれかを指定してください",
 		})
 	}
 
-	// メンバー確認
+	if err := ctrl.db.Model(&team).Updates(updates).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "update_failed",
+			Message: "チーム情報の更新に失敗しました",
+		})
+	}
+	ctrl.db.First(&team, "id = ?", teamId)
+
+	var members []models.TeamMember
+	ctrl.db.Preload("User").Where("team_id = ?", teamId).Find(&members)
+
+	var goal models.Goal
+	var goalPtr *models.Goal
+	if err := ctrl.db.First(&goal, "team_id = ?", teamId).Error; err == nil {
+		goalPtr = &goal
+	}
+
+	return c.JSON(http.StatusOK, response.NewTeamResponse(team, members, goalPtr))
+}
+
+// StartChallenge チャレンジ開始
+// @Summary      チャレンジ開始
+// @Description  メンバー募集中（forming）のチームをactiveにしてチャレンジを開始する。リーダーのみ実行可能。min_members以上のメンバーと目標設定が必要。第1週は翌日0時から始まり、start_policy=monday の場合は最初の月曜0時までの短い週として目標を按分する。
+// @Tags         teams
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.TeamResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      422     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/start [post]
+// @Security     BearerAuth
+func (ctrl *TeamController) StartChallenge(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	// リーダー確認
 	var member models.TeamMember
 	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
 		return c.JSON(http.StatusForbidden, response.ErrorResponse{
@@ -234,77 +509,132 @@ func (ctrl *TeamController) VoteDisband(c echo.Context) error {
 			Message: "このチームのメンバーではありません",
 		})
 	}
+	if member.Role != "leader" {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみチャレンジを開始できます",
+		})
+	}
 
-	// 既に投票済みか確認
-	var existingVote models.DisbandVote
-	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&existingVote).Error; err == nil {
-		return c.JSON(http.StatusConflict, response.ErrorResponse{
-			Error:   "already_voted",
-			Message: "既に解散に投票しています",
+	if team.Status != "forming" {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_forming",
+			Message: "チームはメンバー募集中ではありません",
 		})
 	}
 
-	// 投票を作成
-	vote := models.DisbandVote{
-		ID:     utils.GenerateULID(),
-		TeamID: teamId,
-		UserID: uid,
+	// 最少人数チェック
+	var memberCount int64
+	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+	if int(memberCount) < team.MinMembers {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "not_enough_members",
+			Message: fmt.Sprintf("チャレンジの開始には%d人以上のメンバーが必要です", team.MinMembers),
+		})
 	}
-	if err := ctrl.db.Create(&vote).Error; err != nil {
+
+	// 目標が設定されていないと週次評価できない
+	var goal models.Goal
+	if err := ctrl.db.First(&goal, "team_id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "goal_not_set",
+			Message: "チャレンジを開始する前に目標を設定してください",
+		})
+	}
+
+	// 第1週は翌日0時から。それまでの記録は第1週に含めない
+	// 同時に開始された場合に二重に開始しないよう、forming のままのときだけ更新する
+	startedAt := service.ChallengeStartAt(team, time.Now())
+	res := ctrl.db.Model(&team).Where("status = ?", "forming").Updates(map[string]interface{}{
+		"status":       "active",
+		"started_at":   startedAt,
+		"current_week": 1,
+	})
+	if res.Error != nil {
 		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error:   "vote_failed",
-			Message: "投票に失敗しました",
+			Error:   "start_failed",
+			Message: "チャレンジの開始に失敗しました",
+		})
+	}
+	if res.RowsAffected == 0 {
+		return c.JSON(http.StatusUnprocessableEntity, response.ErrorResponse{
+			Error:   "team_not_forming",
+			Message: "チームは既にメンバー募集中ではありません",
 		})
 	}
 
-	// メンバー数と投票数を確認
-	var memberCount int64
-	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+	var members []models.TeamMember
+	ctrl.db.Preload("User").Where("team_id = ?", teamId).Find(&members)
 
-	var voteCount int64
-	ctrl.db.Model(&models.DisbandVote{}).Where("team_id = ?", teamId).Count(&voteCount)
+	return c.JSON(http.StatusOK, response.NewTeamResponse(team, members, &goal))
+}
 
-	disbanded := false
+// VoteDisband 解散に投票
+func (ctrl *TeamController) VoteDisband(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
 
-	// 全員投票済みなら解散
-	if voteCount >= memberCount {
-		if err := ctrl.db.Transaction(func(tx *gorm.DB) error {
-			// チームのステータスを更新
-			if err := tx.Model(&models.Team{}).Where("id = ?", teamId).Update("status", "disbanded").Error; err != nil {
-				return err
-			}
-			// 解散投票を削除
-			if err := tx.Where("team_id = ?", teamId).Delete(&models.DisbandVote{}).Error; err != nil {
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	if team.Status != "forming" && team.Status != "active" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームは解散投票できる状態ではありません",
+		})
+	}
+
+	// メンバー確認
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	var memberCount int64
+	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+
+	// 投票中の解散提案があれば投票、なければ（期限切れを含め）新しく提案を作成
+	var proposal models.TeamProposal
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND type = ? AND status = ?", teamId, "disband", "open").First(&proposal).Error; err == nil {
+			if err := ctrl.proposalService.Refresh(tx, &proposal); err != nil {
 				return err
 			}
-			// チームメンバーを削除（ユーザーが新しいチームを作成できるように）
-			if err := tx.Where("team_id = ?", teamId).Delete(&models.TeamMember{}).Error; err != nil {
-				return err
+			if proposal.Status == "open" {
+				return ctrl.proposalService.Vote(tx, &proposal, uid, true)
 			}
-			return nil
-		}); err != nil {
-			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-				Error:   "disband_failed",
-				Message: "チームの解散に失敗しました",
-			})
 		}
-		disbanded = true
+		proposal = models.TeamProposal{Type: "disband", ProposedBy: uid}
+		return ctrl.proposalService.CreateProposal(tx, team, &proposal)
+	})
+	var cooldownErr *service.ProposalCooldownError
+	if errors.As(err, &cooldownErr) {
+		return disbandVoteCooldown(c, cooldownErr)
 	}
-
-	var votes []models.DisbandVote
-	ctrl.db.Where("team_id = ?", teamId).Find(&votes)
-	votedUsers := make([]string, len(votes))
-	for i, v := range votes {
-		votedUsers[i] = v.UserID
+	if errors.Is(err, service.ErrAlreadyVoted) {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_voted",
+			Message: "既に解散に投票しています",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "vote_failed",
+			Message: "投票に失敗しました",
+		})
 	}
 
-	return c.JSON(http.StatusOK, response.DisbandVoteResponse{
-		TeamID:     teamId,
-		TotalCount: int(memberCount),
-		VotedCount: int(voteCount),
-		VotedUsers: votedUsers,
-		Disbanded:  disbanded,
-	})
+	resp := ctrl.buildDisbandVoteResponse(teamId, proposal, int(memberCount))
+	resp.Disbanded = proposal.Status == "applied"
+	return c.JSON(http.StatusOK, resp)
 }
 
 // CancelDisbandVote 解散投票を取り消す
@@ -337,35 +667,131 @@ func (ctrl *TeamController) CancelDisbandVote(c echo.Context) error {
 	}
 
 	// 投票を削除
-	result := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).Delete(&models.DisbandVote{})
-	if result.RowsAffected == 0 {
+	var proposal models.TeamProposal
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND type = ? AND status = ?", teamId, "disband", "open").First(&proposal).Error; err != nil {
+			return service.ErrVoteNotFound
+		}
+		return ctrl.proposalService.WithdrawVote(tx, &proposal, uid)
+	})
+	if errors.Is(err, service.ErrVoteNotFound) {
 		return c.JSON(http.StatusNotFound, response.ErrorResponse{
 			Error:   "vote_not_found",
 			Message: "解散投票が見つかりません",
 		})
 	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "cancel_failed",
+			Message: "解散投票の取り消しに失敗しました",
+		})
+	}
+
+	var memberCount int64
+	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+
+	return c.JSON(http.StatusOK, ctrl.buildDisbandVoteResponse(teamId, proposal, int(memberCount)))
+}
+
+// GetDisbandVotes 解散投票状況を取得
+func (ctrl *TeamController) GetDisbandVotes(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	// メンバー確認
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
 
 	var memberCount int64
 	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
 
-	var votes []models.DisbandVote
-	ctrl.db.Where("team_id = ?", teamId).Find(&votes)
-	votedUsers := make([]string, len(votes))
-	for i, v := range votes {
-		votedUsers[i] = v.UserID
+	var proposal models.TeamProposal
+	if err := ctrl.db.Where("team_id = ? AND type = ? AND status = ?", teamId, "disband", "open").First(&proposal).Error; err == nil {
+		// 参照では状態を変えない。期限切れの提案は定期処理か投票時に締め切られるまで、ないものとして返す
+		if service.ProposalPastDeadline(proposal, time.Now()) {
+			proposal = models.TeamProposal{}
+		}
 	}
 
-	return c.JSON(http.StatusOK, response.DisbandVoteResponse{
+	resp := ctrl.buildDisbandVoteResponse(teamId, proposal, int(memberCount))
+	resp.Disbanded = team.Status == "disbanded"
+	return c.JSON(http.StatusOK, resp)
+}
+
+// buildDisbandVoteResponse 解散提案の投票状況を返す。提案がない場合（IDが空）は投票0件として扱う
+func (ctrl *TeamController) buildDisbandVoteResponse(teamId string, proposal models.TeamProposal, memberCount int) response.DisbandVoteResponse {
+	resp := response.DisbandVoteResponse{
 		TeamID:     teamId,
-		TotalCount: int(memberCount),
-		VotedCount: len(votes),
-		VotedUsers: votedUsers,
+		TotalCount: memberCount,
+		VotedUsers: []string{},
 		Disbanded:  false,
+	}
+
+	if proposal.ID != "" {
+		resp.ProposalID = &proposal.ID
+		var votes []models.TeamProposalVote
+		ctrl.db.Where("proposal_id = ? AND approve = ?", proposal.ID, true).Find(&votes)
+		for _, v := range votes {
+			resp.VotedUsers = append(resp.VotedUsers, v.UserID)
+		}
+		if proposal.ExpiresAt != nil {
+			expiresAt := proposal.ExpiresAt.Format(time.RFC3339)
+			resp.ExpiresAt = &expiresAt
+		}
+	}
+	resp.VotedCount = len(resp.VotedUsers)
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err == nil {
+		inactive, _ := service.TeamInactive(ctrl.db, team, time.Now())
+		resp.Inactive = inactive && team.DisbandThreshold != "unanimous"
+		resp.RequiredCount = service.RequiredTeamDisbandVotes(team, memberCount, inactive)
+	}
+
+	if until, err := service.DisbandCooldownUntil(ctrl.db, teamId); err == nil && until != nil && until.After(time.Now()) && proposal.ID == "" {
+		cooldownUntil := until.Format(time.RFC3339)
+		resp.CooldownUntil = &cooldownUntil
+	}
+
+	return resp
+}
+
+// disbandVoteCooldown 解散投票のクールダウン中であることを Retry-After 付きで返す
+func disbandVoteCooldown(c echo.Context, cooldownErr *service.ProposalCooldownError) error {
+	retryAfter := int(math.Ceil(cooldownErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return c.JSON(http.StatusTooManyRequests, response.ErrorResponse{
+		Error:   "disband_vote_cooldown",
+		Message: fmt.Sprintf("前回の解散投票が不成立になったため、新しい解散投票は%d分後から始められます", int(math.Ceil(cooldownErr.RetryAfter.Minutes()))),
 	})
 }
 
-// GetDisbandVotes 解散投票状況を取得
-func (ctrl *TeamController) GetDisbandVotes(c echo.Context) error {
+// LeaveTeam チームから離脱
+// @Summary      チーム離脱
+// @Description  チームから自主的に離脱する。activeチームの場合は厳しさに応じたHPペナルティが発生する。リーダーが離脱した場合は最古参のメンバーがリーダーになる。
+// @Tags         teams
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.LeaveTeamResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/leave [post]
+// @Security     BearerAuth
+func (ctrl *TeamController) LeaveTeam(c echo.Context) error {
 	uid := c.Get("uid").(string)
 	teamId := c.Param("teamId")
 
@@ -377,6 +803,79 @@ func (ctrl *TeamController) GetDisbandVotes(c echo.Context) error {
 		})
 	}
 
+	if team.Status != "forming" && team.Status != "active" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームは離脱できる状態ではありません",
+		})
+	}
+
+	var result *service.RemovalResult
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = ctrl.membershipService.RemoveMember(tx, team, uid, true)
+		if err != nil {
+			return err
+		}
+		return service.RecordMembershipEvent(tx, team.ID, uid, "left", nil)
+	})
+	if errors.Is(err, service.ErrMemberNotFound) {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "leave_failed",
+			Message: "チームからの離脱に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.LeaveTeamResponse{
+		TeamID:      teamId,
+		UserID:      uid,
+		HPPenalty:   result.HPPenalty,
+		CurrentHP:   result.CurrentHP,
+		TeamStatus:  result.TeamStatus,
+		NewLeaderID: result.NewLeaderID,
+	})
+}
+
+// VoteRemoveMember メンバー除名に投票
+// @Summary      メンバー除名に投票
+// @Description  メンバーの除名に投票する。除名投票はリーダーのみ発議でき、対象者以外のメンバーの過半数が賛成すると除名される（HPペナルティなし）。
+// @Tags         teams
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Param        userId  path      string  true  "除名対象のユーザーID"
+// @Success      200     {object}  response.MemberRemovalVoteResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/members/{userId}/removal-vote [post]
+// @Security     BearerAuth
+func (ctrl *TeamController) VoteRemoveMember(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	targetUserId := c.Param("userId")
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	if team.Status != "forming" && team.Status != "active" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_team_status",
+			Message: "このチームは除名投票できる状態ではありません",
+		})
+	}
+
 	// メンバー確認
 	var member models.TeamMember
 	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
@@ -386,21 +885,247 @@ func (ctrl *TeamController) GetDisbandVotes(c echo.Context) error {
 		})
 	}
 
+	if targetUserId == uid {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "cannot_remove_self",
+			Message: "自分自身は除名できません。離脱APIを使用してください",
+		})
+	}
+
+	var target models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, targetUserId).First(&target).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "member_not_found",
+			Message: "対象のメンバーが見つかりません",
+		})
+	}
+
 	var memberCount int64
 	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
 
-	var votes []models.DisbandVote
-	ctrl.db.Where("team_id = ?", teamId).Find(&votes)
-	votedUsers := make([]string, len(votes))
-	for i, v := range votes {
-		votedUsers[i] = v.UserID
+	// 除名提案の発議はリーダーのみ
+	var proposal models.TeamProposal
+	hasOpen := ctrl.db.Where("team_id = ? AND type = ? AND target_user_id = ? AND status = ?", teamId, "remove_member", targetUserId, "open").
+		First(&proposal).Error == nil
+	if !hasOpen && member.Role != "leader" {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "除名投票はリーダーのみ発議できます",
+		})
 	}
 
-	return c.JSON(http.StatusOK, response.DisbandVoteResponse{
-		TeamID:     teamId,
-		TotalCount: int(memberCount),
-		VotedCount: len(votes),
-		VotedUsers: votedUsers,
-		Disbanded:  team.Status == "disbanded",
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if !hasOpen {
+			proposal = models.TeamProposal{Type: "remove_member", ProposedBy: uid, TargetUserID: &targetUserId}
+			return ctrl.proposalService.CreateProposal(tx, team, &proposal)
+		}
+		return ctrl.proposalService.Vote(tx, &proposal, uid, true)
 	})
+	if errors.Is(err, service.ErrAlreadyVoted) {
+		return c.JSON(http.StatusConflict, response.ErrorResponse{
+			Error:   "already_voted",
+			Message: "既に除名に投票しています",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "remove_failed",
+			Message: "メンバーの除名に失敗しました",
+		})
+	}
+
+	resp := ctrl.buildRemovalVoteResponse(teamId, targetUserId, proposal, int(memberCount))
+	resp.Removed = proposal.Status == "applied"
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CancelRemoveMemberVote メンバー除名投票を取り消す
+// @Summary      メンバー除名投票の取り消し
+// @Tags         teams
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Param        userId  path      string  true  "除名対象のユーザーID"
+// @Success      200     {object}  response.MemberRemovalVoteResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/members/{userId}/removal-vote [delete]
+// @Security     BearerAuth
+func (ctrl *TeamController) CancelRemoveMemberVote(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	targetUserId := c.Param("userId")
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	var proposal models.TeamProposal
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND type = ? AND target_user_id = ? AND status = ?", teamId, "remove_member", targetUserId, "open").
+			First(&proposal).Error; err != nil {
+			return service.ErrVoteNotFound
+		}
+		return ctrl.proposalService.WithdrawVote(tx, &proposal, uid)
+	})
+	if errors.Is(err, service.ErrVoteNotFound) {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "vote_not_found",
+			Message: "除名投票が見つかりません",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "cancel_failed",
+			Message: "除名投票の取り消しに失敗しました",
+		})
+	}
+
+	var memberCount int64
+	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+
+	return c.JSON(http.StatusOK, ctrl.buildRemovalVoteResponse(teamId, targetUserId, proposal, int(memberCount)))
+}
+
+// GetRemoveMemberVotes メンバー除名投票状況を取得
+// @Summary      メンバー除名投票状況
+// @Tags         teams
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Param        userId  path      string  true  "除名対象のユーザーID"
+// @Success      200     {object}  response.MemberRemovalVoteResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/members/{userId}/removal-vote [get]
+// @Security     BearerAuth
+func (ctrl *TeamController) GetRemoveMemberVotes(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	targetUserId := c.Param("userId")
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+
+	var memberCount int64
+	ctrl.db.Model(&models.TeamMember{}).Where("team_id = ?", teamId).Count(&memberCount)
+
+	var proposal models.TeamProposal
+	ctrl.db.Where("team_id = ? AND type = ? AND target_user_id = ? AND status = ?", teamId, "remove_member", targetUserId, "open").
+		First(&proposal)
+
+	return c.JSON(http.StatusOK, ctrl.buildRemovalVoteResponse(teamId, targetUserId, proposal, int(memberCount)))
+}
+
+// buildRemovalVoteResponse 除名提案の投票状況を返す。提案がない場合（IDが空）は投票0件として扱う
+func (ctrl *TeamController) buildRemovalVoteResponse(teamId, targetUserId string, proposal models.TeamProposal, memberCount int) response.MemberRemovalVoteResponse {
+	votedUsers := []string{}
+	var proposalID *string
+	if proposal.ID != "" {
+		proposalID = &proposal.ID
+		var votes []models.TeamProposalVote
+		ctrl.db.Where("proposal_id = ? AND approve = ?", proposal.ID, true).Find(&votes)
+		for _, v := range votes {
+			votedUsers = append(votedUsers, v.UserID)
+		}
+	}
+
+	return response.MemberRemovalVoteResponse{
+		TeamID:        teamId,
+		ProposalID:    proposalID,
+		TargetUserID:  targetUserId,
+		EligibleCount: memberCount - 1,
+		VotedCount:    len(votedUsers),
+		RequiredCount: service.RequiredRemovalVotes(memberCount),
+		VotedUsers:    votedUsers,
+		Removed:       false,
+	}
+}
+
+// TransferLeadership リーダー交代
+// @Summary      リーダー交代
+// @Description  リーダーを別のメンバーに引き継ぐ。リーダーのみ実行可能。
+// @Tags         teams
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                              true  "チームID"
+// @Param        body    body      requests.TransferLeadershipRequest  true  "新しいリーダー"
+// @Success      200     {object}  response.TeamResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      404     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/leader [put]
+// @Security     BearerAuth
+func (ctrl *TeamController) TransferLeadership(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+
+	req := new(requests.TransferLeadershipRequest)
+	if err := c.Bind(req); err != nil || req.UserID == "" {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "user_id は必須です",
+		})
+	}
+
+	var team models.Team
+	if err := ctrl.db.First(&team, "id = ?", teamId).Error; err != nil {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "team_not_found",
+			Message: "チームが見つかりません",
+		})
+	}
+
+	var member models.TeamMember
+	if err := ctrl.db.Where("team_id = ? AND user_id = ?", teamId, uid).First(&member).Error; err != nil {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "このチームのメンバーではありません",
+		})
+	}
+	if member.Role != "leader" {
+		return c.JSON(http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "リーダーのみリーダーを交代できます",
+		})
+	}
+	if req.UserID == uid {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "既にリーダーです",
+		})
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		return ctrl.membershipService.TransferLeadership(tx, teamId, uid, req.UserID)
+	})
+	if errors.Is(err, service.ErrMemberNotFound) {
+		return c.JSON(http.StatusNotFound, response.ErrorResponse{
+			Error:   "member_not_found",
+			Message: "対象のメンバーが見つかりません",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "transfer_failed",
+			Message: "リーダーの交代に失敗しました",
+		})
+	}
+
+	var members []models.TeamMember
+	ctrl.db.Preload("User").Where("team_id = ?", teamId).Find(&members)
+
+	var goal models.Goal
+	var goalPtr *models.Goal
+	if err := ctrl.db.First(&goal, "team_id = ?", teamId).Error; err == nil {
+		goalPtr = &goal
+	}
+
+	return c.JSON(http.StatusOK, response.NewTeamResponse(team, members, goalPtr))
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
 	"gorm.io/gorm"
 )
 
@@ -51,9 +52,8 @@ func (ctrl *TeamStatusController) GetTeamStatus(c echo.Context) error {
 		})
 	}
 
-	// 目標取得
-	var goal models.Goal
-	ctrl.db.First(&goal, "team_id = ?", teamId)
+	// 目標取得（今週に適用されている版）
+	goal, _, _ := service.GoalForWeek(ctrl.db, teamId, team.CurrentWeek)
 
 	// メンバー一覧取得
 	var members []models.TeamMember
@@ -112,6 +112,7 @@ func buildHPHistory(evaluations []models.WeeklyEvaluation, maxHP int) []response
 				UserName:  e.User.Name,
 				HPChange:  e.HPChange,
 				TargetMet: e.TargetMet,
+				Frozen:    e.Frozen,
 			})
 		}
 		hpEnd := hpStart + totalChange
@@ -143,11 +144,13 @@ func (ctrl *TeamStatusController) buildMembersProgress(teamId string, members []
 		return progress
 	}
 
-	// 今週の期間を算出
-	weekStart := team.StartedAt.AddDate(0, 0, (team.CurrentWeek-1)*7)
-	weekEnd := weekStart.AddDate(0, 0, 7)
+	// 今週の期間を算出（チームの開始ルールで区切る）
+	weekStart, weekEnd, _ := service.WeekWindow(team, team.CurrentWeek)
 
 	for _, m := range members {
+		memberGoal, override, _ := service.MemberGoalForWeek(ctrl.db, goal, m.UserID, team.CurrentWeek)
+		freeze, _ := service.FrozenWeek(ctrl.db, teamId, m.UserID, team.CurrentWeek)
+
 		// 今週のアクティビティ集計（gymはqualifiedVisitsが必要なため別途取得）
 		var activities []models.Activity
 		ctrl.db.Where("user_id = ? AND team_id = ? AND status = ? AND started_at >= ? AND started_at < ? AND (review_status IS NULL OR review_status != ?)",
@@ -162,8 +165,8 @@ func (ctrl *TeamStatusController) buildMembersProgress(teamId string, members []
 			totalDuration += a.DurationMin
 			if a.ExerciseType == "gym" {
 				totalVisits++
-				if goal.TargetMinDurationMin != nil {
-					if a.DurationMin >= *goal.TargetMinDurationMin {
+				if memberGoal.TargetMinDurationMin != nil {
+					if a.DurationMin >= *memberGoal.TargetMinDurationMin {
 						qualifiedVisits++
 					}
 				} else {
@@ -176,6 +179,7 @@ func (ctrl *TeamStatusController) buildMembersProgress(teamId string, members []
 		if multiplier <= 0 {
 			multiplier = 1.0
 		}
+		multiplier *= service.TargetProration(team, m.JoinedAt, team.CurrentWeek)
 
 		var progressPercent float64
 		var distPtr *float64
@@ -185,17 +189,17 @@ func (ctrl *TeamStatusController) buildMembersProgress(teamId string, members []
 		switch team.ExerciseType {
 		case "running":
 			distPtr = &totalDist
-			if goal.TargetDistanceKM != nil && *goal.TargetDistanceKM > 0 {
-				effectiveTarget := *goal.TargetDistanceKM * multiplier
+			if memberGoal.TargetDistanceKM != nil && *memberGoal.TargetDistanceKM > 0 {
+				effectiveTarget := *memberGoal.TargetDistanceKM * multiplier
 				progressPercent = (totalDist / effectiveTarget) * 100
 			}
 		case "gym":
 			visitsPtr = &totalVisits
 			durationPtr = &totalDuration
-			if goal.TargetVisitsPerWeek != nil && *goal.TargetVisitsPerWeek > 0 {
-				effectiveTarget := float64(*goal.TargetVisitsPerWeek) * multiplier
+			if memberGoal.TargetVisitsPerWeek != nil && *memberGoal.TargetVisitsPerWeek > 0 {
+				effectiveTarget := float64(*memberGoal.TargetVisitsPerWeek) * multiplier
 				visitCount := totalVisits
-				if goal.TargetMinDurationMin != nil {
+				if memberGoal.TargetMinDurationMin != nil {
 					visitCount = qualifiedVisits
 				}
 				progressPercent = (float64(visitCount) / effectiveTarget) * 100
@@ -212,6 +216,8 @@ func (ctrl *TeamStatusController) buildMembersProgress(teamId string, members []
 			CurrentWeekVisits:      visitsPtr,
 			CurrentWeekDurationMin: durationPtr,
 			TargetProgressPercent:  progressPercent,
+			IndividualGoal:         override != nil,
+			Frozen:                 freeze != nil,
 		})
 	}
 
@@ -12,6 +12,7 @@ import (
 	"github.com/trihackathon/api/adapter"
 	"github.com/trihackathon/api/models"
 	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/utils"
 	"gorm.io/gorm"
 )
 
@@ -57,6 +58,7 @@ func (ctrl *UserController) GetMe(c echo.Context) error {
 // @Param        weight     formData  int     true   "体重 (kg)"
 // @Param        chronotype formData  string  true   "朝型夜型 (morning/night/both)"
 // @Param        avatar     formData  file    false  "プロフィール写真"
+// @Param        timezone   formData  string  false  "IANAタイムゾーン（省略時は Asia/Tokyo）"
 // @Success      201   {object}  response.UserResponse
 // @Failure      400   {object}  response.ErrorResponse
 // @Failure      409   {object}  response.ErrorResponse
@@ -103,6 +105,17 @@ func (ctrl *UserController) CreateMe(c echo.Context) error {
 		})
 	}
 
+	timezone := c.FormValue("timezone")
+	if timezone == "" {
+		timezone = utils.DefaultTimezone
+	}
+	if !utils.ValidTimezone(timezone) {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "timezone は Asia/Tokyo のようなIANAタイムゾーン名で指定してください",
+		})
+	}
+
 	var avatarURL string
 	file, err := c.FormFile("avatar")
 	if err == nil && file != nil {
@@ -137,6 +150,7 @@ func (ctrl *UserController) CreateMe(c echo.Context) error {
 		Weight:     weight,
 		Chronotype: chronotype,
 		AvatarURL:  avatarURL,
+		Timezone:   timezone,
 	}
 
 	if err := ctrl.db.Create(&user).Error; err != nil {
@@ -160,6 +174,7 @@ func (ctrl *UserController) CreateMe(c echo.Context) error {
 // @Param        weight     formData  int     false  "体重 (kg)"
 // @Param        chronotype formData  string  false  "朝型夜型 (morning/night/both)"
 // @Param        avatar     formData  file    false  "プロフィール写真"
+// @Param        timezone   formData  string  false  "IANAタイムゾーン（例: Asia/Tokyo）"
 // @Success      200   {object}  response.UserResponse
 // @Failure      400   {object}  response.ErrorResponse
 // @Failure      404   {object}  response.ErrorResponse
@@ -206,6 +221,15 @@ func (ctrl *UserController) UpdateMe(c echo.Context) error {
 	if chronotype := c.FormValue("chronotype"); chronotype != "" {
 		user.Chronotype = chronotype
 	}
+	if timezone := c.FormValue("timezone"); timezone != "" {
+		if !utils.ValidTimezone(timezone) {
+			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+				Error:   "invalid_request",
+				Message: "timezone は Asia/Tokyo のようなIANAタイムゾーン名で指定してください",
+			})
+		}
+		user.Timezone = timezone
+	}
 
 	// アバター更新
 	file, err := c.FormFile("avatar")
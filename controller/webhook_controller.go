@@ -0,0 +1,381 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/requests"
+	"github.com/trihackathon/api/response"
+	"github.com/trihackathon/api/service"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	db             *gorm.DB
+	webhookService *service.WebhookService
+}
+
+func NewWebhookController(db *gorm.DB, webhookService *service.WebhookService) *WebhookController {
+	return &WebhookController{db: db, webhookService: webhookService}
+}
+
+// requireLeader チームのリーダーでなければエラーを返す。WebhookのURLは外部サービスの認証情報を含むためリーダーのみ扱える
+func (ctrl *WebhookController) requireLeader(teamId, uid string) *errorReply {
+	var member models.TeamMember
+	if err := ctrl.db.First(&member, "team_id = ? AND user_id = ?", teamId, uid).Error; err != nil {
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_team_member",
+			Message: "チームのメンバーではありません",
+		}}
+	}
+	if member.Role != "leader" {
+		return &errorReply{http.StatusForbidden, response.ErrorResponse{
+			Error:   "not_leader",
+			Message: "Webhookを管理できるのはリーダーのみです",
+		}}
+	}
+	return nil
+}
+
+// findWebhook チームのWebhookを取得する
+func (ctrl *WebhookController) findWebhook(teamId, webhookId string) (*models.TeamWebhook, *errorReply) {
+	var webhook models.TeamWebhook
+	if err := ctrl.db.First(&webhook, "id = ? AND team_id = ?", webhookId, teamId).Error; err != nil {
+		return nil, &errorReply{http.StatusNotFound, response.ErrorResponse{
+			Error:   "webhook_not_found",
+			Message: "Webhookが見つかりません",
+		}}
+	}
+	return &webhook, nil
+}
+
+// GetWebhooks Webhook一覧
+// @Summary      Webhook一覧
+// @Description  チームに登録されたWebhookと購読できるイベントの一覧を返す（リーダーのみ）
+// @Tags         webhooks
+// @Produce      json
+// @Param        teamId  path      string  true  "チームID"
+// @Success      200     {object}  response.WebhookListResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/webhooks [get]
+// @Security     BearerAuth
+func (ctrl *WebhookController) GetWebhooks(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireLeader(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	var webhooks []models.TeamWebhook
+	ctrl.db.Where("team_id = ?", teamId).Order("created_at ASC").Find(&webhooks)
+
+	result := make([]response.WebhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		result[i] = response.NewWebhookResponse(w, service.WebhookEventList(w))
+	}
+	return c.JSON(http.StatusOK, response.WebhookListResponse{
+		Webhooks:        result,
+		AvailableEvents: service.WebhookEvents,
+	})
+}
+
+// CreateWebhook Webhook登録
+// @Summary      Webhook登録
+// @Description  チームのイベントを送るWebhookを登録する（リーダーのみ、1チーム5件まで）。送信には X-Webhook-Signature（sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + "." + 本文) の16進）が付く。secret はこのレスポンスでのみ返す
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        teamId  path      string                         true  "チームID"
+// @Param        body    body      requests.CreateWebhookRequest  true  "送信先と購読するイベント"
+// @Success      201     {object}  response.WebhookResponse
+// @Failure      400     {object}  response.ErrorResponse
+// @Failure      403     {object}  response.ErrorResponse
+// @Failure      409     {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/webhooks [post]
+// @Security     BearerAuth
+func (ctrl *WebhookController) CreateWebhook(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireLeader(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	req := new(requests.CreateWebhookRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if err := service.ValidateWebhookURL(req.URL); err != nil {
+		return c.JSON(http.StatusBadRequest, webhookErrorBody(err))
+	}
+	events, err := service.NormalizeWebhookEvents(req.Events)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, webhookErrorBody(err))
+	}
+	secret, err := service.GenerateWebhookSecret()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "Webhookの登録に失敗しました",
+		})
+	}
+
+	webhook := models.TeamWebhook{
+		ID:        utils.GenerateULID(),
+		TeamID:    teamId,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedBy: uid,
+	}
+	err = ctrl.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.TeamWebhook{}).Where("team_id = ?", teamId).Count(&count)
+		if count >= service.MaxWebhooksPerTeam {
+			return service.ErrTooManyWebhooks
+		}
+		return tx.Create(&webhook).Error
+	})
+	if errors.Is(err, service.ErrTooManyWebhooks) {
+		return c.JSON(http.StatusConflict, webhookErrorBody(err))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "create_failed",
+			Message: "Webhookの登録に失敗しました",
+		})
+	}
+
+	res := response.NewWebhookResponse(webhook, service.WebhookEventList(webhook))
+	res.Secret = &secret
+	return c.JSON(http.StatusCreated, res)
+}
+
+// UpdateWebhook Webhook更新
+// @Summary      Webhook更新
+// @Description  送信先・購読するイベント・有効/無効を変更する（リーダーのみ）。送信の失敗が続いて無効になったWebhookは active=true で再開できる
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        teamId     path      string                         true  "チームID"
+// @Param        webhookId  path      string                         true  "Webhook ID"
+// @Param        body       body      requests.UpdateWebhookRequest  true  "変更内容"
+// @Success      200        {object}  response.WebhookResponse
+// @Failure      400        {object}  response.ErrorResponse
+// @Failure      403        {object}  response.ErrorResponse
+// @Failure      404        {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/webhooks/{webhookId} [patch]
+// @Security     BearerAuth
+func (ctrl *WebhookController) UpdateWebhook(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireLeader(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	webhook, errResp := ctrl.findWebhook(teamId, c.Param("webhookId"))
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	req := new(requests.UpdateWebhookRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "リクエストの形式が不正です",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.URL != nil {
+		u := strings.TrimSpace(*req.URL)
+		if err := service.ValidateWebhookURL(u); err != nil {
+			return c.JSON(http.StatusBadRequest, webhookErrorBody(err))
+		}
+		updates["url"] = u
+	}
+	if req.Events != nil {
+		events, err := service.NormalizeWebhookEvents(*req.Events)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, webhookErrorBody(err))
+		}
+		updates["events"] = events
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+		if *req.Active {
+			updates["failure_count"] = 0
+		}
+	}
+
+	if len(updates) > 0 {
+		err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(webhook).Updates(updates).Error; err != nil {
+				return err
+			}
+			// 無効にしたWebhookの未送信分は送らない
+			if req.Active != nil && !*req.Active {
+				return tx.Model(&models.WebhookDelivery{}).
+					Where("webhook_id = ? AND status = ?", webhook.ID, "pending").
+					Update("status", "cancelled").Error
+			}
+			return nil
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+				Error:   "update_failed",
+				Message: "Webhookの更新に失敗しました",
+			})
+		}
+		ctrl.db.First(webhook, "id = ?", webhook.ID)
+	}
+
+	return c.JSON(http.StatusOK, response.NewWebhookResponse(*webhook, service.WebhookEventList(*webhook)))
+}
+
+// DeleteWebhook Webhook削除
+// @Summary      Webhook削除
+// @Description  Webhookを削除し、未送信の配信を取り消す（リーダーのみ）
+// @Tags         webhooks
+// @Param        teamId     path  string  true  "チームID"
+// @Param        webhookId  path  string  true  "Webhook ID"
+// @Success      204
+// @Failure      403  {object}  response.ErrorResponse
+// @Failure      404  {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/webhooks/{webhookId} [delete]
+// @Security     BearerAuth
+func (ctrl *WebhookController) DeleteWebhook(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireLeader(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	webhook, errResp := ctrl.findWebhook(teamId, c.Param("webhookId"))
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	err := ctrl.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WebhookDelivery{}).
+			Where("webhook_id = ? AND status = ?", webhook.ID, "pending").
+			Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "delete_failed",
+			Message: "Webhookの削除に失敗しました",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TestWebhook Webhookのテスト送信
+// @Summary      Webhookのテスト送信
+// @Description  webhook.test イベントのサンプルペイロードをすぐに1回送り、結果を返す（リーダーのみ、再送はしない）。開発環境では http://localhost:8080/debug/webhook-receiver を送信先にすると署名の検証結果を確認できる
+// @Tags         webhooks
+// @Produce      json
+// @Param        teamId     path      string  true  "チームID"
+// @Param        webhookId  path      string  true  "Webhook ID"
+// @Success      200        {object}  response.WebhookDeliveryResponse
+// @Failure      403        {object}  response.ErrorResponse
+// @Failure      404        {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/webhooks/{webhookId}/test [post]
+// @Security     BearerAuth
+func (ctrl *WebhookController) TestWebhook(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireLeader(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	webhook, errResp := ctrl.findWebhook(teamId, c.Param("webhookId"))
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	delivery, err := ctrl.webhookService.SendTestWebhook(*webhook, uid)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
+			Error:   "test_failed",
+			Message: "テスト送信に失敗しました",
+		})
+	}
+	return c.JSON(http.StatusOK, response.NewWebhookDeliveryResponse(*delivery))
+}
+
+// GetWebhookDeliveries Webhookの送信ログ
+// @Summary      Webhookの送信ログ
+// @Description  Webhookの配信履歴を新しい順に返す（リーダーのみ）
+// @Tags         webhooks
+// @Produce      json
+// @Param        teamId     path      string  true   "チームID"
+// @Param        webhookId  path      string  true   "Webhook ID"
+// @Param        status     query     string  false  "pending / delivered / dead / cancelled"
+// @Param        limit      query     int     false  "取得件数（1〜100、省略時50）"
+// @Success      200        {object}  response.WebhookDeliveryListResponse
+// @Failure      403        {object}  response.ErrorResponse
+// @Failure      404        {object}  response.ErrorResponse
+// @Router       /api/teams/{teamId}/webhooks/{webhookId}/deliveries [get]
+// @Security     BearerAuth
+func (ctrl *WebhookController) GetWebhookDeliveries(c echo.Context) error {
+	uid := c.Get("uid").(string)
+	teamId := c.Param("teamId")
+	if errResp := ctrl.requireLeader(teamId, uid); errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+	webhook, errResp := ctrl.findWebhook(teamId, c.Param("webhookId"))
+	if errResp != nil {
+		return c.JSON(errResp.status, errResp.body)
+	}
+
+	limit := 50
+	if v, err := strconv.Atoi(c.QueryParam("limit")); err == nil && v >= 1 && v <= 100 {
+		limit = v
+	}
+	query := ctrl.db.Where("webhook_id = ?", webhook.ID)
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	query.Order("created_at DESC").Limit(limit).Find(&deliveries)
+
+	result := make([]response.WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = response.NewWebhookDeliveryResponse(d)
+	}
+	return c.JSON(http.StatusOK, response.WebhookDeliveryListResponse{Deliveries: result})
+}
+
+func webhookErrorBody(err error) response.ErrorResponse {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhookURL):
+		return response.ErrorResponse{
+			Error:   "invalid_url",
+			Message: "url には https のURLを指定してください",
+		}
+	case errors.Is(err, service.ErrInvalidWebhookEvent):
+		return response.ErrorResponse{
+			Error:   "invalid_events",
+			Message: "events には " + strings.Join(service.WebhookEvents, " / ") + " から1つ以上指定してください",
+		}
+	case errors.Is(err, service.ErrTooManyWebhooks):
+		return response.ErrorResponse{
+			Error:   "too_many_webhooks",
+			Message: "Webhookは1チーム" + strconv.Itoa(service.MaxWebhooksPerTeam) + "件まで登録できます",
+		}
+	}
+	return response.ErrorResponse{
+		Error:   "internal_error",
+		Message: "Webhookの処理に失敗しました",
+	}
+}
@@ -28,10 +28,38 @@ func NewDB() *gorm.DB {
 		&models.TeamMember{},
 		&models.InviteCode{},
 		&models.Goal{},
+		&models.GoalVersion{},
+		&models.MemberGoalOverride{},
+		&models.WeekFreeze{},
 		&models.WeeklyEvaluation{},
-		&models.DisbandVote{},
 		&models.ActivityReview{},
+		&models.ReviewOutcome{},
+		&models.ActivityAppeal{},
+		&models.AppealVote{},
+		&models.EvaluationAdjustment{},
 		&models.GymLocation{},
+		&models.HPEvent{},
+		&models.SeasonSummary{},
+		&models.SeasonMemberStat{},
+		&models.InviteCodeUse{},
+		&models.JoinAttemptThrottle{},
+		&models.SecurityAuditEvent{},
+		&models.JoinRequest{},
+		&models.MatchmakingTicket{},
+		&models.TeamProposal{},
+		&models.TeamProposalVote{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.DeviceToken{},
+		&models.NotificationOutbox{},
+		&models.Nudge{},
+		&models.TeamWebhook{},
+		&models.WebhookDelivery{},
+		&models.MembershipEvent{},
+		&models.ActivityReaction{},
+		&models.ActivityComment{},
+		&models.FeedReadMarker{},
+		&models.ChatMessage{},
 	); err != nil {
 		log.Fatalf("マイグレーションエラー: %v", err)
 	}
@@ -3,6 +3,7 @@ package main
 import (
 	"net/http"
 	"os"
+	_ "time/tzdata" // タイムゾーンデータのないコンテナでもIANAタイムゾーンを読み込めるよう埋め込む
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -37,7 +38,7 @@ func main() {
 	// CORSミドルウェアの設定
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"}, // 本番環境では適切なオリジンを指定すべき
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 	}))
 
@@ -53,30 +54,68 @@ func main() {
 	// R2初期化
 	r2 := adapter.NewR2Adapter()
 
+	// サービス初期化
+	evaluationService := service.NewEvaluationService(db)
+	membershipService := service.NewMembershipService(db)
+	inviteService := service.NewInviteService(db)
+	matchmakingService := service.NewMatchmakingService(db, membershipService)
+	proposalService := service.NewProposalService(db, membershipService)
+	cleanupService := service.NewTeamCleanupService(db)
+	predictionService := service.NewPredictionService(db)
+	notificationService := service.NewNotificationService(db, adapter.NewPushSender(fa))
+	nudgeService := service.NewNudgeService(db, predictionService)
+	webhookService := service.NewWebhookService(db)
+	appealService := service.NewAppealService(db)
+	chatService := service.NewChatService(db, service.NewChatHub(),
+		service.NewChatRateModerator(),
+		service.NewBlockedWordModerator(),
+		service.NewChatRepeatModerator(),
+	)
+
 	// コントローラー初期化
-	debugController := controller.NewDebugController(fa, db)
+	debugController := controller.NewDebugController(fa, db, cleanupService)
 	userController := controller.NewUserController(db, r2)
-	teamController := controller.NewTeamController(db)
-	inviteController := controller.NewInviteController(db)
+	teamController := controller.NewTeamController(db, r2, membershipService, proposalService)
+	inviteController := controller.NewInviteController(db, inviteService, membershipService)
 	goalController := controller.NewGoalController(db)
-	activityController := controller.NewActivityController(db)
-	gymController := controller.NewGymController(db)
+	freezeController := controller.NewFreezeController(db)
+	activityController := controller.NewActivityController(db, nudgeService)
+	gymController := controller.NewGymController(db, nudgeService)
 	teamStatusController := controller.NewTeamStatusController(db)
 	evaluationController := controller.NewEvaluationController(db)
-	predictionController := controller.NewPredictionController(db)
-
-	// サービス初期化
-	evaluationService := service.NewEvaluationService(db)
-	cronController := controller.NewCronController(evaluationService)
+	predictionController := controller.NewPredictionController(db, predictionService)
+	seasonController := controller.NewSeasonController(db)
+	rematchController := controller.NewRematchController(db, membershipService)
+	discoveryController := controller.NewDiscoveryController(db, membershipService)
+	matchmakingController := controller.NewMatchmakingController(db, matchmakingService)
+	proposalController := controller.NewProposalController(db, proposalService)
+	notificationController := controller.NewNotificationController(db)
+	memberGoalController := controller.NewMemberGoalController(db)
+	webhookController := controller.NewWebhookController(db, webhookService)
+	feedController := controller.NewFeedController(db)
+	reviewController := controller.NewReviewController(db)
+	appealController := controller.NewAppealController(db, appealService)
+	chatController := controller.NewChatController(db, chatService)
+	cronController := controller.NewCronController(evaluationService, inviteService, matchmakingService, proposalService, cleanupService, notificationService, nudgeService, webhookService, appealService)
 
 	// 認証不要のルート
 	e.GET("/debug/health", debugController.Health)
 	e.GET("/debug/token", debugController.Token)
 	e.POST("/debug/cleanup-disbanded-teams", debugController.CleanupDisbandedTeams)
 	e.GET("/debug/user-team-status", debugController.GetUserTeamStatus)
+	e.POST("/debug/webhook-receiver", debugController.ReceiveWebhook)
+	e.GET("/debug/webhook-receiver", debugController.GetReceivedWebhooks)
 
 	// Cronエンドポイント（Firebase認証の外）
 	e.POST("/cron/weekly-evaluation", cronController.RunWeeklyEvaluation)
+	e.POST("/cron/purge-invite-codes", cronController.PurgeInviteCodes)
+	e.POST("/cron/matchmaking", cronController.RunMatchmaking)
+	e.POST("/cron/expire-proposals", cronController.ExpireProposals)
+	e.POST("/cron/team-cleanup", cronController.RunTeamCleanup)
+	e.POST("/cron/dispatch-notifications", cronController.DispatchNotifications)
+	e.POST("/cron/nudges", cronController.RunNudges)
+	e.POST("/cron/dispatch-webhooks", cronController.DispatchWebhooks)
+	e.POST("/cron/resolve-appeals", cronController.ResolveAppeals)
 
 	// 認証必須のルートグループ
 	api := e.Group("/api")
@@ -86,23 +125,80 @@ func main() {
 	api.GET("/users/me", userController.GetMe)
 	api.POST("/users/me", userController.CreateMe)
 	api.PUT("/users/me", userController.UpdateMe)
+	api.GET("/users/me/teams/history", seasonController.GetMyTeamHistory)
+
+	// 通知 API
+	api.GET("/notifications", notificationController.GetNotifications)
+	api.POST("/notifications/read-all", notificationController.MarkAllNotificationsRead)
+	api.POST("/notifications/:notificationId/read", notificationController.MarkNotificationRead)
+	api.GET("/notification-preferences", notificationController.GetNotificationPreferences)
+	api.PUT("/notification-preferences", notificationController.UpdateNotificationPreferences)
+	api.POST("/devices", notificationController.RegisterDevice)
+	api.DELETE("/devices/:deviceId", notificationController.DeleteDevice)
 
 	// チーム API
 	api.POST("/teams", teamController.CreateTeam)
 	api.GET("/teams/me", teamController.GetMyTeam)
 	api.GET("/teams/:teamId", teamController.GetTeam)
+	api.PATCH("/teams/:teamId", teamController.UpdateTeam)
+	api.POST("/teams/:teamId/start", teamController.StartChallenge)
+	api.POST("/teams/:teamId/leave", teamController.LeaveTeam)
+	api.PUT("/teams/:teamId/leader", teamController.TransferLeadership)
+	api.POST("/teams/:teamId/members/:userId/removal-vote", teamController.VoteRemoveMember)
+	api.DELETE("/teams/:teamId/members/:userId/removal-vote", teamController.CancelRemoveMemberVote)
+	api.GET("/teams/:teamId/members/:userId/removal-vote", teamController.GetRemoveMemberVotes)
 	api.POST("/teams/:teamId/disband-vote", teamController.VoteDisband)
 	api.DELETE("/teams/:teamId/disband-vote", teamController.CancelDisbandVote)
 	api.GET("/teams/:teamId/disband-votes", teamController.GetDisbandVotes)
+	api.GET("/teams/:teamId/summary", seasonController.GetTeamSummary)
+
+	// チーム提案 API（解散・除名・設定変更）
+	api.POST("/teams/:teamId/proposals", proposalController.CreateProposal)
+	api.GET("/teams/:teamId/proposals", proposalController.GetProposals)
+	api.GET("/teams/:teamId/proposals/:proposalId", proposalController.GetProposal)
+	api.DELETE("/teams/:teamId/proposals/:proposalId", proposalController.CancelProposal)
+	api.POST("/teams/:teamId/proposals/:proposalId/vote", proposalController.VoteProposal)
+	api.DELETE("/teams/:teamId/proposals/:proposalId/vote", proposalController.WithdrawProposalVote)
+
+	// 再戦 API
+	api.POST("/teams/:teamId/rematch", rematchController.ProposeRematch)
+	api.GET("/teams/:teamId/rematch", rematchController.GetRematch)
+	api.POST("/teams/:teamId/rematch/confirm", rematchController.ConfirmRematch)
 
 	// 招待コード API
 	api.POST("/teams/:teamId/invite", inviteController.CreateInviteCode)
+	api.GET("/teams/:teamId/invites", inviteController.ListInviteCodes)
+	api.DELETE("/teams/:teamId/invites/:code", inviteController.RevokeInviteCode)
+	api.GET("/teams/:teamId/invites/:code/qr", inviteController.GetInviteQRCode)
 	api.POST("/teams/join", inviteController.JoinTeam)
 
+	// チーム探索・参加申請 API
+	api.GET("/teams/directory", discoveryController.GetTeamDirectory)
+	api.POST("/teams/:teamId/join-requests", discoveryController.CreateJoinRequest)
+	api.GET("/teams/:teamId/join-requests", discoveryController.GetJoinRequests)
+	api.POST("/teams/:teamId/join-requests/:requestId/approve", discoveryController.ApproveJoinRequest)
+	api.POST("/teams/:teamId/join-requests/:requestId/reject", discoveryController.RejectJoinRequest)
+	api.DELETE("/teams/:teamId/join-requests/:requestId", discoveryController.CancelJoinRequest)
+
+	// マッチング API
+	api.POST("/matchmaking", matchmakingController.JoinMatchmaking)
+	api.GET("/matchmaking/me", matchmakingController.GetMyMatchmaking)
+	api.DELETE("/matchmaking/me", matchmakingController.CancelMatchmaking)
+
 	// 目標設定 API
 	api.POST("/teams/:teamId/goal", goalController.CreateGoal)
 	api.GET("/teams/:teamId/goal", goalController.GetGoal)
 	api.PUT("/teams/:teamId/goal", goalController.UpdateGoal)
+	api.GET("/teams/:teamId/goal/history", goalController.GetGoalHistory)
+	api.GET("/teams/:teamId/goal/suggestions", goalController.GetGoalSuggestions)
+	api.GET("/teams/:teamId/freezes", freezeController.GetFreezes)
+	api.POST("/teams/:teamId/freezes", freezeController.CreateFreeze)
+	api.DELETE("/teams/:teamId/freezes/:freezeId", freezeController.CancelFreeze)
+	api.POST("/teams/:teamId/member-goals", memberGoalController.ProposeMemberGoal)
+	api.GET("/teams/:teamId/member-goals", memberGoalController.GetMemberGoals)
+	api.DELETE("/teams/:teamId/member-goals/:memberGoalId", memberGoalController.DeleteMemberGoal)
+	api.POST("/teams/:teamId/member-goals/:memberGoalId/accept", memberGoalController.AcceptMemberGoal)
+	api.POST("/teams/:teamId/member-goals/:memberGoalId/decline", memberGoalController.DeclineMemberGoal)
 
 	// アクティビティ API（ランニング）
 	api.POST("/activities/running/start", activityController.StartRunning)
@@ -125,6 +221,31 @@ func main() {
 	// アクティビティレビュー API
 	api.POST("/activities/:activityId/review", activityController.PostActivityReview)
 	api.GET("/activities/:activityId/reviews", activityController.GetActivityReviews)
+	api.GET("/activities/:activityId/review-status", reviewController.GetReviewStatus)
+	api.GET("/teams/:teamId/review-stats", reviewController.GetReviewerStats)
+	api.POST("/activities/:activityId/appeal", appealController.FileAppeal)
+	api.GET("/activities/:activityId/appeal", appealController.GetAppeal)
+	api.POST("/activities/:activityId/appeal/votes", appealController.VoteAppeal)
+	api.GET("/teams/:teamId/appeals", appealController.GetTeamAppeals)
+
+	// フィード API
+	api.GET("/teams/:teamId/feed", feedController.GetFeed)
+	api.GET("/teams/:teamId/feed/unread", feedController.GetFeedUnread)
+	api.POST("/teams/:teamId/feed/read", feedController.MarkFeedRead)
+	api.GET("/activities/:activityId/reactions", feedController.GetReactions)
+	api.POST("/activities/:activityId/reactions", feedController.AddReaction)
+	api.DELETE("/activities/:activityId/reactions", feedController.RemoveReaction)
+	api.GET("/activities/:activityId/comments", feedController.GetComments)
+	api.POST("/activities/:activityId/comments", feedController.PostComment)
+	api.DELETE("/activities/:activityId/comments/:commentId", feedController.DeleteComment)
+
+	// チームチャット API
+	api.GET("/teams/:teamId/chat/messages", chatController.GetMessages)
+	api.POST("/teams/:teamId/chat/messages", chatController.PostMessage)
+	api.DELETE("/teams/:teamId/chat/messages/:messageId", chatController.DeleteMessage)
+	// SSEはクエリのトークンも受け付けるため、/api グループの認証を通さず個別に登録する
+	e.GET("/api/teams/:teamId/chat/stream", chatController.StreamMessages,
+		customMiddleware.EventStreamToken, customMiddleware.FirebaseAuth(fa))
 
 	// チーム HP・状態 API
 	api.GET("/teams/:teamId/status", teamStatusController.GetTeamStatus)
@@ -132,9 +253,19 @@ func main() {
 	// 週次評価 API
 	api.GET("/teams/:teamId/evaluations", evaluationController.GetEvaluations)
 	api.GET("/teams/:teamId/evaluations/current", evaluationController.GetCurrentWeekEvaluation)
+	api.GET("/teams/:teamId/evaluations/adjustments", appealController.GetEvaluationAdjustments)
 
 	// 失敗予測 API
 	api.GET("/predictions/me", predictionController.GetMyPrediction)
+	api.GET("/teams/:teamId/forecast", predictionController.GetTeamForecast)
+
+	// Webhook API
+	api.GET("/teams/:teamId/webhooks", webhookController.GetWebhooks)
+	api.POST("/teams/:teamId/webhooks", webhookController.CreateWebhook)
+	api.PATCH("/teams/:teamId/webhooks/:webhookId", webhookController.UpdateWebhook)
+	api.DELETE("/teams/:teamId/webhooks/:webhookId", webhookController.DeleteWebhook)
+	api.POST("/teams/:teamId/webhooks/:webhookId/test", webhookController.TestWebhook)
+	api.GET("/teams/:teamId/webhooks/:webhookId/deliveries", webhookController.GetWebhookDeliveries)
 
 	// Health check endpoint
 	// @Summary      Health check
@@ -48,3 +48,22 @@ func FirebaseAuth(fa *adapter.FirebaseAdapter) echo.MiddlewareFunc {
 		}
 	}
 }
+
+// EventStreamToken クエリの access_token を Authorization ヘッダーとして扱うミドルウェア
+// EventSource はヘッダーを付けられないため、SSEのルートに限り FirebaseAuth の前に挟んで使う
+func EventStreamToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if req.Header.Get("Authorization") == "" && isEventStream(c) {
+			if token := c.QueryParam("access_token"); token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		return next(c)
+	}
+}
+
+// isEventStream Server-Sent Events の接続か
+func isEventStream(c echo.Context) bool {
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "text/event-stream")
+}
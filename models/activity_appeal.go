@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ActivityAppeal 否認されたアクティビティへの異議申し立て。本人以外のメンバーが改めて投票する
+type ActivityAppeal struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	ActivityID   string     `json:"activity_id" gorm:"not null;uniqueIndex"` // 1つのアクティビティに申し立ては1回まで
+	TeamID       string     `json:"team_id" gorm:"not null;index:idx_appeal_team_status"`
+	UserID       string     `json:"user_id" gorm:"not null"` // 申し立てた本人（アクティビティの持ち主）
+	WeekNumber   int        `json:"week_number" gorm:"not null"`
+	Explanation  string     `json:"explanation" gorm:"type:text;default:''"`
+	EvidenceURL  string     `json:"evidence_url" gorm:"default:''"`
+	Status       string     `json:"status" gorm:"not null;default:'open';index:idx_appeal_team_status"` // open / overturned / upheld
+	Resolution   string     `json:"resolution" gorm:"default:''"`                                       // quorum: 票数で決まった / expired: 期限までに承認が必要数に届かず否認のまま
+	Recalculated bool       `json:"recalculated" gorm:"default:false"`                                  // 評価済みの週を評価し直したか
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User     User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Activity Activity `json:"activity,omitempty" gorm:"foreignKey:ActivityID"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ActivityComment アクティビティへのコメント。ParentID があれば返信で、返信への返信も最初のコメントにぶら下げる
+type ActivityComment struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	ActivityID string     `json:"activity_id" gorm:"not null;index"`
+	TeamID     string     `json:"team_id" gorm:"not null;index:idx_comment_team_created"`
+	UserID     string     `json:"user_id" gorm:"not null"`
+	ParentID   *string    `json:"parent_id" gorm:"index"`
+	Body       string     `json:"body" gorm:"not null"`
+	DeletedAt  *time.Time `json:"deleted_at"` // 削除しても返信のスレッドが残るよう行は消さず本文を空にする
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index:idx_comment_team_created" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ActivityReaction チームメイトのアクティビティへの絵文字リアクション。同じ絵文字は1人1回
+type ActivityReaction struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	ActivityID string    `json:"activity_id" gorm:"not null;uniqueIndex:idx_reaction_activity_user_emoji"`
+	TeamID     string    `json:"team_id" gorm:"not null;index"`
+	UserID     string    `json:"user_id" gorm:"not null;uniqueIndex:idx_reaction_activity_user_emoji"`
+	Emoji      string    `json:"emoji" gorm:"not null;uniqueIndex:idx_reaction_activity_user_emoji"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
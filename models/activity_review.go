@@ -3,12 +3,14 @@ package models
 import "time"
 
 type ActivityReview struct {
-	ID         string    `json:"id" gorm:"primaryKey"`
-	ActivityID string    `json:"activity_id" gorm:"not null;uniqueIndex:idx_activity_reviewer"`
-	ReviewerID string    `json:"reviewer_id" gorm:"not null;uniqueIndex:idx_activity_reviewer"`
-	Status     string    `json:"status" gorm:"not null"` // "approved" | "rejected"
-	Comment    string    `json:"comment"`
-	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ActivityID     string    `json:"activity_id" gorm:"not null;uniqueIndex:idx_activity_reviewer"`
+	ReviewerID     string    `json:"reviewer_id" gorm:"not null;uniqueIndex:idx_activity_reviewer"`
+	Status         string    `json:"status" gorm:"not null"`            // "approved" | "rejected"
+	ReasonCategory string    `json:"reason_category" gorm:"default:''"` // 否認の理由（gps_anomaly / not_exercise / duplicate / wrong_location / too_short / other）
+	Comment        string    `json:"comment"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 
 	Reviewer User `json:"reviewer,omitempty" gorm:"foreignKey:ReviewerID"`
 }
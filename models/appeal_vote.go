@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AppealVote 異議申し立てへの投票。Approve はアクティビティを認めるか
+type AppealVote struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	AppealID  string    `json:"appeal_id" gorm:"not null;uniqueIndex:idx_appeal_vote_user"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_appeal_vote_user"`
+	Approve   bool      `json:"approve" gorm:"not null"`
+	Comment   string    `json:"comment" gorm:"default:''"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ChatMessage チームチャットのメッセージ。ID は ULID で、チーム内の並び順にも使う
+type ChatMessage struct {
+	ID         string     `json:"id" gorm:"primaryKey;index:idx_chat_team_id,priority:2"`
+	TeamID     string     `json:"team_id" gorm:"not null;index:idx_chat_team_id,priority:1"`
+	UserID     string     `json:"user_id" gorm:"not null;index:idx_chat_user_created"`
+	Body       string     `json:"body" gorm:"not null"`
+	Flagged    bool       `json:"flagged" gorm:"default:false"` // モデレーションで要確認とされた
+	FlagReason string     `json:"flag_reason" gorm:"default:''"`
+	DeletedAt  *time.Time `json:"deleted_at"` // 削除したメッセージは本文を空にして残す
+	DeletedBy  *string    `json:"deleted_by"` // 削除した投稿者またはリーダー
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index:idx_chat_user_created" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// DeviceToken プッシュ通知を送る端末の登録トークン
+type DeviceToken struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	UserID     string    `json:"user_id" gorm:"not null;index"`
+	Token      string    `json:"token" gorm:"not null;uniqueIndex"`
+	Platform   string    `json:"platform" gorm:"not null"` // ios / android / web
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// EvaluationAdjustment 評価済みの週を評価し直した記録。変更前後の値を残す
+type EvaluationAdjustment struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	TeamID           string    `json:"team_id" gorm:"not null;index:idx_eval_adjustment_team_week"`
+	WeekNumber       int       `json:"week_number" gorm:"not null;index:idx_eval_adjustment_team_week"`
+	UserID           string    `json:"user_id" gorm:"not null"` // 評価し直したメンバー
+	EvaluationID     string    `json:"evaluation_id" gorm:"not null"`
+	Reason           string    `json:"reason" gorm:"not null"` // appeal_overturned
+	AppealID         *string   `json:"appeal_id"`
+	ActivityID       *string   `json:"activity_id"`
+	TargetMetBefore  bool      `json:"target_met_before"`
+	TargetMetAfter   bool      `json:"target_met_after"`
+	DistanceBefore   float64   `json:"distance_before"`
+	DistanceAfter    float64   `json:"distance_after"`
+	VisitsBefore     int       `json:"visits_before"`
+	VisitsAfter      int       `json:"visits_after"`
+	DurationBefore   int       `json:"duration_before"`
+	DurationAfter    int       `json:"duration_after"`
+	HPChangeBefore   int       `json:"hp_change_before"` // このメンバーの評価のHP変動（全員達成ボーナス込み）
+	HPChangeAfter    int       `json:"hp_change_after"`
+	AllMetBefore     bool      `json:"all_met_before"`
+	AllMetAfter      bool      `json:"all_met_after"`
+	TeamHPBefore     int       `json:"team_hp_before"`
+	TeamHPAfter      int       `json:"team_hp_after"`
+	TeamStatusBefore string    `json:"team_status_before"` // active / completed / disbanded
+	TeamStatusAfter  string    `json:"team_status_after"`  // HPが尽きて解散したチームのHPが戻ると active（最終週なら completed）
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// FeedReadMarker ユーザーがチームのフィードをどこまで読んだか。未読数はこれより後の項目・コメントを数える
+type FeedReadMarker struct {
+	UserID     string    `json:"user_id" gorm:"primaryKey"`
+	TeamID     string    `json:"team_id" gorm:"primaryKey"`
+	LastReadAt time.Time `json:"last_read_at" gorm:"not null"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -9,6 +9,8 @@ type Goal struct {
 	TargetDistanceKM     *float64  `json:"target_distance_km"`           // running用
 	TargetVisitsPerWeek  *int      `json:"target_visits_per_week"`       // gym用
 	TargetMinDurationMin *int      `json:"target_min_duration_min"`      // gym用
+	Version              int       `json:"version" gorm:"default:0"`             // 最新の版。0は版管理前の目標
+	EffectiveFromWeek    int       `json:"effective_from_week" gorm:"default:1"` // 最新の版が適用される週
 	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt            time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// GoalVersion チーム目標の版。effective_from_week 以降の週の評価に使われる
+type GoalVersion struct {
+	ID                   string    `json:"id" gorm:"primaryKey"`
+	GoalID               string    `json:"goal_id" gorm:"not null;index"`
+	TeamID               string    `json:"team_id" gorm:"not null;uniqueIndex:idx_goal_version_team_version"`
+	Version              int       `json:"version" gorm:"not null;uniqueIndex:idx_goal_version_team_version"`
+	EffectiveFromWeek    int       `json:"effective_from_week" gorm:"not null"`
+	TargetDistanceKM     *float64  `json:"target_distance_km"`
+	TargetVisitsPerWeek  *int      `json:"target_visits_per_week"`
+	TargetMinDurationMin *int      `json:"target_min_duration_min"`
+	ChangedBy            *string   `json:"changed_by"`                     // 変更したユーザー。提案・自動作成の場合は nil
+	Source               string    `json:"source" gorm:"default:'manual'"` // manual / proposal / rematch / matchmaking / initial
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// HPEvent 週次評価以外で発生したチームHPの変動履歴
+type HPEvent struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	TeamID     string    `json:"team_id" gorm:"not null;index"`
+	UserID     string    `json:"user_id" gorm:"not null"` // 変動の原因となったユーザー
+	Reason     string    `json:"reason" gorm:"not null"`  // member_left / appeal_overturned
+	HPChange   int       `json:"hp_change" gorm:"not null"`
+	HPAfter    int       `json:"hp_after" gorm:"not null"`
+	WeekNumber int       `json:"week_number" gorm:"default:0"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -3,11 +3,14 @@ package models
 import "time"
 
 type InviteCode struct {
-	Code      string    `json:"code" gorm:"primaryKey;size:6"`
-	TeamID    string    `json:"team_id" gorm:"not null"`
-	CreatedBy string    `json:"created_by" gorm:"not null"`
-	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Code      string     `json:"code" gorm:"primaryKey;size:6"`
+	TeamID    string     `json:"team_id" gorm:"not null;index"`
+	CreatedBy string     `json:"created_by" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	MaxUses   *int       `json:"max_uses"` // nil は無制限
+	UseCount  int        `json:"use_count" gorm:"not null;default:0"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
 
 	Team Team `json:"team,omitempty" gorm:"foreignKey:TeamID"`
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// InviteCodeUse 招待コードの使用履歴（誰がどのコードで参加したか）
+// コード削除後も履歴を残すため InviteCode への外部キーは張らない
+type InviteCodeUse struct {
+	ID     string    `json:"id" gorm:"primaryKey"`
+	Code   string    `json:"code" gorm:"not null;size:6;index"`
+	TeamID string    `json:"team_id" gorm:"not null;index"`
+	UserID string    `json:"user_id" gorm:"not null"`
+	UsedAt time.Time `json:"used_at" gorm:"autoCreateTime"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// JoinAttemptThrottle 招待コード参加の失敗回数とロックアウト状態（ユーザー単位・IP単位）
+type JoinAttemptThrottle struct {
+	Key           string     `json:"key" gorm:"primaryKey"` // user:<uid> / ip:<address>
+	FailureCount  int        `json:"failure_count" gorm:"not null;default:0"`
+	LockoutCount  int        `json:"lockout_count" gorm:"not null;default:0"` // バックオフ計算用の連続ロックアウト回数
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
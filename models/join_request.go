@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// JoinRequest 公開チームへの参加申請（リーダーが承認・却下する）
+type JoinRequest struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	TeamID    string     `json:"team_id" gorm:"not null;index"`
+	UserID    string     `json:"user_id" gorm:"not null;index"`
+	Message   string     `json:"message" gorm:"default:''"`
+	Status    string     `json:"status" gorm:"not null;default:'pending'"` // pending / approved / rejected / cancelled
+	DecidedBy *string    `json:"decided_by"`
+	DecidedAt *time.Time `json:"decided_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Team Team `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// MatchmakingTicket チーム未所属ユーザーのマッチング待ち
+type MatchmakingTicket struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"user_id" gorm:"not null;index"`
+	ExerciseType string    `json:"exercise_type" gorm:"not null"`                  // running / gym
+	Strictness   string    `json:"strictness" gorm:"not null"`                     // normal / strict / relaxed
+	Chronotype   string    `json:"chronotype" gorm:"not null"`                     // 申し込み時点のユーザーの朝型夜型
+	TargetLevel  float64   `json:"target_level" gorm:"not null"`                   // running: 週の目標km / gym: 週の目標回数
+	Status       string    `json:"status" gorm:"not null;default:'waiting';index"` // waiting / matched / cancelled
+	TeamID       *string   `json:"team_id"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
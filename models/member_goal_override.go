@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MemberGoalOverride メンバー個別の目標。リーダーが提案し、本人が承認すると effective_from_week から適用される
+// nil の目標値はチーム目標を使う
+type MemberGoalOverride struct {
+	ID                   string     `json:"id" gorm:"primaryKey"`
+	TeamID               string     `json:"team_id" gorm:"not null;index:idx_member_goal_team_user"`
+	UserID               string     `json:"user_id" gorm:"not null;index:idx_member_goal_team_user"`
+	ProposedBy           string     `json:"proposed_by" gorm:"not null"`
+	Status               string     `json:"status" gorm:"not null;default:'proposed'"` // proposed / accepted / declined / cancelled / superseded / ended
+	TargetDistanceKM     *float64   `json:"target_distance_km"`
+	TargetVisitsPerWeek  *int       `json:"target_visits_per_week"`
+	TargetMinDurationMin *int       `json:"target_min_duration_min"`
+	EffectiveFromWeek    *int       `json:"effective_from_week"`  // 承認時に決まる適用開始週
+	EffectiveUntilWeek   *int       `json:"effective_until_week"` // この週から適用しない（nil は終了なし）
+	RespondedAt          *time.Time `json:"responded_at"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
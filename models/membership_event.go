@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// MembershipEvent チームメンバーの参加・離脱・除名・リーダー交代の履歴。メンバー行は離脱時に削除されるため、フィードにはこちらを使う
+type MembershipEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	TeamID    string    `json:"team_id" gorm:"not null;index:idx_membership_event_team_created"`
+	UserID    string    `json:"user_id" gorm:"not null"`
+	Type      string    `json:"type" gorm:"not null"` // joined / left / removed / leader_changed
+	ActorID   *string   `json:"actor_id"`             // 操作したユーザー（リーダー交代の前任者など）。自分の操作や投票の結果は nil
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_membership_event_team_created" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Notification アプリ内通知
+type Notification struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	UserID    string     `json:"user_id" gorm:"not null;index:idx_notification_user_created"`
+	Type      string     `json:"type" gorm:"not null"` // team_expired など
+	Title     string     `json:"title" gorm:"not null"`
+	Body      string     `json:"body" gorm:"default:''"`
+	TeamID    *string    `json:"team_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;index:idx_notification_user_created" json:"created_at"`
+}
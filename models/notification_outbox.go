@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// NotificationOutbox 送信待ちのプッシュ通知。端末ごとに1行で、失敗したら時間をおいて再送する
+type NotificationOutbox struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	NotificationID string     `json:"notification_id" gorm:"not null;index"`
+	UserID         string     `json:"user_id" gorm:"not null;index"`
+	DeviceTokenID  string     `json:"device_token_id" gorm:"not null;index"`
+	Type           string     `json:"type" gorm:"not null"`
+	Title          string     `json:"title" gorm:"not null"`
+	Body           string     `json:"body" gorm:"default:''"`
+	TeamID         *string    `json:"team_id"`
+	Status         string     `json:"status" gorm:"not null;default:'pending';index:idx_outbox_status_next"` // pending / sent / dead / cancelled
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_outbox_status_next"`
+	LastError      string     `json:"last_error" gorm:"default:''"`
+	SentAt         *time.Time `json:"sent_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// NotificationPreference ユーザーごとの通知設定。行がないユーザーは既定値（日本語・プッシュ通知あり）
+type NotificationPreference struct {
+	UserID          string    `json:"user_id" gorm:"primaryKey"`
+	Locale          string    `json:"locale" gorm:"not null;default:'ja'"` // ja / en
+	PushEnabled     bool      `json:"push_enabled" gorm:"not null;default:true"`
+	MutedCategories string    `json:"muted_categories" gorm:"default:''"` // プッシュ通知しないカテゴリのカンマ区切り
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
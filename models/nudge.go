@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Nudge 送ったナッジ（運動の声かけ）の記録。ルールごと・1日あたりの送信数の制限に使う
+type Nudge struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"not null;index:idx_nudge_user_day"`
+	TeamID      string    `json:"team_id" gorm:"not null;index"`
+	Rule        string    `json:"rule" gorm:"not null"`                             // behind_pace / weak_weekday / teammate_finished / teammate_behind
+	DayKey      string    `json:"day_key" gorm:"not null;index:idx_nudge_user_day"` // 受け取ったユーザーのタイムゾーンでの日付（YYYY-MM-DD）
+	WeekNumber  int       `json:"week_number" gorm:"not null"`
+	TriggeredBy *string   `json:"triggered_by"` // きっかけになったチームメイト
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
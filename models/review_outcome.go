@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ReviewOutcome 週次評価の時点で確定したアクティビティのレビュー結果。確定後はレビューを変更できない
+type ReviewOutcome struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	ActivityID       string    `json:"activity_id" gorm:"not null;uniqueIndex"`
+	TeamID           string    `json:"team_id" gorm:"not null;index:idx_review_outcome_team_week"`
+	UserID           string    `json:"user_id" gorm:"not null"` // アクティビティの持ち主
+	WeekNumber       int       `json:"week_number" gorm:"not null;index:idx_review_outcome_team_week"`
+	Outcome          string    `json:"outcome" gorm:"not null"`    // approved / rejected
+	Resolution       string    `json:"resolution" gorm:"not null"` // quorum: 票数で決まった / no_quorum: 否認が必要数に届かず承認扱い / appeal: 異議申し立てで覆った
+	Policy           string    `json:"policy" gorm:"not null"`     // 確定時の review_quorum
+	EligibleCount    int       `json:"eligible_count"`             // 本人を除くメンバー数
+	Quorum           int       `json:"quorum"`
+	Approvals        int       `json:"approvals"`
+	Rejections       int       `json:"rejections"`
+	ReasonCategories string    `json:"reason_categories" gorm:"default:''"` // 否認理由（カンマ区切り）
+	DecidedAt        time.Time `json:"decided_at"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
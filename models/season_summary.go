@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// SeasonSummary チャレンジ終了（completed / disbanded）時点のチーム成績
+type SeasonSummary struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	TeamID         string     `json:"team_id" gorm:"not null;uniqueIndex"`
+	TeamName       string     `json:"team_name" gorm:"not null"`
+	ExerciseType   string     `json:"exercise_type" gorm:"not null"`
+	Strictness     string     `json:"strictness"`
+	Result         string     `json:"result" gorm:"not null"` // completed / disbanded
+	FinalHP        int        `json:"final_hp"`
+	MaxHP          int        `json:"max_hp"`
+	WeeksPlayed    int        `json:"weeks_played"` // 評価済みの週数
+	ChallengeWeeks int        `json:"challenge_weeks"`
+	PreviousTeamID *string    `json:"previous_team_id"`
+	StartedAt      *time.Time `json:"started_at"`
+	EndedAt        time.Time  `json:"ended_at" gorm:"not null"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	Members []SeasonMemberStat `json:"members,omitempty" gorm:"foreignKey:SummaryID"`
+}
+
+// SeasonMemberStat シーズン中のメンバー別成績
+type SeasonMemberStat struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	SummaryID        string    `json:"summary_id" gorm:"not null;index"`
+	TeamID           string    `json:"team_id" gorm:"not null;index"`
+	UserID           string    `json:"user_id" gorm:"not null;index"`
+	Role             string    `json:"role"`           // 終了時点のロール。途中離脱者は空
+	InTeamAtEnd      bool      `json:"in_team_at_end"` // シーズン終了時点でチームに在籍していたか
+	WeeksEvaluated   int       `json:"weeks_evaluated"`
+	WeeksMet         int       `json:"weeks_met"`
+	WeeksFrozen      int       `json:"weeks_frozen"` // フリーズトークンで評価を免除した週数
+	TotalDistanceKM  float64   `json:"total_distance_km"`
+	TotalVisits      int       `json:"total_visits"`
+	TotalDurationMin int       `json:"total_duration_min"`
+	TotalHPChange    int       `json:"total_hp_change"`
+	LongestStreak    int       `json:"longest_streak"` // 連続達成週数の最大
+	FinalStreak      int       `json:"final_streak"`   // 最終週まで続いた連続達成週数
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
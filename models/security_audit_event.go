@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SecurityAuditEvent 不審なアクセスの監査ログ
+type SecurityAuditEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	EventType string    `json:"event_type" gorm:"not null;index"` // invalid_invite_code / join_locked_out / join_attempt_while_locked
+	UserID    string    `json:"user_id" gorm:"index"`
+	IPAddress string    `json:"ip_address" gorm:"index"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
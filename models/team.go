@@ -3,17 +3,35 @@ package models
 import "time"
 
 type Team struct {
-	ID           string     `json:"id" gorm:"primaryKey"`
-	Name         string     `json:"name" gorm:"not null"`
-	ExerciseType string     `json:"exercise_type" gorm:"not null"`          // running / gym
-	Strictness   string     `json:"strictness" gorm:"default:'normal'"`     // normal / strict / relaxed
-	Status       string     `json:"status" gorm:"default:'forming'"`        // forming / active / completed / disbanded
-	MaxHP        int        `json:"max_hp" gorm:"default:100"`
-	CurrentHP    int        `json:"current_hp" gorm:"default:100"`
-	CurrentWeek  int        `json:"current_week" gorm:"default:0"`
-	StartedAt    *time.Time `json:"started_at"`
-	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	ID             string     `json:"id" gorm:"primaryKey"`
+	Name           string     `json:"name" gorm:"not null"`
+	ExerciseType   string     `json:"exercise_type" gorm:"not null"`      // running / gym
+	Strictness     string     `json:"strictness" gorm:"default:'normal'"` // normal / strict / relaxed
+	Status         string     `json:"status" gorm:"default:'forming'"`    // forming / active / completed / disbanded / expired
+	MaxHP          int        `json:"max_hp" gorm:"default:100"`
+	CurrentHP      int        `json:"current_hp" gorm:"default:100"`
+	CurrentWeek    int        `json:"current_week" gorm:"default:0"`
+	MinMembers     int        `json:"min_members" gorm:"default:3"`     // チャレンジ開始に必要な最少人数
+	MaxMembers     int        `json:"max_members" gorm:"default:3"`     // 参加できる最大人数
+	ChallengeWeeks int        `json:"challenge_weeks" gorm:"default:0"` // チャレンジ期間（週）。新規作成時は1〜52（省略時4）。0は期間導入前に作成された無期限チームのみ
+	StartedAt      *time.Time `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`                          // completed / disbanded になった日時
+	PreviousTeamID *string    `json:"previous_team_id" gorm:"index"`     // 再戦元のチーム
+	Discoverable   bool       `json:"discoverable" gorm:"default:false"` // チーム一覧に公開し参加申請を受け付けるか
+	AvatarURL      string     `json:"avatar_url" gorm:"default:''"`
+	// DisbandThreshold 解散投票の可決条件。unanimous: 常に全員一致 / majority_when_inactive: 活動が途絶えたチームは過半数
+	DisbandThreshold string `json:"disband_threshold" gorm:"default:'majority_when_inactive'"`
+	// StartPolicy 週の区切り方。midnight: 開始翌日の0時から7日ごと / monday: 開始翌日の0時に始め、月曜0時で区切る
+	StartPolicy string `json:"start_policy" gorm:"default:'midnight'"`
+	Timezone    string `json:"timezone" gorm:"default:'Asia/Tokyo'"` // IANAタイムゾーン。週の区切りと日付の集計に使う
+	// FreezeTokensPerMember シーズン開始時にメンバーへ配るフリーズトークンの数
+	FreezeTokensPerMember int `json:"freeze_tokens_per_member" gorm:"default:1"`
+	// ReviewQuorum アクティビティの承認・否認が決まる票数。majority: 本人以外の過半数 / any: 1票 / all: 本人以外の全員
+	ReviewQuorum string `json:"review_quorum" gorm:"default:'majority'"`
+	// ReviewWindowHours 週の終わりから何時間レビューを受け付けるか。週次評価はこれが過ぎてから行う
+	ReviewWindowHours int       `json:"review_window_hours" gorm:"default:12"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
 	Members []TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// TeamProposal チームへの提案（解散・除名・設定変更）。メンバーの投票で可決される
+type TeamProposal struct {
+	ID           string  `json:"id" gorm:"primaryKey"`
+	TeamID       string  `json:"team_id" gorm:"not null;index"`
+	Type         string  `json:"type" gorm:"not null"` // disband / remove_member / change_settings
+	ProposedBy   string  `json:"proposed_by" gorm:"not null"`
+	TargetUserID *string `json:"target_user_id"`                              // remove_member の対象
+	Status       string  `json:"status" gorm:"not null;default:'open';index"` // open / passed / applied / rejected / expired / cancelled
+
+	// change_settings の変更内容（nil は変更なし）
+	NewStrictness           *string  `json:"new_strictness"`
+	NewChallengeWeeks       *int     `json:"new_challenge_weeks"`
+	NewTargetDistanceKM     *float64 `json:"new_target_distance_km"`
+	NewTargetVisitsPerWeek  *int     `json:"new_target_visits_per_week"`
+	NewTargetMinDurationMin *int     `json:"new_target_min_duration_min"`
+	NewDisbandThreshold     *string  `json:"new_disband_threshold"`
+	NewReviewQuorum         *string  `json:"new_review_quorum"`
+	NewReviewWindowHours    *int     `json:"new_review_window_hours"`
+
+	ApplyFromWeek *int       `json:"apply_from_week"` // 可決された設定変更を反映する週
+	ExpiresAt     *time.Time `json:"expires_at"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Votes []TeamProposalVote `json:"votes,omitempty" gorm:"foreignKey:ProposalID"`
+}
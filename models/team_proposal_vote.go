@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type TeamProposalVote struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	ProposalID string    `json:"proposal_id" gorm:"not null;uniqueIndex:idx_proposal_vote_user"`
+	TeamID     string    `json:"team_id" gorm:"not null;index"`
+	UserID     string    `json:"user_id" gorm:"not null;uniqueIndex:idx_proposal_vote_user"`
+	Approve    bool      `json:"approve" gorm:"not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TeamWebhook チームのイベントを外部（Discord・Slackのボットなど）に送るWebhookの登録
+type TeamWebhook struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	TeamID         string     `json:"team_id" gorm:"not null;index"`
+	URL            string     `json:"url" gorm:"not null"`
+	Secret         string     `json:"-" gorm:"not null"`          // 署名用の共有シークレット。作成時のみ返す
+	Events         string     `json:"events" gorm:"not null"`     // 購読するイベントのカンマ区切り
+	Active         bool       `json:"active" gorm:"default:true"` // 送信上限まで失敗が続くと false になる
+	CreatedBy      string     `json:"created_by" gorm:"not null"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at"`
+	FailureCount   int        `json:"failure_count" gorm:"default:0"` // 連続で送信を諦めた回数
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
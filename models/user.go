@@ -10,6 +10,7 @@ type User struct {
 	Weight     int       `json:"weight" gorm:"default:60"`         // kg
 	Chronotype string    `json:"chronotype" gorm:"default:'both'"` // morning / night / both
 	AvatarURL  string    `json:"avatar_url" gorm:"default:''"`
+	Timezone   string    `json:"timezone" gorm:"default:'Asia/Tokyo'"` // IANAタイムゾーン
 	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// WebhookDelivery Webhookの送信キュー兼送信ログ。失敗したら時間をおいて再送する
+type WebhookDelivery struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	WebhookID      string     `json:"webhook_id" gorm:"not null;index"`
+	TeamID         string     `json:"team_id" gorm:"not null"`
+	EventID        string     `json:"event_id" gorm:"not null"` // 同じイベントの配信は複数のWebhookで共通
+	Event          string     `json:"event" gorm:"not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"not null;default:'pending';index:idx_webhook_delivery_status_next"` // pending / delivered / dead / cancelled
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_status_next"`
+	ResponseStatus int        `json:"response_status" gorm:"default:0"`
+	ResponseBody   string     `json:"response_body" gorm:"default:''"` // 先頭のみ保存
+	LastError      string     `json:"last_error" gorm:"default:''"`
+	DurationMS     int        `json:"duration_ms" gorm:"default:0"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
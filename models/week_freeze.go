@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// WeekFreeze メンバーがフリーズトークンを使って週次評価を免除した週
+type WeekFreeze struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	TeamID      string     `json:"team_id" gorm:"not null;uniqueIndex:idx_freeze_team_user_week"`
+	UserID      string     `json:"user_id" gorm:"not null;uniqueIndex:idx_freeze_team_user_week"`
+	WeekNumber  int        `json:"week_number" gorm:"not null;uniqueIndex:idx_freeze_team_user_week"`
+	Reason      string     `json:"reason" gorm:"default:''"`
+	Status      string     `json:"status" gorm:"not null;default:'scheduled'"` // scheduled / used / cancelled
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
@@ -12,6 +12,12 @@ type WeeklyEvaluation struct {
 	TotalVisits      int       `json:"total_visits" gorm:"default:0"`
 	TotalDurationMin int       `json:"total_duration_min" gorm:"default:0"`
 	HPChange         int       `json:"hp_change" gorm:"default:0"`
+	TargetMultiplier float64   `json:"target_multiplier" gorm:"default:1"` // 評価に使った目標倍率（按分込み）。異議申し立てで評価し直すときに使う
+	GoalVersionID    *string   `json:"goal_version_id"`                    // 評価に使った目標の版
+	GoalVersion      int       `json:"goal_version" gorm:"default:0"`
+	MemberGoalID     *string   `json:"member_goal_id"`              // 評価に使った個別目標
+	Frozen           bool      `json:"frozen" gorm:"default:false"` // フリーズトークンで評価を免除した週
+	FreezeID         *string   `json:"freeze_id"`
 	EvaluatedAt      time.Time `json:"evaluated_at"`
 	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -2,9 +2,73 @@ package requests
 
 // CreateTeamRequest チーム作成リクエスト
 type CreateTeamRequest struct {
-	Name         string `json:"name" example:"朝ランチーム"`
-	ExerciseType string `json:"exercise_type" example:"running"`
-	Strictness   string `json:"strictness" example:"normal"`
+	Name           string `json:"name" example:"朝ランチーム"`
+	ExerciseType   string `json:"exercise_type" example:"running"`
+	Strictness     string `json:"strictness" example:"normal"`
+	MinMembers     *int   `json:"min_members" example:"3"`     // 省略時は3
+	MaxMembers     *int   `json:"max_members" example:"3"`     // 省略時はmin_membersと3の大きい方
+	ChallengeWeeks *int   `json:"challenge_weeks" example:"4"` // チャレンジ期間（週）。1〜52、省略時は4。0（無期限）は不可
+	Discoverable   bool   `json:"discoverable" example:"true"` // チーム一覧に公開し参加申請を受け付ける
+	// DisbandThreshold 解散投票の可決条件（unanimous / majority_when_inactive）。省略時は majority_when_inactive
+	DisbandThreshold string `json:"disband_threshold" example:"majority_when_inactive"`
+	FreezeTokens     *int   `json:"freeze_tokens_per_member" example:"1"` // メンバーごとのフリーズトークン（1〜3）。省略時は1
+	// StartPolicy 週の区切り方。midnight: 開始翌日の0時から7日ごと / monday: 開始翌日の0時に始め月曜0時で区切る（短い第1週は目標を按分）。省略時は midnight
+	StartPolicy string `json:"start_policy" example:"monday"`
+	Timezone    string `json:"timezone" example:"Asia/Tokyo"` // 週の区切りに使うIANAタイムゾーン。省略時はリーダーのタイムゾーン
+	// ReviewQuorum アクティビティの承認・否認に必要な票数（majority: 本人以外の過半数 / any: 1票 / all: 本人以外の全員）。省略時は majority
+	ReviewQuorum      string `json:"review_quorum" example:"majority"`
+	ReviewWindowHours *int   `json:"review_window_hours" example:"12"` // 週の終わりからレビューを受け付ける時間（1〜48）。省略時は12
+}
+
+// CreateJoinRequestRequest 参加申請リクエスト
+type CreateJoinRequestRequest struct {
+	Message string `json:"message" example:"朝6時台に走れます！"`
+}
+
+// MatchmakingRequest マッチング申し込みリクエスト
+type MatchmakingRequest struct {
+	ExerciseType        string   `json:"exercise_type" example:"running"`
+	Strictness          string   `json:"strictness" example:"normal"` // 省略時はnormal
+	TargetDistanceKM    *float64 `json:"target_distance_km" example:"15.0"`
+	TargetVisitsPerWeek *int     `json:"target_visits_per_week"`
+}
+
+// CreateProposalRequest チーム提案リクエスト
+type CreateProposalRequest struct {
+	Type                 string   `json:"type" example:"change_settings"` // disband / remove_member / change_settings
+	TargetUserID         *string  `json:"target_user_id"`                 // remove_member の対象
+	Strictness           *string  `json:"strictness" example:"strict"`
+	ChallengeWeeks       *int     `json:"challenge_weeks" example:"6"`
+	TargetDistanceKM     *float64 `json:"target_distance_km" example:"20.0"`
+	TargetVisitsPerWeek  *int     `json:"target_visits_per_week"`
+	TargetMinDurationMin *int     `json:"target_min_duration_min"`
+	DisbandThreshold     *string  `json:"disband_threshold" example:"unanimous"`
+	ReviewQuorum         *string  `json:"review_quorum" example:"all"`
+	ReviewWindowHours    *int     `json:"review_window_hours" example:"24"`
+}
+
+// ProposalVoteRequest 提案への投票リクエスト
+type ProposalVoteRequest struct {
+	Approve bool `json:"approve" example:"true"`
+}
+
+// TransferLeadershipRequest リーダー交代リクエスト
+type TransferLeadershipRequest struct {
+	UserID string `json:"user_id" example:"firebaseUID456"`
+}
+
+// RematchRequest 再戦リクエスト
+type RematchRequest struct {
+	Name           string `json:"name" example:"朝ランチーム2"`         // 省略時は前回のチーム名
+	GoalMode       string `json:"goal_mode" example:"carry_over"` // carry_over（前回と同じ） / auto（成績から自動調整） / adjust（指定値）
+	ChallengeWeeks *int   `json:"challenge_weeks" example:"4"`    // 省略時は前回と同じ
+	CreateGoalRequest
+}
+
+// CreateInviteCodeRequest 招待コード生成リクエスト（省略時は24時間・回数無制限）
+type CreateInviteCodeRequest struct {
+	ExpiresInHours *int `json:"expires_in_hours" example:"24"` // 1〜168
+	MaxUses        *int `json:"max_uses" example:"2"`          // 1以上、省略で無制限
 }
 
 // JoinTeamRequest 招待コードでチーム参加リクエスト
@@ -19,6 +83,18 @@ type CreateGoalRequest struct {
 	TargetMinDurationMin *int     `json:"target_min_duration_min" example:"60"`
 }
 
+// CreateWeekFreezeRequest フリーズ予約リクエスト
+type CreateWeekFreezeRequest struct {
+	WeekNumber *int   `json:"week_number" example:"3"` // 省略時はまだ始まっていない最初の週
+	Reason     string `json:"reason" example:"出張のため"`
+}
+
+// CreateMemberGoalRequest メンバー個別目標の提案リクエスト。省略した目標値はチーム目標を使う
+type CreateMemberGoalRequest struct {
+	UserID string `json:"user_id" example:"firebaseUID123"`
+	CreateGoalRequest
+}
+
 // StartRunningRequest ランニング開始リクエスト
 type StartRunningRequest struct {
 	Latitude  float64 `json:"latitude" example:"35.6812362"`
@@ -69,6 +145,67 @@ type GymCheckoutRequest struct {
 
 // PostActivityReviewRequest アクティビティレビューリクエスト
 type PostActivityReviewRequest struct {
-	Status  string `json:"status" example:"approved"`  // "approved" | "rejected"
+	Status  string `json:"status" example:"approved"` // "approved" | "rejected"
 	Comment string `json:"comment" example:"いいペースですね！"`
+	// ReasonCategory 否認の理由（gps_anomaly / not_exercise / duplicate / wrong_location / too_short / other）。rejected のとき必須で、other はコメントも必須
+	ReasonCategory string `json:"reason_category" example:"gps_anomaly"`
+}
+
+// RegisterDeviceRequest プッシュ通知の端末登録リクエスト
+type RegisterDeviceRequest struct {
+	Token    string `json:"token" example:"fcm-registration-token"`
+	Platform string `json:"platform" example:"ios"` // "ios" | "android" | "web"
+}
+
+// UpdateNotificationPreferenceRequest 通知設定の更新リクエスト。省略した項目は変更しない
+type UpdateNotificationPreferenceRequest struct {
+	Locale          *string   `json:"locale" example:"ja"` // "ja" | "en"
+	PushEnabled     *bool     `json:"push_enabled" example:"true"`
+	MutedCategories *[]string `json:"muted_categories"` // review / team / evaluation / vote / goal / nudge / teammate_nudge / social
+}
+
+// CreateWebhookRequest Webhook登録リクエスト
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://discord.com/api/webhooks/xxx/yyy"`
+	Events []string `json:"events"` // activity.completed / review.posted / evaluation.completed / hp.changed / team.disbanded / member.joined
+}
+
+// UpdateWebhookRequest Webhook更新リクエスト。省略した項目は変更しない
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url" example:"https://discord.com/api/webhooks/xxx/yyy"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active" example:"true"` // true にすると連続失敗回数もリセットする
+}
+
+// AddReactionRequest アクティビティへのリアクションリクエスト
+type AddReactionRequest struct {
+	Emoji string `json:"emoji" example:"🔥"` // 👍 / 🔥 / 💪 / 👏 / 🎉 / 😮
+}
+
+// PostActivityCommentRequest アクティビティへのコメントリクエスト
+type PostActivityCommentRequest struct {
+	Body     string  `json:"body" example:"ナイスラン！"`
+	ParentID *string `json:"parent_id"` // 返信先のコメントID。省略するとスレッドを始める
+}
+
+// MarkFeedReadRequest フィードの既読リクエスト
+type MarkFeedReadRequest struct {
+	ReadAt *string `json:"read_at" example:"2026-02-10T09:00:00Z"` // ここまでを既読にする（RFC3339）。省略すると現在時刻
+}
+
+// PostChatMessageRequest チームチャットの投稿リクエスト
+type PostChatMessageRequest struct {
+	Body string `json:"body" example:"今日は19時から走ります！"`
+}
+
+// FileAppealRequest 否認への異議申し立てリクエスト。explanation と evidence_url のどちらかは必須
+type FileAppealRequest struct {
+	Explanation string `json:"explanation" example:"トンネル区間でGPSが飛びましたが、実際に走っています"`                // 1000文字以内
+	EvidenceURL string `json:"evidence_url" example:"https://example.com/screenshots/watch.png"` // 別アプリの記録などのURL
+}
+
+// AppealVoteRequest 異議申し立てへの投票リクエスト
+type AppealVoteRequest struct {
+	Approve bool   `json:"approve" example:"true"` // true: アクティビティを認める / false: 否認のまま
+	Comment string `json:"comment" example:"ペースも普段通りなので認めます"`
 }
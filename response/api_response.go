@@ -28,6 +28,12 @@ func NewTeamResponse(team models.Team, members []models.TeamMember, goal *models
 		startedAt = &s
 	}
 
+	var endedAt *string
+	if team.EndedAt != nil {
+		s := team.EndedAt.Format(time.RFC3339)
+		endedAt = &s
+	}
+
 	var goalResponse *GoalResponse
 	if goal != nil {
 		gr := GoalResponse{
@@ -37,6 +43,8 @@ func NewTeamResponse(team models.Team, members []models.TeamMember, goal *models
 			TargetDistanceKM:     goal.TargetDistanceKM,
 			TargetVisitsPerWeek:  goal.TargetVisitsPerWeek,
 			TargetMinDurationMin: goal.TargetMinDurationMin,
+			Version:              goal.Version,
+			EffectiveFromWeek:    goal.EffectiveFromWeek,
 			CreatedAt:            goal.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:            goal.UpdatedAt.Format(time.RFC3339),
 		}
@@ -44,19 +52,221 @@ func NewTeamResponse(team models.Team, members []models.TeamMember, goal *models
 	}
 
 	return TeamResponse{
-		ID:           team.ID,
-		Name:         team.Name,
-		ExerciseType: team.ExerciseType,
-		Strictness:   team.Strictness,
-		Status:       team.Status,
-		MaxHP:        team.MaxHP,
-		CurrentHP:    team.CurrentHP,
-		CurrentWeek:  team.CurrentWeek,
-		StartedAt:    startedAt,
-		Members:      memberResponses,
-		Goal:         goalResponse,
-		CreatedAt:    team.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    team.UpdatedAt.Format(time.RFC3339),
+		ID:               team.ID,
+		Name:             team.Name,
+		ExerciseType:     team.ExerciseType,
+		Strictness:       team.Strictness,
+		Status:           team.Status,
+		MaxHP:            team.MaxHP,
+		CurrentHP:        team.CurrentHP,
+		CurrentWeek:      team.CurrentWeek,
+		MinMembers:       team.MinMembers,
+		MaxMembers:       team.MaxMembers,
+		ChallengeWeeks:   team.ChallengeWeeks,
+		PreviousTeamID:   team.PreviousTeamID,
+		Discoverable:     team.Discoverable,
+		AvatarURL:        team.AvatarURL,
+		DisbandThreshold: team.DisbandThreshold,
+		FreezeTokens:     team.FreezeTokensPerMember,
+		StartPolicy:      team.StartPolicy,
+		Timezone:         team.Timezone,
+		ReviewQuorum:     team.ReviewQuorum,
+		ReviewWindow:     team.ReviewWindowHours,
+		StartedAt:        startedAt,
+		EndedAt:          endedAt,
+		Members:          memberResponses,
+		Goal:             goalResponse,
+		CreatedAt:        team.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        team.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// NewSeasonMemberStatResponse SeasonMemberStatモデルからレスポンスを構築する
+func NewSeasonMemberStatResponse(st models.SeasonMemberStat) SeasonMemberStatResponse {
+	var rate float64
+	if st.WeeksEvaluated > 0 {
+		rate = float64(st.WeeksMet) / float64(st.WeeksEvaluated)
+	}
+	return SeasonMemberStatResponse{
+		UserID:           st.UserID,
+		UserName:         st.User.Name,
+		Role:             st.Role,
+		InTeamAtEnd:      st.InTeamAtEnd,
+		WeeksEvaluated:   st.WeeksEvaluated,
+		WeeksMet:         st.WeeksMet,
+		WeeksFrozen:      st.WeeksFrozen,
+		AchievementRate:  rate,
+		TotalDistanceKM:  st.TotalDistanceKM,
+		TotalVisits:      st.TotalVisits,
+		TotalDurationMin: st.TotalDurationMin,
+		TotalHPChange:    st.TotalHPChange,
+		LongestStreak:    st.LongestStreak,
+		FinalStreak:      st.FinalStreak,
+	}
+}
+
+// NewSeasonSummaryResponse SeasonSummaryモデル（Members.User をPreload済み）からレスポンスを構築する
+func NewSeasonSummaryResponse(summary models.SeasonSummary) SeasonSummaryResponse {
+	members := make([]SeasonMemberStatResponse, len(summary.Members))
+	for i, st := range summary.Members {
+		members[i] = NewSeasonMemberStatResponse(st)
+	}
+
+	var startedAt *string
+	if summary.StartedAt != nil {
+		s := summary.StartedAt.Format(time.RFC3339)
+		startedAt = &s
+	}
+
+	return SeasonSummaryResponse{
+		TeamID:         summary.TeamID,
+		TeamName:       summary.TeamName,
+		ExerciseType:   summary.ExerciseType,
+		Strictness:     summary.Strictness,
+		Result:         summary.Result,
+		FinalHP:        summary.FinalHP,
+		MaxHP:          summary.MaxHP,
+		WeeksPlayed:    summary.WeeksPlayed,
+		ChallengeWeeks: summary.ChallengeWeeks,
+		PreviousTeamID: summary.PreviousTeamID,
+		StartedAt:      startedAt,
+		EndedAt:        summary.EndedAt.Format(time.RFC3339),
+		Members:        members,
+	}
+}
+
+// NewInviteCodeDetailResponse InviteCodeモデルと使用履歴（User をPreload済み）からレスポンスを構築する
+func NewInviteCodeDetailResponse(code models.InviteCode, uses []models.InviteCodeUse, now time.Time) InviteCodeDetailResponse {
+	status := "active"
+	switch {
+	case code.RevokedAt != nil:
+		status = "revoked"
+	case now.After(code.ExpiresAt):
+		status = "expired"
+	case code.MaxUses != nil && code.UseCount >= *code.MaxUses:
+		status = "exhausted"
+	}
+
+	var revokedAt *string
+	if code.RevokedAt != nil {
+		s := code.RevokedAt.Format(time.RFC3339)
+		revokedAt = &s
+	}
+
+	useResponses := make([]InviteCodeUseResponse, len(uses))
+	for i, u := range uses {
+		useResponses[i] = InviteCodeUseResponse{
+			UserID:   u.UserID,
+			UserName: u.User.Name,
+			UsedAt:   u.UsedAt.Format(time.RFC3339),
+		}
+	}
+
+	return InviteCodeDetailResponse{
+		Code:      code.Code,
+		CreatedBy: code.CreatedBy,
+		Status:    status,
+		ExpiresAt: code.ExpiresAt.Format(time.RFC3339),
+		MaxUses:   code.MaxUses,
+		UseCount:  code.UseCount,
+		RevokedAt: revokedAt,
+		CreatedAt: code.CreatedAt.Format(time.RFC3339),
+		Uses:      useResponses,
+	}
+}
+
+// NewJoinRequestResponse JoinRequestモデル（User をPreload済み）からレスポンスを構築する
+func NewJoinRequestResponse(jr models.JoinRequest) JoinRequestResponse {
+	var decidedAt *string
+	if jr.DecidedAt != nil {
+		s := jr.DecidedAt.Format(time.RFC3339)
+		decidedAt = &s
+	}
+	return JoinRequestResponse{
+		ID:         jr.ID,
+		TeamID:     jr.TeamID,
+		UserID:     jr.UserID,
+		UserName:   jr.User.Name,
+		Chronotype: jr.User.Chronotype,
+		Message:    jr.Message,
+		Status:     jr.Status,
+		DecidedAt:  decidedAt,
+		CreatedAt:  jr.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// NewMatchmakingTicketResponse MatchmakingTicketモデルからレスポンスを構築する
+func NewMatchmakingTicketResponse(t models.MatchmakingTicket) MatchmakingTicketResponse {
+	return MatchmakingTicketResponse{
+		ID:           t.ID,
+		ExerciseType: t.ExerciseType,
+		Strictness:   t.Strictness,
+		Chronotype:   t.Chronotype,
+		TargetLevel:  t.TargetLevel,
+		Status:       t.Status,
+		TeamID:       t.TeamID,
+		CreatedAt:    t.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// NewMemberGoalResponse MemberGoalOverrideモデルからレスポンスを構築する
+func NewMemberGoalResponse(o models.MemberGoalOverride) MemberGoalResponse {
+	var respondedAt *string
+	if o.RespondedAt != nil {
+		s := o.RespondedAt.Format(time.RFC3339)
+		respondedAt = &s
+	}
+	return MemberGoalResponse{
+		ID:                   o.ID,
+		TeamID:               o.TeamID,
+		UserID:               o.UserID,
+		ProposedBy:           o.ProposedBy,
+		Status:               o.Status,
+		TargetDistanceKM:     o.TargetDistanceKM,
+		TargetVisitsPerWeek:  o.TargetVisitsPerWeek,
+		TargetMinDurationMin: o.TargetMinDurationMin,
+		EffectiveFromWeek:    o.EffectiveFromWeek,
+		EffectiveUntilWeek:   o.EffectiveUntilWeek,
+		RespondedAt:          respondedAt,
+		CreatedAt:            o.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// NewWeekFreezeResponse WeekFreezeモデルからレスポンスを構築する（User をプリロードしておくこと）
+func NewWeekFreezeResponse(f models.WeekFreeze, weekStartsAt *time.Time) WeekFreezeResponse {
+	var startsAt *string
+	if weekStartsAt != nil {
+		s := weekStartsAt.Format(time.RFC3339)
+		startsAt = &s
+	}
+	return WeekFreezeResponse{
+		ID:           f.ID,
+		TeamID:       f.TeamID,
+		UserID:       f.UserID,
+		UserName:     f.User.Name,
+		WeekNumber:   f.WeekNumber,
+		WeekStartsAt: startsAt,
+		Reason:       f.Reason,
+		Status:       f.Status,
+		CreatedAt:    f.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// NewNotificationResponse Notificationモデルからレスポンスを構築する
+func NewNotificationResponse(n models.Notification) NotificationResponse {
+	var readAt *string
+	if n.ReadAt != nil {
+		s := n.ReadAt.Format(time.RFC3339)
+		readAt = &s
+	}
+	return NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		Title:     n.Title,
+		Body:      n.Body,
+		TeamID:    n.TeamID,
+		ReadAt:    readAt,
+		CreatedAt: n.CreatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -76,25 +286,322 @@ type GoalResponse struct {
 	TargetDistanceKM     *float64 `json:"target_distance_km" example:"15.0"`
 	TargetVisitsPerWeek  *int     `json:"target_visits_per_week"`
 	TargetMinDurationMin *int     `json:"target_min_duration_min"`
+	Version              int      `json:"version" example:"2"`
+	EffectiveFromWeek    int      `json:"effective_from_week" example:"1"` // この目標が適用される最初の週
 	CreatedAt            string   `json:"created_at" example:"2026-02-10T09:00:00Z"`
 	UpdatedAt            string   `json:"updated_at" example:"2026-02-10T09:00:00Z"`
+	// Pending 翌週から適用される変更。今週の評価には使われない
+	Pending *GoalResponse `json:"pending,omitempty"`
+}
+
+// WeekFreezeResponse フリーズした週
+type WeekFreezeResponse struct {
+	ID           string  `json:"id" example:"01JARQ3KEXAMPLE00070"`
+	TeamID       string  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	UserID       string  `json:"user_id" example:"firebaseUID123"`
+	UserName     string  `json:"user_name" example:"山田太郎"`
+	WeekNumber   int     `json:"week_number" example:"3"`
+	WeekStartsAt *string `json:"week_starts_at" example:"2026-02-24T09:00:00Z"` // 開始前のチームは null
+	Reason       string  `json:"reason" example:"出張のため"`
+	Status       string  `json:"status" example:"scheduled"` // scheduled（予約中） / used（評価で使用済み） / cancelled
+	CreatedAt    string  `json:"created_at" example:"2026-02-20T09:00:00Z"`
+}
+
+// FreezeBalanceResponse フリーズトークンの残高
+type FreezeBalanceResponse struct {
+	Allowance int `json:"allowance" example:"1"` // シーズン開始時に配られた数
+	Earned    int `json:"earned" example:"1"`    // 4週連続達成ごとに獲得した数（1シーズン2つまで）
+	Used      int `json:"used" example:"1"`      // 予約済み・使用済みの数
+	Remaining int `json:"remaining" example:"1"`
+}
+
+// WeekFreezeListResponse チームのフリーズ一覧レスポンス
+type WeekFreezeListResponse struct {
+	TeamID            string                `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	CurrentWeek       int                   `json:"current_week" example:"2"`
+	NextFreezableWeek int                   `json:"next_freezable_week" example:"3"` // まだ始まっておらずフリーズできる最初の週
+	MyTokens          FreezeBalanceResponse `json:"my_tokens"`
+	Freezes           []WeekFreezeResponse  `json:"freezes"`
+}
+
+// MemberGoalResponse メンバー個別目標レスポンス
+type MemberGoalResponse struct {
+	ID                   string   `json:"id" example:"01JARQ3KEXAMPLE00050"`
+	TeamID               string   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	UserID               string   `json:"user_id" example:"firebaseUID456"`
+	ProposedBy           string   `json:"proposed_by" example:"firebaseUID123"`
+	Status               string   `json:"status" example:"proposed"` // proposed / accepted / declined / cancelled / superseded / ended
+	TargetDistanceKM     *float64 `json:"target_distance_km" example:"8.0"`
+	TargetVisitsPerWeek  *int     `json:"target_visits_per_week"`
+	TargetMinDurationMin *int     `json:"target_min_duration_min"`
+	EffectiveFromWeek    *int     `json:"effective_from_week" example:"3"`
+	EffectiveUntilWeek   *int     `json:"effective_until_week"`
+	RespondedAt          *string  `json:"responded_at"`
+	CreatedAt            string   `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// GoalVersionResponse 目標の版レスポンス
+type GoalVersionResponse struct {
+	ID                   string   `json:"id" example:"01JARQ3KEXAMPLE00040"`
+	Version              int      `json:"version" example:"2"`
+	EffectiveFromWeek    int      `json:"effective_from_week" example:"3"`
+	TargetDistanceKM     *float64 `json:"target_distance_km" example:"12.0"`
+	TargetVisitsPerWeek  *int     `json:"target_visits_per_week"`
+	TargetMinDurationMin *int     `json:"target_min_duration_min"`
+	ChangedBy            *string  `json:"changed_by" example:"firebaseUID123"`
+	Source               string   `json:"source" example:"manual"`  // manual / proposal / rematch / matchmaking / initial
+	InEffect             bool     `json:"in_effect" example:"true"` // 現在の週に適用されている版か
+	CreatedAt            string   `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// GoalHistoryResponse 目標の変更履歴レスポンス
+type GoalHistoryResponse struct {
+	TeamID      string                `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	CurrentWeek int                   `json:"current_week" example:"2"`
+	Versions    []GoalVersionResponse `json:"versions"`
+}
+
+// GoalSuggestionsResponse 目標の提案レスポンス
+type GoalSuggestionsResponse struct {
+	TeamID          string                   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	ExerciseType    string                   `json:"exercise_type" example:"running"`
+	Basis           string                   `json:"basis" example:"history"` // history（履歴から算出） / default（履歴不足のため既定値）
+	MembersAnalyzed int                      `json:"members_analyzed" example:"3"`
+	WeeksAnalyzed   int                      `json:"weeks_analyzed" example:"20"`
+	Suggestions     []GoalSuggestionResponse `json:"suggestions"`
+}
+
+// GoalSuggestionResponse 提案する目標
+type GoalSuggestionResponse struct {
+	Level                string                      `json:"level" example:"balanced"` // conservative / balanced / ambitious
+	TargetDistanceKM     *float64                    `json:"target_distance_km" example:"12.5"`
+	TargetVisitsPerWeek  *int                        `json:"target_visits_per_week" example:"3"`
+	TargetMinDurationMin *int                        `json:"target_min_duration_min" example:"45"`
+	EstimatedSuccessRate *float64                    `json:"estimated_success_rate" example:"0.42"` // チーム全員が1週間で達成する推定確率。basis=default の場合は null
+	Members              []MemberSuccessRateResponse `json:"members"`
+}
+
+// MemberSuccessRateResponse 提案した目標に対するメンバーごとの推定達成率
+type MemberSuccessRateResponse struct {
+	UserID      string  `json:"user_id" example:"firebaseUID123"`
+	UserName    string  `json:"user_name" example:"山田太郎"`
+	Samples     int     `json:"samples" example:"8"` // 分析した週の数
+	SuccessRate float64 `json:"success_rate" example:"0.7"`
 }
 
 // TeamResponse チームレスポンス
 type TeamResponse struct {
-	ID           string               `json:"id" example:"01JARQ3KEXAMPLE00001"`
-	Name         string               `json:"name" example:"朝ランチーム"`
-	ExerciseType string               `json:"exercise_type" example:"running"`
-	Strictness   string               `json:"strictness" example:"normal"`
-	Status       string               `json:"status" example:"forming"`
-	MaxHP        int                  `json:"max_hp" example:"100"`
-	CurrentHP    int                  `json:"current_hp" example:"100"`
-	CurrentWeek  int                  `json:"current_week" example:"0"`
-	StartedAt    *string              `json:"started_at"`
-	Members      []TeamMemberResponse `json:"members"`
-	Goal         *GoalResponse        `json:"goal,omitempty"`
-	CreatedAt    string               `json:"created_at" example:"2026-02-10T09:00:00Z"`
-	UpdatedAt    string               `json:"updated_at" example:"2026-02-10T09:00:00Z"`
+	ID               string               `json:"id" example:"01JARQ3KEXAMPLE00001"`
+	Name             string               `json:"name" example:"朝ランチーム"`
+	ExerciseType     string               `json:"exercise_type" example:"running"`
+	Strictness       string               `json:"strictness" example:"normal"`
+	Status           string               `json:"status" example:"forming"`
+	MaxHP            int                  `json:"max_hp" example:"100"`
+	CurrentHP        int                  `json:"current_hp" example:"100"`
+	CurrentWeek      int                  `json:"current_week" example:"0"`
+	MinMembers       int                  `json:"min_members" example:"3"`
+	MaxMembers       int                  `json:"max_members" example:"3"`
+	ChallengeWeeks   int                  `json:"challenge_weeks" example:"4"`
+	PreviousTeamID   *string              `json:"previous_team_id"`
+	Discoverable     bool                 `json:"discoverable" example:"false"`
+	AvatarURL        string               `json:"avatar_url" example:"https://example.com/avatars/teams/01JARQ3KEXAMPLE00001/icon.png"`
+	DisbandThreshold string               `json:"disband_threshold" example:"majority_when_inactive"` // unanimous / majority_when_inactive
+	FreezeTokens     int                  `json:"freeze_tokens_per_member" example:"1"`               // シーズン開始時に配られるフリーズトークンの数
+	StartPolicy      string               `json:"start_policy" example:"midnight"`                    // midnight / monday
+	Timezone         string               `json:"timezone" example:"Asia/Tokyo"`                      // 週の区切りに使うIANAタイムゾーン
+	ReviewQuorum     string               `json:"review_quorum" example:"majority"`                   // majority / any / all
+	ReviewWindow     int                  `json:"review_window_hours" example:"12"`                   // 週の終わりからレビューを受け付ける時間
+	StartedAt        *string              `json:"started_at"`                                         // 第1週の開始日時
+	EndedAt          *string              `json:"ended_at"`
+	Members          []TeamMemberResponse `json:"members"`
+	Goal             *GoalResponse        `json:"goal,omitempty"`
+	CreatedAt        string               `json:"created_at" example:"2026-02-10T09:00:00Z"`
+	UpdatedAt        string               `json:"updated_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// TeamDirectoryEntry 公開チーム一覧の要素
+type TeamDirectoryEntry struct {
+	TeamID         string        `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	Name           string        `json:"name" example:"朝ランチーム"`
+	ExerciseType   string        `json:"exercise_type" example:"running"`
+	Strictness     string        `json:"strictness" example:"normal"`
+	MemberCount    int           `json:"member_count" example:"2"`
+	MinMembers     int           `json:"min_members" example:"3"`
+	MaxMembers     int           `json:"max_members" example:"3"`
+	ChallengeWeeks int           `json:"challenge_weeks" example:"4"`
+	Chronotypes    []string      `json:"chronotypes"` // メンバーの朝型夜型
+	Goal           *GoalResponse `json:"goal,omitempty"`
+	CreatedAt      string        `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// TeamDirectoryResponse 公開チーム一覧レスポンス
+type TeamDirectoryResponse struct {
+	Teams []TeamDirectoryEntry `json:"teams"`
+}
+
+// JoinRequestResponse 参加申請レスポンス
+type JoinRequestResponse struct {
+	ID         string  `json:"id" example:"01JARQ3KEXAMPLE00010"`
+	TeamID     string  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	UserID     string  `json:"user_id" example:"firebaseUID456"`
+	UserName   string  `json:"user_name" example:"佐藤花子"`
+	Chronotype string  `json:"chronotype" example:"morning"`
+	Message    string  `json:"message" example:"朝6時台に走れます！"`
+	Status     string  `json:"status" example:"pending"`
+	DecidedAt  *string `json:"decided_at"`
+	CreatedAt  string  `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// MatchmakingTicketResponse マッチング申し込みレスポンス
+type MatchmakingTicketResponse struct {
+	ID           string  `json:"id" example:"01JARQ3KEXAMPLE00011"`
+	ExerciseType string  `json:"exercise_type" example:"running"`
+	Strictness   string  `json:"strictness" example:"normal"`
+	Chronotype   string  `json:"chronotype" example:"morning"`
+	TargetLevel  float64 `json:"target_level" example:"15.0"`
+	Status       string  `json:"status" example:"waiting"`
+	TeamID       *string `json:"team_id"`
+	CreatedAt    string  `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// NotificationResponse アプリ内通知レスポンス
+type NotificationResponse struct {
+	ID        string  `json:"id" example:"01JARQ3KEXAMPLE00030"`
+	Type      string  `json:"type" example:"team_expired"`
+	Title     string  `json:"title" example:"チームの募集期限が切れました"`
+	Body      string  `json:"body" example:"「朝ランチーム」は期限までにメンバーが揃わなかったため終了しました"`
+	TeamID    *string `json:"team_id"`
+	ReadAt    *string `json:"read_at"`
+	CreatedAt string  `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// NotificationListResponse アプリ内通知一覧レスポンス
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count" example:"2"`
+}
+
+// DeviceTokenResponse 登録済み端末レスポンス
+type DeviceTokenResponse struct {
+	ID         string `json:"id" example:"01JARQ3KEXAMPLE00040"`
+	Platform   string `json:"platform" example:"ios"`
+	LastSeenAt string `json:"last_seen_at" example:"2026-02-10T09:00:00Z"`
+	CreatedAt  string `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// NewDeviceTokenResponse DeviceTokenモデルからレスポンスを構築する。トークン自体は返さない
+func NewDeviceTokenResponse(d models.DeviceToken) DeviceTokenResponse {
+	return DeviceTokenResponse{
+		ID:         d.ID,
+		Platform:   d.Platform,
+		LastSeenAt: d.LastSeenAt.Format(time.RFC3339),
+		CreatedAt:  d.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// NotificationPreferenceResponse 通知設定レスポンス
+type NotificationPreferenceResponse struct {
+	Locale          string                `json:"locale" example:"ja"`
+	PushEnabled     bool                  `json:"push_enabled" example:"true"`
+	MutedCategories []string              `json:"muted_categories"`
+	Categories      []string              `json:"categories"` // 設定できるカテゴリ
+	Devices         []DeviceTokenResponse `json:"devices"`
+}
+
+// WebhookResponse Webhookレスポンス
+type WebhookResponse struct {
+	ID             string   `json:"id" example:"01JARQ3KEXAMPLE00050"`
+	TeamID         string   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	URL            string   `json:"url" example:"https://discord.com/api/webhooks/xxx/yyy"`
+	Events         []string `json:"events"`
+	Active         bool     `json:"active" example:"true"`
+	Secret         *string  `json:"secret,omitempty"`                  // 作成時のみ返す。X-Webhook-Signature の検証に使う
+	SecretHint     string   `json:"secret_hint" example:"whsec_…9f3a"` // シークレットの末尾4文字
+	FailureCount   int      `json:"failure_count" example:"0"`
+	LastDeliveryAt *string  `json:"last_delivery_at"`
+	CreatedAt      string   `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// NewWebhookResponse TeamWebhookモデルからレスポンスを構築する。シークレットは含めない
+func NewWebhookResponse(w models.TeamWebhook, events []string) WebhookResponse {
+	var lastDeliveryAt *string
+	if w.LastDeliveryAt != nil {
+		s := w.LastDeliveryAt.Format(time.RFC3339)
+		lastDeliveryAt = &s
+	}
+	hint := "whsec_…"
+	if len(w.Secret) >= 4 {
+		hint += w.Secret[len(w.Secret)-4:]
+	}
+	return WebhookResponse{
+		ID:             w.ID,
+		TeamID:         w.TeamID,
+		URL:            w.URL,
+		Events:         events,
+		Active:         w.Active,
+		SecretHint:     hint,
+		FailureCount:   w.FailureCount,
+		LastDeliveryAt: lastDeliveryAt,
+		CreatedAt:      w.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// WebhookListResponse Webhook一覧レスポンス
+type WebhookListResponse struct {
+	Webhooks        []WebhookResponse `json:"webhooks"`
+	AvailableEvents []string          `json:"available_events"`
+}
+
+// WebhookDeliveryResponse Webhookの送信ログ
+type WebhookDeliveryResponse struct {
+	ID             string  `json:"id" example:"01JARQ3KEXAMPLE00051"`
+	WebhookID      string  `json:"webhook_id" example:"01JARQ3KEXAMPLE00050"`
+	EventID        string  `json:"event_id" example:"01JARQ3KEXAMPLE00052"`
+	Event          string  `json:"event" example:"activity.completed"`
+	Status         string  `json:"status" example:"delivered"` // pending / delivered / dead / cancelled
+	Attempts       int     `json:"attempts" example:"1"`
+	ResponseStatus int     `json:"response_status" example:"204"`
+	ResponseBody   string  `json:"response_body" example:""`
+	LastError      string  `json:"last_error" example:""`
+	DurationMS     int     `json:"duration_ms" example:"120"`
+	Payload        string  `json:"payload"`
+	NextAttemptAt  *string `json:"next_attempt_at"` // pending のときのみ
+	DeliveredAt    *string `json:"delivered_at"`
+	CreatedAt      string  `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// NewWebhookDeliveryResponse WebhookDeliveryモデルからレスポンスを構築する
+func NewWebhookDeliveryResponse(d models.WebhookDelivery) WebhookDeliveryResponse {
+	var nextAttemptAt, deliveredAt *string
+	if d.Status == "pending" {
+		s := d.NextAttemptAt.Format(time.RFC3339)
+		nextAttemptAt = &s
+	}
+	if d.DeliveredAt != nil {
+		s := d.DeliveredAt.Format(time.RFC3339)
+		deliveredAt = &s
+	}
+	return WebhookDeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		LastError:      d.LastError,
+		DurationMS:     d.DurationMS,
+		Payload:        d.Payload,
+		NextAttemptAt:  nextAttemptAt,
+		DeliveredAt:    deliveredAt,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// WebhookDeliveryListResponse Webhookの送信ログ一覧
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
 }
 
 // InviteCodeResponse 招待コードレスポンス
@@ -105,12 +612,41 @@ type InviteCodeResponse struct {
 	ExerciseType       string `json:"exercise_type" example:"running"`
 	ExpiresAt          string `json:"expires_at" example:"2026-02-11T09:00:00Z"`
 	CurrentMemberCount int    `json:"current_member_count" example:"1"`
+	MaxMemberCount     int    `json:"max_member_count" example:"3"`
+	MaxUses            *int   `json:"max_uses" example:"2"`
+	QRCodeURL          string `json:"qr_code_url" example:"/api/teams/01JARQ3KEXAMPLE00001/invites/A3K9X2/qr"`
+}
+
+// InviteCodeUseResponse 招待コード使用履歴
+type InviteCodeUseResponse struct {
+	UserID   string `json:"user_id" example:"firebaseUID123"`
+	UserName string `json:"user_name" example:"山田太郎"`
+	UsedAt   string `json:"used_at" example:"2026-02-10T10:00:00Z"`
+}
+
+// InviteCodeDetailResponse 招待コード一覧の要素
+type InviteCodeDetailResponse struct {
+	Code      string                  `json:"code" example:"A3K9X2"`
+	CreatedBy string                  `json:"created_by" example:"firebaseUID123"`
+	Status    string                  `json:"status" example:"active"` // active / expired / revoked / exhausted
+	ExpiresAt string                  `json:"expires_at" example:"2026-02-11T09:00:00Z"`
+	MaxUses   *int                    `json:"max_uses" example:"2"`
+	UseCount  int                     `json:"use_count" example:"1"`
+	RevokedAt *string                 `json:"revoked_at"`
+	CreatedAt string                  `json:"created_at" example:"2026-02-10T09:00:00Z"`
+	Uses      []InviteCodeUseResponse `json:"uses"`
+}
+
+// InviteCodeListResponse 招待コード一覧レスポンス
+type InviteCodeListResponse struct {
+	TeamID  string                     `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	Invites []InviteCodeDetailResponse `json:"invites"`
 }
 
 // JoinTeamResponse チーム参加レスポンス
 type JoinTeamResponse struct {
 	Team      TeamResponse `json:"team"`
-	TeamReady bool         `json:"team_ready" example:"false"`
+	TeamReady bool         `json:"team_ready" example:"false"` // 最少人数が揃いチャレンジを開始できる状態か
 }
 
 // GPSPointResponse GPSポイントレスポンス
@@ -149,8 +685,50 @@ type ActivityReviewResponse struct {
 	ReviewerID   string `json:"reviewer_id" example:"firebaseUID456"`
 	ReviewerName string `json:"reviewer_name" example:"佐藤花子"`
 	Status       string `json:"status" example:"approved"`
-	Comment      string `json:"comment" example:"いいペースですね！"`
-	CreatedAt    string `json:"created_at" example:"2026-02-10T12:00:00Z"`
+	// ReasonCategory 否認の理由（gps_anomaly / not_exercise / duplicate / wrong_location / too_short / other）
+	ReasonCategory string               `json:"reason_category,omitempty" example:"gps_anomaly"`
+	Comment        string               `json:"comment" example:"いいペースですね！"`
+	Tally          *ReviewTallyResponse `json:"tally,omitempty"` // 投稿直後の集計（投稿時のみ）
+	CreatedAt      string               `json:"created_at" example:"2026-02-10T12:00:00Z"`
+}
+
+// ReviewTallyResponse アクティビティのレビュー集計
+type ReviewTallyResponse struct {
+	ActivityID       string   `json:"activity_id" example:"01JARQ3KEXAMPLE00003"`
+	ReviewStatus     string   `json:"review_status" example:"pending"` // pending / approved / rejected
+	Policy           string   `json:"policy" example:"majority"`
+	EligibleCount    int      `json:"eligible_count" example:"2"` // 本人を除くメンバー数
+	Quorum           int      `json:"quorum" example:"2"`         // 承認・否認が決まる票数
+	Approvals        int      `json:"approvals" example:"1"`
+	Rejections       int      `json:"rejections" example:"0"`
+	ReasonCategories []string `json:"reason_categories"`
+	Deadline         *string  `json:"deadline"` // レビューの受付期限
+	WindowOpen       bool     `json:"window_open" example:"true"`
+	// Outcome 週次評価で確定した結果（approved / rejected）。未確定なら null
+	Outcome    *string `json:"outcome"`
+	Resolution *string `json:"resolution"` // quorum: 票数で決まった / no_quorum: 否認が必要数に届かず承認扱い
+}
+
+// ReviewerStatResponse チーム内でのレビュアーの記録
+type ReviewerStatResponse struct {
+	UserID            string         `json:"user_id" example:"firebaseUID456"`
+	UserName          string         `json:"user_name" example:"佐藤花子"`
+	EligibleCount     int            `json:"eligible_count" example:"12"` // レビューできた他のメンバーの確定済みアクティビティ数
+	Reviews           int            `json:"reviews" example:"9"`
+	ParticipationRate float64        `json:"participation_rate" example:"0.75"`
+	Approvals         int            `json:"approvals" example:"8"`
+	Rejections        int            `json:"rejections" example:"1"`
+	Upheld            int            `json:"upheld" example:"1"`     // 否認がそのまま確定した数
+	Overturned        int            `json:"overturned" example:"0"` // 否認したが承認で確定した数
+	ReasonBreakdown   map[string]int `json:"reason_breakdown"`
+}
+
+// ReviewerStatsResponse チームのレビュアー統計
+type ReviewerStatsResponse struct {
+	TeamID            string                 `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	ReviewQuorum      string                 `json:"review_quorum" example:"majority"`
+	ReviewWindowHours int                    `json:"review_window_hours" example:"12"`
+	Reviewers         []ReviewerStatResponse `json:"reviewers"`
 }
 
 // SendGPSPointsResponse GPSポイント送信レスポンス
@@ -177,6 +755,7 @@ type HPChangeEntry struct {
 	UserName  string `json:"user_name" example:"山田太郎"`
 	HPChange  int    `json:"hp_change" example:"0"`
 	TargetMet bool   `json:"target_met" example:"true"`
+	Frozen    bool   `json:"frozen" example:"false"` // フリーズトークンで評価を免除した週
 }
 
 // WeekHPHistory 週別HP履歴
@@ -195,6 +774,8 @@ type MemberProgress struct {
 	CurrentWeekVisits      *int     `json:"current_week_visits"`
 	CurrentWeekDurationMin *int     `json:"current_week_duration_min"`
 	TargetProgressPercent  float64  `json:"target_progress_percent" example:"83.3"`
+	IndividualGoal         bool     `json:"individual_goal" example:"false"` // 個別目標で評価されているか
+	Frozen                 bool     `json:"frozen" example:"false"`          // 今週をフリーズしているか
 }
 
 // TeamStatusResponse チームHP・状態レスポンス
@@ -221,6 +802,8 @@ type WeeklyEvaluationResponse struct {
 	TotalVisits      int     `json:"total_visits" example:"0"`
 	TotalDurationMin int     `json:"total_duration_min" example:"0"`
 	HPChange         int     `json:"hp_change" example:"0"`
+	GoalVersion      int     `json:"goal_version" example:"1"` // 評価に使った目標の版（0は版管理前）
+	Frozen           bool    `json:"frozen" example:"false"`   // フリーズトークンで評価を免除した週
 	EvaluatedAt      string  `json:"evaluated_at" example:"2026-01-27T00:00:00Z"`
 }
 
@@ -243,6 +826,9 @@ type CurrentWeekMemberProgress struct {
 	TargetProgressPercent float64               `json:"target_progress_percent" example:"83.3"`
 	OnTrack               bool                  `json:"on_track" example:"true"`
 	TargetMultiplier      float64               `json:"target_multiplier" example:"1.0"` // 1.0=通常, 1.5=前週未達成ペナルティ
+	TargetProration       float64               `json:"target_proration" example:"1.0"`  // 短い週の目標按分率（参加できる日数 / 7）
+	IndividualGoal        bool                  `json:"individual_goal" example:"false"` // 個別目標で評価されているか
+	Frozen                bool                  `json:"frozen" example:"false"`          // 今週をフリーズしているか
 	ActivitiesThisWeek    []WeekActivitySummary `json:"activities_this_week"`
 }
 
@@ -250,6 +836,7 @@ type CurrentWeekMemberProgress struct {
 type CurrentWeekEvaluationResponse struct {
 	TeamID        string                      `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
 	WeekNumber    int                         `json:"week_number" example:"3"`
+	StartPolicy   string                      `json:"start_policy" example:"monday"` // midnight / monday
 	WeekStart     string                      `json:"week_start" example:"2026-02-03T00:00:00Z"`
 	WeekEnd       string                      `json:"week_end" example:"2026-02-09T23:59:59Z"`
 	DaysRemaining int                         `json:"days_remaining" example:"0"`
@@ -267,18 +854,457 @@ type DailyStat struct {
 
 // DisbandVoteResponse 解散投票レスポンス
 type DisbandVoteResponse struct {
-	TeamID     string   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
-	TotalCount int      `json:"total_count" example:"3"`
-	VotedCount int      `json:"voted_count" example:"1"`
-	VotedUsers []string `json:"voted_users"`
-	Disbanded  bool     `json:"disbanded" example:"false"`
+	TeamID        string   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	ProposalID    *string  `json:"proposal_id"` // 投票中の解散提案
+	TotalCount    int      `json:"total_count" example:"3"`
+	VotedCount    int      `json:"voted_count" example:"1"`
+	RequiredCount int      `json:"required_count" example:"3"` // 解散に必要な投票数
+	Inactive      bool     `json:"inactive" example:"false"`   // 活動が途絶えているため過半数で可決できる
+	VotedUsers    []string `json:"voted_users"`
+	Disbanded     bool     `json:"disbanded" example:"false"`
+	ExpiresAt     *string  `json:"expires_at"`     // 投票期限
+	CooldownUntil *string  `json:"cooldown_until"` // 次の解散投票を始められるようになる日時
+}
+
+// SeasonMemberStatResponse シーズンのメンバー別成績
+type SeasonMemberStatResponse struct {
+	UserID           string  `json:"user_id" example:"firebaseUID123"`
+	UserName         string  `json:"user_name" example:"山田太郎"`
+	Role             string  `json:"role" example:"leader"`
+	InTeamAtEnd      bool    `json:"in_team_at_end" example:"true"` // シーズン終了時点の在籍メンバーか（再戦に参加できる）
+	WeeksEvaluated   int     `json:"weeks_evaluated" example:"4"`
+	WeeksMet         int     `json:"weeks_met" example:"3"`
+	WeeksFrozen      int     `json:"weeks_frozen" example:"1"` // フリーズで評価を免除した週数（weeks_evaluated には含まない）
+	AchievementRate  float64 `json:"achievement_rate" example:"0.75"`
+	TotalDistanceKM  float64 `json:"total_distance_km" example:"58.2"`
+	TotalVisits      int     `json:"total_visits" example:"0"`
+	TotalDurationMin int     `json:"total_duration_min" example:"0"`
+	TotalHPChange    int     `json:"total_hp_change" example:"-15"`
+	LongestStreak    int     `json:"longest_streak" example:"2"`
+	FinalStreak      int     `json:"final_streak" example:"1"`
+}
+
+// SeasonSummaryResponse シーズン結果レスポンス
+type SeasonSummaryResponse struct {
+	TeamID         string                     `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	TeamName       string                     `json:"team_name" example:"朝ランチーム"`
+	ExerciseType   string                     `json:"exercise_type" example:"running"`
+	Strictness     string                     `json:"strictness" example:"normal"`
+	Result         string                     `json:"result" example:"completed"`
+	FinalHP        int                        `json:"final_hp" example:"85"`
+	MaxHP          int                        `json:"max_hp" example:"100"`
+	WeeksPlayed    int                        `json:"weeks_played" example:"4"`
+	ChallengeWeeks int                        `json:"challenge_weeks" example:"4"`
+	PreviousTeamID *string                    `json:"previous_team_id"`
+	StartedAt      *string                    `json:"started_at"`
+	EndedAt        string                     `json:"ended_at" example:"2026-03-10T00:00:00Z"`
+	Members        []SeasonMemberStatResponse `json:"members"`
+}
+
+// TeamHistoryEntry 過去チーム履歴
+type TeamHistoryEntry struct {
+	TeamID         string                   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	TeamName       string                   `json:"team_name" example:"朝ランチーム"`
+	ExerciseType   string                   `json:"exercise_type" example:"running"`
+	Result         string                   `json:"result" example:"completed"`
+	FinalHP        int                      `json:"final_hp" example:"85"`
+	MaxHP          int                      `json:"max_hp" example:"100"`
+	WeeksPlayed    int                      `json:"weeks_played" example:"4"`
+	PreviousTeamID *string                  `json:"previous_team_id"`
+	StartedAt      *string                  `json:"started_at"`
+	EndedAt        string                   `json:"ended_at" example:"2026-03-10T00:00:00Z"`
+	MyStats        SeasonMemberStatResponse `json:"my_stats"`
+}
+
+// RematchResponse 再戦状況レスポンス
+type RematchResponse struct {
+	PreviousTeamID string       `json:"previous_team_id" example:"01JARQ3KEXAMPLE00001"`
+	Team           TeamResponse `json:"team"`
+	ConfirmedUsers []string     `json:"confirmed_users"`
+	PendingUsers   []string     `json:"pending_users"` // 前シーズンのメンバーで未参加のユーザー
+}
+
+// LeaveTeamResponse チーム離脱・除名レスポンス
+type LeaveTeamResponse struct {
+	TeamID      string  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	UserID      string  `json:"user_id" example:"firebaseUID123"`
+	HPPenalty   int     `json:"hp_penalty" example:"15"`
+	CurrentHP   int     `json:"current_hp" example:"70"`
+	TeamStatus  string  `json:"team_status" example:"active"`
+	NewLeaderID *string `json:"new_leader_id"` // リーダーが抜けて昇格したメンバー
+}
+
+// MemberRemovalVoteResponse メンバー除名投票レスポンス
+type MemberRemovalVoteResponse struct {
+	TeamID        string   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	ProposalID    *string  `json:"proposal_id"` // 投票中の除名提案
+	TargetUserID  string   `json:"target_user_id" example:"firebaseUID789"`
+	EligibleCount int      `json:"eligible_count" example:"2"` // 対象者を除くメンバー数
+	VotedCount    int      `json:"voted_count" example:"1"`
+	RequiredCount int      `json:"required_count" example:"2"`
+	VotedUsers    []string `json:"voted_users"`
+	Removed       bool     `json:"removed" example:"false"`
+}
+
+// ProposalVoteResponse 提案への投票
+type ProposalVoteResponse struct {
+	UserID    string `json:"user_id" example:"firebaseUID123"`
+	Approve   bool   `json:"approve" example:"true"`
+	CreatedAt string `json:"created_at" example:"2026-02-10T09:00:00Z"`
+}
+
+// ProposalChanges 設定変更提案の変更内容
+type ProposalChanges struct {
+	Strictness           *string  `json:"strictness,omitempty" example:"strict"`
+	ChallengeWeeks       *int     `json:"challenge_weeks,omitempty" example:"6"`
+	TargetDistanceKM     *float64 `json:"target_distance_km,omitempty" example:"20.0"`
+	TargetVisitsPerWeek  *int     `json:"target_visits_per_week,omitempty"`
+	TargetMinDurationMin *int     `json:"target_min_duration_min,omitempty"`
+	DisbandThreshold     *string  `json:"disband_threshold,omitempty" example:"unanimous"`
+	ReviewQuorum         *string  `json:"review_quorum,omitempty" example:"all"`
+	ReviewWindowHours    *int     `json:"review_window_hours,omitempty" example:"24"`
+}
+
+// TeamProposalResponse チーム提案レスポンス
+type TeamProposalResponse struct {
+	ID            string                 `json:"id" example:"01JARQ3KEXAMPLE00020"`
+	TeamID        string                 `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	Type          string                 `json:"type" example:"change_settings"` // disband / remove_member / change_settings
+	ProposedBy    string                 `json:"proposed_by" example:"firebaseUID123"`
+	TargetUserID  *string                `json:"target_user_id"`
+	Changes       *ProposalChanges       `json:"changes,omitempty"`
+	Status        string                 `json:"status" example:"open"` // open / passed / applied / rejected / expired / cancelled
+	EligibleCount int                    `json:"eligible_count" example:"3"`
+	RequiredCount int                    `json:"required_count" example:"2"`
+	ApproveCount  int                    `json:"approve_count" example:"1"`
+	RejectCount   int                    `json:"reject_count" example:"0"`
+	Votes         []ProposalVoteResponse `json:"votes"`
+	ApplyFromWeek *int                   `json:"apply_from_week" example:"3"` // 設定変更が反映される週
+	ExpiresAt     *string                `json:"expires_at"`
+	ResolvedAt    *string                `json:"resolved_at"`
+	CreatedAt     string                 `json:"created_at" example:"2026-02-10T09:00:00Z"`
 }
 
 // PredictionResponse 失敗予測レスポンス
 type PredictionResponse struct {
-	UserID              string      `json:"user_id" example:"firebaseUID123"`
-	AnalysisPeriodWeeks int         `json:"analysis_period_weeks" example:"4"`
-	DailyStats          []DailyStat `json:"daily_stats"`
-	DangerDays          []string    `json:"danger_days"`
-	Recommendation      string      `json:"recommendation" example:"月曜日が危険です。月曜日は運動をサボりやすい傾向があります。"`
+	UserID              string                  `json:"user_id" example:"firebaseUID123"`
+	TeamID              string                  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	WeekNumber          int                     `json:"week_number" example:"3"`
+	WeekStart           string                  `json:"week_start" example:"2026-01-12T00:00:00+09:00"`
+	WeekEnd             string                  `json:"week_end" example:"2026-01-19T00:00:00+09:00"`
+	AnalysisPeriodWeeks int                     `json:"analysis_period_weeks" example:"8"`
+	TargetAmount        float64                 `json:"target_amount" example:"10.0"`  // running は km、gym は回数
+	AchievedAmount      float64                 `json:"achieved_amount" example:"4.5"` // 今週の実績
+	RemainingAmount     float64                 `json:"remaining_amount" example:"5.5"`
+	NeededDays          int                     `json:"needed_days" example:"2"`             // 目標達成にあと何日運動が必要か
+	Frozen              bool                    `json:"frozen" example:"false"`              // 今週をフリーズしている
+	MissProbability     float64                 `json:"miss_probability" example:"0.35"`     // 今週の目標を達成できない確率
+	StreakWeeks         int                     `json:"streak_weeks" example:"2"`            // 直近で連続して目標を達成した週数
+	DaysSinceLast       int                     `json:"days_since_last" example:"1"`         // 最後に運動してからの日数（記録がない場合は -1）
+	TeammatesActiveRate float64                 `json:"teammates_active_rate" example:"0.5"` // 直近48時間に運動したチームメイトの割合
+	DailyStats          []DailyStat             `json:"daily_stats"`
+	DangerDays          []string                `json:"danger_days"`
+	Days                []DayPredictionResponse `json:"days"`            // 今週の残りの日ごとの予測
+	SuggestedSlots      []SuggestedSlotResponse `json:"suggested_slots"` // 運動におすすめの時間帯
+	Recommendation      string                  `json:"recommendation" example:"月曜日が危険です。月曜日は運動をサボりやすい傾向があります。"`
+}
+
+// TeamForecastResponse チームの失敗予測レスポンス
+type TeamForecastResponse struct {
+	TeamID              string                   `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	WeekNumber          int                      `json:"week_number" example:"3"`
+	WeekStart           string                   `json:"week_start" example:"2026-01-12T00:00:00+09:00"`
+	WeekEnd             string                   `json:"week_end" example:"2026-01-19T00:00:00+09:00"`
+	CurrentHP           int                      `json:"current_hp" example:"70"`
+	MaxHP               int                      `json:"max_hp" example:"100"`
+	AllMetProbability   float64                  `json:"all_met_probability" example:"0.28"`  // 今週全員が目標を達成する確率
+	ExpectedHPChange    float64                  `json:"expected_hp_change" example:"-12.4"`  // 今週の評価でのHP変動の期待値
+	ExpectedHP          float64                  `json:"expected_hp" example:"57.6"`          // 今週の評価後のHPの期待値
+	DisbandProbability  float64                  `json:"disband_probability" example:"0.05"`  // 今週の評価で解散する確率
+	SurvivalProbability float64                  `json:"survival_probability" example:"0.62"` // horizon_weeks の間に解散しない確率
+	HorizonWeeks        int                      `json:"horizon_weeks" example:"6"`
+	SeasonEndsInHorizon bool                     `json:"season_ends_in_horizon" example:"true"` // horizon_weeks がチャレンジの最終週まで届いている
+	Members             []MemberForecastResponse `json:"members"`                               // リスクの高い順
+	DisbandWeeks        []DisbandWeekResponse    `json:"disband_weeks"`                         // 何週後の評価で解散するかの確率分布
+}
+
+// MemberForecastResponse メンバーの今週の達成見込み
+type MemberForecastResponse struct {
+	UserID           string  `json:"user_id" example:"firebaseUID123"`
+	UserName         string  `json:"user_name" example:"山田太郎"`
+	TargetAmount     float64 `json:"target_amount" example:"10.0"` // running は km、gym は回数
+	AchievedAmount   float64 `json:"achieved_amount" example:"4.5"`
+	RemainingAmount  float64 `json:"remaining_amount" example:"5.5"`
+	Frozen           bool    `json:"frozen" example:"false"`
+	MeetProbability  float64 `json:"meet_probability" example:"0.45"`    // 今週の目標を達成する確率
+	HistoricalRate   float64 `json:"historical_rate" example:"0.7"`      // 過去の週次評価の達成率
+	EvaluatedWeeks   int     `json:"evaluated_weeks" example:"6"`        // historical_rate の元になった週数
+	ExpectedHPChange float64 `json:"expected_hp_change" example:"-8.25"` // このメンバーの未達成によるHP変動の期待値
+	Risk             string  `json:"risk" example:"high"`                // high / medium / low / met / frozen
+}
+
+// DisbandWeekResponse 何週後の評価で解散するかの確率
+type DisbandWeekResponse struct {
+	WeeksFromNow int     `json:"weeks_from_now" example:"1"`
+	WeekNumber   int     `json:"week_number" example:"3"`
+	Probability  float64 `json:"probability" example:"0.05"`
+}
+
+// DayPredictionResponse 日ごとの運動確率
+type DayPredictionResponse struct {
+	Date                string   `json:"date" example:"2026-01-14"`
+	DayOfWeek           int      `json:"day_of_week" example:"3"`
+	DayName             string   `json:"day_name" example:"水曜日"`
+	ActivityProbability float64  `json:"activity_probability" example:"0.62"`
+	Factors             []string `json:"factors"` // 確率に影響した要因（weekday_low, streak, inactive_recently, teammates_active, already_active_today, usual_hours_passed, chronotype_mismatch）
+}
+
+// SuggestedSlotResponse おすすめの運動時間帯
+type SuggestedSlotResponse struct {
+	Start               string  `json:"start" example:"2026-01-14T19:00:00+09:00"`
+	End                 string  `json:"end" example:"2026-01-14T20:00:00+09:00"`
+	ActivityProbability float64 `json:"activity_probability" example:"0.62"`
+	Reason              string  `json:"reason" example:"usual_time"` // usual_time: 普段運動している時刻 / chronotype: 朝型夜型から推定
+}
+
+// ReactionSummaryResponse 絵文字ごとのリアクション数
+type ReactionSummaryResponse struct {
+	Emoji       string   `json:"emoji" example:"🔥"`
+	Count       int      `json:"count" example:"2"`
+	UserIDs     []string `json:"user_ids"`
+	ReactedByMe bool     `json:"reacted_by_me" example:"true"`
+}
+
+// ActivityReactionsResponse アクティビティのリアクション一覧レスポンス
+type ActivityReactionsResponse struct {
+	ActivityID string                    `json:"activity_id" example:"01JARQ3KEXAMPLE00003"`
+	Reactions  []ReactionSummaryResponse `json:"reactions"`
+}
+
+// ActivityCommentResponse アクティビティへのコメント
+type ActivityCommentResponse struct {
+	ID         string                    `json:"id" example:"01JARQ3KEXAMPLE00050"`
+	ActivityID string                    `json:"activity_id" example:"01JARQ3KEXAMPLE00003"`
+	UserID     string                    `json:"user_id" example:"firebaseUID456"`
+	UserName   string                    `json:"user_name" example:"佐藤花子"`
+	ParentID   *string                   `json:"parent_id"`
+	Body       string                    `json:"body" example:"ナイスラン！"`
+	Deleted    bool                      `json:"deleted" example:"false"` // 削除済み（本文は空）
+	CreatedAt  string                    `json:"created_at" example:"2026-02-10T12:00:00Z"`
+	Replies    []ActivityCommentResponse `json:"replies,omitempty"`
+}
+
+// NewActivityCommentResponse ActivityCommentモデルからレスポンスを構築する
+func NewActivityCommentResponse(c models.ActivityComment) ActivityCommentResponse {
+	return ActivityCommentResponse{
+		ID:         c.ID,
+		ActivityID: c.ActivityID,
+		UserID:     c.UserID,
+		UserName:   c.User.Name,
+		ParentID:   c.ParentID,
+		Body:       c.Body,
+		Deleted:    c.DeletedAt != nil,
+		CreatedAt:  c.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ActivityCommentListResponse アクティビティのコメント一覧レスポンス。スレッドごとに返信をまとめる
+type ActivityCommentListResponse struct {
+	ActivityID   string                    `json:"activity_id" example:"01JARQ3KEXAMPLE00003"`
+	CommentCount int                       `json:"comment_count" example:"3"` // 削除済みを除くコメント数
+	Comments     []ActivityCommentResponse `json:"comments"`
+}
+
+// FeedActivityResponse フィードのアクティビティ
+type FeedActivityResponse struct {
+	Activity           ActivityResponse          `json:"activity"`
+	Reactions          []ReactionSummaryResponse `json:"reactions"`
+	CommentCount       int                       `json:"comment_count" example:"3"`
+	UnreadCommentCount int                       `json:"unread_comment_count" example:"1"`
+}
+
+// FeedEvaluationResponse フィードの週次評価（週単位にまとめたもの）
+type FeedEvaluationResponse struct {
+	WeekNumber     int             `json:"week_number" example:"2"`
+	EvaluatedAt    string          `json:"evaluated_at" example:"2026-01-27T00:00:00Z"`
+	EvaluatedCount int             `json:"evaluated_count" example:"3"` // フリーズを除いた評価対象の人数
+	MetCount       int             `json:"met_count" example:"2"`
+	FrozenCount    int             `json:"frozen_count" example:"0"`
+	HPChange       int             `json:"hp_change" example:"-10"`
+	Members        []HPChangeEntry `json:"members"`
+}
+
+// FeedHPChangeResponse フィードの週次評価以外のHP変動
+type FeedHPChangeResponse struct {
+	Reason     string `json:"reason" example:"member_left"` // member_left / appeal_overturned
+	UserID     string `json:"user_id" example:"firebaseUID123"`
+	UserName   string `json:"user_name" example:"山田太郎"`
+	HPChange   int    `json:"hp_change" example:"-10"`
+	HPAfter    int    `json:"hp_after" example:"75"`
+	WeekNumber int    `json:"week_number" example:"3"`
+}
+
+// FeedMembershipResponse フィードのメンバーの出入り
+type FeedMembershipResponse struct {
+	Type     string  `json:"type" example:"joined"` // joined / left / removed / leader_changed
+	UserID   string  `json:"user_id" example:"firebaseUID123"`
+	UserName string  `json:"user_name" example:"山田太郎"`
+	ActorID  *string `json:"actor_id"`
+}
+
+// FeedItemResponse フィードの1項目。type に対応するフィールドだけが入る
+type FeedItemResponse struct {
+	ID         string                  `json:"id" example:"activity:01JARQ3KEXAMPLE00003"`
+	Type       string                  `json:"type" example:"activity"` // activity / evaluation / hp_change / membership
+	OccurredAt string                  `json:"occurred_at" example:"2026-02-10T07:35:00Z"`
+	Unread     bool                    `json:"unread" example:"true"`
+	Activity   *FeedActivityResponse   `json:"activity,omitempty"`
+	Evaluation *FeedEvaluationResponse `json:"evaluation,omitempty"`
+	HPChange   *FeedHPChangeResponse   `json:"hp_change,omitempty"`
+	Membership *FeedMembershipResponse `json:"membership,omitempty"`
+}
+
+// FeedUnreadResponse フィードの未読数
+type FeedUnreadResponse struct {
+	TeamID     string  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	LastReadAt *string `json:"last_read_at"`
+	Items      int     `json:"items" example:"4"`       // 他のメンバーによる未読の項目
+	Comments   int     `json:"comments" example:"2"`    // 他のメンバーによる未読のコメント
+	MyComments int     `json:"my_comments" example:"1"` // うち自分のアクティビティ・コメントへのもの
+}
+
+// FeedResponse チームフィードレスポンス
+type FeedResponse struct {
+	TeamID     string             `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	Items      []FeedItemResponse `json:"items"`
+	NextCursor *string            `json:"next_cursor"` // 続きを取得するときに before に渡す。最後まで取得したら null
+	Unread     FeedUnreadResponse `json:"unread"`
+}
+
+// ChatMessageResponse チームチャットのメッセージ
+type ChatMessageResponse struct {
+	ID        string  `json:"id" example:"01JARQ3KEXAMPLE00060"`
+	TeamID    string  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	UserID    string  `json:"user_id" example:"firebaseUID123"`
+	UserName  string  `json:"user_name" example:"山田太郎"`
+	Body      string  `json:"body" example:"今日は19時から走ります！"`
+	Flagged   bool    `json:"flagged" example:"false"` // モデレーションで要確認とされた
+	Deleted   bool    `json:"deleted" example:"false"` // 削除済み（本文は空）
+	DeletedBy *string `json:"deleted_by"`
+	CreatedAt string  `json:"created_at" example:"2026-02-10T12:00:00Z"`
+}
+
+// NewChatMessageResponse ChatMessageモデルからレスポンスを構築する
+func NewChatMessageResponse(m models.ChatMessage) ChatMessageResponse {
+	return ChatMessageResponse{
+		ID:        m.ID,
+		TeamID:    m.TeamID,
+		UserID:    m.UserID,
+		UserName:  m.User.Name,
+		Body:      m.Body,
+		Flagged:   m.Flagged,
+		Deleted:   m.DeletedAt != nil,
+		DeletedBy: m.DeletedBy,
+		CreatedAt: m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ChatMessageListResponse チームチャットの履歴レスポンス。messages は古い順
+type ChatMessageListResponse struct {
+	TeamID     string                `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	Messages   []ChatMessageResponse `json:"messages"`
+	HasMore    bool                  `json:"has_more" example:"true"` // 取得した方向にまだメッセージがあるか
+	NextCursor *string               `json:"next_cursor"`             // 続きを取得するときに before（after で取得した場合は after）に渡すID
+}
+
+// AppealVoteResponse 異議申し立てへの投票
+type AppealVoteResponse struct {
+	UserID    string `json:"user_id" example:"firebaseUID456"`
+	UserName  string `json:"user_name" example:"佐藤花子"`
+	Approve   bool   `json:"approve" example:"true"` // アクティビティを認めるか
+	Comment   string `json:"comment" example:"ペースも普段通りなので認めます"`
+	CreatedAt string `json:"created_at" example:"2026-02-11T09:00:00Z"`
+}
+
+// ActivityAppealResponse 否認への異議申し立て
+type ActivityAppealResponse struct {
+	ID            string               `json:"id" example:"01JARQ3KEXAMPLE00040"`
+	ActivityID    string               `json:"activity_id" example:"01JARQ3KEXAMPLE00003"`
+	TeamID        string               `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	UserID        string               `json:"user_id" example:"firebaseUID123"`
+	UserName      string               `json:"user_name" example:"山田太郎"`
+	WeekNumber    int                  `json:"week_number" example:"2"`
+	Explanation   string               `json:"explanation" example:"トンネル区間でGPSが飛びましたが、実際に走っています"`
+	EvidenceURL   string               `json:"evidence_url" example:"https://example.com/screenshots/watch.png"`
+	Status        string               `json:"status" example:"open"`        // open / overturned / upheld
+	Resolution    string               `json:"resolution" example:""`        // quorum / expired
+	Recalculated  bool                 `json:"recalculated" example:"false"` // 評価済みの週を評価し直したか
+	EligibleCount int                  `json:"eligible_count" example:"2"`   // 投票できる本人以外のメンバー数
+	Quorum        int                  `json:"quorum" example:"2"`
+	Approvals     int                  `json:"approvals" example:"1"`
+	Rejections    int                  `json:"rejections" example:"0"`
+	Votes         []AppealVoteResponse `json:"votes"`
+	ExpiresAt     string               `json:"expires_at" example:"2026-02-14T09:00:00Z"`
+	ResolvedAt    *string              `json:"resolved_at"`
+	CreatedAt     string               `json:"created_at" example:"2026-02-11T09:00:00Z"`
+}
+
+// EvaluationAdjustmentResponse 評価済みの週を評価し直した記録
+type EvaluationAdjustmentResponse struct {
+	ID               string  `json:"id" example:"01JARQ3KEXAMPLE00041"`
+	TeamID           string  `json:"team_id" example:"01JARQ3KEXAMPLE00001"`
+	WeekNumber       int     `json:"week_number" example:"2"`
+	UserID           string  `json:"user_id" example:"firebaseUID123"`
+	EvaluationID     string  `json:"evaluation_id" example:"01JARQ3KEXAMPLE00030"`
+	Reason           string  `json:"reason" example:"appeal_overturned"`
+	AppealID         *string `json:"appeal_id"`
+	ActivityID       *string `json:"activity_id"`
+	TargetMetBefore  bool    `json:"target_met_before" example:"false"`
+	TargetMetAfter   bool    `json:"target_met_after" example:"true"`
+	DistanceBefore   float64 `json:"distance_before" example:"8.2"`
+	DistanceAfter    float64 `json:"distance_after" example:"13.4"`
+	VisitsBefore     int     `json:"visits_before" example:"0"`
+	VisitsAfter      int     `json:"visits_after" example:"0"`
+	DurationBefore   int     `json:"duration_before" example:"48"`
+	DurationAfter    int     `json:"duration_after" example:"79"`
+	HPChangeBefore   int     `json:"hp_change_before" example:"-15"`
+	HPChangeAfter    int     `json:"hp_change_after" example:"5"`
+	AllMetBefore     bool    `json:"all_met_before" example:"false"`
+	AllMetAfter      bool    `json:"all_met_after" example:"true"`
+	TeamHPBefore     int     `json:"team_hp_before" example:"70"`
+	TeamHPAfter      int     `json:"team_hp_after" example:"100"`
+	TeamStatusBefore string  `json:"team_status_before" example:"disbanded"`
+	TeamStatusAfter  string  `json:"team_status_after" example:"active"` // 解散したチームのHPが戻ると再開する
+	CreatedAt        string  `json:"created_at" example:"2026-02-12T09:00:00Z"`
+}
+
+// NewEvaluationAdjustmentResponse EvaluationAdjustmentモデルからレスポンスを構築する
+func NewEvaluationAdjustmentResponse(a models.EvaluationAdjustment) EvaluationAdjustmentResponse {
+	return EvaluationAdjustmentResponse{
+		ID:               a.ID,
+		TeamID:           a.TeamID,
+		WeekNumber:       a.WeekNumber,
+		UserID:           a.UserID,
+		EvaluationID:     a.EvaluationID,
+		Reason:           a.Reason,
+		AppealID:         a.AppealID,
+		ActivityID:       a.ActivityID,
+		TargetMetBefore:  a.TargetMetBefore,
+		TargetMetAfter:   a.TargetMetAfter,
+		DistanceBefore:   a.DistanceBefore,
+		DistanceAfter:    a.DistanceAfter,
+		VisitsBefore:     a.VisitsBefore,
+		VisitsAfter:      a.VisitsAfter,
+		DurationBefore:   a.DurationBefore,
+		DurationAfter:    a.DurationAfter,
+		HPChangeBefore:   a.HPChangeBefore,
+		HPChangeAfter:    a.HPChangeAfter,
+		AllMetBefore:     a.AllMetBefore,
+		AllMetAfter:      a.AllMetAfter,
+		TeamHPBefore:     a.TeamHPBefore,
+		TeamHPAfter:      a.TeamHPAfter,
+		TeamStatusBefore: a.TeamStatusBefore,
+		TeamStatusAfter:  a.TeamStatusAfter,
+		CreatedAt:        a.CreatedAt.Format(time.RFC3339),
+	}
 }
@@ -15,6 +15,7 @@ type UserResponse struct {
 	Weight     int    `json:"weight" example:"70"`
 	Chronotype string `json:"chronotype" example:"morning"`
 	AvatarURL  string `json:"avatar_url" example:"https://r2.example.com/avatars/uid/image.jpg"`
+	Timezone   string `json:"timezone" example:"Asia/Tokyo"`
 	CreatedAt  string `json:"created_at" example:"2024-01-01T00:00:00Z"`
 	UpdatedAt  string `json:"updated_at" example:"2024-01-01T00:00:00Z"`
 }
@@ -28,6 +29,7 @@ func NewUserResponse(user models.User) UserResponse {
 		Weight:     user.Weight,
 		Chronotype: user.Chronotype,
 		AvatarURL:  user.AvatarURL,
+		Timezone:   user.Timezone,
 		CreatedAt:  user.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  user.UpdatedAt.Format(time.RFC3339),
 	}
@@ -0,0 +1,713 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultAppealVotingHours 異議申し立ての投票期間の既定値（APPEAL_VOTING_HOURS で変更可）
+	DefaultAppealVotingHours = 72
+	// MaxAppealExplanationLength 申し立ての説明の最大文字数
+	MaxAppealExplanationLength = 1000
+	// maxEvidenceURLLength 証拠URLの最大長
+	maxEvidenceURLLength = 500
+)
+
+var (
+	ErrNotActivityOwner       = errors.New("only the activity owner can appeal")
+	ErrActivityNotRejected    = errors.New("activity is not rejected")
+	ErrAppealExists           = errors.New("activity has already been appealed")
+	ErrAppealWindowClosed     = errors.New("appeal window is closed")
+	ErrAppealNeedsExplanation = errors.New("appeal requires an explanation or evidence")
+	ErrAppealTooLong          = errors.New("appeal explanation is too long")
+	ErrInvalidEvidenceURL     = errors.New("invalid evidence url")
+	ErrNoAppealVoters         = errors.New("no teammates can vote on the appeal")
+	ErrAppealClosed           = errors.New("appeal is closed")
+	ErrVoteOwnAppeal          = errors.New("cannot vote on own appeal")
+	ErrActivityUnderAppeal    = errors.New("activity is under appeal")
+)
+
+type AppealService struct {
+	db *gorm.DB
+}
+
+func NewAppealService(db *gorm.DB) *AppealService {
+	return &AppealService{db: db}
+}
+
+// AppealVotingPeriod 申し立ての投票期間
+func AppealVotingPeriod() time.Duration {
+	return time.Duration(envInt("APPEAL_VOTING_HOURS", DefaultAppealVotingHours)) * time.Hour
+}
+
+// seasonEndedByEvaluation 週次評価でシーズンが終わったチーム（HPが尽きて解散・最終週を終えて完走）なら、最後に評価された週を返す
+// 投票や人数不足による解散はメンバーの判断で終えたものとして扱い、ok=false を返す
+func seasonEndedByEvaluation(tx *gorm.DB, team models.Team) (week int, ok bool, err error) {
+	if team.Status != "completed" && (team.Status != "disbanded" || team.CurrentHP > 0) {
+		return 0, false, nil
+	}
+	if err := tx.Model(&models.WeeklyEvaluation{}).Where("team_id = ?", team.ID).
+		Select("COALESCE(MAX(week_number), 0)").Scan(&week).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to fetch last evaluated week: %w", err)
+	}
+	return week, week > 0, nil
+}
+
+// AppealDeadline 申し立てられる期限。アクティビティの翌週の終わりまで（評価し直した結果を翌々週の目標倍率に反映できる範囲）
+func AppealDeadline(team models.Team, activity models.Activity) *time.Time {
+	_, end, ok := WeekWindow(team, WeekOf(team, activity.StartedAt)+1)
+	if !ok {
+		return nil
+	}
+	return &end
+}
+
+// AppealTally 申し立てへの投票の集計。今のメンバーのうち本人以外の票だけを数える
+type AppealTally struct {
+	Policy     string
+	Eligible   int
+	Quorum     int
+	Approvals  int // アクティビティを認める票
+	Rejections int // 否認のままにする票
+}
+
+// TallyAppeal 申し立てへの投票をチームの review_quorum で数える
+func TallyAppeal(tx *gorm.DB, team models.Team, appeal models.ActivityAppeal) (AppealTally, error) {
+	tally := AppealTally{Policy: team.ReviewQuorum}
+	if !ValidReviewQuorum(tally.Policy) {
+		tally.Policy = "majority"
+	}
+
+	var memberIDs []string
+	if err := tx.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id <> ?", team.ID, appeal.UserID).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		return tally, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	tally.Eligible = len(memberIDs)
+	tally.Quorum = ReviewQuorum(tally.Policy, tally.Eligible)
+	if len(memberIDs) == 0 {
+		return tally, nil
+	}
+
+	var votes []models.AppealVote
+	if err := tx.Where("appeal_id = ? AND user_id IN ?", appeal.ID, memberIDs).Find(&votes).Error; err != nil {
+		return tally, fmt.Errorf("failed to fetch appeal votes: %w", err)
+	}
+	for _, v := range votes {
+		if v.Approve {
+			tally.Approvals++
+		} else {
+			tally.Rejections++
+		}
+	}
+	return tally, nil
+}
+
+// AppealFor アクティビティへの申し立て。なければ nil
+func AppealFor(tx *gorm.DB, activityID string) (*models.ActivityAppeal, error) {
+	var appeal models.ActivityAppeal
+	err := tx.First(&appeal, "activity_id = ?", activityID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch appeal: %w", err)
+	}
+	return &appeal, nil
+}
+
+// validateEvidenceURL 証拠として添付するURL（http / https）を検証する
+func validateEvidenceURL(raw string) error {
+	if len(raw) > maxEvidenceURLLength {
+		return ErrInvalidEvidenceURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidEvidenceURL
+	}
+	return nil
+}
+
+// FileAppeal 否認されたアクティビティに異議を申し立て、本人以外のメンバーの投票を始める
+// 申し立て中はアクティビティへの通常のレビューを受け付けない
+// 週次評価でシーズンが終わったチームは、終わった週のアクティビティだけ申し立てられる
+func (s *AppealService) FileAppeal(tx *gorm.DB, team models.Team, activity models.Activity, userID, explanation, evidenceURL string, now time.Time) (*models.ActivityAppeal, error) {
+	explanation = strings.TrimSpace(explanation)
+	evidenceURL = strings.TrimSpace(evidenceURL)
+	if activity.UserID != userID {
+		return nil, ErrNotActivityOwner
+	}
+	if explanation == "" && evidenceURL == "" {
+		return nil, ErrAppealNeedsExplanation
+	}
+	if utf8.RuneCountInString(explanation) > MaxAppealExplanationLength {
+		return nil, ErrAppealTooLong
+	}
+	if evidenceURL != "" {
+		if err := validateEvidenceURL(evidenceURL); err != nil {
+			return nil, err
+		}
+	}
+	if team.Status != "active" {
+		endedWeek, ok, err := seasonEndedByEvaluation(tx, team)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || WeekOf(team, activity.StartedAt) != endedWeek {
+			return nil, ErrAppealWindowClosed
+		}
+	}
+	if deadline := AppealDeadline(team, activity); deadline == nil || !now.Before(*deadline) {
+		return nil, ErrAppealWindowClosed
+	}
+
+	// 同時に申し立てても1件だけ作られるようアクティビティ行をロックする
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&activity, "id = ?", activity.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock activity: %w", err)
+	}
+	if activity.ReviewStatus != "rejected" {
+		return nil, ErrActivityNotRejected
+	}
+	if existing, err := AppealFor(tx, activity.ID); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrAppealExists
+	}
+
+	var voters int64
+	if err := tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id <> ?", team.ID, userID).Count(&voters).Error; err != nil {
+		return nil, fmt.Errorf("failed to count members: %w", err)
+	}
+	if voters == 0 {
+		return nil, ErrNoAppealVoters
+	}
+
+	period := AppealVotingPeriod()
+	appeal := models.ActivityAppeal{
+		ID:          utils.GenerateULID(),
+		ActivityID:  activity.ID,
+		TeamID:      team.ID,
+		UserID:      userID,
+		WeekNumber:  WeekOf(team, activity.StartedAt),
+		Explanation: explanation,
+		EvidenceURL: evidenceURL,
+		Status:      "open",
+		ExpiresAt:   now.Add(period),
+	}
+	if err := tx.Create(&appeal).Error; err != nil {
+		return nil, fmt.Errorf("failed to create appeal: %w", err)
+	}
+
+	var owner models.User
+	tx.Select("name").First(&owner, "id = ?", userID)
+	if err := NotifyTeam(tx, team.ID, userID, "appeal_filed", map[string]string{
+		"member": owner.Name,
+		"date":   activity.StartedAt.In(TeamLocation(team)).Format("2006-01-02"),
+		"hours":  strconv.Itoa(int(period.Hours())),
+	}); err != nil {
+		return nil, err
+	}
+	if err := EmitTeamEvent(tx, team.ID, "appeal.filed", map[string]interface{}{
+		"appeal_id":    appeal.ID,
+		"activity_id":  activity.ID,
+		"user_id":      userID,
+		"week_number":  appeal.WeekNumber,
+		"explanation":  appeal.Explanation,
+		"evidence_url": appeal.EvidenceURL,
+		"expires_at":   appeal.ExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+	return &appeal, nil
+}
+
+// Vote 申し立てに投票する。approve はアクティビティを認めるか。投票し直すと上書きし、必要数に届けばその場で決着する
+func (s *AppealService) Vote(tx *gorm.DB, team models.Team, appeal *models.ActivityAppeal, userID string, approve bool, comment string, now time.Time) error {
+	if appeal.UserID == userID {
+		return ErrVoteOwnAppeal
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(appeal, "id = ?", appeal.ID).Error; err != nil {
+		return fmt.Errorf("failed to lock appeal: %w", err)
+	}
+	if appeal.Status != "open" || !now.Before(appeal.ExpiresAt) {
+		return ErrAppealClosed
+	}
+	var member models.TeamMember
+	if err := tx.First(&member, "team_id = ? AND user_id = ?", team.ID, userID).Error; err != nil {
+		return ErrMemberNotFound
+	}
+
+	vote := models.AppealVote{
+		ID:       utils.GenerateULID(),
+		AppealID: appeal.ID,
+		UserID:   userID,
+		Approve:  approve,
+		Comment:  strings.TrimSpace(comment),
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "appeal_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"approve": approve, "comment": vote.Comment, "updated_at": now}),
+	}).Create(&vote).Error; err != nil {
+		return fmt.Errorf("failed to save appeal vote: %w", err)
+	}
+
+	return resolveAppeal(tx, team, appeal, now, false)
+}
+
+// AppealExpiryResult 期限切れの申し立ての締め切り結果
+type AppealExpiryResult struct {
+	Resolved   int `json:"resolved"`
+	Overturned int `json:"overturned"`
+	Upheld     int `json:"upheld"`
+}
+
+// ExpireAppeals 投票期限を過ぎた申し立てを締め切る。承認が必要数に届いていなければ否認のままにする
+func (s *AppealService) ExpireAppeals() (*AppealExpiryResult, error) {
+	now := time.Now()
+	var appeals []models.ActivityAppeal
+	if err := s.db.Where("status = ? AND expires_at <= ?", "open", now).Order("created_at ASC").Find(&appeals).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch expired appeals: %w", err)
+	}
+
+	result := &AppealExpiryResult{}
+	for i := range appeals {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var team models.Team
+			if err := tx.First(&team, "id = ?", appeals[i].TeamID).Error; err != nil {
+				return fmt.Errorf("failed to fetch team: %w", err)
+			}
+			return resolveAppeal(tx, team, &appeals[i], now, true)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve appeal %s: %w", appeals[i].ID, err)
+		}
+		result.Resolved++
+		switch appeals[i].Status {
+		case "overturned":
+			result.Overturned++
+		case "upheld":
+			result.Upheld++
+		}
+	}
+	return result, nil
+}
+
+// resolveAppeal 集計が必要数に届いていれば決着させる。expired のときは届いていなくても否認のままで締め切る
+// 承認と否認の両方が届いたときは、異議を認める側を優先する
+func resolveAppeal(tx *gorm.DB, team models.Team, appeal *models.ActivityAppeal, now time.Time, expired bool) error {
+	if appeal.Status != "open" {
+		return nil
+	}
+	tally, err := TallyAppeal(tx, team, *appeal)
+	if err != nil {
+		return err
+	}
+
+	var adjustment *models.EvaluationAdjustment
+	switch {
+	case tally.Eligible > 0 && tally.Approvals >= tally.Quorum:
+		adjustment, err = overturnRejection(tx, team, appeal)
+		if err != nil {
+			return err
+		}
+		if err := closeAppeal(tx, appeal, "overturned", "quorum", adjustment != nil, now); err != nil {
+			return err
+		}
+	case tally.Eligible > 0 && tally.Rejections >= tally.Quorum:
+		if err := closeAppeal(tx, appeal, "upheld", "quorum", false, now); err != nil {
+			return err
+		}
+	case expired:
+		if err := closeAppeal(tx, appeal, "upheld", "expired", false, now); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	resultJa, resultEn := "認められず、否認のままとなりました", "the rejection stands"
+	if appeal.Status == "overturned" {
+		resultJa, resultEn = "認められました", "your activity now counts"
+		if adjustment != nil {
+			resultJa += "。評価済みの週を評価し直しました"
+			resultEn += " and the evaluated week was recalculated"
+		}
+	}
+	var activity models.Activity
+	if err := tx.Select("started_at").First(&activity, "id = ?", appeal.ActivityID).Error; err != nil {
+		return fmt.Errorf("failed to fetch activity: %w", err)
+	}
+	loc := utils.LoadLocation(UserTimezone(tx, appeal.UserID))
+	if err := Notify(tx, appeal.UserID, "appeal_resolved", map[string]string{
+		"date":      activity.StartedAt.In(loc).Format("2006-01-02"),
+		"result_ja": resultJa,
+		"result_en": resultEn,
+	}, &appeal.TeamID); err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"appeal_id":   appeal.ID,
+		"activity_id": appeal.ActivityID,
+		"user_id":     appeal.UserID,
+		"week_number": appeal.WeekNumber,
+		"status":      appeal.Status,
+		"resolution":  appeal.Resolution,
+		"approvals":   tally.Approvals,
+		"rejections":  tally.Rejections,
+		"quorum":      tally.Quorum,
+	}
+	if adjustment != nil {
+		payload["adjustment"] = map[string]interface{}{
+			"target_met_before": adjustment.TargetMetBefore,
+			"target_met_after":  adjustment.TargetMetAfter,
+			"all_met_after":     adjustment.AllMetAfter,
+			"team_hp_before":    adjustment.TeamHPBefore,
+			"team_hp_after":     adjustment.TeamHPAfter,
+			"team_status_after": adjustment.TeamStatusAfter,
+		}
+	}
+	return EmitTeamEvent(tx, team.ID, "appeal.resolved", payload)
+}
+
+// closeAppeal 申し立てを決着させる
+func closeAppeal(tx *gorm.DB, appeal *models.ActivityAppeal, status, resolution string, recalculated bool, now time.Time) error {
+	appeal.Status = status
+	appeal.Resolution = resolution
+	appeal.Recalculated = recalculated
+	appeal.ResolvedAt = &now
+	if err := tx.Model(appeal).Updates(map[string]interface{}{
+		"status":       status,
+		"resolution":   resolution,
+		"recalculated": recalculated,
+		"resolved_at":  now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to close appeal: %w", err)
+	}
+	return nil
+}
+
+// overturnRejection 否認を取り消してアクティビティを承認にし、確定済みのレビュー結果も書き換える
+// 週が評価済みなら評価し直し、その記録を返す
+func overturnRejection(tx *gorm.DB, team models.Team, appeal *models.ActivityAppeal) (*models.EvaluationAdjustment, error) {
+	if err := tx.Model(&models.Activity{}).Where("id = ?", appeal.ActivityID).
+		Update("review_status", "approved").Error; err != nil {
+		return nil, fmt.Errorf("failed to update review status: %w", err)
+	}
+	if err := tx.Model(&models.ReviewOutcome{}).Where("activity_id = ?", appeal.ActivityID).
+		Updates(map[string]interface{}{"outcome": "approved", "resolution": "appeal"}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update review outcome: %w", err)
+	}
+	return recalculateMemberWeek(tx, team, appeal)
+}
+
+// recalculateMemberWeek 申し立てが通ったメンバーの週を、評価時の目標と目標倍率で評価し直す
+// 未達成が達成に変わればペナルティを取り消し、全員達成になればボーナスも加える。チームが活動中ならHPに反映してHPEventを残す
+// 週次評価でシーズンが終わったチームもHPに反映する。HPが尽きて解散したチームのHPが戻れば reviveTeam でチャレンジを再開する
+// 投票や人数不足で解散したチームは評価の記録だけを直し、HPは変えない。目標倍率は、翌週がまだ評価されていない場合だけ計算し直す
+func recalculateMemberWeek(tx *gorm.DB, team models.Team, appeal *models.ActivityAppeal) (*models.EvaluationAdjustment, error) {
+	var eval models.WeeklyEvaluation
+	err := tx.Where("team_id = ? AND user_id = ? AND week_number = ?", team.ID, appeal.UserID, appeal.WeekNumber).First(&eval).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && eval.Frozen) {
+		// まだ評価されていない週は、週次評価で承認済みとして数えられる
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch evaluation: %w", err)
+	}
+
+	// 途中のHP変動を取りこぼさないよう最新のチームをロックして読み直す
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&team, "id = ?", team.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock team: %w", err)
+	}
+
+	start, end, ok := WeekWindow(team, appeal.WeekNumber)
+	if !ok {
+		return nil, nil
+	}
+	goal, _, err := GoalForWeek(tx, team.ID, appeal.WeekNumber)
+	if err != nil {
+		return nil, fmt.Errorf("goal not found: %w", err)
+	}
+	memberGoal, _, err := MemberGoalForWeek(tx, goal, appeal.UserID, appeal.WeekNumber)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := memberWeekTotals(tx, team.ID, appeal.UserID, memberGoal, start, end)
+	if err != nil {
+		return nil, err
+	}
+	multiplier := eval.TargetMultiplier
+	if multiplier <= 0 {
+		multiplier = 1.0
+	}
+	// 承認されたアクティビティが増えるだけなので、達成が未達成に変わることはない
+	targetMet := eval.TargetMet || weekTargetMet(team.ExerciseType, memberGoal, totals, multiplier)
+
+	var evals []models.WeeklyEvaluation
+	if err := tx.Where("team_id = ? AND week_number = ? AND frozen = ?", team.ID, appeal.WeekNumber, false).
+		Find(&evals).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch evaluations: %w", err)
+	}
+	allMetBefore, allMetAfter := len(evals) > 0, len(evals) > 0
+	for _, e := range evals {
+		if !e.TargetMet {
+			allMetBefore = false
+		}
+		if !e.TargetMet && e.ID != eval.ID {
+			allMetAfter = false
+		}
+	}
+	allMetAfter = allMetAfter && targetMet
+
+	// 評価時のHP変動から全員達成ボーナスを除いた分がペナルティ
+	base := eval.HPChange
+	if allMetBefore {
+		base -= AllMetBonus
+	}
+	if targetMet && !eval.TargetMet {
+		base = 0
+	}
+	hpChangeAfter := base
+	if allMetAfter {
+		hpChangeAfter += AllMetBonus
+	}
+	teamDelta := hpChangeAfter - eval.HPChange
+	if allMetAfter && !allMetBefore {
+		// 本人以外の評価対象メンバーにもボーナスが付く
+		teamDelta += AllMetBonus * (len(evals) - 1)
+	}
+
+	adjustment := models.EvaluationAdjustment{
+		ID:               utils.GenerateULID(),
+		TeamID:           team.ID,
+		WeekNumber:       appeal.WeekNumber,
+		UserID:           appeal.UserID,
+		EvaluationID:     eval.ID,
+		Reason:           "appeal_overturned",
+		AppealID:         &appeal.ID,
+		ActivityID:       &appeal.ActivityID,
+		TargetMetBefore:  eval.TargetMet,
+		TargetMetAfter:   targetMet,
+		DistanceBefore:   eval.TotalDistanceKM,
+		DistanceAfter:    totals.DistanceKM,
+		VisitsBefore:     eval.TotalVisits,
+		VisitsAfter:      totals.Visits,
+		DurationBefore:   eval.TotalDurationMin,
+		DurationAfter:    totals.DurationMin,
+		HPChangeBefore:   eval.HPChange,
+		HPChangeAfter:    hpChangeAfter,
+		AllMetBefore:     allMetBefore,
+		AllMetAfter:      allMetAfter,
+		TeamHPBefore:     team.CurrentHP,
+		TeamHPAfter:      team.CurrentHP,
+		TeamStatusBefore: team.Status,
+		TeamStatusAfter:  team.Status,
+	}
+
+	if err := tx.Model(&eval).Updates(map[string]interface{}{
+		"target_met":         targetMet,
+		"total_distance_km":  totals.DistanceKM,
+		"total_visits":       totals.Visits,
+		"total_duration_min": totals.DurationMin,
+		"hp_change":          hpChangeAfter,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update evaluation: %w", err)
+	}
+	if allMetAfter && !allMetBefore {
+		if err := tx.Model(&models.WeeklyEvaluation{}).
+			Where("team_id = ? AND week_number = ? AND frozen = ? AND id <> ?", team.ID, appeal.WeekNumber, false, eval.ID).
+			Update("hp_change", gorm.Expr("hp_change + ?", AllMetBonus)).Error; err != nil {
+			return nil, fmt.Errorf("failed to add all-met bonus: %w", err)
+		}
+	}
+
+	newHP := team.CurrentHP + teamDelta
+	if newHP > team.MaxHP {
+		newHP = team.MaxHP
+	}
+	if newHP < 0 {
+		newHP = 0
+	}
+	applyHP := team.Status == "active"
+	revive := false
+	if !applyHP && newHP != team.CurrentHP {
+		endedWeek, endedByEvaluation, err := seasonEndedByEvaluation(tx, team)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case endedByEvaluation && team.Status == "completed":
+			applyHP = true
+		case endedByEvaluation && team.Status == "disbanded" && newHP > 0:
+			// 別のチームへ移ったメンバーがいるか、メンバーが最少人数に満たなければ再開できないため、記録だけを直す
+			revive, err = canReviveTeam(tx, team)
+			if err != nil {
+				return nil, err
+			}
+			applyHP = revive
+		}
+		if revive {
+			status, err := reviveTeam(tx, team, endedWeek, newHP)
+			if err != nil {
+				return nil, err
+			}
+			adjustment.TeamStatusAfter = status
+		}
+	}
+	if applyHP {
+		adjustment.TeamHPAfter = newHP
+		if newHP != team.CurrentHP {
+			if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Update("current_hp", newHP).Error; err != nil {
+				return nil, fmt.Errorf("failed to update team hp: %w", err)
+			}
+			event := models.HPEvent{
+				ID:         utils.GenerateULID(),
+				TeamID:     team.ID,
+				UserID:     appeal.UserID,
+				Reason:     "appeal_overturned",
+				HPChange:   newHP - team.CurrentHP,
+				HPAfter:    newHP,
+				WeekNumber: appeal.WeekNumber,
+			}
+			if err := tx.Create(&event).Error; err != nil {
+				return nil, fmt.Errorf("failed to record hp event: %w", err)
+			}
+			if err := EmitTeamEvent(tx, team.ID, "hp.changed", map[string]interface{}{
+				"reason":      event.Reason,
+				"user_id":     appeal.UserID,
+				"week_number": appeal.WeekNumber,
+				"hp_before":   team.CurrentHP,
+				"hp_after":    newHP,
+				"hp_change":   event.HPChange,
+				"max_hp":      team.MaxHP,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if team.Status == "completed" && applyHP {
+		if err := tx.Model(&models.SeasonSummary{}).Where("team_id = ?", team.ID).Update("final_hp", newHP).Error; err != nil {
+			return nil, fmt.Errorf("failed to update season summary: %w", err)
+		}
+	}
+	if err := tx.Create(&adjustment).Error; err != nil {
+		return nil, fmt.Errorf("failed to record evaluation adjustment: %w", err)
+	}
+	team.Status = adjustment.TeamStatusAfter
+
+	// 翌週がまだ評価されていなければ、評価し直した結果で翌週の目標倍率を計算し直す
+	if team.Status == "active" && appeal.WeekNumber == team.CurrentWeek-1 && adjustment.TargetMetAfter != adjustment.TargetMetBefore {
+		var memberIDs []string
+		if err := tx.Model(&models.TeamMember{}).Where("team_id = ?", team.ID).Pluck("user_id", &memberIDs).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch members: %w", err)
+		}
+		var weekEvals []models.WeeklyEvaluation
+		tx.Where("team_id = ? AND week_number = ? AND user_id IN ?", team.ID, appeal.WeekNumber, memberIDs).Find(&weekEvals)
+		if err := updateTargetMultipliers(tx, team.ID, weekEvals); err != nil {
+			return nil, err
+		}
+	}
+	return &adjustment, nil
+}
+
+// canReviveTeam 解散したチームを再開できるか。解散後に別のチームへ参加したメンバーがいれば、1ユーザー1チームを保つため再開しない
+// メンバー登録が消えて最少人数に満たないチームも再開しない
+func canReviveTeam(tx *gorm.DB, team models.Team) (bool, error) {
+	var members int64
+	if err := tx.Model(&models.TeamMember{}).Where("team_id = ?", team.ID).Count(&members).Error; err != nil {
+		return false, fmt.Errorf("failed to count members: %w", err)
+	}
+	if int(members) < team.MinMembers {
+		return false, nil
+	}
+
+	var moved int64
+	if err := tx.Model(&models.TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id").
+		Where("team_members.user_id IN (?) AND team_members.team_id <> ? AND teams.status IN ?",
+			tx.Model(&models.TeamMember{}).Select("user_id").Where("team_id = ?", team.ID),
+			team.ID, []string{"forming", "active"}).
+		Count(&moved).Error; err != nil {
+		return false, fmt.Errorf("failed to check members: %w", err)
+	}
+	return moved == 0, nil
+}
+
+// reviveTeam HPが尽きて解散したチームを、評価し直してHPが戻ったときに再開し、再開後のステータスを返す
+// 解散中に始まった週は resumeWeek で飛ばし、その先の週から評価する。解散した週が最終週か、再開できる週が期間内に
+// 残っていなければ完走として終え直す。シーズンサマリーは書き直し、解散時に取り下げた提案は元に戻さない
+func reviveTeam(tx *gorm.DB, team models.Team, endedWeek, hp int) (string, error) {
+	if err := tx.Where("summary_id IN (?)", tx.Model(&models.SeasonSummary{}).Select("id").Where("team_id = ?", team.ID)).
+		Delete(&models.SeasonMemberStat{}).Error; err != nil {
+		return "", fmt.Errorf("failed to delete season member stats: %w", err)
+	}
+	if err := tx.Where("team_id = ?", team.ID).Delete(&models.SeasonSummary{}).Error; err != nil {
+		return "", fmt.Errorf("failed to delete season summary: %w", err)
+	}
+
+	status := "active"
+	resume := resumeWeek(team, endedWeek, time.Now())
+	updates := map[string]interface{}{
+		"status":       status,
+		"current_hp":   hp,
+		"current_week": resume,
+		"ended_at":     nil,
+	}
+	final := team.ChallengeWeeks > 0 && resume > team.ChallengeWeeks
+	if final {
+		// 完走したチームと同じく最後に評価した週を current_week に残す
+		status = "completed"
+		updates["current_week"] = endedWeek
+	}
+	if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(updates).Error; err != nil {
+		return "", fmt.Errorf("failed to revive team: %w", err)
+	}
+	if final {
+		if err := FinishSeason(tx, team.ID, "completed"); err != nil {
+			return "", err
+		}
+	}
+
+	statusJa := fmt.Sprintf("第%d週からチャレンジを再開します", resume)
+	statusEn := fmt.Sprintf("The challenge resumes from week %d.", resume)
+	if final {
+		statusJa, statusEn = "チャレンジを完走しました", "The challenge is complete."
+	}
+	if err := NotifyTeam(tx, team.ID, "", "team_revived", map[string]string{
+		"team":      team.Name,
+		"week":      strconv.Itoa(endedWeek),
+		"hp":        strconv.Itoa(hp),
+		"status_ja": statusJa,
+		"status_en": statusEn,
+	}); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// resumeWeek 解散したチームを now に再開したとき、次に評価する週
+// 解散中のアクティビティはチームに紐付いていないため、翌日0時（ChallengeStartAt）より前に始まった週は評価せずに飛ばす
+func resumeWeek(team models.Team, endedWeek int, now time.Time) int {
+	resumeAt := ChallengeStartAt(team, now)
+	week := endedWeek + 1
+	for {
+		start, _, ok := WeekWindow(team, week)
+		if !ok || !start.Before(resumeAt) {
+			return week
+		}
+		week++
+	}
+}
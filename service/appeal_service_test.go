@@ -0,0 +1,178 @@
+package service
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/trihackathon/api/models"
+)
+
+func TestResumeWeek(t *testing.T) {
+	tests := []struct {
+		name      string
+		team      models.Team
+		endedWeek int
+		now       time.Time
+		want      int
+	}{
+		{
+			// 第3週（3/16〜）の途中で再開。翌日0時より前に始まった第3週は飛ばす
+			name:      "skips the week in progress",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 2, 0, 0)),
+			endedWeek: 2,
+			now:       nyTime(t, 2026, 3, 20, 10, 0),
+			want:      4,
+		},
+		{
+			// 翌日0時にちょうど第4週が始まる
+			name:      "week starting tomorrow",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 2, 0, 0)),
+			endedWeek: 2,
+			now:       nyTime(t, 2026, 3, 22, 23, 0),
+			want:      4,
+		},
+		{
+			name:      "skips every week that passed while disbanded",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 2, 0, 0)),
+			endedWeek: 2,
+			now:       nyTime(t, 2026, 3, 23, 1, 0),
+			want:      5,
+		},
+		{
+			// 水曜開始。第2週は 3/9（月）〜、第3週は 3/16（月）〜
+			name:      "monday policy resumes on the next monday",
+			team:      startedTeam(newYork, "monday", nyTime(t, 2026, 3, 4, 0, 0)),
+			endedWeek: 2,
+			now:       nyTime(t, 2026, 3, 18, 9, 0),
+			want:      4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeWeek(tt.team, tt.endedWeek, tt.now); got != tt.want {
+				t.Errorf("resumeWeek() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanReviveTeam(t *testing.T) {
+	tests := []struct {
+		name    string
+		members int64
+		moved   int64
+		want    bool
+	}{
+		{"all members still here", 3, 0, true},
+		{"below min members", 2, 0, false},
+		{"member joined another team", 3, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeGorm(t,
+				fakeRule{
+					Contains: `FROM "team_members" JOIN teams`,
+					Columns:  []string{"count"},
+					Rows:     [][]driver.Value{{tt.moved}},
+				},
+				fakeRule{
+					Contains: `FROM "team_members"`,
+					Columns:  []string{"count"},
+					Rows:     [][]driver.Value{{tt.members}},
+				},
+			)
+			got, err := canReviveTeam(db, models.Team{ID: "team-1", MinMembers: 3})
+			if err != nil {
+				t.Fatalf("canReviveTeam() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("canReviveTeam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviveTeamSkipsWeeksWhileDisbanded(t *testing.T) {
+	team := models.Team{
+		ID:             "team-1",
+		Name:           "朝ランチーム",
+		Status:         "disbanded",
+		ExerciseType:   "running",
+		ChallengeWeeks: 8,
+		CurrentWeek:    3,
+		Timezone:       newYork,
+		StartPolicy:    "midnight",
+	}
+	// 第2週の評価で解散し、第3週が終わって第4週の途中で再開する
+	startedAt := ChallengeStartAt(team, time.Now()).AddDate(0, 0, -24)
+	team.StartedAt = &startedAt
+
+	db, fake := newFakeGorm(t,
+		fakeRule{
+			Contains: `FROM "goals"`,
+			Columns:  []string{"id", "team_id", "target_distance_km"},
+			Rows:     [][]driver.Value{{"goal-1", "team-1", 10.0}},
+		},
+	)
+
+	status, err := reviveTeam(db, team, 2, 30)
+	if err != nil {
+		t.Fatalf("reviveTeam() error = %v", err)
+	}
+	if status != "active" {
+		t.Fatalf("status = %s, want active", status)
+	}
+	updates := fake.Executed(`UPDATE "teams"`)
+	if len(updates) != 1 || !hasArg(updates[0], "active") || !hasArg(updates[0], int64(5)) {
+		t.Fatalf("team updates = %+v, want active from week 5", updates)
+	}
+
+	// 解散中に過ぎた週は評価せず、第5週が終わるまで評価しない
+	team.Status = "active"
+	team.CurrentHP = 30
+	team.CurrentWeek = 5
+	if err := NewEvaluationService(db).evaluateTeam(team); err != nil {
+		t.Fatalf("evaluateTeam() error = %v", err)
+	}
+	if n := len(fake.Executed(`weekly_evaluations`)); n != 0 {
+		t.Errorf("weekly evaluation queries = %d, want 0 before the resumed week ends", n)
+	}
+	if n := len(fake.Executed(`UPDATE "teams"`)); n != 1 {
+		t.Errorf("team updates = %d, want only the revive", n)
+	}
+}
+
+func TestReviveTeamCompletesWhenNoWeekIsLeft(t *testing.T) {
+	team := models.Team{
+		ID:             "team-1",
+		Name:           "朝ランチーム",
+		Status:         "disbanded",
+		ChallengeWeeks: 4,
+		CurrentWeek:    4,
+		Timezone:       newYork,
+		StartPolicy:    "midnight",
+	}
+	// 第3週の評価で解散し、第4週の途中で再開する
+	startedAt := ChallengeStartAt(team, time.Now()).AddDate(0, 0, -24)
+	team.StartedAt = &startedAt
+
+	db, fake := newFakeGorm(t,
+		fakeRule{
+			Contains: `SELECT * FROM "teams"`,
+			Columns:  []string{"id", "name", "status", "challenge_weeks", "current_week", "current_hp"},
+			Rows:     [][]driver.Value{{"team-1", "朝ランチーム", "completed", int64(4), int64(3), int64(20)}},
+		},
+	)
+	status, err := reviveTeam(db, team, 3, 20)
+	if err != nil {
+		t.Fatalf("reviveTeam() error = %v", err)
+	}
+	if status != "completed" {
+		t.Fatalf("status = %s, want completed", status)
+	}
+	updates := fake.Executed(`UPDATE "teams"`)
+	if len(updates) == 0 || !hasArg(updates[0], int64(3)) {
+		t.Errorf("team updates = %+v, want current_week kept at the evaluated week 3", updates)
+	}
+}
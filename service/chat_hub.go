@@ -0,0 +1,76 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/trihackathon/api/models"
+)
+
+// chatSubscriberBuffer 購読者ごとに溜めておけるイベント数。溢れた購読者は切断し、再接続時に Last-Event-ID から取り直させる
+const chatSubscriberBuffer = 32
+
+// ChatEvent チャットのリアルタイム配信イベント
+type ChatEvent struct {
+	Type    string // message.created / message.deleted
+	Message models.ChatMessage
+}
+
+// ChatHub チームごとのチャット購読者にイベントを配る。プロセス内のみで共有するため、複数台構成では同じ台に接続した購読者にしか届かない
+type ChatHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan ChatEvent]struct{}
+}
+
+func NewChatHub() *ChatHub {
+	return &ChatHub{subs: map[string]map[chan ChatEvent]struct{}{}}
+}
+
+// Subscribe チームのイベントを購読する。返り値の関数で購読をやめる。配信が追いつかないとチャネルが閉じられる
+func (h *ChatHub) Subscribe(teamID string) (<-chan ChatEvent, func()) {
+	ch := make(chan ChatEvent, chatSubscriberBuffer)
+	h.mu.Lock()
+	if h.subs[teamID] == nil {
+		h.subs[teamID] = map[chan ChatEvent]struct{}{}
+	}
+	h.subs[teamID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(teamID, ch)
+	}
+}
+
+// Publish チームの購読者全員にイベントを送る。送れなかった購読者は切断する
+func (h *ChatHub) Publish(teamID string, event ChatEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[teamID] {
+		select {
+		case ch <- event:
+		default:
+			h.remove(teamID, ch)
+		}
+	}
+}
+
+// Subscribers チームの購読者数
+func (h *ChatHub) Subscribers(teamID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[teamID])
+}
+
+// remove 購読者を外してチャネルを閉じる。h.mu を取った状態で呼ぶ
+func (h *ChatHub) remove(teamID string, ch chan ChatEvent) {
+	subs := h.subs[teamID]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(h.subs, teamID)
+	}
+}
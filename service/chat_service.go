@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxChatMessageLength チャットメッセージの最大文字数
+	MaxChatMessageLength = 1000
+	// DefaultChatPageSize 履歴を1回に返す件数の既定値
+	DefaultChatPageSize = 50
+	// MaxChatPageSize 履歴を1回に返す件数の上限
+	MaxChatPageSize = 200
+	// chatRateWindow 投稿数を数える期間
+	chatRateWindow = time.Minute
+)
+
+var (
+	ErrInvalidChatMessage  = errors.New("invalid chat message")
+	ErrChatClosed          = errors.New("team chat is closed")
+	ErrChatMessageNotFound = errors.New("chat message not found")
+	ErrChatMessageDeleted  = errors.New("chat message already deleted")
+	ErrChatDeleteForbidden = errors.New("only the author or the leader can delete a message")
+)
+
+// ChatRejectedError モデレーションで投稿が拒否された
+type ChatRejectedError struct {
+	Moderator string
+	Reason    string
+}
+
+func (e *ChatRejectedError) Error() string {
+	return fmt.Sprintf("chat message rejected by %s: %s", e.Moderator, e.Reason)
+}
+
+// ModerationVerdict モデレーターの判定
+type ModerationVerdict struct {
+	Action string // allow / flag / reject
+	Reason string
+}
+
+// ChatModerator 保存前のメッセージを検査するフック。reject で投稿を拒否し、flag で要確認の印を付けて保存する
+type ChatModerator interface {
+	Name() string
+	Moderate(tx *gorm.DB, msg *models.ChatMessage) (ModerationVerdict, error)
+}
+
+// BlockedWordModerator CHAT_BLOCKED_WORDS（カンマ区切り）の語を含むメッセージを拒否する
+type BlockedWordModerator struct {
+	words []string
+}
+
+func NewBlockedWordModerator() *BlockedWordModerator {
+	var words []string
+	for _, w := range strings.Split(os.Getenv("CHAT_BLOCKED_WORDS"), ",") {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			words = append(words, w)
+		}
+	}
+	return &BlockedWordModerator{words: words}
+}
+
+func (m *BlockedWordModerator) Name() string { return "blocked_words" }
+
+func (m *BlockedWordModerator) Moderate(tx *gorm.DB, msg *models.ChatMessage) (ModerationVerdict, error) {
+	body := strings.ToLower(msg.Body)
+	for _, w := range m.words {
+		if strings.Contains(body, w) {
+			return ModerationVerdict{Action: "reject", Reason: "blocked_word"}, nil
+		}
+	}
+	return ModerationVerdict{Action: "allow"}, nil
+}
+
+// ChatRateModerator 1分あたり CHAT_RATE_LIMIT 件（既定20件）を超える投稿を拒否する
+type ChatRateModerator struct {
+	limit int
+}
+
+func NewChatRateModerator() *ChatRateModerator {
+	return &ChatRateModerator{limit: envInt("CHAT_RATE_LIMIT", 20)}
+}
+
+func (m *ChatRateModerator) Name() string { return "rate_limit" }
+
+func (m *ChatRateModerator) Moderate(tx *gorm.DB, msg *models.ChatMessage) (ModerationVerdict, error) {
+	var recent int64
+	if err := tx.Model(&models.ChatMessage{}).
+		Where("user_id = ? AND created_at > ?", msg.UserID, time.Now().Add(-chatRateWindow)).
+		Count(&recent).Error; err != nil {
+		return ModerationVerdict{}, fmt.Errorf("failed to count recent messages: %w", err)
+	}
+	if int(recent) >= m.limit {
+		return ModerationVerdict{Action: "reject", Reason: "rate_limited"}, nil
+	}
+	return ModerationVerdict{Action: "allow"}, nil
+}
+
+// ChatRepeatModerator 同じ文字を長く繰り返したメッセージを要確認にする
+type ChatRepeatModerator struct {
+	maxRun int
+}
+
+func NewChatRepeatModerator() *ChatRepeatModerator {
+	return &ChatRepeatModerator{maxRun: 30}
+}
+
+func (m *ChatRepeatModerator) Name() string { return "repeated_characters" }
+
+func (m *ChatRepeatModerator) Moderate(tx *gorm.DB, msg *models.ChatMessage) (ModerationVerdict, error) {
+	var prev rune
+	run := 0
+	for _, r := range msg.Body {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run > m.maxRun {
+			return ModerationVerdict{Action: "flag", Reason: "repeated_characters"}, nil
+		}
+	}
+	return ModerationVerdict{Action: "allow"}, nil
+}
+
+type ChatService struct {
+	db         *gorm.DB
+	hub        *ChatHub
+	moderators []ChatModerator
+}
+
+// NewChatService moderators は登録順に実行し、最初の reject で打ち切る
+func NewChatService(db *gorm.DB, hub *ChatHub, moderators ...ChatModerator) *ChatService {
+	return &ChatService{db: db, hub: hub, moderators: moderators}
+}
+
+// Subscribe チームのチャットのリアルタイム配信を購読する
+func (s *ChatService) Subscribe(teamID string) (<-chan ChatEvent, func()) {
+	return s.hub.Subscribe(teamID)
+}
+
+// PostMessage メッセージを検査して保存し、購読者に配信する。チャットは forming / active のチームでのみ書き込める
+func (s *ChatService) PostMessage(team models.Team, userID, body string) (*models.ChatMessage, error) {
+	if team.Status != "forming" && team.Status != "active" {
+		return nil, ErrChatClosed
+	}
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > MaxChatMessageLength {
+		return nil, ErrInvalidChatMessage
+	}
+
+	msg := models.ChatMessage{
+		ID:     utils.GenerateULID(),
+		TeamID: team.ID,
+		UserID: userID,
+		Body:   body,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range s.moderators {
+			verdict, err := m.Moderate(tx, &msg)
+			if err != nil {
+				return err
+			}
+			switch verdict.Action {
+			case "reject":
+				return &ChatRejectedError{Moderator: m.Name(), Reason: verdict.Reason}
+			case "flag":
+				msg.Flagged = true
+				msg.FlagReason = verdict.Reason
+			}
+		}
+		if err := tx.Create(&msg).Error; err != nil {
+			return fmt.Errorf("failed to create message: %w", err)
+		}
+		return tx.First(&msg.User, "id = ?", userID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.hub.Publish(team.ID, ChatEvent{Type: "message.created", Message: msg})
+	return &msg, nil
+}
+
+// DeleteMessage 投稿者本人かリーダーがメッセージを削除し、購読者に配信する。本文は空にして行は残す
+func (s *ChatService) DeleteMessage(teamID, messageID string, actor models.TeamMember) (*models.ChatMessage, error) {
+	var msg models.ChatMessage
+	if err := s.db.Preload("User").First(&msg, "id = ? AND team_id = ?", messageID, teamID).Error; err != nil {
+		return nil, ErrChatMessageNotFound
+	}
+	if msg.DeletedAt != nil {
+		return nil, ErrChatMessageDeleted
+	}
+	if msg.UserID != actor.UserID && actor.Role != "leader" {
+		return nil, ErrChatDeleteForbidden
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&msg).Updates(map[string]interface{}{
+		"body":       "",
+		"deleted_at": now,
+		"deleted_by": actor.UserID,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete message: %w", err)
+	}
+	msg.Body = ""
+	msg.DeletedAt = &now
+	msg.DeletedBy = &actor.UserID
+
+	s.hub.Publish(teamID, ChatEvent{Type: "message.deleted", Message: msg})
+	return &msg, nil
+}
+
+// History チームのメッセージを ID 順に返す。before を指定するとそれより古い limit 件、after を指定するとそれより新しい limit 件
+// hasMore は指定した方向にまだメッセージがあるか
+func (s *ChatService) History(teamID, before, after string, limit int) (messages []models.ChatMessage, hasMore bool, err error) {
+	query := s.db.Preload("User").Where("team_id = ?", teamID)
+	if after != "" {
+		query = query.Where("id > ?", after).Order("id ASC")
+	} else {
+		if before != "" {
+			query = query.Where("id < ?", before)
+		}
+		query = query.Order("id DESC")
+	}
+	if err := query.Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	if len(messages) > limit {
+		hasMore = true
+		messages = messages[:limit]
+	}
+	// 新しい順に取った場合は古い順に並べ直す
+	if after == "" {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, hasMore, nil
+}
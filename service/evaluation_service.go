@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/trihackathon/api/models"
@@ -20,6 +21,7 @@ func NewEvaluationService(db *gorm.DB) *EvaluationService {
 type EvaluationResult struct {
 	EvaluatedTeams int `json:"evaluated_teams"`
 	DisbandedTeams int `json:"disbanded_teams"`
+	CompletedTeams int `json:"completed_teams"`
 }
 
 func (s *EvaluationService) RunWeeklyEvaluation() (*EvaluationResult, error) {
@@ -43,11 +45,14 @@ func (s *EvaluationService) RunWeeklyEvaluation() (*EvaluationResult, error) {
 		}
 		result.EvaluatedTeams++
 
-		// Check if team was disbanded
+		// Check if team was disbanded or completed
 		var updatedTeam models.Team
 		s.db.First(&updatedTeam, "id = ?", team.ID)
-		if updatedTeam.Status == "disbanded" {
+		switch updatedTeam.Status {
+		case "disbanded":
 			result.DisbandedTeams++
+		case "completed":
+			result.CompletedTeams++
 		}
 	}
 
@@ -56,18 +61,18 @@ func (s *EvaluationService) RunWeeklyEvaluation() (*EvaluationResult, error) {
 
 func (s *EvaluationService) evaluateTeam(team models.Team) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		// Get goal
-		var goal models.Goal
-		if err := tx.First(&goal, "team_id = ?", team.ID).Error; err != nil {
+		// Get the goal version in effect for this week
+		goal, goalVersion, err := GoalForWeek(tx, team.ID, team.CurrentWeek)
+		if err != nil {
 			return fmt.Errorf("goal not found: %w", err)
 		}
 
-		// Calculate week period
-		weekStart := team.StartedAt.AddDate(0, 0, (team.CurrentWeek-1)*7)
-		weekEnd := weekStart.AddDate(0, 0, 7)
+		// Calculate week period (aligned by the team's start policy)
+		weekStart, weekEnd, _ := WeekWindow(team, team.CurrentWeek)
 
-		// Only evaluate if the week has ended
-		if time.Now().Before(weekEnd) {
+		// Only evaluate once the week has ended and its review window has closed
+		now := time.Now()
+		if now.Before(weekEnd.Add(ReviewWindow(team))) {
 			return nil
 		}
 
@@ -80,6 +85,11 @@ func (s *EvaluationService) evaluateTeam(team models.Team) error {
 			return nil
 		}
 
+		// レビュー結果を確定させてから、否認されたアクティビティを除いて集計する
+		if err := SnapshotReviewOutcomes(tx, team, team.CurrentWeek, now); err != nil {
+			return err
+		}
+
 		// Get all members
 		var members []models.TeamMember
 		if err := tx.Where("team_id = ?", team.ID).Find(&members).Error; err != nil {
@@ -88,74 +98,41 @@ func (s *EvaluationService) evaluateTeam(team models.Team) error {
 
 		allMet := true
 		totalHPChange := 0
+		evaluatedMembers := 0
 
 		for _, member := range members {
-			// Get completed activities for this member in this week (exclude rejected)
-			var activities []models.Activity
-			tx.Where("user_id = ? AND team_id = ? AND status = ? AND started_at >= ? AND started_at < ? AND (review_status IS NULL OR review_status != ?)",
-				member.UserID, team.ID, "completed", weekStart, weekEnd, "rejected").
-				Find(&activities)
-
-			var totalDist float64
-			var totalVisits int
-			var totalDuration int
-			var qualifiedVisits int // 滞在時間が目標を満たした訪問回数
-
-			for _, a := range activities {
-				totalDist += a.DistanceKM
-				totalDuration += a.DurationMin
-				if a.ExerciseType == "gym" {
-					totalVisits++
-					// target_min_duration_min が設定されている場合はその時間以上の訪問のみカウント
-					if goal.TargetMinDurationMin != nil {
-						if a.DurationMin >= *goal.TargetMinDurationMin {
-							qualifiedVisits++
-						}
-					} else {
-						qualifiedVisits++
-					}
-				}
+			// フリーズした週は達成判定もHP変動もなしで記録だけ残す
+			freeze, err := FrozenWeek(tx, team.ID, member.UserID, team.CurrentWeek)
+			if err != nil {
+				return err
 			}
 
-			// 有効目標 = ベース目標 × メンバーの倍率（前週未達成時は1.5倍ペナルティ）
+			// チーム目標に個別目標を重ねた、このメンバーの目標
+			memberGoal, override, err := MemberGoalForWeek(tx, goal, member.UserID, team.CurrentWeek)
+			if err != nil {
+				return err
+			}
+
+			// 有効目標 = ベース目標 × メンバーの倍率（前週未達成時は1.5倍ペナルティ） × 短い週の按分率
 			multiplier := member.TargetMultiplier
 			if multiplier <= 0 {
 				multiplier = 1.0
 			}
+			multiplier *= TargetProration(team, member.JoinedAt, team.CurrentWeek)
 
-			// Check if target is met
-			targetMet := false
-			switch team.ExerciseType {
-			case "running":
-				if goal.TargetDistanceKM != nil {
-					effectiveTarget := *goal.TargetDistanceKM * multiplier
-					if totalDist >= effectiveTarget {
-						targetMet = true
-					}
-				}
-			case "gym":
-				// 達成条件: 目標滞在時間を満たした訪問回数が目標回数以上
-				if goal.TargetVisitsPerWeek != nil {
-					effectiveTarget := float64(*goal.TargetVisitsPerWeek) * multiplier
-					if float64(qualifiedVisits) >= effectiveTarget {
-						targetMet = true
-					}
-				}
+			totals, err := memberWeekTotals(tx, team.ID, member.UserID, memberGoal, weekStart, weekEnd)
+			if err != nil {
+				return err
 			}
+			targetMet := weekTargetMet(team.ExerciseType, memberGoal, totals, multiplier)
 
 			// Calculate HP change
 			hpChange := 0
-			if !targetMet {
-				allMet = false
-				switch team.Strictness {
-				case "relaxed":
-					hpChange = -10
-				case "normal":
-					hpChange = -15
-				case "strict":
-					hpChange = -25
-				default:
-					hpChange = -15
+			if freeze == nil {
+				evaluatedMembers++
+				if !targetMet {
+					allMet = false
+					hpChange = -HPPenalty(team.Strictness)
 				}
 			}
 
@@ -168,55 +145,50 @@ func (s *EvaluationService) evaluateTeam(team models.Team) error {
 				UserID:           member.UserID,
 				WeekNumber:       team.CurrentWeek,
 				TargetMet:        targetMet,
-				TotalDistanceKM:  totalDist,
-				TotalVisits:      totalVisits,
-				TotalDurationMin: totalDuration,
+				TotalDistanceKM:  totals.DistanceKM,
+				TotalVisits:      totals.Visits,
+				TotalDurationMin: totals.DurationMin,
+				TargetMultiplier: multiplier,
 				HPChange:         hpChange,
+				GoalVersion:      goal.Version,
 				EvaluatedAt:      time.Now(),
 			}
+			if goalVersion != nil {
+				eval.GoalVersionID = &goalVersion.ID
+			}
+			if override != nil {
+				eval.MemberGoalID = &override.ID
+			}
+			if freeze != nil {
+				eval.TargetMet = false
+				eval.Frozen = true
+				eval.FreezeID = &freeze.ID
+			}
 			if err := tx.Create(&eval).Error; err != nil {
 				return fmt.Errorf("failed to create evaluation: %w", err)
 			}
+			if freeze != nil {
+				if err := tx.Model(freeze).Update("status", "used").Error; err != nil {
+					return fmt.Errorf("failed to update freeze: %w", err)
+				}
+			}
 
 		}
 
-		// 翌週の目標倍率: 全員達成→全員1.0 / 全員未達成→全員1.5倍 / 一部未達成→達成者のみ1.5倍
-		if allMet {
-			if err := tx.Model(&models.TeamMember{}).
-				Where("team_id = ?", team.ID).
-				Update("target_multiplier", 1.0).Error; err != nil {
-				return fmt.Errorf("failed to reset member target multiplier: %w", err)
-			}
-		} else {
-			var evals []models.WeeklyEvaluation
-			tx.Where("team_id = ? AND week_number = ?", team.ID, team.CurrentWeek).Find(&evals)
-			anyMet := false
-			for _, e := range evals {
-				if e.TargetMet {
-					anyMet = true
-					break
-				}
-			}
-			for _, e := range evals {
-				nextMultiplier := 1.5
-				if anyMet && !e.TargetMet {
-					nextMultiplier = 1.0 // 一部未達成で自分は未達成
-				}
-				if err := tx.Model(&models.TeamMember{}).
-					Where("team_id = ? AND user_id = ?", team.ID, e.UserID).
-					Update("target_multiplier", nextMultiplier).Error; err != nil {
-					return fmt.Errorf("failed to update member target multiplier: %w", err)
-				}
-			}
+		// 翌週の目標倍率を更新
+		var evals []models.WeeklyEvaluation
+		tx.Where("team_id = ? AND week_number = ?", team.ID, team.CurrentWeek).Find(&evals)
+		if err := updateTargetMultipliers(tx, team.ID, evals); err != nil {
+			return err
 		}
 
-		// All members met bonus: +5 per member
-		if allMet && len(members) > 0 {
-			bonus := 5
-			totalHPChange += bonus * len(members)
+		// All members met bonus: +5 per evaluated member (frozen members are excluded)
+		if allMet && evaluatedMembers > 0 {
+			bonus := AllMetBonus
+			totalHPChange += bonus * evaluatedMembers
 			// Update each evaluation record with the bonus
 			tx.Model(&models.WeeklyEvaluation{}).
-				Where("team_id = ? AND week_number = ?", team.ID, team.CurrentWeek).
+				Where("team_id = ? AND week_number = ? AND frozen = ?", team.ID, team.CurrentWeek, false).
 				Update("hp_change", gorm.Expr("hp_change + ?", bonus))
 		}
 
@@ -234,15 +206,194 @@ func (s *EvaluationService) evaluateTeam(team models.Team) error {
 			"current_week": team.CurrentWeek + 1,
 		}
 
-		// Disband if HP <= 0
-		if newHP <= 0 {
-			updates["status"] = "disbanded"
+		// The final week stays as current_week once the season is completed
+		completed := newHP > 0 && team.ChallengeWeeks > 0 && team.CurrentWeek >= team.ChallengeWeeks
+		if completed {
+			delete(updates, "current_week")
 		}
 
 		if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(updates).Error; err != nil {
 			return fmt.Errorf("failed to update team: %w", err)
 		}
 
+		if err := emitEvaluationEvents(tx, team, evals, allMet, newHP); err != nil {
+			return err
+		}
+
+		// HPが減った・尽きたことをメンバーに知らせる
+		week := strconv.Itoa(team.CurrentWeek)
+		switch {
+		case newHP <= 0:
+			if err := NotifyTeam(tx, team.ID, "", "team_disbanded", map[string]string{
+				"team": team.Name,
+				"week": week,
+			}); err != nil {
+				return err
+			}
+		case newHP < team.CurrentHP:
+			if err := NotifyTeam(tx, team.ID, "", "hp_lost", map[string]string{
+				"team":   team.Name,
+				"week":   week,
+				"damage": strconv.Itoa(team.CurrentHP - newHP),
+				"hp":     strconv.Itoa(newHP),
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Disband if HP <= 0, complete the season after the final week
+		if newHP <= 0 {
+			return FinishSeason(tx, team.ID, "disbanded")
+		}
+		if completed {
+			return FinishSeason(tx, team.ID, "completed")
+		}
+
+		// 可決済みの設定変更を翌週から反映
+		return applyPassedSettings(tx, team.ID, team.CurrentWeek+1)
+	})
+}
+
+// weekTotals メンバーの1週間のアクティビティの合計
+type weekTotals struct {
+	DistanceKM      float64
+	Visits          int
+	DurationMin     int
+	QualifiedVisits int // 滞在時間が目標を満たした訪問回数
+}
+
+// memberWeekTotals [start, end) に完了したアクティビティを、否認されたものを除いて合計する
+func memberWeekTotals(tx *gorm.DB, teamID, userID string, memberGoal models.Goal, start, end time.Time) (weekTotals, error) {
+	var activities []models.Activity
+	if err := tx.Where("user_id = ? AND team_id = ? AND status = ? AND started_at >= ? AND started_at < ? AND (review_status IS NULL OR review_status != ?)",
+		userID, teamID, "completed", start, end, "rejected").
+		Find(&activities).Error; err != nil {
+		return weekTotals{}, fmt.Errorf("failed to fetch activities: %w", err)
+	}
+
+	var totals weekTotals
+	for _, a := range activities {
+		totals.DistanceKM += a.DistanceKM
+		totals.DurationMin += a.DurationMin
+		if a.ExerciseType == "gym" {
+			totals.Visits++
+			// target_min_duration_min が設定されている場合はその時間以上の訪問のみカウント
+			if memberGoal.TargetMinDurationMin == nil || a.DurationMin >= *memberGoal.TargetMinDurationMin {
+				totals.QualifiedVisits++
+			}
+		}
+	}
+	return totals, nil
+}
+
+// weekTargetMet 合計が目標 × multiplier に届いたか
+func weekTargetMet(exerciseType string, memberGoal models.Goal, totals weekTotals, multiplier float64) bool {
+	switch exerciseType {
+	case "running":
+		return memberGoal.TargetDistanceKM != nil && totals.DistanceKM >= *memberGoal.TargetDistanceKM*multiplier
+	case "gym":
+		// 達成条件: 目標滞在時間を満たした訪問回数が目標回数以上
+		return memberGoal.TargetVisitsPerWeek != nil && float64(totals.QualifiedVisits) >= float64(*memberGoal.TargetVisitsPerWeek)*multiplier
+	}
+	return false
+}
+
+// emitEvaluationEvents 週次評価の結果とHPの変動をWebhookで送る
+func emitEvaluationEvents(tx *gorm.DB, team models.Team, evals []models.WeeklyEvaluation, allMet bool, newHP int) error {
+	evaluated := 0
+	for _, e := range evals {
+		if !e.Frozen {
+			evaluated++
+		}
+	}
+	bonus := allMet && evaluated > 0
+
+	members := make([]map[string]interface{}, len(evals))
+	for i, e := range evals {
+		hpChange := e.HPChange
+		if bonus && !e.Frozen {
+			hpChange += AllMetBonus
+		}
+		members[i] = map[string]interface{}{
+			"user_id":            e.UserID,
+			"target_met":         e.TargetMet,
+			"frozen":             e.Frozen,
+			"hp_change":          hpChange,
+			"total_distance_km":  e.TotalDistanceKM,
+			"total_visits":       e.TotalVisits,
+			"total_duration_min": e.TotalDurationMin,
+		}
+	}
+	if err := EmitTeamEvent(tx, team.ID, "evaluation.completed", map[string]interface{}{
+		"week_number": team.CurrentWeek,
+		"all_met":     bonus,
+		"hp_before":   team.CurrentHP,
+		"hp_after":    newHP,
+		"max_hp":      team.MaxHP,
+		"members":     members,
+	}); err != nil {
+		return err
+	}
+
+	if newHP == team.CurrentHP {
 		return nil
+	}
+	return EmitTeamEvent(tx, team.ID, "hp.changed", map[string]interface{}{
+		"reason":      "weekly_evaluation",
+		"week_number": team.CurrentWeek,
+		"hp_before":   team.CurrentHP,
+		"hp_after":    newHP,
+		"hp_change":   newHP - team.CurrentHP,
+		"max_hp":      team.MaxHP,
 	})
 }
+
+// AllMetBonus 全員が目標を達成した週に、評価対象のメンバー1人あたり回復するHP
+const AllMetBonus = 5
+
+// HPPenalty 目標未達成1回あたりのHP減少量をチームの厳しさから返す
+func HPPenalty(strictness string) int {
+	switch strictness {
+	case "relaxed":
+		return 10
+	case "strict":
+		return 25
+	default:
+		return 15
+	}
+}
+
+// updateTargetMultipliers 週次評価結果から翌週の目標倍率を設定する
+// 全員達成→全員1.0 / 全員未達成→全員1.5倍 / 一部未達成→達成者のみ1.5倍
+// フリーズしたメンバーは判定に含めず、倍率も据え置く
+func updateTargetMultipliers(tx *gorm.DB, teamID string, evals []models.WeeklyEvaluation) error {
+	var evaluated []models.WeeklyEvaluation
+	for _, e := range evals {
+		if !e.Frozen {
+			evaluated = append(evaluated, e)
+		}
+	}
+	evals = evaluated
+
+	allMet := true
+	anyMet := false
+	for _, e := range evals {
+		if e.TargetMet {
+			anyMet = true
+		} else {
+			allMet = false
+		}
+	}
+	for _, e := range evals {
+		nextMultiplier := 1.5
+		if allMet || (anyMet && !e.TargetMet) {
+			nextMultiplier = 1.0
+		}
+		if err := tx.Model(&models.TeamMember{}).
+			Where("team_id = ? AND user_id = ?", teamID, e.UserID).
+			Update("target_multiplier", nextMultiplier).Error; err != nil {
+			return fmt.Errorf("failed to update member target multiplier: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB 実行されたSQLを記録し、SQLに含まれる文字列ごとに決めた結果を返すテスト用のDB
+// 一致するルールがなければ、SELECT は0件、INSERT / UPDATE / DELETE は1件に作用したものとして扱う
+type fakeDB struct {
+	mu    sync.Mutex
+	rules []fakeRule
+	stmts []fakeStmt
+}
+
+// fakeRule SQLに Contains を含む文への応答
+type fakeRule struct {
+	Contains string
+	Columns  []string
+	Rows     [][]driver.Value
+	Affected int64
+	Err      error
+}
+
+// fakeStmt 実行されたSQLと引数
+type fakeStmt struct {
+	SQL  string
+	Args []driver.Value
+}
+
+// newFakeGorm fakeDB に繋いだ gorm.DB を返す
+func newFakeGorm(t *testing.T, rules ...fakeRule) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{rules: rules}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open fake db: %v", err)
+	}
+	return db, fake
+}
+
+// Executed SQLに contains を含む、実行された文
+func (f *fakeDB) Executed(contains string) []fakeStmt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []fakeStmt
+	for _, s := range f.stmts {
+		if strings.Contains(s.SQL, contains) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) (fakeRule, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.stmts = append(f.stmts, fakeStmt{SQL: query, Args: values})
+	for _, r := range f.rules {
+		if strings.Contains(query, r.Contains) {
+			return r, true
+		}
+	}
+	return fakeRule{}, false
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{db: f} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{db: d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rule, ok := c.db.record(query, args)
+	if !ok {
+		return driver.RowsAffected(1), nil
+	}
+	if rule.Err != nil {
+		return nil, rule.Err
+	}
+	return driver.RowsAffected(rule.Affected), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rule, ok := c.db.record(query, args)
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	if rule.Err != nil {
+		return nil, rule.Err
+	}
+	return &fakeRows{columns: rule.Columns, rows: rule.Rows}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
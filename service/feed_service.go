@@ -0,0 +1,534 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultFeedLimit フィードを1回に返す件数の既定値
+	DefaultFeedLimit = 30
+	// MaxFeedLimit フィードを1回に返す件数の上限
+	MaxFeedLimit = 100
+	// MaxCommentLength コメントの最大文字数
+	MaxCommentLength = 500
+)
+
+// ReactionEmojis アクティビティに付けられる絵文字リアクション
+var ReactionEmojis = []string{"👍", "🔥", "💪", "👏", "🎉", "😮"}
+
+// MembershipEventTypes フィードに流すメンバーの出入り
+var MembershipEventTypes = []string{"joined", "left", "removed", "leader_changed"}
+
+var (
+	ErrActivityNotInTeam  = errors.New("activity does not belong to a team")
+	ErrInvalidReaction    = errors.New("invalid reaction emoji")
+	ErrInvalidComment     = errors.New("invalid comment body")
+	ErrCommentNotFound    = errors.New("comment not found")
+	ErrNotCommentAuthor   = errors.New("not the comment author")
+	ErrCommentAlreadyGone = errors.New("comment already deleted")
+)
+
+// ValidReactionEmoji リアクションに使える絵文字か
+func ValidReactionEmoji(emoji string) bool {
+	for _, e := range ReactionEmojis {
+		if e == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordMembershipEvent メンバーの出入りをフィード用に記録する
+func RecordMembershipEvent(tx *gorm.DB, teamID, userID, eventType string, actorID *string) error {
+	event := models.MembershipEvent{
+		ID:      utils.GenerateULID(),
+		TeamID:  teamID,
+		UserID:  userID,
+		Type:    eventType,
+		ActorID: actorID,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record membership event: %w", err)
+	}
+	return nil
+}
+
+// FeedEvaluation フィードに流す週次評価。メンバーごとの評価を週単位にまとめたもの
+type FeedEvaluation struct {
+	WeekNumber     int
+	EvaluatedAt    time.Time
+	EvaluatedCount int // フリーズを除いた評価対象の人数
+	MetCount       int
+	FrozenCount    int
+	HPChange       int
+	Members        []models.WeeklyEvaluation
+}
+
+// ReactionSummary 絵文字ごとのリアクション数
+type ReactionSummary struct {
+	Emoji       string
+	Count       int
+	UserIDs     []string
+	ReactedByMe bool
+}
+
+// FeedActivity フィードに流すアクティビティと、付いたリアクション・コメントの数
+type FeedActivity struct {
+	Activity           models.Activity
+	Reactions          []ReactionSummary
+	CommentCount       int
+	UnreadCommentCount int
+}
+
+// FeedItem チームフィードの1項目。Type に応じて Activity / Evaluation / HPEvent / Membership のいずれかが入る
+type FeedItem struct {
+	Type       string // activity / evaluation / hp_change / membership
+	ID         string
+	OccurredAt time.Time
+	Unread     bool
+
+	Activity   *FeedActivity
+	Evaluation *FeedEvaluation
+	HPEvent    *models.HPEvent
+	Membership *models.MembershipEvent
+}
+
+// FeedUnread チームフィードの未読数
+type FeedUnread struct {
+	LastReadAt *time.Time
+	Items      int // 他のメンバーによる未読の項目
+	Comments   int // 他のメンバーによる未読のコメント
+	MyComments int // 自分のアクティビティ・コメントへの未読のコメント（Comments の内数）
+}
+
+// FeedReadSince 未読の起点。一度も既読にしていなければ参加日時から数える
+func FeedReadSince(tx *gorm.DB, teamID, userID string) (time.Time, *time.Time) {
+	var marker models.FeedReadMarker
+	if err := tx.First(&marker, "team_id = ? AND user_id = ?", teamID, userID).Error; err == nil {
+		return marker.LastReadAt, &marker.LastReadAt
+	}
+	var member models.TeamMember
+	if err := tx.Select("joined_at").First(&member, "team_id = ? AND user_id = ?", teamID, userID).Error; err == nil {
+		return member.JoinedAt, nil
+	}
+	return time.Time{}, nil
+}
+
+// BuildFeed チームのアクティビティ・週次評価・HP変動・メンバーの出入りを新しい順に混ぜて返す。before より前の limit 件
+func BuildFeed(tx *gorm.DB, teamID, viewerID string, before time.Time, limit int) ([]FeedItem, error) {
+	readSince, _ := FeedReadSince(tx, teamID, viewerID)
+	var items []FeedItem
+
+	// それぞれ limit 件ずつ取り、混ぜてから limit 件に切る
+	var activities []models.Activity
+	if err := tx.Preload("User").
+		Where("team_id = ? AND status = ? AND ended_at IS NOT NULL AND ended_at < ?", teamID, "completed", before).
+		Order("ended_at DESC").Limit(limit).
+		Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activities: %w", err)
+	}
+	for _, a := range activities {
+		items = append(items, FeedItem{
+			Type:       "activity",
+			ID:         "activity:" + a.ID,
+			OccurredAt: *a.EndedAt,
+			Unread:     a.UserID != viewerID && a.EndedAt.After(readSince),
+			Activity:   &FeedActivity{Activity: a},
+		})
+	}
+
+	evaluations, err := feedEvaluations(tx, teamID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range evaluations {
+		e := &evaluations[i]
+		items = append(items, FeedItem{
+			Type:       "evaluation",
+			ID:         fmt.Sprintf("evaluation:%d", e.WeekNumber),
+			OccurredAt: e.EvaluatedAt,
+			Unread:     e.EvaluatedAt.After(readSince),
+			Evaluation: e,
+		})
+	}
+
+	var hpEvents []models.HPEvent
+	if err := tx.Preload("User").
+		Where("team_id = ? AND created_at < ?", teamID, before).
+		Order("created_at DESC").Limit(limit).
+		Find(&hpEvents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch hp events: %w", err)
+	}
+	for i := range hpEvents {
+		e := &hpEvents[i]
+		items = append(items, FeedItem{
+			Type:       "hp_change",
+			ID:         "hp_change:" + e.ID,
+			OccurredAt: e.CreatedAt,
+			Unread:     e.CreatedAt.After(readSince),
+			HPEvent:    e,
+		})
+	}
+
+	var membershipEvents []models.MembershipEvent
+	if err := tx.Preload("User").
+		Where("team_id = ? AND created_at < ?", teamID, before).
+		Order("created_at DESC").Limit(limit).
+		Find(&membershipEvents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch membership events: %w", err)
+	}
+	for i := range membershipEvents {
+		e := &membershipEvents[i]
+		items = append(items, FeedItem{
+			Type:       "membership",
+			ID:         "membership:" + e.ID,
+			OccurredAt: e.CreatedAt,
+			Unread:     e.UserID != viewerID && e.CreatedAt.After(readSince),
+			Membership: e,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].OccurredAt.After(items[j].OccurredAt)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	if err := attachActivitySocial(tx, items, viewerID, readSince); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// feedEvaluations before より前に評価された週を新しい順に limit 週分まとめる
+func feedEvaluations(tx *gorm.DB, teamID string, before time.Time, limit int) ([]FeedEvaluation, error) {
+	var weeks []struct {
+		WeekNumber  int
+		EvaluatedAt time.Time
+	}
+	if err := tx.Model(&models.WeeklyEvaluation{}).
+		Select("week_number, MAX(evaluated_at) AS evaluated_at").
+		Where("team_id = ?", teamID).
+		Group("week_number").
+		Having("MAX(evaluated_at) < ?", before).
+		Order("evaluated_at DESC").Limit(limit).
+		Scan(&weeks).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch evaluated weeks: %w", err)
+	}
+	if len(weeks) == 0 {
+		return nil, nil
+	}
+
+	weekNumbers := make([]int, len(weeks))
+	for i, w := range weeks {
+		weekNumbers[i] = w.WeekNumber
+	}
+	var evals []models.WeeklyEvaluation
+	if err := tx.Preload("User").
+		Where("team_id = ? AND week_number IN ?", teamID, weekNumbers).
+		Order("user_id ASC").
+		Find(&evals).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch evaluations: %w", err)
+	}
+
+	result := make([]FeedEvaluation, len(weeks))
+	index := map[int]int{}
+	for i, w := range weeks {
+		result[i] = FeedEvaluation{WeekNumber: w.WeekNumber, EvaluatedAt: w.EvaluatedAt}
+		index[w.WeekNumber] = i
+	}
+	for _, e := range evals {
+		fe := &result[index[e.WeekNumber]]
+		fe.Members = append(fe.Members, e)
+		fe.HPChange += e.HPChange
+		switch {
+		case e.Frozen:
+			fe.FrozenCount++
+		case e.TargetMet:
+			fe.EvaluatedCount++
+			fe.MetCount++
+		default:
+			fe.EvaluatedCount++
+		}
+	}
+	return result, nil
+}
+
+// attachActivitySocial フィードのアクティビティにリアクションとコメント数を付ける
+func attachActivitySocial(tx *gorm.DB, items []FeedItem, viewerID string, readSince time.Time) error {
+	var activityIDs []string
+	byID := map[string]*FeedActivity{}
+	for _, item := range items {
+		if item.Activity != nil {
+			activityIDs = append(activityIDs, item.Activity.Activity.ID)
+			byID[item.Activity.Activity.ID] = item.Activity
+		}
+	}
+	if len(activityIDs) == 0 {
+		return nil
+	}
+
+	reactions, err := ReactionSummaries(tx, activityIDs, viewerID)
+	if err != nil {
+		return err
+	}
+	for id, r := range reactions {
+		byID[id].Reactions = r
+	}
+
+	var counts []struct {
+		ActivityID string
+		Total      int
+		Unread     int
+	}
+	if err := tx.Model(&models.ActivityComment{}).
+		Select("activity_id, COUNT(*) AS total, COUNT(CASE WHEN user_id <> ? AND created_at > ? THEN 1 END) AS unread", viewerID, readSince).
+		Where("activity_id IN ? AND deleted_at IS NULL", activityIDs).
+		Group("activity_id").
+		Scan(&counts).Error; err != nil {
+		return fmt.Errorf("failed to count comments: %w", err)
+	}
+	for _, c := range counts {
+		byID[c.ActivityID].CommentCount = c.Total
+		byID[c.ActivityID].UnreadCommentCount = c.Unread
+	}
+	return nil
+}
+
+// ReactionSummaries アクティビティごとに絵文字別のリアクション数をまとめる。絵文字は ReactionEmojis の順
+func ReactionSummaries(tx *gorm.DB, activityIDs []string, viewerID string) (map[string][]ReactionSummary, error) {
+	var reactions []models.ActivityReaction
+	if err := tx.Where("activity_id IN ?", activityIDs).Order("created_at ASC").Find(&reactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reactions: %w", err)
+	}
+
+	grouped := map[string]map[string]*ReactionSummary{}
+	for _, r := range reactions {
+		if grouped[r.ActivityID] == nil {
+			grouped[r.ActivityID] = map[string]*ReactionSummary{}
+		}
+		s := grouped[r.ActivityID][r.Emoji]
+		if s == nil {
+			s = &ReactionSummary{Emoji: r.Emoji}
+			grouped[r.ActivityID][r.Emoji] = s
+		}
+		s.Count++
+		s.UserIDs = append(s.UserIDs, r.UserID)
+		if r.UserID == viewerID {
+			s.ReactedByMe = true
+		}
+	}
+
+	result := map[string][]ReactionSummary{}
+	for activityID, byEmoji := range grouped {
+		for _, emoji := range ReactionEmojis {
+			if s, ok := byEmoji[emoji]; ok {
+				result[activityID] = append(result[activityID], *s)
+			}
+		}
+	}
+	return result, nil
+}
+
+// CountFeedUnread readSince より後に他のメンバーが追加したフィード項目とコメントを数える
+func CountFeedUnread(tx *gorm.DB, teamID, userID string) (FeedUnread, error) {
+	readSince, lastReadAt := FeedReadSince(tx, teamID, userID)
+	unread := FeedUnread{LastReadAt: lastReadAt}
+
+	var activities, hpEvents, membershipEvents, evaluatedWeeks int64
+	if err := tx.Model(&models.Activity{}).
+		Where("team_id = ? AND status = ? AND user_id <> ? AND ended_at > ?", teamID, "completed", userID, readSince).
+		Count(&activities).Error; err != nil {
+		return unread, fmt.Errorf("failed to count activities: %w", err)
+	}
+	if err := tx.Model(&models.HPEvent{}).
+		Where("team_id = ? AND created_at > ?", teamID, readSince).
+		Count(&hpEvents).Error; err != nil {
+		return unread, fmt.Errorf("failed to count hp events: %w", err)
+	}
+	if err := tx.Model(&models.MembershipEvent{}).
+		Where("team_id = ? AND user_id <> ? AND created_at > ?", teamID, userID, readSince).
+		Count(&membershipEvents).Error; err != nil {
+		return unread, fmt.Errorf("failed to count membership events: %w", err)
+	}
+	if err := tx.Model(&models.WeeklyEvaluation{}).
+		Where("team_id = ? AND evaluated_at > ?", teamID, readSince).
+		Distinct("week_number").
+		Count(&evaluatedWeeks).Error; err != nil {
+		return unread, fmt.Errorf("failed to count evaluations: %w", err)
+	}
+	unread.Items = int(activities + hpEvents + membershipEvents + evaluatedWeeks)
+
+	var comments, myComments int64
+	others := tx.Model(&models.ActivityComment{}).
+		Where("team_id = ? AND user_id <> ? AND deleted_at IS NULL AND created_at > ?", teamID, userID, readSince).
+		Session(&gorm.Session{})
+	if err := others.Count(&comments).Error; err != nil {
+		return unread, fmt.Errorf("failed to count comments: %w", err)
+	}
+	myActivities := tx.Model(&models.Activity{}).Select("id").Where("team_id = ? AND user_id = ?", teamID, userID)
+	myThreads := tx.Model(&models.ActivityComment{}).Select("id").Where("team_id = ? AND user_id = ?", teamID, userID)
+	if err := others.
+		Where("activity_id IN (?) OR parent_id IN (?)", myActivities, myThreads).
+		Count(&myComments).Error; err != nil {
+		return unread, fmt.Errorf("failed to count comments: %w", err)
+	}
+	unread.Comments = int(comments)
+	unread.MyComments = int(myComments)
+	return unread, nil
+}
+
+// MarkFeedRead at までのフィードを既読にする。既読位置は戻さない
+func MarkFeedRead(tx *gorm.DB, teamID, userID string, at time.Time) (time.Time, error) {
+	marker := models.FeedReadMarker{UserID: userID, TeamID: teamID, LastReadAt: at}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "team_id"}},
+		DoUpdates: clause.Set{{
+			Column: clause.Column{Name: "last_read_at"},
+			Value:  gorm.Expr("GREATEST(feed_read_markers.last_read_at, excluded.last_read_at)"),
+		}, {
+			Column: clause.Column{Name: "updated_at"},
+			Value:  gorm.Expr("excluded.updated_at"),
+		}},
+	}).Create(&marker).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to mark feed read: %w", err)
+	}
+	if err := tx.First(&marker, "team_id = ? AND user_id = ?", teamID, userID).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch read marker: %w", err)
+	}
+	return marker.LastReadAt, nil
+}
+
+// AddReaction アクティビティにリアクションを付ける。同じ絵文字を付け直しても1つのまま
+func AddReaction(tx *gorm.DB, activity models.Activity, userID, emoji string) error {
+	if activity.TeamID == nil {
+		return ErrActivityNotInTeam
+	}
+	if !ValidReactionEmoji(emoji) {
+		return ErrInvalidReaction
+	}
+	reaction := models.ActivityReaction{
+		ID:         utils.GenerateULID(),
+		ActivityID: activity.ID,
+		TeamID:     *activity.TeamID,
+		UserID:     userID,
+		Emoji:      emoji,
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error; err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveReaction 自分のリアクションを外す
+func RemoveReaction(tx *gorm.DB, activity models.Activity, userID, emoji string) error {
+	if !ValidReactionEmoji(emoji) {
+		return ErrInvalidReaction
+	}
+	if err := tx.Where("activity_id = ? AND user_id = ? AND emoji = ?", activity.ID, userID, emoji).
+		Delete(&models.ActivityReaction{}).Error; err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	return nil
+}
+
+// PostComment アクティビティにコメントする。返信への返信は最初のコメントのスレッドにまとめる
+// アクティビティの持ち主と、返信先のコメントを書いた人に通知する
+func PostComment(tx *gorm.DB, activity models.Activity, userID, body string, parentID *string) (*models.ActivityComment, error) {
+	if activity.TeamID == nil {
+		return nil, ErrActivityNotInTeam
+	}
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > MaxCommentLength {
+		return nil, ErrInvalidComment
+	}
+
+	var parent *models.ActivityComment
+	if parentID != nil {
+		var p models.ActivityComment
+		if err := tx.First(&p, "id = ? AND activity_id = ?", *parentID, activity.ID).Error; err != nil {
+			return nil, ErrCommentNotFound
+		}
+		if p.ParentID != nil {
+			if err := tx.First(&p, "id = ?", *p.ParentID).Error; err != nil {
+				return nil, ErrCommentNotFound
+			}
+		}
+		parent = &p
+	}
+
+	comment := models.ActivityComment{
+		ID:         utils.GenerateULID(),
+		ActivityID: activity.ID,
+		TeamID:     *activity.TeamID,
+		UserID:     userID,
+		Body:       body,
+	}
+	if parent != nil {
+		comment.ParentID = &parent.ID
+	}
+	if err := tx.Create(&comment).Error; err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	var author models.User
+	tx.Select("name").First(&author, "id = ?", userID)
+	params := map[string]string{
+		"member": author.Name,
+		"body":   commentPreview(body),
+	}
+	notified := map[string]bool{userID: true}
+	if parent != nil && !notified[parent.UserID] && parent.DeletedAt == nil {
+		notified[parent.UserID] = true
+		if err := Notify(tx, parent.UserID, "comment_replied", params, activity.TeamID); err != nil {
+			return nil, err
+		}
+	}
+	if !notified[activity.UserID] {
+		if err := Notify(tx, activity.UserID, "activity_commented", params, activity.TeamID); err != nil {
+			return nil, err
+		}
+	}
+
+	comment.User = author
+	return &comment, nil
+}
+
+// commentPreview 通知に載せるコメントの先頭部分
+func commentPreview(body string) string {
+	const previewLength = 40
+	if utf8.RuneCountInString(body) <= previewLength {
+		return body
+	}
+	return string([]rune(body)[:previewLength]) + "…"
+}
+
+// DeleteComment 自分のコメントを削除する。返信のスレッドが残るよう本文だけ消す
+func DeleteComment(tx *gorm.DB, comment *models.ActivityComment, userID string, now time.Time) error {
+	if comment.UserID != userID {
+		return ErrNotCommentAuthor
+	}
+	if comment.DeletedAt != nil {
+		return ErrCommentAlreadyGone
+	}
+	if err := tx.Model(comment).Updates(map[string]interface{}{
+		"body":       "",
+		"deleted_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	comment.Body = ""
+	comment.DeletedAt = &now
+	return nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/trihackathon/api/models"
+)
+
+// forecastHorizonWeeks 終了週のないチームで、解散までの週数の分布を計算する期間
+const forecastHorizonWeeks = 12
+
+// MemberForecast メンバーが今週の目標を達成する見込み
+type MemberForecast struct {
+	Member           models.TeamMember
+	Progress         WeekProgress
+	MeetProbability  float64 // 今週の目標を達成する確率
+	HistoricalRate   float64 // 過去の週次評価の達成率（平滑化済み）
+	EvaluatedWeeks   int
+	ExpectedHPChange float64 // このメンバーの未達成によるHP変動の期待値
+	Risk             string  // high / medium / low / met / frozen
+}
+
+// DisbandWeekProbability 何週後の評価で解散するかの確率
+type DisbandWeekProbability struct {
+	WeeksFromNow int
+	WeekNumber   int
+	Probability  float64
+}
+
+// TeamForecast チームの今週の見込みと、HPが尽きて解散するまでの週数の分布
+type TeamForecast struct {
+	Team                models.Team
+	WeekNumber          int
+	WeekStart           time.Time
+	WeekEnd             time.Time
+	Members             []MemberForecast
+	AllMetProbability   float64
+	ExpectedHPChange    float64 // 今週の評価でのHP変動の期待値（上限・下限を反映）
+	ExpectedHP          float64 // 今週の評価後のHPの期待値
+	DisbandProbability  float64 // 今週の評価で解散する確率
+	DisbandWeeks        []DisbandWeekProbability
+	SurvivalProbability float64 // 最終週（または HorizonWeeks 週後）まで解散しない確率
+	HorizonWeeks        int
+	SeasonEndsInHorizon bool // HorizonWeeks がチャレンジの終了週まで届いている
+}
+
+// ForecastTeam active チームのメンバーごとの今週の達成確率と、HPの推移から解散までの週数の分布を求める
+// 今週は現在の進捗と習慣からの予測を、来週以降は各メンバーの過去の達成率を使う（目標倍率の変化は考慮しない）
+func (s *PredictionService) ForecastTeam(team models.Team, now time.Time) (*TeamForecast, error) {
+	if team.Status != "active" {
+		return nil, ErrNoActiveTeam
+	}
+	week := team.CurrentWeek
+	weekStart, weekEnd, ok := WeekWindow(team, week)
+	if !ok {
+		return nil, ErrNoActiveTeam
+	}
+
+	var members []models.TeamMember
+	if err := s.db.Preload("User").Where("team_id = ?", team.ID).Order("joined_at ASC").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+
+	// 週の経過に応じて、過去の達成率から今週の予測へ重みを移す
+	elapsed := 0.0
+	if total := weekEnd.Sub(weekStart); total > 0 {
+		elapsed = math.Min(1, math.Max(0, float64(now.Sub(weekStart))/float64(total)))
+	}
+
+	penalty := float64(HPPenalty(team.Strictness))
+	result := &TeamForecast{Team: team, WeekNumber: week, WeekStart: weekStart, WeekEnd: weekEnd}
+	historical := make(map[string]float64, len(members))
+	for _, m := range members {
+		prediction, err := s.PredictMember(team, m, m.User, now)
+		if err != nil {
+			return nil, err
+		}
+		rate, weeks, err := s.historicalRate(team.ExerciseType, m.UserID)
+		if err != nil {
+			return nil, err
+		}
+		historical[m.UserID] = rate
+
+		f := MemberForecast{
+			Member:         m,
+			Progress:       prediction.Progress,
+			HistoricalRate: math.Round(rate*100) / 100,
+			EvaluatedWeeks: weeks,
+		}
+		switch {
+		case prediction.Progress.Frozen:
+			f.Risk = "frozen"
+		case prediction.Progress.Remaining() <= 0:
+			f.MeetProbability = 1
+			f.Risk = "met"
+		default:
+			meet := elapsed*(1-prediction.MissProbability) + (1-elapsed)*rate
+			f.MeetProbability = math.Round(meet*100) / 100
+			f.ExpectedHPChange = math.Round(-penalty*(1-meet)*100) / 100
+			switch miss := 1 - meet; {
+			case miss >= 0.5:
+				f.Risk = "high"
+			case miss >= 0.25:
+				f.Risk = "medium"
+			default:
+				f.Risk = "low"
+			}
+		}
+		result.Members = append(result.Members, f)
+	}
+
+	// 声をかけるべきメンバーが先頭に来るよう、リスクの高い順に並べる
+	riskOrder := map[string]int{"high": 0, "medium": 1, "low": 2, "met": 3, "frozen": 4}
+	sort.SliceStable(result.Members, func(i, j int) bool {
+		a, b := result.Members[i], result.Members[j]
+		if riskOrder[a.Risk] != riskOrder[b.Risk] {
+			return riskOrder[a.Risk] < riskOrder[b.Risk]
+		}
+		return a.MeetProbability < b.MeetProbability
+	})
+
+	// 予約済みのフリーズは来週以降の評価からも外す
+	var freezes []models.WeekFreeze
+	if err := s.db.Where("team_id = ? AND week_number > ? AND status = ?", team.ID, week, "scheduled").Find(&freezes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch freezes: %w", err)
+	}
+	frozen := make(map[int]map[string]bool)
+	for _, f := range freezes {
+		if frozen[f.WeekNumber] == nil {
+			frozen[f.WeekNumber] = make(map[string]bool)
+		}
+		frozen[f.WeekNumber][f.UserID] = true
+	}
+
+	lastWeek := week + forecastHorizonWeeks - 1
+	if team.ChallengeWeeks > 0 && team.ChallengeWeeks < lastWeek {
+		lastWeek = team.ChallengeWeeks
+		result.SeasonEndsInHorizon = true
+	}
+	if lastWeek < week {
+		lastWeek = week
+	}
+	result.HorizonWeeks = lastWeek - week + 1
+
+	// HPごとの確率分布を週ごとに更新する。HP 0 は解散で吸収される
+	hp := make([]float64, team.MaxHP+1)
+	hp[clampHP(team.CurrentHP, team.MaxHP)] = 1
+	for w := week; w <= lastWeek; w++ {
+		var probs []float64
+		if w == week {
+			for _, f := range result.Members {
+				if f.Risk != "frozen" {
+					probs = append(probs, f.MeetProbability)
+				}
+			}
+		} else {
+			for _, m := range members {
+				if !frozen[w][m.UserID] {
+					probs = append(probs, historical[m.UserID])
+				}
+			}
+		}
+
+		misses := missDistribution(probs)
+		next := make([]float64, len(hp))
+		for h := 1; h < len(hp); h++ {
+			if hp[h] == 0 {
+				continue
+			}
+			for k, pk := range misses {
+				change := -int(penalty) * k
+				if k == 0 {
+					change = AllMetBonus * len(probs)
+				}
+				next[clampHP(h+change, team.MaxHP)] += hp[h] * pk
+			}
+		}
+
+		if w == week {
+			result.AllMetProbability = math.Round(misses[0]*100) / 100
+			expected := 0.0
+			for h, p := range next {
+				expected += float64(h) * p
+			}
+			result.ExpectedHP = math.Round(expected*100) / 100
+			result.ExpectedHPChange = math.Round((expected-float64(team.CurrentHP))*100) / 100
+			result.DisbandProbability = math.Round(next[0]*100) / 100
+		}
+		result.DisbandWeeks = append(result.DisbandWeeks, DisbandWeekProbability{
+			WeeksFromNow: w - week + 1,
+			WeekNumber:   w,
+			Probability:  math.Round(next[0]*1000) / 1000,
+		})
+		next[0] = 0
+		hp = next
+	}
+
+	survival := 0.0
+	for _, p := range hp {
+		survival += p
+	}
+	result.SurvivalProbability = math.Round(survival*1000) / 1000
+	return result, nil
+}
+
+// historicalRate ユーザーの同じ種目の週次評価（フリーズを除く）から求めた達成率。評価がない週も扱えるよう平滑化する
+func (s *PredictionService) historicalRate(exerciseType, userID string) (float64, int, error) {
+	var evals []models.WeeklyEvaluation
+	if err := s.db.Joins("JOIN teams ON teams.id = weekly_evaluations.team_id").
+		Where("weekly_evaluations.user_id = ? AND weekly_evaluations.frozen = ? AND teams.exercise_type = ?", userID, false, exerciseType).
+		Find(&evals).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch evaluations: %w", err)
+	}
+	met := 0
+	for _, e := range evals {
+		if e.TargetMet {
+			met++
+		}
+	}
+	return (float64(met) + 1) / (float64(len(evals)) + 2), len(evals), nil
+}
+
+// missDistribution 各メンバーの達成確率から、未達成の人数の分布を求める
+func missDistribution(meetProbs []float64) []float64 {
+	dist := make([]float64, len(meetProbs)+1)
+	dist[0] = 1
+	for i, p := range meetProbs {
+		miss := 1 - p
+		for j := i + 1; j > 0; j-- {
+			dist[j] = dist[j]*(1-miss) + dist[j-1]*miss
+		}
+		dist[0] *= 1 - miss
+	}
+	return dist
+}
+
+func clampHP(hp, maxHP int) int {
+	if hp < 0 {
+		return 0
+	}
+	if hp > maxHP {
+		return maxHP
+	}
+	return hp
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// freezeEarnStreak 連続で目標を達成するとフリーズトークンを1つ獲得できる週数
+	freezeEarnStreak = 4
+	// freezeEarnLimit 1シーズンに連続達成で獲得できるフリーズトークンの上限
+	freezeEarnLimit = 2
+)
+
+var (
+	ErrFreezeWeekStarted      = errors.New("freeze week has already started")
+	ErrFreezeWeekOutOfRange   = errors.New("freeze week is out of the challenge")
+	ErrFreezeAlreadyScheduled = errors.New("week is already frozen")
+	ErrNoFreezeTokens         = errors.New("no freeze tokens left")
+)
+
+// FreezeBalance メンバーのフリーズトークンの残高
+type FreezeBalance struct {
+	Allowance int // シーズン開始時に配られた数
+	Earned    int // 連続達成で獲得した数
+	Used      int // 予約済み・使用済みの数
+	Remaining int
+}
+
+// WeekStart week 週目の開始日時。開始前のチームは nil
+func WeekStart(team models.Team, week int) *time.Time {
+	start, _, ok := WeekWindow(team, week)
+	if !ok {
+		return nil
+	}
+	return &start
+}
+
+// FreezeBalanceFor メンバーのフリーズトークンの残高を、チームの配布数・これまでの週次評価・予約済みのフリーズから求める
+func FreezeBalanceFor(tx *gorm.DB, team models.Team, userID string) (FreezeBalance, error) {
+	var evals []models.WeeklyEvaluation
+	if err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).
+		Order("week_number ASC").
+		Find(&evals).Error; err != nil {
+		return FreezeBalance{}, fmt.Errorf("failed to fetch evaluations: %w", err)
+	}
+
+	var used int64
+	if err := tx.Model(&models.WeekFreeze{}).
+		Where("team_id = ? AND user_id = ? AND status IN ?", team.ID, userID, []string{"scheduled", "used"}).
+		Count(&used).Error; err != nil {
+		return FreezeBalance{}, fmt.Errorf("failed to count freezes: %w", err)
+	}
+
+	balance := FreezeBalance{
+		Allowance: team.FreezeTokensPerMember,
+		Earned:    earnedFreezeTokens(evals),
+		Used:      int(used),
+	}
+	balance.Remaining = balance.Allowance + balance.Earned - balance.Used
+	if balance.Remaining < 0 {
+		balance.Remaining = 0
+	}
+	return balance, nil
+}
+
+// earnedFreezeTokens freezeEarnStreak 週連続で達成するごとに1つ獲得する。フリーズした週は連続を途切れさせないが数えもしない
+func earnedFreezeTokens(evals []models.WeeklyEvaluation) int {
+	earned, streak := 0, 0
+	for _, e := range evals {
+		switch {
+		case e.Frozen:
+			continue
+		case e.TargetMet:
+			streak++
+		default:
+			streak = 0
+		}
+		if streak == freezeEarnStreak {
+			earned++
+			streak = 0
+		}
+	}
+	if earned > freezeEarnLimit {
+		earned = freezeEarnLimit
+	}
+	return earned
+}
+
+// NextFreezableWeek まだ始まっていない最初の週を返す
+func NextFreezableWeek(team models.Team, now time.Time) int {
+	week := 1
+	if team.Status == "active" && team.CurrentWeek > 0 {
+		week = team.CurrentWeek
+	}
+	for {
+		start := WeekStart(team, week)
+		if start == nil || now.Before(*start) {
+			return week
+		}
+		week++
+	}
+}
+
+// ScheduleFreeze トークンを1つ使い、まだ始まっていない週の評価を免除する
+func ScheduleFreeze(tx *gorm.DB, team models.Team, userID string, week int, reason string, now time.Time) (*models.WeekFreeze, error) {
+	if week < 1 || (team.ChallengeWeeks > 0 && week > team.ChallengeWeeks) {
+		return nil, ErrFreezeWeekOutOfRange
+	}
+	if start := WeekStart(team, week); start != nil && !now.Before(*start) {
+		return nil, ErrFreezeWeekStarted
+	}
+
+	// 同じメンバーの同時リクエストで残高を超えて使わないようメンバー行をロックする
+	var member models.TeamMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&member, "team_id = ? AND user_id = ?", team.ID, userID).Error; err != nil {
+		return nil, ErrMemberNotFound
+	}
+
+	var existing int64
+	tx.Model(&models.WeekFreeze{}).
+		Where("team_id = ? AND user_id = ? AND week_number = ? AND status IN ?", team.ID, userID, week, []string{"scheduled", "used"}).
+		Count(&existing)
+	if existing > 0 {
+		return nil, ErrFreezeAlreadyScheduled
+	}
+
+	balance, err := FreezeBalanceFor(tx, team, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Remaining <= 0 {
+		return nil, ErrNoFreezeTokens
+	}
+
+	// 取り消したフリーズは同じ週に再予約できるよう行を使い回す
+	var freeze models.WeekFreeze
+	err = tx.Where("team_id = ? AND user_id = ? AND week_number = ?", team.ID, userID, week).First(&freeze).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		freeze = models.WeekFreeze{
+			ID:         utils.GenerateULID(),
+			TeamID:     team.ID,
+			UserID:     userID,
+			WeekNumber: week,
+			Reason:     reason,
+			Status:     "scheduled",
+		}
+		if err := tx.Create(&freeze).Error; err != nil {
+			return nil, fmt.Errorf("failed to create freeze: %w", err)
+		}
+		return &freeze, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch freeze: %w", err)
+	}
+
+	freeze.Reason = reason
+	freeze.Status = "scheduled"
+	freeze.CancelledAt = nil
+	if err := tx.Model(&freeze).Updates(map[string]interface{}{
+		"reason":       reason,
+		"status":       "scheduled",
+		"cancelled_at": nil,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to reschedule freeze: %w", err)
+	}
+	return &freeze, nil
+}
+
+// CancelFreeze 週が始まる前のフリーズを取り消し、トークンを戻す
+func CancelFreeze(tx *gorm.DB, team models.Team, freeze *models.WeekFreeze, now time.Time) error {
+	if freeze.Status != "scheduled" {
+		return ErrFreezeWeekStarted
+	}
+	if start := WeekStart(team, freeze.WeekNumber); start != nil && !now.Before(*start) {
+		return ErrFreezeWeekStarted
+	}
+	freeze.Status = "cancelled"
+	freeze.CancelledAt = &now
+	if err := tx.Model(freeze).Updates(map[string]interface{}{
+		"status":       freeze.Status,
+		"cancelled_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to cancel freeze: %w", err)
+	}
+	return nil
+}
+
+// FrozenWeek メンバーがその週をフリーズしていれば返す
+func FrozenWeek(tx *gorm.DB, teamID, userID string, week int) (*models.WeekFreeze, error) {
+	var freeze models.WeekFreeze
+	err := tx.Where("team_id = ? AND user_id = ? AND week_number = ? AND status IN ?",
+		teamID, userID, week, []string{"scheduled", "used"}).
+		First(&freeze).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch freeze: %w", err)
+	}
+	return &freeze, nil
+}
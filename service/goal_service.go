@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+// NextGoalWeek 今から目標を変更した場合に反映される週を返す
+// activeチームは評価中の週を変えないよう翌週から、開始前のチームは第1週から
+func NextGoalWeek(team models.Team) int {
+	if team.Status == "active" && team.CurrentWeek > 0 {
+		return team.CurrentWeek + 1
+	}
+	return 1
+}
+
+// CreateGoal 目標を作成し、第1週から有効な版1を記録する
+func CreateGoal(tx *gorm.DB, goal *models.Goal, changedBy *string, source string) error {
+	goal.Version = 1
+	goal.EffectiveFromWeek = 1
+	if err := tx.Create(goal).Error; err != nil {
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+	return createGoalVersion(tx, *goal, changedBy, source)
+}
+
+// RecordGoalChange goal に設定済みの新しい目標値を effectiveFromWeek から有効な新しい版として保存する
+// 版管理前の目標は、変更前の値を第1週からの版1として先に記録する
+func RecordGoalChange(tx *gorm.DB, goal *models.Goal, effectiveFromWeek int, changedBy *string, source string) error {
+	var current models.Goal
+	if err := tx.First(&current, "id = ?", goal.ID).Error; err != nil {
+		return fmt.Errorf("failed to fetch goal: %w", err)
+	}
+	if current.Version == 0 {
+		current.Version = 1
+		current.EffectiveFromWeek = 1
+		if err := createGoalVersion(tx, current, nil, "initial"); err != nil {
+			return err
+		}
+	}
+
+	goal.Version = current.Version + 1
+	goal.EffectiveFromWeek = effectiveFromWeek
+	if err := tx.Model(&models.Goal{}).Where("id = ?", goal.ID).Updates(map[string]interface{}{
+		"target_distance_km":      goal.TargetDistanceKM,
+		"target_visits_per_week":  goal.TargetVisitsPerWeek,
+		"target_min_duration_min": goal.TargetMinDurationMin,
+		"version":                 goal.Version,
+		"effective_from_week":     goal.EffectiveFromWeek,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	return createGoalVersion(tx, *goal, changedBy, source)
+}
+
+func createGoalVersion(tx *gorm.DB, goal models.Goal, changedBy *string, source string) error {
+	version := models.GoalVersion{
+		ID:                   utils.GenerateULID(),
+		GoalID:               goal.ID,
+		TeamID:               goal.TeamID,
+		Version:              goal.Version,
+		EffectiveFromWeek:    goal.EffectiveFromWeek,
+		TargetDistanceKM:     goal.TargetDistanceKM,
+		TargetVisitsPerWeek:  goal.TargetVisitsPerWeek,
+		TargetMinDurationMin: goal.TargetMinDurationMin,
+		ChangedBy:            changedBy,
+		Source:               source,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("failed to create goal version: %w", err)
+	}
+	return nil
+}
+
+// GoalForWeek 指定週の評価に使う目標を返す。目標値はその週に有効な版のものに置き換える
+// 版管理前の目標は版が nil になる
+func GoalForWeek(tx *gorm.DB, teamID string, week int) (models.Goal, *models.GoalVersion, error) {
+	var goal models.Goal
+	if err := tx.First(&goal, "team_id = ?", teamID).Error; err != nil {
+		return models.Goal{}, nil, err
+	}
+	if week < 1 {
+		week = 1
+	}
+
+	var version models.GoalVersion
+	err := tx.Where("team_id = ? AND effective_from_week <= ?", teamID, week).
+		Order("effective_from_week DESC, version DESC").
+		First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return goal, nil, nil
+	}
+	if err != nil {
+		return models.Goal{}, nil, fmt.Errorf("failed to fetch goal version: %w", err)
+	}
+
+	goal.TargetDistanceKM = version.TargetDistanceKM
+	goal.TargetVisitsPerWeek = version.TargetVisitsPerWeek
+	goal.TargetMinDurationMin = version.TargetMinDurationMin
+	goal.Version = version.Version
+	goal.EffectiveFromWeek = version.EffectiveFromWeek
+	return goal, &version, nil
+}
+
+// MemberGoalForWeek チーム目標にメンバー個別目標を重ねて、指定週にそのメンバーへ適用する目標を返す
+// 個別目標がない週はチーム目標をそのまま返す
+func MemberGoalForWeek(tx *gorm.DB, teamGoal models.Goal, userID string, week int) (models.Goal, *models.MemberGoalOverride, error) {
+	if week < 1 {
+		week = 1
+	}
+
+	var override models.MemberGoalOverride
+	err := tx.Where("team_id = ? AND user_id = ? AND status IN ? AND effective_from_week <= ? AND (effective_until_week IS NULL OR effective_until_week > ?)",
+		teamGoal.TeamID, userID, []string{"accepted", "superseded", "ended"}, week, week).
+		Order("effective_from_week DESC, responded_at DESC").
+		First(&override).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return teamGoal, nil, nil
+	}
+	if err != nil {
+		return teamGoal, nil, fmt.Errorf("failed to fetch member goal: %w", err)
+	}
+
+	goal := teamGoal
+	if override.TargetDistanceKM != nil {
+		goal.TargetDistanceKM = override.TargetDistanceKM
+	}
+	if override.TargetVisitsPerWeek != nil {
+		goal.TargetVisitsPerWeek = override.TargetVisitsPerWeek
+	}
+	if override.TargetMinDurationMin != nil {
+		goal.TargetMinDurationMin = override.TargetMinDurationMin
+	}
+	return goal, &override, nil
+}
+
+// AcceptMemberGoal 個別目標を承認し、次に評価する週から適用する。適用中の個別目標はその前の週までで終了する
+func AcceptMemberGoal(tx *gorm.DB, team models.Team, override *models.MemberGoalOverride) error {
+	now := time.Now()
+	week := NextGoalWeek(team)
+
+	if err := tx.Model(&models.MemberGoalOverride{}).
+		Where("team_id = ? AND user_id = ? AND status = ? AND id <> ?", override.TeamID, override.UserID, "accepted", override.ID).
+		Updates(map[string]interface{}{"status": "superseded", "effective_until_week": week}).Error; err != nil {
+		return fmt.Errorf("failed to supersede member goal: %w", err)
+	}
+
+	override.Status = "accepted"
+	override.EffectiveFromWeek = &week
+	override.RespondedAt = &now
+	if err := tx.Model(override).Updates(map[string]interface{}{
+		"status":              override.Status,
+		"effective_from_week": week,
+		"responded_at":        now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to accept member goal: %w", err)
+	}
+	return nil
+}
+
+// EndMemberGoal 適用中の個別目標を次に評価する週から外す
+func EndMemberGoal(tx *gorm.DB, team models.Team, override *models.MemberGoalOverride) error {
+	week := NextGoalWeek(team)
+	override.Status = "ended"
+	override.EffectiveUntilWeek = &week
+	if err := tx.Model(override).Updates(map[string]interface{}{
+		"status":               override.Status,
+		"effective_until_week": week,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to end member goal: %w", err)
+	}
+	return nil
+}
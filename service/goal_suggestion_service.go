@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// suggestionLookbackWeeks アクティビティから週ごとの実績を集計する期間
+	suggestionLookbackWeeks = 8
+	// suggestionMinSamples 履歴に基づく提案に必要なメンバー1人あたりの最少週数
+	suggestionMinSamples = 2
+)
+
+// suggestionLevels 提案の段階と、目標にするメンバーの週実績のパーセンタイル
+// 最も実績の少ないメンバーに合わせるため、チーム全体で揃えやすい値になる
+var suggestionLevels = []struct {
+	Level      string
+	Percentile float64
+}{
+	{"conservative", 0.25},
+	{"balanced", 0.5},
+	{"ambitious", 0.75},
+}
+
+// MemberSuggestionStat メンバーごとの分析結果
+type MemberSuggestionStat struct {
+	UserID      string
+	UserName    string
+	Samples     int
+	SuccessRate float64
+}
+
+// GoalSuggestion 目標の提案
+type GoalSuggestion struct {
+	Level                string
+	TargetDistanceKM     *float64
+	TargetVisitsPerWeek  *int
+	TargetMinDurationMin *int
+	// EstimatedSuccessRate チーム全員が1週間目標を達成する推定確率。履歴がない場合は nil
+	EstimatedSuccessRate *float64
+	Members              []MemberSuggestionStat
+}
+
+// GoalSuggestionResult 目標提案の結果
+type GoalSuggestionResult struct {
+	Basis           string // history / default
+	MembersAnalyzed int
+	WeeksAnalyzed   int
+	Suggestions     []GoalSuggestion
+}
+
+// memberWeeklySamples メンバーの週ごとの実績。running は距離（km）、gym は訪問回数
+type memberWeeklySamples struct {
+	userID   string
+	userName string
+	values   []float64
+}
+
+// SuggestGoals メンバーの過去のアクティビティと過去シーズンの週次評価から、控えめ・標準・挑戦的な目標を提案する
+func SuggestGoals(tx *gorm.DB, team models.Team, now time.Time) (*GoalSuggestionResult, error) {
+	var members []models.TeamMember
+	if err := tx.Preload("User").Where("team_id = ?", team.ID).Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+
+	minDuration, err := suggestMinDuration(tx, team, members, now)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]memberWeeklySamples, 0, len(members))
+	weeks := 0
+	for _, m := range members {
+		values, err := weeklySamples(tx, team, m.UserID, minDuration, now)
+		if err != nil {
+			return nil, err
+		}
+		weeks += len(values)
+		if len(values) < suggestionMinSamples {
+			continue
+		}
+		samples = append(samples, memberWeeklySamples{userID: m.UserID, userName: m.User.Name, values: values})
+	}
+
+	if len(samples) == 0 {
+		return defaultGoalSuggestions(team, minDuration), nil
+	}
+
+	result := &GoalSuggestionResult{
+		Basis:           "history",
+		MembersAnalyzed: len(samples),
+		WeeksAnalyzed:   weeks,
+	}
+
+	step := 0.5
+	if team.ExerciseType == "gym" {
+		step = 1
+	}
+	prev := 0.0
+	for _, level := range suggestionLevels {
+		target := math.Inf(1)
+		for _, s := range samples {
+			target = math.Min(target, percentile(s.values, level.Percentile))
+		}
+		// 刻みに切り下げ、前の段階より低くはしない
+		target = math.Max(step, math.Floor(target/step)*step)
+		if target < prev {
+			target = prev
+		}
+		prev = target
+		result.Suggestions = append(result.Suggestions, buildSuggestion(team.ExerciseType, level.Level, target, minDuration, samples))
+	}
+	return result, nil
+}
+
+// percentile 線形補間でパーセンタイル値を返す
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// weeklySamples 直近 suggestionLookbackWeeks 週のアクティビティをチームの暦で7日ごとに集計し、それより前の過去シーズンの週次評価を加える
+func weeklySamples(tx *gorm.DB, team models.Team, userID string, minDuration *int, now time.Time) ([]float64, error) {
+	loc := TeamLocation(team)
+	since := utils.StartOfDay(now, loc).AddDate(0, 0, 1-suggestionLookbackWeeks*7)
+
+	var activities []models.Activity
+	if err := tx.Where("user_id = ? AND exercise_type = ? AND status = ? AND started_at >= ? AND (review_status IS NULL OR review_status != ?)",
+		userID, team.ExerciseType, "completed", since, "rejected").
+		Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activities: %w", err)
+	}
+
+	var first *time.Time
+	buckets := make([]float64, suggestionLookbackWeeks)
+	for _, a := range activities {
+		idx := utils.DaysBetween(a.StartedAt, now, loc) / 7
+		if idx < 0 || idx >= suggestionLookbackWeeks {
+			continue
+		}
+		if first == nil || a.StartedAt.Before(*first) {
+			startedAt := a.StartedAt
+			first = &startedAt
+		}
+		switch team.ExerciseType {
+		case "running":
+			buckets[idx] += a.DistanceKM
+		case "gym":
+			if minDuration == nil || a.DurationMin >= *minDuration {
+				buckets[idx]++
+			}
+		}
+	}
+
+	var values []float64
+	if first != nil {
+		// 初めて記録した週より前は未使用期間として数えない
+		oldest := utils.DaysBetween(*first, now, loc) / 7
+		values = append(values, buckets[:oldest+1]...)
+	}
+
+	var evaluations []models.WeeklyEvaluation
+	if err := tx.Joins("JOIN teams ON teams.id = weekly_evaluations.team_id").
+		Where("weekly_evaluations.user_id = ? AND teams.exercise_type = ? AND weekly_evaluations.evaluated_at < ? AND weekly_evaluations.frozen = ?",
+			userID, team.ExerciseType, since, false).
+		Find(&evaluations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch evaluations: %w", err)
+	}
+	for _, e := range evaluations {
+		switch team.ExerciseType {
+		case "running":
+			values = append(values, e.TotalDistanceKM)
+		case "gym":
+			values = append(values, float64(e.TotalVisits))
+		}
+	}
+	return values, nil
+}
+
+// suggestMinDuration gym の場合、メンバーのジム滞在時間の下位25%を5分単位に切り下げた値を最低滞在時間として提案する
+func suggestMinDuration(tx *gorm.DB, team models.Team, members []models.TeamMember, now time.Time) (*int, error) {
+	if team.ExerciseType != "gym" || len(members) == 0 {
+		return nil, nil
+	}
+	userIDs := make([]string, len(members))
+	for i, m := range members {
+		userIDs[i] = m.UserID
+	}
+
+	var durations []int
+	if err := tx.Model(&models.Activity{}).
+		Where("user_id IN ? AND exercise_type = ? AND status = ? AND started_at >= ? AND duration_min > 0",
+			userIDs, "gym", "completed", now.AddDate(0, 0, -suggestionLookbackWeeks*7)).
+		Order("duration_min ASC").
+		Pluck("duration_min", &durations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch gym durations: %w", err)
+	}
+	if len(durations) == 0 {
+		return nil, nil
+	}
+	d := durations[len(durations)/4] / 5 * 5
+	if d < 15 {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// memberSuccessRate 目標以上を達成した週の割合。週数が少ない場合に0%・100%と断定しないよう事前分布で平滑化する
+func memberSuccessRate(values []float64, target float64) float64 {
+	met := 0
+	for _, v := range values {
+		if v >= target {
+			met++
+		}
+	}
+	return (float64(met) + 1) / (float64(len(values)) + 2)
+}
+
+// teamSuccessRate メンバー全員が同じ週に目標を達成する確率（メンバー間は独立とみなす）
+func teamSuccessRate(samples []memberWeeklySamples, target float64) float64 {
+	rate := 1.0
+	for _, s := range samples {
+		rate *= memberSuccessRate(s.values, target)
+	}
+	return rate
+}
+
+func buildSuggestion(exerciseType, level string, target float64, minDuration *int, samples []memberWeeklySamples) GoalSuggestion {
+	s := GoalSuggestion{Level: level}
+	switch exerciseType {
+	case "running":
+		dist := target
+		s.TargetDistanceKM = &dist
+	case "gym":
+		visits := int(target)
+		s.TargetVisitsPerWeek = &visits
+		s.TargetMinDurationMin = minDuration
+	}
+
+	rate := math.Round(teamSuccessRate(samples, target)*100) / 100
+	s.EstimatedSuccessRate = &rate
+	for _, m := range samples {
+		s.Members = append(s.Members, MemberSuggestionStat{
+			UserID:      m.userID,
+			UserName:    m.userName,
+			Samples:     len(m.values),
+			SuccessRate: math.Round(memberSuccessRate(m.values, target)*100) / 100,
+		})
+	}
+	sort.SliceStable(s.Members, func(i, j int) bool { return s.Members[i].SuccessRate < s.Members[j].SuccessRate })
+	return s
+}
+
+// defaultGoalSuggestions 履歴がない場合の初心者向けの既定値
+func defaultGoalSuggestions(team models.Team, minDuration *int) *GoalSuggestionResult {
+	result := &GoalSuggestionResult{Basis: "default"}
+	defaults := map[string][3]float64{
+		"running": {5, 10, 15},
+		"gym":     {1, 2, 3},
+	}[team.ExerciseType]
+	for i, level := range suggestionLevels {
+		s := GoalSuggestion{Level: level.Level}
+		switch team.ExerciseType {
+		case "running":
+			dist := defaults[i]
+			s.TargetDistanceKM = &dist
+		case "gym":
+			visits := int(defaults[i])
+			s.TargetVisitsPerWeek = &visits
+			s.TargetMinDurationMin = minDuration
+		}
+		result.Suggestions = append(result.Suggestions, s)
+	}
+	return result
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// inviteCodeRetention 期限切れ・失効後に一覧で確認できるよう残しておく期間
+	inviteCodeRetention = 24 * time.Hour
+
+	// 招待コード参加の試行制限。IPはNAT配下で共有されるためユーザーより緩くする
+	joinFailureWindow   = 15 * time.Minute
+	maxUserJoinFailures = 5
+	maxIPJoinFailures   = 20
+	joinLockoutBase     = time.Minute
+	joinLockoutMax      = 24 * time.Hour
+	joinLockoutDecay    = 24 * time.Hour // 最後の失敗からこの期間が経てばバックオフをリセット
+)
+
+// JoinLockedError 招待コード参加がロックアウト中であることを表す
+type JoinLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *JoinLockedError) Error() string {
+	return fmt.Sprintf("join attempts locked for %s", e.RetryAfter)
+}
+
+type InviteService struct {
+	db *gorm.DB
+}
+
+func NewInviteService(db *gorm.DB) *InviteService {
+	return &InviteService{db: db}
+}
+
+// PurgeResult 期限切れ招待コード削除の結果
+type PurgeResult struct {
+	DeletedCount int64 `json:"deleted_count"`
+}
+
+// PurgeExpiredCodes 期限切れ・失効から一定期間経過した招待コードを削除する
+// 使用履歴（InviteCodeUse）は残す
+func (s *InviteService) PurgeExpiredCodes() (*PurgeResult, error) {
+	threshold := time.Now().Add(-inviteCodeRetention)
+	result := s.db.Where("expires_at < ? OR revoked_at < ?", threshold, threshold).Delete(&models.InviteCode{})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to purge invite codes: %w", result.Error)
+	}
+
+	// ロックが解けて一定期間経った試行制限も掃除する
+	if err := s.db.Where("(locked_until IS NULL OR locked_until < ?) AND last_failure_at < ?", time.Now(), time.Now().Add(-joinLockoutDecay)).
+		Delete(&models.JoinAttemptThrottle{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to purge join throttles: %w", err)
+	}
+
+	return &PurgeResult{DeletedCount: result.RowsAffected}, nil
+}
+
+func userThrottleKey(userID string) string { return "user:" + userID }
+func ipThrottleKey(ip string) string       { return "ip:" + ip }
+
+// CheckJoinThrottle ユーザー・IPのいずれかがロックアウト中なら *JoinLockedError を返す
+func (s *InviteService) CheckJoinThrottle(userID, ip string) error {
+	now := time.Now()
+	var throttles []models.JoinAttemptThrottle
+	if err := s.db.Where("key IN ? AND locked_until > ?", []string{userThrottleKey(userID), ipThrottleKey(ip)}, now).
+		Find(&throttles).Error; err != nil {
+		return fmt.Errorf("failed to check join throttle: %w", err)
+	}
+
+	var retryAfter time.Duration
+	for _, t := range throttles {
+		if d := t.LockedUntil.Sub(now); d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if retryAfter > 0 {
+		s.RecordAuditEvent("join_attempt_while_locked", userID, ip, "")
+		return &JoinLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordJoinFailure 無効な招待コードでの参加失敗を記録し、上限に達したらロックアウトする
+// ロックアウト期間は連続ロックアウトごとに倍増する（1分, 2分, 4分 ... 最大24時間）
+// 今回の失敗でロックアウトした場合は *JoinLockedError を返す
+func (s *InviteService) RecordJoinFailure(userID, ip, detail string) error {
+	s.RecordAuditEvent("invalid_invite_code", userID, ip, detail)
+
+	var lockedUntil time.Time
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, k := range []struct {
+			key   string
+			limit int
+		}{
+			{userThrottleKey(userID), maxUserJoinFailures},
+			{ipThrottleKey(ip), maxIPJoinFailures},
+		} {
+			locked, err := s.incrementFailure(tx, k.key, k.limit)
+			if err != nil {
+				return err
+			}
+			if locked != nil {
+				s.RecordAuditEvent("join_locked_out", userID, ip,
+					fmt.Sprintf("%s locked until %s", k.key, locked.Format(time.RFC3339)))
+				if locked.After(lockedUntil) {
+					lockedUntil = *locked
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !lockedUntil.IsZero() {
+		return &JoinLockedError{RetryAfter: time.Until(lockedUntil)}
+	}
+	return nil
+}
+
+// incrementFailure キーの失敗回数を加算し、ロックアウトした場合は解除時刻を返す
+func (s *InviteService) incrementFailure(tx *gorm.DB, key string, limit int) (*time.Time, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.JoinAttemptThrottle{Key: key}).Error; err != nil {
+		return nil, fmt.Errorf("failed to create join throttle: %w", err)
+	}
+	var t models.JoinAttemptThrottle
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, "key = ?", key).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch join throttle: %w", err)
+	}
+
+	now := time.Now()
+	if t.LastFailureAt != nil {
+		if now.Sub(*t.LastFailureAt) > joinFailureWindow {
+			t.FailureCount = 0
+		}
+		if now.Sub(*t.LastFailureAt) > joinLockoutDecay {
+			t.LockoutCount = 0
+		}
+	}
+	t.FailureCount++
+	t.LastFailureAt = &now
+
+	var lockedUntil *time.Time
+	if t.FailureCount >= limit {
+		t.LockoutCount++
+		d := joinLockoutBase << uint(t.LockoutCount-1)
+		if d > joinLockoutMax || d <= 0 {
+			d = joinLockoutMax
+		}
+		until := now.Add(d)
+		t.LockedUntil = &until
+		t.FailureCount = 0
+		lockedUntil = &until
+	}
+
+	if err := tx.Save(&t).Error; err != nil {
+		return nil, fmt.Errorf("failed to update join throttle: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// ResetJoinThrottle 参加成功時にユーザーの失敗回数をリセットする（IPは共有されうるため残す）
+func (s *InviteService) ResetJoinThrottle(userID string) error {
+	if err := s.db.Where("key = ?", userThrottleKey(userID)).Delete(&models.JoinAttemptThrottle{}).Error; err != nil {
+		return fmt.Errorf("failed to reset join throttle: %w", err)
+	}
+	return nil
+}
+
+// RecordAuditEvent セキュリティ監査ログを記録する。記録の失敗で本処理は止めない
+func (s *InviteService) RecordAuditEvent(eventType, userID, ip, detail string) {
+	event := models.SecurityAuditEvent{
+		ID:        utils.GenerateULID(),
+		EventType: eventType,
+		UserID:    userID,
+		IPAddress: ip,
+		Detail:    detail,
+	}
+	if err := s.db.Create(&event).Error; err != nil {
+		log.Printf("[RecordAuditEvent] failed to record %s: %v", eventType, err)
+	}
+}
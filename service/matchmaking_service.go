@@ -0,0 +1,198 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	matchTeamSize       = 3
+	matchChallengeWeeks = 4
+	// matchTargetSpread 同じチームにまとめる目標レベルの最大比（最大 / 最小）
+	matchTargetSpread = 1.5
+)
+
+type MatchmakingService struct {
+	db                *gorm.DB
+	membershipService *MembershipService
+}
+
+func NewMatchmakingService(db *gorm.DB, membershipService *MembershipService) *MatchmakingService {
+	return &MatchmakingService{db: db, membershipService: membershipService}
+}
+
+// MatchmakingResult マッチング実行結果
+type MatchmakingResult struct {
+	MatchedTeams   int      `json:"matched_teams"`
+	MatchedUsers   int      `json:"matched_users"`
+	WaitingTickets int      `json:"waiting_tickets"`
+	TeamIDs        []string `json:"team_ids"`
+}
+
+// RunMatchmaking 待機中のチケットを運動種別・厳しさ・朝型夜型・目標レベルが近いもの同士で3人チームにまとめる
+// 朝型・夜型の枠を先に埋め、both のユーザーはどちらの枠にも入れる
+func (s *MatchmakingService) RunMatchmaking() (*MatchmakingResult, error) {
+	var tickets []models.MatchmakingTicket
+	if err := s.db.Where("status = ?", "waiting").Order("created_at ASC").Find(&tickets).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tickets: %w", err)
+	}
+
+	// 申し込み後に別経路でチームに入ったユーザーのチケットは取り消す
+	waiting := make([]models.MatchmakingTicket, 0, len(tickets))
+	for _, t := range tickets {
+		var count int64
+		s.db.Model(&models.TeamMember{}).
+			Joins("JOIN teams ON teams.id = team_members.team_id").
+			Where("team_members.user_id = ? AND teams.status IN ?", t.UserID, []string{"forming", "active"}).
+			Count(&count)
+		if count > 0 {
+			s.db.Model(&t).Update("status", "cancelled")
+			continue
+		}
+		waiting = append(waiting, t)
+	}
+
+	groups := make(map[string][]models.MatchmakingTicket)
+	var groupKeys []string
+	for _, t := range waiting {
+		key := t.ExerciseType + "/" + t.Strictness
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	result := &MatchmakingResult{TeamIDs: []string{}}
+	for _, key := range groupKeys {
+		used := make(map[string]bool)
+		for _, chronotype := range []string{"morning", "night", "both"} {
+			var candidates []models.MatchmakingTicket
+			for _, t := range groups[key] {
+				if !used[t.ID] && (t.Chronotype == chronotype || t.Chronotype == "both") {
+					candidates = append(candidates, t)
+				}
+			}
+			for _, group := range groupByTargetLevel(candidates) {
+				teamID, err := s.createMatchedTeam(group)
+				if err != nil {
+					log.Printf("[RunMatchmaking] failed to create team: %v", err)
+					continue
+				}
+				for _, t := range group {
+					used[t.ID] = true
+				}
+				result.MatchedTeams++
+				result.MatchedUsers += len(group)
+				result.TeamIDs = append(result.TeamIDs, teamID)
+			}
+		}
+		for _, t := range groups[key] {
+			if !used[t.ID] {
+				result.WaitingTickets++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// groupByTargetLevel 目標レベル順に並べ、差が matchTargetSpread 以内の連続した matchTeamSize 人ずつをまとめる
+func groupByTargetLevel(candidates []models.MatchmakingTicket) [][]models.MatchmakingTicket {
+	sorted := make([]models.MatchmakingTicket, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TargetLevel < sorted[j].TargetLevel })
+
+	var groups [][]models.MatchmakingTicket
+	for i := 0; i+matchTeamSize <= len(sorted); {
+		low, high := sorted[i].TargetLevel, sorted[i+matchTeamSize-1].TargetLevel
+		if low > 0 && high/low <= matchTargetSpread {
+			groups = append(groups, sorted[i:i+matchTeamSize])
+			i += matchTeamSize
+			continue
+		}
+		i++
+	}
+	return groups
+}
+
+// createMatchedTeam マッチしたチケットから forming チームを作成する。最も早く申し込んだユーザーがリーダー
+func (s *MatchmakingService) createMatchedTeam(group []models.MatchmakingTicket) (string, error) {
+	leader := group[0]
+	for _, t := range group[1:] {
+		if t.CreatedAt.Before(leader.CreatedAt) {
+			leader = t
+		}
+	}
+
+	// 目標は中央値に合わせる
+	levels := make([]float64, len(group))
+	for i, t := range group {
+		levels[i] = t.TargetLevel
+	}
+	sort.Float64s(levels)
+	median := levels[len(levels)/2]
+
+	team := models.Team{
+		ID:             utils.GenerateULID(),
+		Name:           matchedTeamName(leader.ExerciseType),
+		ExerciseType:   leader.ExerciseType,
+		Strictness:     leader.Strictness,
+		Status:         "forming",
+		MaxHP:          100,
+		CurrentHP:      100,
+		CurrentWeek:    0,
+		MinMembers:     matchTeamSize,
+		MaxMembers:     matchTeamSize,
+		ChallengeWeeks: matchChallengeWeeks,
+		Timezone:       UserTimezone(s.db, leader.UserID),
+	}
+	goal := models.Goal{
+		ID:           utils.GenerateULID(),
+		TeamID:       team.ID,
+		ExerciseType: team.ExerciseType,
+	}
+	if team.ExerciseType == "running" {
+		dist := math.Max(0.5, math.Round(median*2)/2)
+		goal.TargetDistanceKM = &dist
+	} else {
+		visits := int(math.Max(1, math.Round(median)))
+		goal.TargetVisitsPerWeek = &visits
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		for _, t := range group {
+			role := "member"
+			if t.ID == leader.ID {
+				role = "leader"
+			}
+			if _, err := s.membershipService.AddMember(tx, team, t.UserID, role); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.MatchmakingTicket{}).Where("id = ? AND status = ?", t.ID, "waiting").
+				Updates(map[string]interface{}{"status": "matched", "team_id": team.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return CreateGoal(tx, &goal, nil, "matchmaking")
+	})
+	if err != nil {
+		return "", err
+	}
+	return team.ID, nil
+}
+
+func matchedTeamName(exerciseType string) string {
+	if exerciseType == "gym" {
+		return "マッチングジムチーム"
+	}
+	return "マッチングランチーム"
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMemberNotFound = errors.New("member not found")
+	ErrAlreadyInTeam  = errors.New("user already belongs to an active team")
+	ErrTeamFull       = errors.New("team is full")
+)
+
+type MembershipService struct {
+	db *gorm.DB
+}
+
+func NewMembershipService(db *gorm.DB) *MembershipService {
+	return &MembershipService{db: db}
+}
+
+// RemovalResult メンバー離脱・除名の処理結果
+type RemovalResult struct {
+	HPPenalty   int     `json:"hp_penalty"`
+	CurrentHP   int     `json:"current_hp"`
+	TeamStatus  string  `json:"team_status"`
+	NewLeaderID *string `json:"new_leader_id"`
+}
+
+// RequiredDisbandVotes 解散に必要な投票数を返す。3人以下は全員一致、それより大きいチームは3分の2以上
+func RequiredDisbandVotes(memberCount int) int {
+	if memberCount <= 3 {
+		return memberCount
+	}
+	return (memberCount*2 + 2) / 3
+}
+
+// RequiredRemovalVotes 除名に必要な投票数を返す。対象者を除くメンバーの過半数
+func RequiredRemovalVotes(memberCount int) int {
+	return (memberCount-1)/2 + 1
+}
+
+// AddMember メンバーとしてチームに追加する。forming / active のチームに所属中のユーザーや満員のチームには追加できない
+func (s *MembershipService) AddMember(tx *gorm.DB, team models.Team, userID, role string) (*models.TeamMember, error) {
+	var existingCount int64
+	if err := tx.Model(&models.TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id").
+		Where("team_members.user_id = ? AND teams.status IN ?", userID, []string{"forming", "active"}).
+		Count(&existingCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+	if existingCount > 0 {
+		return nil, ErrAlreadyInTeam
+	}
+
+	member := models.TeamMember{
+		ID:     utils.GenerateULID(),
+		TeamID: team.ID,
+		UserID: userID,
+		Role:   role,
+	}
+	if err := tx.Create(&member).Error; err != nil {
+		return nil, fmt.Errorf("failed to create member: %w", err)
+	}
+
+	// 同時参加で上限を超えた場合はロールバックさせる
+	var memberCount int64
+	if err := tx.Model(&models.TeamMember{}).Where("team_id = ?", team.ID).Count(&memberCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count members: %w", err)
+	}
+	if int(memberCount) > team.MaxMembers {
+		return nil, ErrTeamFull
+	}
+	if err := RecordMembershipEvent(tx, team.ID, userID, "joined", nil); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	tx.Select("name").First(&user, "id = ?", userID)
+	if err := NotifyTeam(tx, team.ID, userID, "member_joined", map[string]string{
+		"member": user.Name,
+		"team":   team.Name,
+	}); err != nil {
+		return nil, err
+	}
+	if err := EmitTeamEvent(tx, team.ID, "member.joined", map[string]interface{}{
+		"user_id":      userID,
+		"user_name":    user.Name,
+		"role":         role,
+		"member_count": memberCount,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// RemoveMember チームからメンバーを外し、関連データを整合させる
+// penalize が true かつチームがactiveの場合、厳しさに応じたHPペナルティを課す
+func (s *MembershipService) RemoveMember(tx *gorm.DB, team models.Team, userID string, penalize bool) (*RemovalResult, error) {
+	var member models.TeamMember
+	if err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).First(&member).Error; err != nil {
+		return nil, ErrMemberNotFound
+	}
+	if err := tx.Delete(&member).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete member: %w", err)
+	}
+
+	// 投票中の提案から本人の票を外し、本人を対象とする除名提案は取り下げる
+	openProposals := tx.Model(&models.TeamProposal{}).Select("id").Where("team_id = ? AND status = ?", team.ID, "open")
+	if err := tx.Where("user_id = ? AND proposal_id IN (?)", userID, openProposals).
+		Delete(&models.TeamProposalVote{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete proposal votes: %w", err)
+	}
+	if err := tx.Model(&models.TeamProposal{}).
+		Where("team_id = ? AND status = ? AND type = ? AND target_user_id = ?", team.ID, "open", "remove_member", userID).
+		Updates(map[string]interface{}{"status": "cancelled", "resolved_at": time.Now()}).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel removal proposals: %w", err)
+	}
+
+	// 個別目標は提案中のものを取り下げ、適用中のものは終了する
+	if err := tx.Model(&models.MemberGoalOverride{}).
+		Where("team_id = ? AND user_id = ? AND status = ?", team.ID, userID, "proposed").
+		Update("status", "cancelled").Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel member goals: %w", err)
+	}
+	if err := tx.Model(&models.MemberGoalOverride{}).
+		Where("team_id = ? AND user_id = ? AND status = ?", team.ID, userID, "accepted").
+		Updates(map[string]interface{}{"status": "ended", "effective_until_week": NextGoalWeek(team)}).Error; err != nil {
+		return nil, fmt.Errorf("failed to end member goals: %w", err)
+	}
+
+	result := &RemovalResult{CurrentHP: team.CurrentHP, TeamStatus: team.Status}
+	updates := map[string]interface{}{}
+
+	if penalize && team.Status == "active" {
+		penalty := HPPenalty(team.Strictness)
+		newHP := team.CurrentHP - penalty
+		if newHP < 0 {
+			newHP = 0
+		}
+		event := models.HPEvent{
+			ID:         utils.GenerateULID(),
+			TeamID:     team.ID,
+			UserID:     userID,
+			Reason:     "member_left",
+			HPChange:   newHP - team.CurrentHP,
+			HPAfter:    newHP,
+			WeekNumber: team.CurrentWeek,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return nil, fmt.Errorf("failed to record hp event: %w", err)
+		}
+		if err := EmitTeamEvent(tx, team.ID, "hp.changed", map[string]interface{}{
+			"reason":      event.Reason,
+			"user_id":     userID,
+			"week_number": team.CurrentWeek,
+			"hp_before":   team.CurrentHP,
+			"hp_after":    newHP,
+			"hp_change":   event.HPChange,
+			"max_hp":      team.MaxHP,
+		}); err != nil {
+			return nil, err
+		}
+		updates["current_hp"] = newHP
+		result.HPPenalty = penalty
+		result.CurrentHP = newHP
+		if newHP <= 0 {
+			result.TeamStatus = "disbanded"
+		}
+	}
+
+	var remaining []models.TeamMember
+	if err := tx.Where("team_id = ?", team.ID).Order("joined_at ASC").Find(&remaining).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+
+	if len(remaining) == 0 {
+		result.TeamStatus = "disbanded"
+	} else if member.Role == "leader" {
+		// リーダーが抜けた場合は最も古くから在籍しているメンバーを昇格
+		if err := tx.Model(&remaining[0]).Update("role", "leader").Error; err != nil {
+			return nil, fmt.Errorf("failed to promote leader: %w", err)
+		}
+		if err := RecordMembershipEvent(tx, team.ID, remaining[0].UserID, "leader_changed", nil); err != nil {
+			return nil, err
+		}
+		result.NewLeaderID = &remaining[0].UserID
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update team: %w", err)
+		}
+	}
+	if result.TeamStatus == "disbanded" {
+		if err := FinishSeason(tx, team.ID, "disbanded"); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	// 抜けたメンバーを除いた前週の評価で目標倍率を再計算
+	if team.CurrentWeek > 1 {
+		userIDs := make([]string, len(remaining))
+		for i, m := range remaining {
+			userIDs[i] = m.UserID
+		}
+		var evals []models.WeeklyEvaluation
+		tx.Where("team_id = ? AND week_number = ? AND user_id IN ?", team.ID, team.CurrentWeek-1, userIDs).Find(&evals)
+		if err := updateTargetMultipliers(tx, team.ID, evals); err != nil {
+			return nil, err
+		}
+	}
+
+	// 人数が減ったことで可決ラインに届いた提案を実行する
+	if err := resolveOpenProposals(tx, s, team.ID); err != nil {
+		return nil, err
+	}
+	var updated models.Team
+	if err := tx.First(&updated, "id = ?", team.ID).Error; err == nil {
+		result.TeamStatus = updated.Status
+	}
+
+	return result, nil
+}
+
+// Disband 投票によりチームを解散し、メンバーが新しいチームに参加できるようにする
+func (s *MembershipService) Disband(tx *gorm.DB, teamID string) error {
+	if err := FinishSeason(tx, teamID, "disbanded"); err != nil {
+		return err
+	}
+	if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamMember{}).Error; err != nil {
+		return fmt.Errorf("failed to delete members: %w", err)
+	}
+	return nil
+}
+
+// TransferLeadership リーダーを別のメンバーに交代する
+func (s *MembershipService) TransferLeadership(tx *gorm.DB, teamID, fromUserID, toUserID string) error {
+	var next models.TeamMember
+	if err := tx.Where("team_id = ? AND user_id = ?", teamID, toUserID).First(&next).Error; err != nil {
+		return ErrMemberNotFound
+	}
+	if err := tx.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", teamID, fromUserID).
+		Update("role", "member").Error; err != nil {
+		return fmt.Errorf("failed to demote leader: %w", err)
+	}
+	if err := tx.Model(&next).Update("role", "leader").Error; err != nil {
+		return fmt.Errorf("failed to promote leader: %w", err)
+	}
+	return RecordMembershipEvent(tx, teamID, toUserID, "leader_changed", &fromUserID)
+}
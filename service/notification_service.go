@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/trihackathon/api/adapter"
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultPushMaxAttempts プッシュ通知を諦めるまでの送信回数（PUSH_MAX_ATTEMPTS で変更可能）
+	defaultPushMaxAttempts = 5
+	// pushRetryBase 1回目の再送までの待ち時間。以降は2倍ずつ延ばす
+	pushRetryBase = time.Minute
+	// pushRetryMax 再送間隔の上限
+	pushRetryMax = time.Hour
+	// pushDispatchBatch 1回の配信処理で送る件数の上限
+	pushDispatchBatch = 200
+	// pushTimeout 1件の送信のタイムアウト
+	pushTimeout = 10 * time.Second
+	// pushClaimLease 配信処理が確保した通知を他の処理が拾わない時間。1回分を送り切れる長さにする
+	pushClaimLease = pushDispatchBatch*pushTimeout + time.Minute
+)
+
+var (
+	ErrUnknownNotificationType = errors.New("unknown notification type")
+	ErrInvalidPlatform         = errors.New("invalid device platform")
+)
+
+// DevicePlatforms 登録できる端末の種類
+var DevicePlatforms = []string{"ios", "android", "web"}
+
+func pushMaxAttempts() int {
+	return envInt("PUSH_MAX_ATTEMPTS", defaultPushMaxAttempts)
+}
+
+// CreateNotification ユーザーにアプリ内通知を作成し、プッシュ通知を送信キューに積む
+func CreateNotification(tx *gorm.DB, userID, notificationType, title, body string, teamID *string) error {
+	notification := models.Notification{
+		ID:     utils.GenerateULID(),
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+		TeamID: teamID,
+	}
+	if err := tx.Create(&notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return enqueuePush(tx, notification)
+}
+
+// Notify 通知タイプの文面をユーザーの言語で組み立てて通知する
+func Notify(tx *gorm.DB, userID, notificationType string, params map[string]string, teamID *string) error {
+	pref, err := NotificationPreferenceFor(tx, userID)
+	if err != nil {
+		return err
+	}
+	title, body, err := renderNotification(notificationType, pref.Locale, params)
+	if err != nil {
+		return fmt.Errorf("failed to render notification %s: %w", notificationType, err)
+	}
+	return CreateNotification(tx, userID, notificationType, title, body, teamID)
+}
+
+// NotifyTeam チームのメンバー全員（except を除く）に通知する
+func NotifyTeam(tx *gorm.DB, teamID, except, notificationType string, params map[string]string) error {
+	var members []models.TeamMember
+	if err := tx.Where("team_id = ? AND user_id <> ?", teamID, except).Find(&members).Error; err != nil {
+		return fmt.Errorf("failed to fetch members: %w", err)
+	}
+	for _, m := range members {
+		if err := Notify(tx, m.UserID, notificationType, params, &teamID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotificationPreferenceFor ユーザーの通知設定。未設定なら既定値を返す（保存はしない）
+func NotificationPreferenceFor(tx *gorm.DB, userID string) (models.NotificationPreference, error) {
+	pref := models.NotificationPreference{UserID: userID, Locale: "ja", PushEnabled: true}
+	err := tx.First(&pref, "user_id = ?", userID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return pref, fmt.Errorf("failed to fetch notification preference: %w", err)
+	}
+	return pref, nil
+}
+
+// MutedCategories 通知設定でプッシュ通知を止めているカテゴリ
+func MutedCategories(pref models.NotificationPreference) []string {
+	muted := []string{}
+	for _, c := range strings.Split(pref.MutedCategories, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			muted = append(muted, c)
+		}
+	}
+	return muted
+}
+
+// ValidNotificationCategory カテゴリ名が有効か
+func ValidNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidNotificationLocale 通知の言語が有効か
+func ValidNotificationLocale(locale string) bool {
+	for _, l := range NotificationLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// pushAllowed 通知設定でこの通知タイプのプッシュ通知を送ってよいか
+func pushAllowed(pref models.NotificationPreference, notificationType string) bool {
+	if !pref.PushEnabled {
+		return false
+	}
+	category := notificationCategory[notificationType]
+	for _, c := range MutedCategories(pref) {
+		if c == category {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueuePush ユーザーの登録端末ごとに送信待ちの行を作る。実際の送信は DispatchOutbox で行う
+func enqueuePush(tx *gorm.DB, n models.Notification) error {
+	pref, err := NotificationPreferenceFor(tx, n.UserID)
+	if err != nil {
+		return err
+	}
+	if !pushAllowed(pref, n.Type) {
+		return nil
+	}
+
+	var devices []models.DeviceToken
+	if err := tx.Where("user_id = ?", n.UserID).Find(&devices).Error; err != nil {
+		return fmt.Errorf("failed to fetch device tokens: %w", err)
+	}
+	for _, d := range devices {
+		row := models.NotificationOutbox{
+			ID:             utils.GenerateULID(),
+			NotificationID: n.ID,
+			UserID:         n.UserID,
+			DeviceTokenID:  d.ID,
+			Type:           n.Type,
+			Title:          n.Title,
+			Body:           n.Body,
+			TeamID:         n.TeamID,
+			Status:         "pending",
+			NextAttemptAt:  time.Now(),
+		}
+		if err := tx.Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to enqueue push: %w", err)
+		}
+	}
+	return nil
+}
+
+// RegisterDevice 端末トークンを登録する。別のユーザーが登録していたトークンは付け替える
+func RegisterDevice(tx *gorm.DB, userID, token, platform string) (*models.DeviceToken, bool, error) {
+	valid := false
+	for _, p := range DevicePlatforms {
+		if p == platform {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, false, ErrInvalidPlatform
+	}
+
+	now := time.Now()
+	var device models.DeviceToken
+	err := tx.Where("token = ?", token).First(&device).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		device = models.DeviceToken{
+			ID:         utils.GenerateULID(),
+			UserID:     userID,
+			Token:      token,
+			Platform:   platform,
+			LastSeenAt: now,
+		}
+		if err := tx.Create(&device).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to create device token: %w", err)
+		}
+		return &device, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch device token: %w", err)
+	}
+
+	// 端末の持ち主が変わった場合、前のユーザー宛ての未送信分は送らない
+	if device.UserID != userID {
+		if err := tx.Model(&models.NotificationOutbox{}).
+			Where("device_token_id = ? AND status = ?", device.ID, "pending").
+			Update("status", "cancelled").Error; err != nil {
+			return nil, false, fmt.Errorf("failed to cancel pushes: %w", err)
+		}
+	}
+	device.UserID = userID
+	device.Platform = platform
+	device.LastSeenAt = now
+	if err := tx.Model(&device).Updates(map[string]interface{}{
+		"user_id":      userID,
+		"platform":     platform,
+		"last_seen_at": now,
+	}).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to update device token: %w", err)
+	}
+	return &device, false, nil
+}
+
+// UnregisterDevice 端末トークンを削除し、未送信のプッシュ通知を取り消す
+func UnregisterDevice(tx *gorm.DB, device models.DeviceToken) error {
+	if err := tx.Model(&models.NotificationOutbox{}).
+		Where("device_token_id = ? AND status = ?", device.ID, "pending").
+		Update("status", "cancelled").Error; err != nil {
+		return fmt.Errorf("failed to cancel pushes: %w", err)
+	}
+	if err := tx.Delete(&device).Error; err != nil {
+		return fmt.Errorf("failed to delete device token: %w", err)
+	}
+	return nil
+}
+
+type NotificationService struct {
+	db     *gorm.DB
+	sender adapter.PushSender
+}
+
+func NewNotificationService(db *gorm.DB, sender adapter.PushSender) *NotificationService {
+	return &NotificationService{db: db, sender: sender}
+}
+
+// OutboxDispatchResult プッシュ通知の配信結果
+type OutboxDispatchResult struct {
+	Transport string `json:"transport"`
+	Sent      int    `json:"sent"`
+	Retrying  int    `json:"retrying"`  // 失敗して再送を待っている
+	Dead      int    `json:"dead"`      // 再送上限に達した・トークンが失効した
+	Cancelled int    `json:"cancelled"` // 端末の登録が消えていた
+	Errors    int    `json:"errors"`    // 結果を記録できなかった。確保の期限が切れた後にもう一度送る
+}
+
+// DispatchOutbox 送信時刻を過ぎたプッシュ通知を送る。失敗したものは指数バックオフで再送を予約する
+// 送る分を短いトランザクションで確保してから、トランザクションの外で1件ずつ送って結果を記録する。
+// 途中でDBエラーが起きても送信済みの記録は巻き戻らず、記録できなかった通知は確保の期限切れ後にもう一度送られる
+func (s *NotificationService) DispatchOutbox() (*OutboxDispatchResult, error) {
+	result := &OutboxDispatchResult{Transport: s.sender.Name()}
+	maxAttempts := pushMaxAttempts()
+
+	rows, err := s.claimOutbox(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		var device models.DeviceToken
+		err := s.db.First(&device, "id = ?", row.DeviceTokenID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && device.UserID != row.UserID) {
+			if err := s.db.Model(row).Update("status", "cancelled").Error; err != nil {
+				log.Printf("[DispatchOutbox] failed to cancel push %s: %v", row.ID, err)
+				result.Errors++
+				continue
+			}
+			result.Cancelled++
+			continue
+		}
+		if err != nil {
+			log.Printf("[DispatchOutbox] failed to fetch device %s: %v", row.DeviceTokenID, err)
+			result.Errors++
+			continue
+		}
+
+		data := map[string]string{"type": row.Type, "notification_id": row.NotificationID}
+		if row.TeamID != nil {
+			data["team_id"] = *row.TeamID
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+		sendErr := s.sender.Send(ctx, adapter.PushMessage{
+			Token: device.Token,
+			Title: row.Title,
+			Body:  row.Body,
+			Data:  data,
+		})
+		cancel()
+
+		now := time.Now()
+		attempts := row.Attempts + 1
+		updates := map[string]interface{}{"attempts": attempts, "last_error": ""}
+		if sendErr != nil {
+			log.Printf("[DispatchOutbox] failed to send push %s (attempt %d): %v", row.ID, attempts, sendErr)
+			updates["last_error"] = sendErr.Error()
+		}
+		var outcome *int
+		switch {
+		case sendErr == nil:
+			updates["status"] = "sent"
+			updates["sent_at"] = now
+			outcome = &result.Sent
+		case errors.Is(sendErr, adapter.ErrInvalidPushToken), attempts >= maxAttempts:
+			updates["status"] = "dead"
+			outcome = &result.Dead
+		default:
+			updates["next_attempt_at"] = now.Add(pushRetryDelay(attempts))
+			outcome = &result.Retrying
+		}
+		if err := s.db.Model(row).Updates(updates).Error; err != nil {
+			log.Printf("[DispatchOutbox] failed to record push %s: %v", row.ID, err)
+			result.Errors++
+			continue
+		}
+		*outcome++
+
+		// 失効したトークンは登録ごと消す
+		if errors.Is(sendErr, adapter.ErrInvalidPushToken) {
+			if err := s.db.Transaction(func(tx *gorm.DB) error {
+				return UnregisterDevice(tx, device)
+			}); err != nil {
+				log.Printf("[DispatchOutbox] failed to unregister device %s: %v", device.ID, err)
+				result.Errors++
+			}
+		}
+	}
+	return result, nil
+}
+
+// claimOutbox 送る通知を確保する。next_attempt_at を確保の期限まで進めておき、複数のcronが同時に動いても同じ行を二重に送らないようにする
+func (s *NotificationService) claimOutbox(now time.Time) ([]models.NotificationOutbox, error) {
+	var rows []models.NotificationOutbox
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("next_attempt_at ASC").
+			Limit(pushDispatchBatch).
+			Find(&rows).Error; err != nil {
+			return fmt.Errorf("failed to fetch outbox: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		ids := make([]string, len(rows))
+		for i, r := range rows {
+			ids[i] = r.ID
+		}
+		if err := tx.Model(&models.NotificationOutbox{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(pushClaimLease)).Error; err != nil {
+			return fmt.Errorf("failed to claim outbox: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// pushRetryDelay attempts 回失敗した後、次に送るまでの待ち時間
+func pushRetryDelay(attempts int) time.Duration {
+	delay := pushRetryBase
+	for i := 1; i < attempts && delay < pushRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > pushRetryMax {
+		delay = pushRetryMax
+	}
+	return delay
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// NotificationLocales 通知文面の言語
+var NotificationLocales = []string{"ja", "en"}
+
+// NotificationCategories 通知設定でまとめてオン・オフできる通知の種類
+// nudge は自分への声かけ、teammate_nudge はチームメイトを応援してほしいという声かけ。ミュートするとアプリ内通知も作らない
+// social は自分のアクティビティやコメントへのコメント
+var NotificationCategories = []string{"review", "team", "evaluation", "vote", "goal", "nudge", "teammate_nudge", "social"}
+
+// notificationCategory 通知タイプが属するカテゴリ
+var notificationCategory = map[string]string{
+	"activity_rejected":       "review",
+	"appeal_filed":            "review",
+	"appeal_resolved":         "review",
+	"member_joined":           "team",
+	"team_expired":            "team",
+	"week_frozen":             "team",
+	"hp_lost":                 "evaluation",
+	"team_disbanded":          "evaluation",
+	"team_revived":            "evaluation",
+	"disband_vote_started":    "vote",
+	"member_goal_proposed":    "goal",
+	"nudge_behind_pace":       "nudge",
+	"nudge_weak_weekday":      "nudge",
+	"nudge_teammate_finished": "nudge",
+	"nudge_teammate_behind":   "teammate_nudge",
+	"activity_commented":      "social",
+	"comment_replied":         "social",
+}
+
+type notificationTemplate struct {
+	Title string
+	Body  string
+}
+
+// notificationTemplates 通知タイプ・言語ごとの文面。{{.xxx}} を Notify の params で置き換える
+var notificationTemplates = map[string]map[string]notificationTemplate{
+	"activity_rejected": {
+		"ja": {"アクティビティが否認されました", "{{.date}}のアクティビティが{{.rejections}}人に否認されました（理由: {{.reason_ja}}）。レビュー期間が終わるまでに承認されなければ週次評価に含まれません"},
+		"en": {"Your activity was rejected", "{{.rejections}} teammate(s) rejected your activity on {{.date}} ({{.reason_en}}). It won't count toward the weekly evaluation unless the decision changes before the review window closes."},
+	},
+	"appeal_filed": {
+		"ja": {"否認への異議申し立てがありました", "{{.member}}さんが{{.date}}のアクティビティの否認に異議を申し立てました。{{.hours}}時間以内に投票してください"},
+		"en": {"An activity rejection was appealed", "{{.member}} appealed the rejection of their activity on {{.date}}. Please vote within {{.hours}} hours."},
+	},
+	"appeal_resolved": {
+		"ja": {"異議申し立ての結果が出ました", "{{.date}}のアクティビティへの異議申し立ては、{{.result_ja}}"},
+		"en": {"Your appeal was decided", "Your appeal for the activity on {{.date}} was decided: {{.result_en}}."},
+	},
+	"member_joined": {
+		"ja": {"新しいメンバーが参加しました", "{{.member}}さんが「{{.team}}」に参加しました"},
+		"en": {"A new teammate joined", "{{.member}} joined \"{{.team}}\"."},
+	},
+	"hp_lost": {
+		"ja": {"チームのHPが減りました", "第{{.week}}週の評価で「{{.team}}」のHPが{{.damage}}減り、残り{{.hp}}になりました"},
+		"en": {"Your team lost HP", "\"{{.team}}\" lost {{.damage}} HP in the week {{.week}} evaluation. {{.hp}} HP left."},
+	},
+	"team_disbanded": {
+		"ja": {"チームが解散しました", "第{{.week}}週の評価で「{{.team}}」のHPが0になり、チームは解散しました"},
+		"en": {"Your team was disbanded", "\"{{.team}}\" ran out of HP in the week {{.week}} evaluation and was disbanded."},
+	},
+	"team_revived": {
+		"ja": {"チームが復活しました", "異議申し立てで第{{.week}}週を評価し直した結果「{{.team}}」のHPが{{.hp}}に戻り、チームは{{.status_ja}}"},
+		"en": {"Your team is back", "After an appeal, week {{.week}} was re-evaluated and \"{{.team}}\" is back at {{.hp}} HP. {{.status_en}}"},
+	},
+	"disband_vote_started": {
+		"ja": {"解散投票が始まりました", "{{.proposer}}さんが「{{.team}}」の解散を提案しました。{{.expires}}までに投票してください"},
+		"en": {"A disband vote has started", "{{.proposer}} proposed disbanding \"{{.team}}\". Please vote by {{.expires}}."},
+	},
+	"team_expired": {
+		"ja": {"チームの募集期限が切れました", "「{{.team}}」は期限までにメンバーが揃わなかったため終了しました。新しいチームを作成するか、別のチームに参加してください"},
+		"en": {"Your team expired", "\"{{.team}}\" closed because it did not fill up in time. Create a new team or join another one."},
+	},
+	"week_frozen": {
+		"ja": {"メンバーが週をフリーズしました", "{{.member}}さんが第{{.week}}週をフリーズしました。この週は{{.member}}さんの評価が免除されます"},
+		"en": {"A teammate froze a week", "{{.member}} froze week {{.week}} and will not be evaluated that week."},
+	},
+	"member_goal_proposed": {
+		"ja": {"個別目標が提案されました", "「{{.team}}」のリーダーからあなた専用の目標が提案されました。確認して承認してください"},
+		"en": {"A personal goal was proposed", "The leader of \"{{.team}}\" proposed a personal goal for you. Please review and accept it."},
+	},
+	"nudge_behind_pace": {
+		"ja": {"今週のペースが遅れています", "今週の目標まであと{{.remaining_ja}}、残り{{.days}}日です。今日少しでも進めておきましょう"},
+		"en": {"You're behind pace this week", "{{.remaining_en}} to go with {{.days}} day(s) left this week. A little progress today goes a long way."},
+	},
+	"nudge_weak_weekday": {
+		"ja": {"今日は{{.weekday_ja}}です", "{{.weekday_ja}}はいつも運動が途切れがちです。今週の目標まであと{{.remaining_ja}}、今日こそ記録を残しましょう"},
+		"en": {"It's {{.weekday_en}}", "{{.weekday_en}} is usually a tough day for you. {{.remaining_en}} to go this week - let's log something today."},
+	},
+	"nudge_teammate_finished": {
+		"ja": {"{{.member}}さんが運動しました", "{{.member}}さんが今日の運動を終えました。あなたも続きましょう（今週の目標まであと{{.remaining_ja}}）"},
+		"en": {"{{.member}} just worked out", "{{.member}} just finished a workout. Your turn! ({{.remaining_en}} to go this week)"},
+	},
+	"nudge_teammate_behind": {
+		"ja": {"{{.member}}さんを応援しましょう", "今日が今週の最終日です。{{.member}}さんは目標まであと{{.remaining_ja}}です。声をかけてあげましょう"},
+		"en": {"Cheer on {{.member}}", "Today is the last day of the week and {{.member}} still has {{.remaining_en}} to go. Send some encouragement!"},
+	},
+	"activity_commented": {
+		"ja": {"{{.member}}さんがコメントしました", "あなたのアクティビティに{{.member}}さんがコメントしました：「{{.body}}」"},
+		"en": {"{{.member}} commented", "{{.member}} commented on your activity: \"{{.body}}\""},
+	},
+	"comment_replied": {
+		"ja": {"{{.member}}さんが返信しました", "あなたのコメントに{{.member}}さんが返信しました：「{{.body}}」"},
+		"en": {"{{.member}} replied", "{{.member}} replied to your comment: \"{{.body}}\""},
+	},
+}
+
+// renderNotification 通知タイプの文面を locale で組み立てる。その言語の文面がなければ日本語を使う
+func renderNotification(notificationType, locale string, params map[string]string) (title, body string, err error) {
+	templates, ok := notificationTemplates[notificationType]
+	if !ok {
+		return "", "", ErrUnknownNotificationType
+	}
+	tmpl, ok := templates[locale]
+	if !ok {
+		tmpl = templates["ja"]
+	}
+	if title, err = renderText(tmpl.Title, params); err != nil {
+		return "", "", err
+	}
+	if body, err = renderText(tmpl.Body, params); err != nil {
+		return "", "", err
+	}
+	return title, body, nil
+}
+
+func renderText(text string, params map[string]string) (string, error) {
+	t, err := template.New("notification").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
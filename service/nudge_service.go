@@ -0,0 +1,316 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultNudgeDailyLimit 1人が1日に受け取るナッジの上限（NUDGE_DAILY_LIMIT で変更可能）
+	defaultNudgeDailyLimit = 2
+	// nudgePaceSlack 週の経過に対してこれだけ進捗が遅れていたらペース遅れとみなす
+	nudgePaceSlack = 0.2
+)
+
+// NudgeRules ナッジを送るルール
+var NudgeRules = []string{"behind_pace", "weak_weekday", "teammate_finished", "teammate_behind"}
+
+// quietHours 朝型夜型ごとのナッジを送らない時間帯 [start, end)（ユーザーのタイムゾーンの時）
+var quietHours = map[string][2]int{
+	"morning": {21, 6},
+	"night":   {0, 9},
+	"both":    {22, 7},
+}
+
+var (
+	weekdayNamesJA = [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"}
+	weekdayNamesEN = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+func nudgeDailyLimit() int {
+	return envInt("NUDGE_DAILY_LIMIT", defaultNudgeDailyLimit)
+}
+
+// InQuietHours local（ユーザーのタイムゾーンの時刻）が朝型夜型に応じた静かな時間帯か
+func InQuietHours(chronotype string, local time.Time) bool {
+	q, ok := quietHours[chronotype]
+	if !ok {
+		q = quietHours["both"]
+	}
+	h := local.Hour()
+	if q[0] <= q[1] {
+		return h >= q[0] && h < q[1]
+	}
+	return h >= q[0] || h < q[1]
+}
+
+// BehindPace 週の経過日数に対して進捗が遅れているか。初日と週が終わった後は判定しない
+func BehindPace(progress WeekProgress, totalDays, daysRemaining int) bool {
+	if progress.Frozen || progress.Target <= 0 || progress.Remaining() <= 0 {
+		return false
+	}
+	elapsed := totalDays - daysRemaining
+	if totalDays <= 0 || daysRemaining <= 0 || elapsed <= 0 {
+		return false
+	}
+	expected := float64(elapsed) / float64(totalDays)
+	return progress.Achieved/progress.Target < expected-nudgePaceSlack
+}
+
+// NudgeResult ナッジの実行結果
+type NudgeResult struct {
+	EvaluatedMembers int            `json:"evaluated_members"`
+	Sent             map[string]int `json:"sent"` // ルールごとの送信数
+	SkippedQuiet     int            `json:"skipped_quiet_hours"`
+	SkippedLimit     int            `json:"skipped_rate_limit"`
+	SkippedMuted     int            `json:"skipped_muted"`
+}
+
+func newNudgeResult() *NudgeResult {
+	return &NudgeResult{Sent: map[string]int{}}
+}
+
+type NudgeService struct {
+	db                *gorm.DB
+	predictionService *PredictionService
+}
+
+func NewNudgeService(db *gorm.DB, predictionService *PredictionService) *NudgeService {
+	return &NudgeService{db: db, predictionService: predictionService}
+}
+
+// RunNudges active チームのメンバーを見て、ペースが遅れている・苦手な曜日が来た場合に声をかける
+// 最終日にペースが遅れているメンバーがいればチームメイトにも応援を頼む
+func (s *NudgeService) RunNudges(now time.Time) (*NudgeResult, error) {
+	var teams []models.Team
+	if err := s.db.Where("status = ?", "active").Find(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch teams: %w", err)
+	}
+
+	result := newNudgeResult()
+	for _, team := range teams {
+		week := team.CurrentWeek
+		weekStart, _, ok := WeekWindow(team, week)
+		if !ok || now.Before(weekStart) {
+			continue
+		}
+		totalDays := DaysRemaining(team, week, weekStart)
+		daysRemaining := DaysRemaining(team, week, now)
+
+		var members []models.TeamMember
+		if err := s.db.Preload("User").Where("team_id = ?", team.ID).Find(&members).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch members: %w", err)
+		}
+		for _, m := range members {
+			progress, err := MemberWeekProgress(s.db, team, m, week)
+			if err != nil {
+				return nil, err
+			}
+			if progress.Frozen || progress.Remaining() <= 0 {
+				continue
+			}
+			result.EvaluatedMembers++
+			remaining := remainingParams(team.ExerciseType, progress.Remaining())
+
+			if BehindPace(progress, totalDays, daysRemaining) {
+				params := withParams(remaining, map[string]string{"days": strconv.Itoa(daysRemaining)})
+				if err := s.nudge(m.User, team, "behind_pace", params, nil, now, result); err != nil {
+					return nil, err
+				}
+				// 最終日はチームメイトにも応援を頼む
+				if daysRemaining == 1 {
+					params := withParams(remaining, map[string]string{"member": m.User.Name})
+					for _, other := range members {
+						if other.UserID == m.UserID {
+							continue
+						}
+						if err := s.nudge(other.User, team, "teammate_behind", params, &m.UserID, now, result); err != nil {
+							return nil, err
+						}
+					}
+				}
+			}
+
+			if err := s.nudgeWeakWeekday(team, m, remaining, now, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// nudgeWeakWeekday 今日がユーザーの苦手な曜日で、まだ運動していなければ声をかける
+func (s *NudgeService) nudgeWeakWeekday(team models.Team, m models.TeamMember, remaining map[string]string, now time.Time, result *NudgeResult) error {
+	loc := utils.LoadLocation(m.User.Timezone)
+	// 予測の計算は重いので、送れない状況なら先に打ち切る
+	if InQuietHours(m.User.Chronotype, now.In(loc)) {
+		return nil
+	}
+	if sent, err := s.sentToday(m.UserID, "weak_weekday", now, loc); err != nil || sent {
+		return err
+	}
+
+	prediction, err := s.predictionService.PredictMember(team, m, m.User, now)
+	if err != nil {
+		return err
+	}
+	today := int(now.In(loc).Weekday())
+	if !prediction.Weekdays[today].Weak() || prediction.DaysSinceLast == 0 {
+		return nil
+	}
+	params := withParams(remaining, map[string]string{
+		"weekday_ja": weekdayNamesJA[today],
+		"weekday_en": weekdayNamesEN[today],
+	})
+	return s.nudge(m.User, team, "weak_weekday", params, nil, now, result)
+}
+
+// AfterActivity チームメイトが運動を終えたとき、今日まだ運動しておらず目標が残っているメンバーに声をかける
+func (s *NudgeService) AfterActivity(activity models.Activity, now time.Time) (*NudgeResult, error) {
+	result := newNudgeResult()
+	if activity.TeamID == nil || activity.Status != "completed" {
+		return result, nil
+	}
+	var team models.Team
+	if err := s.db.First(&team, "id = ? AND status = ?", *activity.TeamID, "active").Error; err != nil {
+		return result, nil
+	}
+	var actor models.User
+	if err := s.db.First(&actor, "id = ?", activity.UserID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	var members []models.TeamMember
+	if err := s.db.Preload("User").Where("team_id = ? AND user_id <> ?", team.ID, activity.UserID).Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	for _, m := range members {
+		progress, err := MemberWeekProgress(s.db, team, m, team.CurrentWeek)
+		if err != nil {
+			return nil, err
+		}
+		if progress.Frozen || progress.Remaining() <= 0 {
+			continue
+		}
+		result.EvaluatedMembers++
+
+		loc := utils.LoadLocation(m.User.Timezone)
+		var today int64
+		if err := s.db.Model(&models.Activity{}).
+			Where("user_id = ? AND status IN ? AND started_at >= ?", m.UserID, []string{"in_progress", "completed"}, utils.StartOfDay(now, loc)).
+			Count(&today).Error; err != nil {
+			return nil, fmt.Errorf("failed to count activities: %w", err)
+		}
+		if today > 0 {
+			continue
+		}
+
+		params := withParams(remainingParams(team.ExerciseType, progress.Remaining()), map[string]string{"member": actor.Name})
+		if err := s.nudge(m.User, team, "teammate_finished", params, &activity.UserID, now, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// nudge 静かな時間帯・ミュート・送信数の制限を確認してから通知する
+// 同じルールは1日1回まで、ナッジ全体では1日 NUDGE_DAILY_LIMIT 回まで
+func (s *NudgeService) nudge(user models.User, team models.Team, rule string, params map[string]string, triggeredBy *string, now time.Time, result *NudgeResult) error {
+	notificationType := "nudge_" + rule
+	loc := utils.LoadLocation(user.Timezone)
+	if InQuietHours(user.Chronotype, now.In(loc)) {
+		result.SkippedQuiet++
+		return nil
+	}
+
+	pref, err := NotificationPreferenceFor(s.db, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, c := range MutedCategories(pref) {
+		if c == notificationCategory[notificationType] {
+			result.SkippedMuted++
+			return nil
+		}
+	}
+
+	dayKey := now.In(loc).Format("2006-01-02")
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var sentToday []models.Nudge
+		if err := tx.Where("user_id = ? AND day_key = ?", user.ID, dayKey).Find(&sentToday).Error; err != nil {
+			return fmt.Errorf("failed to fetch nudges: %w", err)
+		}
+		if len(sentToday) >= nudgeDailyLimit() {
+			result.SkippedLimit++
+			return nil
+		}
+		for _, n := range sentToday {
+			if n.Rule == rule {
+				result.SkippedLimit++
+				return nil
+			}
+		}
+
+		nudge := models.Nudge{
+			ID:          utils.GenerateULID(),
+			UserID:      user.ID,
+			TeamID:      team.ID,
+			Rule:        rule,
+			DayKey:      dayKey,
+			WeekNumber:  team.CurrentWeek,
+			TriggeredBy: triggeredBy,
+		}
+		if err := tx.Create(&nudge).Error; err != nil {
+			return fmt.Errorf("failed to create nudge: %w", err)
+		}
+		if err := Notify(tx, user.ID, notificationType, withParams(params, map[string]string{"team": team.Name}), &team.ID); err != nil {
+			return err
+		}
+		result.Sent[rule]++
+		return nil
+	})
+}
+
+// sentToday ユーザーのタイムゾーンで今日、そのルールのナッジを送ったか
+func (s *NudgeService) sentToday(userID, rule string, now time.Time, loc *time.Location) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.Nudge{}).
+		Where("user_id = ? AND rule = ? AND day_key = ?", userID, rule, now.In(loc).Format("2006-01-02")).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to count nudges: %w", err)
+	}
+	return count > 0, nil
+}
+
+// remainingParams 目標までの残りを通知の文面用に言語ごとに整形する
+func remainingParams(exerciseType string, remaining float64) map[string]string {
+	if exerciseType == "gym" {
+		visits := int(math.Ceil(remaining))
+		return map[string]string{
+			"remaining_ja": fmt.Sprintf("%d回", visits),
+			"remaining_en": fmt.Sprintf("%d visit(s)", visits),
+		}
+	}
+	km := strconv.FormatFloat(math.Ceil(remaining*10)/10, 'f', -1, 64)
+	return map[string]string{
+		"remaining_ja": km + "km",
+		"remaining_en": km + " km",
+	}
+}
+
+func withParams(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
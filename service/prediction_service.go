@@ -0,0 +1,519 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// PredictionHistoryWeeks 習慣の分析に使う期間
+	PredictionHistoryWeeks = 8
+	// predictionMinHourSamples 開始時刻の傾向を実績から判断するのに必要なアクティビティ数。足りない場合は朝型夜型を使う
+	predictionMinHourSamples = 5
+)
+
+var ErrNoActiveTeam = errors.New("user has no active team")
+
+// chronotypeHours 朝型夜型ごとの運動しやすい時刻（実績が少ない場合の事前分布）
+var chronotypeHours = map[string][]int{
+	"morning": {6, 7, 8},
+	"night":   {19, 20, 21},
+	"both":    {7, 12, 19},
+}
+
+// WeekProgress メンバーの今週の目標と進捗
+type WeekProgress struct {
+	Target      float64 // running は km、gym は目標滞在時間を満たした訪問回数。倍率・按分率を反映済み
+	Achieved    float64
+	MinDuration *int
+	Frozen      bool
+}
+
+// Remaining 目標までの残り
+func (p WeekProgress) Remaining() float64 {
+	if p.Frozen {
+		return 0
+	}
+	return math.Max(0, p.Target-p.Achieved)
+}
+
+// DayPrediction 今週の残りの各日の予測
+type DayPrediction struct {
+	Date        time.Time
+	DayOfWeek   int
+	Probability float64 // その日に運動する確率
+	Factors     []string
+}
+
+// SuggestedSlot 運動を勧める時間帯
+type SuggestedSlot struct {
+	Start       time.Time
+	End         time.Time
+	Probability float64
+	Reason      string
+}
+
+// WeekdayStat 過去の曜日別の運動実績
+type WeekdayStat struct {
+	DayOfWeek   int
+	ActiveDays  int
+	TotalDays   int
+	SuccessRate float64
+}
+
+// Weak 運動が途切れがちな曜日か。データが少ない（運動した日が2日未満）曜日は判定しない
+func (w WeekdayStat) Weak() bool {
+	return w.ActiveDays >= 2 && w.SuccessRate < 0.4
+}
+
+// Prediction 今週の目標を達成できない確率の予測
+type Prediction struct {
+	Team            models.Team
+	WeekNumber      int
+	WeekStart       time.Time
+	WeekEnd         time.Time
+	Progress        WeekProgress
+	NeededDays      int // 目標達成にあと何日運動が必要か
+	MissProbability float64
+	StreakWeeks     int // 直近で連続して目標を達成した週数
+	DaysSinceLast   int // 最後に運動してからの日数（記録がない場合は -1）
+	TeammatesActive float64
+	Weekdays        []WeekdayStat
+	Days            []DayPrediction
+	Slots           []SuggestedSlot
+}
+
+type PredictionService struct {
+	db *gorm.DB
+}
+
+func NewPredictionService(db *gorm.DB) *PredictionService {
+	return &PredictionService{db: db}
+}
+
+// ActiveTeamFor ユーザーが所属している active チームを返す
+func ActiveTeamFor(tx *gorm.DB, userID string) (models.Team, models.TeamMember, error) {
+	var member models.TeamMember
+	err := tx.Preload("Team").
+		Joins("JOIN teams ON teams.id = team_members.team_id").
+		Where("team_members.user_id = ? AND teams.status = ?", userID, "active").
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Team{}, models.TeamMember{}, ErrNoActiveTeam
+	}
+	if err != nil {
+		return models.Team{}, models.TeamMember{}, fmt.Errorf("failed to fetch team: %w", err)
+	}
+	return member.Team, member, nil
+}
+
+// MemberWeekProgress 週次評価と同じ基準で、メンバーの week 週目の目標と進捗を求める
+func MemberWeekProgress(tx *gorm.DB, team models.Team, member models.TeamMember, week int) (WeekProgress, error) {
+	goal, _, err := GoalForWeek(tx, team.ID, week)
+	if err != nil {
+		return WeekProgress{}, fmt.Errorf("goal not found: %w", err)
+	}
+	memberGoal, _, err := MemberGoalForWeek(tx, goal, member.UserID, week)
+	if err != nil {
+		return WeekProgress{}, err
+	}
+	freeze, err := FrozenWeek(tx, team.ID, member.UserID, week)
+	if err != nil {
+		return WeekProgress{}, err
+	}
+
+	multiplier := member.TargetMultiplier
+	if multiplier <= 0 {
+		multiplier = 1.0
+	}
+	multiplier *= TargetProration(team, member.JoinedAt, week)
+
+	progress := WeekProgress{MinDuration: memberGoal.TargetMinDurationMin, Frozen: freeze != nil}
+	switch team.ExerciseType {
+	case "running":
+		if memberGoal.TargetDistanceKM != nil {
+			progress.Target = *memberGoal.TargetDistanceKM * multiplier
+		}
+	case "gym":
+		if memberGoal.TargetVisitsPerWeek != nil {
+			progress.Target = float64(*memberGoal.TargetVisitsPerWeek) * multiplier
+		}
+	}
+
+	weekStart, weekEnd, ok := WeekWindow(team, week)
+	if !ok {
+		return progress, nil
+	}
+	var activities []models.Activity
+	if err := tx.Where("user_id = ? AND team_id = ? AND status = ? AND started_at >= ? AND started_at < ? AND (review_status IS NULL OR review_status != ?)",
+		member.UserID, team.ID, "completed", weekStart, weekEnd, "rejected").
+		Find(&activities).Error; err != nil {
+		return progress, fmt.Errorf("failed to fetch activities: %w", err)
+	}
+	for _, a := range activities {
+		progress.Achieved += activityContribution(team.ExerciseType, a, progress.MinDuration)
+	}
+	return progress, nil
+}
+
+// activityContribution アクティビティが目標に寄与する量
+func activityContribution(exerciseType string, a models.Activity, minDuration *int) float64 {
+	switch exerciseType {
+	case "running":
+		return a.DistanceKM
+	case "gym":
+		if a.ExerciseType == "gym" && (minDuration == nil || a.DurationMin >= *minDuration) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// habitProfile 過去のアクティビティから求めた運動習慣
+type habitProfile struct {
+	weekdays     [7]WeekdayStat
+	hourWeights  [24]float64 // 開始時刻の分布（合計1）
+	perDay       float64     // 運動した日1日あたりの目標への寄与
+	activeDates  map[string]bool
+	lastActivity *time.Time
+}
+
+// PredictForUser ユーザーの active チームについて、今週の残りの日ごとの運動確率と目標未達成の確率、おすすめの時間帯を求める
+func (s *PredictionService) PredictForUser(userID string, now time.Time) (*Prediction, error) {
+	team, member, err := ActiveTeamFor(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return s.PredictMember(team, member, user, now)
+}
+
+// PredictMember チームのメンバー1人について今週の予測を求める
+func (s *PredictionService) PredictMember(team models.Team, member models.TeamMember, user models.User, now time.Time) (*Prediction, error) {
+	week := team.CurrentWeek
+	weekStart, weekEnd, ok := WeekWindow(team, week)
+	if !ok {
+		return nil, ErrNoActiveTeam
+	}
+	progress, err := MemberWeekProgress(s.db, team, member, week)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := utils.LoadLocation(user.Timezone)
+	habits, err := s.loadHabits(team, user, progress.MinDuration, now, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Prediction{
+		Team:          team,
+		WeekNumber:    week,
+		WeekStart:     weekStart,
+		WeekEnd:       weekEnd,
+		Progress:      progress,
+		DaysSinceLast: -1,
+	}
+	result.Weekdays = habits.weekdays[:]
+	if habits.lastActivity != nil {
+		result.DaysSinceLast = utils.DaysBetween(*habits.lastActivity, now, loc)
+	}
+	if result.StreakWeeks, err = s.streakWeeks(team.ID, user.ID); err != nil {
+		return nil, err
+	}
+	if result.TeammatesActive, err = s.teammatesActive(team, user.ID, now); err != nil {
+		return nil, err
+	}
+
+	// 今週の残りの日ごとの運動確率
+	dayStart := utils.StartOfDay(now, loc)
+	if weekStart.After(now) {
+		dayStart = utils.StartOfDay(weekStart, loc)
+	}
+	todayKey := now.In(loc).Format("2006-01-02")
+	for d := dayStart; d.Before(weekEnd); d = d.AddDate(0, 0, 1) {
+		result.Days = append(result.Days, predictDay(d, now, todayKey, habits, user.Chronotype, result))
+	}
+
+	remaining := progress.Remaining()
+	if remaining > 0 {
+		result.NeededDays = int(math.Ceil(remaining / habits.perDay))
+		probs := make([]float64, len(result.Days))
+		for i, d := range result.Days {
+			probs[i] = d.Probability
+		}
+		result.MissProbability = math.Round(probAtMost(probs, result.NeededDays-1)*100) / 100
+		result.Slots = suggestSlots(team, result.Days, habits, progress.MinDuration, result.NeededDays, now, weekEnd)
+	}
+	return result, nil
+}
+
+// loadHabits 直近 PredictionHistoryWeeks 週のアクティビティから曜日・時刻ごとの傾向を求める
+func (s *PredictionService) loadHabits(team models.Team, user models.User, minDuration *int, now time.Time, loc *time.Location) (*habitProfile, error) {
+	since := utils.StartOfDay(now, loc).AddDate(0, 0, -PredictionHistoryWeeks*7)
+
+	var activities []models.Activity
+	if err := s.db.Where("user_id = ? AND exercise_type = ? AND status = ? AND started_at >= ? AND (review_status IS NULL OR review_status != ?)",
+		user.ID, team.ExerciseType, "completed", since, "rejected").
+		Order("started_at ASC").
+		Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activities: %w", err)
+	}
+
+	habits := &habitProfile{activeDates: make(map[string]bool)}
+	for i := range habits.weekdays {
+		habits.weekdays[i].DayOfWeek = i
+	}
+	// 今日は終わっていないので分母に含めない
+	for d := since; d.Before(utils.StartOfDay(now, loc)); d = d.AddDate(0, 0, 1) {
+		habits.weekdays[d.Weekday()].TotalDays++
+	}
+
+	contributionByDate := make(map[string]float64)
+	var hours [24]float64
+	for _, a := range activities {
+		local := a.StartedAt.In(loc)
+		key := local.Format("2006-01-02")
+		if !habits.activeDates[key] && local.Before(utils.StartOfDay(now, loc)) {
+			habits.weekdays[local.Weekday()].ActiveDays++
+		}
+		habits.activeDates[key] = true
+		hours[local.Hour()]++
+		contributionByDate[key] += activityContribution(team.ExerciseType, a, minDuration)
+		startedAt := a.StartedAt
+		habits.lastActivity = &startedAt
+	}
+	for i := range habits.weekdays {
+		w := &habits.weekdays[i]
+		if w.TotalDays > 0 {
+			w.SuccessRate = float64(w.ActiveDays) / float64(w.TotalDays)
+		}
+	}
+
+	// 開始時刻の分布。実績が少ない場合は朝型夜型の時刻を混ぜる
+	prior := chronotypeHours[user.Chronotype]
+	if prior == nil {
+		prior = chronotypeHours["both"]
+	}
+	priorWeight := 0.0
+	if len(activities) < predictionMinHourSamples {
+		priorWeight = float64(predictionMinHourSamples - len(activities))
+	}
+	total := 0.0
+	for h := range hours {
+		habits.hourWeights[h] = hours[h] + 0.05 // どの時刻もわずかに可能性を残す
+	}
+	for _, h := range prior {
+		habits.hourWeights[h] += priorWeight / float64(len(prior))
+	}
+	for _, w := range habits.hourWeights {
+		total += w
+	}
+	for h := range habits.hourWeights {
+		habits.hourWeights[h] /= total
+	}
+
+	// 運動した日1日あたりの寄与。記録がない場合は gym 1回、running 3km とみなす
+	habits.perDay = 1
+	if team.ExerciseType == "running" {
+		habits.perDay = 3
+	}
+	if len(contributionByDate) > 0 {
+		sum := 0.0
+		for _, v := range contributionByDate {
+			sum += v
+		}
+		if avg := sum / float64(len(contributionByDate)); avg > 0 {
+			habits.perDay = avg
+		}
+	}
+	return habits, nil
+}
+
+// predictDay その日に運動する確率を、曜日の実績を基準に時刻・連続達成・チームメイトの活動で補正して求める
+func predictDay(day, now time.Time, todayKey string, habits *habitProfile, chronotype string, p *Prediction) DayPrediction {
+	w := habits.weekdays[day.Weekday()]
+	// 曜日の実績（事前分布で平滑化）
+	prob := (float64(w.ActiveDays) + 1) / (float64(w.TotalDays) + 3)
+	result := DayPrediction{Date: day, DayOfWeek: int(day.Weekday())}
+	if w.TotalDays >= 2 && w.SuccessRate < 0.4 {
+		result.Factors = append(result.Factors, "weekday_low")
+	}
+
+	logit := math.Log(prob / (1 - prob))
+	if p.StreakWeeks >= 2 {
+		logit += 0.15 * math.Min(float64(p.StreakWeeks), 4)
+		result.Factors = append(result.Factors, "streak")
+	}
+	if p.DaysSinceLast >= 4 {
+		logit -= 0.2 * math.Min(float64(p.DaysSinceLast-3), 4)
+		result.Factors = append(result.Factors, "inactive_recently")
+	}
+	if p.TeammatesActive >= 0.5 {
+		logit += 0.4 * p.TeammatesActive
+		result.Factors = append(result.Factors, "teammates_active")
+	}
+	prob = 1 / (1 + math.Exp(-logit))
+
+	// 今日は残り時間に習慣的な時刻がどれだけ含まれるかで割り引く
+	if day.Format("2006-01-02") == todayKey {
+		if habits.activeDates[todayKey] {
+			prob *= 0.3
+			result.Factors = append(result.Factors, "already_active_today")
+		}
+		remaining := 0.0
+		for h := now.In(day.Location()).Hour() + 1; h < 24; h++ {
+			remaining += habits.hourWeights[h]
+		}
+		prob *= remaining
+		if remaining < 0.3 {
+			result.Factors = append(result.Factors, "usual_hours_passed")
+		}
+	}
+	if chronotypeMismatch(habits, chronotype) {
+		result.Factors = append(result.Factors, "chronotype_mismatch")
+	}
+
+	result.Probability = math.Round(prob*100) / 100
+	return result
+}
+
+// chronotypeMismatch 実際に運動している時刻が登録された朝型夜型とずれているか
+func chronotypeMismatch(habits *habitProfile, chronotype string) bool {
+	hours, ok := chronotypeHours[chronotype]
+	if !ok || chronotype == "both" {
+		return false
+	}
+	mass := 0.0
+	for _, h := range hours {
+		for d := -1; d <= 1; d++ {
+			if hh := h + d; hh >= 0 && hh < 24 {
+				mass += habits.hourWeights[hh]
+			}
+		}
+	}
+	return mass < 0.2
+}
+
+// suggestSlots 運動確率の高い日から必要な日数分、習慣的な時刻を勧める
+func suggestSlots(team models.Team, days []DayPrediction, habits *habitProfile, minDuration *int, needed int, now, weekEnd time.Time) []SuggestedSlot {
+	order := make([]int, len(days))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return days[order[a]].Probability > days[order[b]].Probability })
+
+	length := 60 * time.Minute
+	if team.ExerciseType == "gym" && minDuration != nil && *minDuration > 0 {
+		length = time.Duration(*minDuration+15) * time.Minute
+	}
+
+	var slots []SuggestedSlot
+	for _, i := range order {
+		if len(slots) >= needed {
+			break
+		}
+		day := days[i]
+		hour, ok := bestHour(habits, day.Date, now, weekEnd, length)
+		if !ok {
+			continue
+		}
+		start := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), hour, 0, 0, 0, day.Date.Location())
+		reason := "usual_time"
+		if habits.lastActivity == nil {
+			reason = "chronotype"
+		}
+		slots = append(slots, SuggestedSlot{Start: start, End: start.Add(length), Probability: day.Probability, Reason: reason})
+	}
+	sort.Slice(slots, func(a, b int) bool { return slots[a].Start.Before(slots[b].Start) })
+	return slots
+}
+
+// bestHour その日のうち、まだ過ぎておらず週内に終わる時刻から最も習慣的な時刻を選ぶ
+func bestHour(habits *habitProfile, day, now, weekEnd time.Time, length time.Duration) (int, bool) {
+	best, bestWeight := -1, -1.0
+	for h := 5; h <= 22; h++ {
+		start := time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, day.Location())
+		if !start.After(now) || start.Add(length).After(weekEnd) {
+			continue
+		}
+		if habits.hourWeights[h] > bestWeight {
+			best, bestWeight = h, habits.hourWeights[h]
+		}
+	}
+	return best, best >= 0
+}
+
+// probAtMost 各日の運動確率が独立なとき、運動する日数が k 日以下になる確率（ポアソン二項分布）
+func probAtMost(probs []float64, k int) float64 {
+	if k < 0 {
+		return 0
+	}
+	dist := make([]float64, len(probs)+1)
+	dist[0] = 1
+	for i, p := range probs {
+		for j := i + 1; j > 0; j-- {
+			dist[j] = dist[j]*(1-p) + dist[j-1]*p
+		}
+		dist[0] *= 1 - p
+	}
+	sum := 0.0
+	for j := 0; j <= k && j < len(dist); j++ {
+		sum += dist[j]
+	}
+	return math.Min(1, sum)
+}
+
+// streakWeeks 直近で連続して目標を達成した週数（フリーズした週は飛ばす）
+func (s *PredictionService) streakWeeks(teamID, userID string) (int, error) {
+	var evals []models.WeeklyEvaluation
+	if err := s.db.Where("team_id = ? AND user_id = ?", teamID, userID).
+		Order("week_number DESC").
+		Find(&evals).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch evaluations: %w", err)
+	}
+	streak := 0
+	for _, e := range evals {
+		if e.Frozen {
+			continue
+		}
+		if !e.TargetMet {
+			break
+		}
+		streak++
+	}
+	return streak, nil
+}
+
+// teammatesActive 直近48時間に運動したチームメイトの割合
+func (s *PredictionService) teammatesActive(team models.Team, userID string, now time.Time) (float64, error) {
+	var teammates []models.TeamMember
+	if err := s.db.Where("team_id = ? AND user_id <> ?", team.ID, userID).Find(&teammates).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	if len(teammates) == 0 {
+		return 0, nil
+	}
+	ids := make([]string, len(teammates))
+	for i, m := range teammates {
+		ids[i] = m.UserID
+	}
+	var active int64
+	if err := s.db.Model(&models.Activity{}).
+		Where("user_id IN ? AND team_id = ? AND status = ? AND started_at >= ?", ids, team.ID, "completed", now.Add(-48*time.Hour)).
+		Distinct("user_id").
+		Count(&active).Error; err != nil {
+		return 0, fmt.Errorf("failed to count teammate activities: %w", err)
+	}
+	return float64(active) / float64(len(teammates)), nil
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProposalNotOpen = errors.New("proposal is not open")
+	ErrAlreadyVoted    = errors.New("already voted")
+	ErrNotEligible     = errors.New("user is not eligible to vote")
+	ErrVoteNotFound    = errors.New("vote not found")
+)
+
+// settingsProposalTTL 設定変更提案の投票期限
+const settingsProposalTTL = 72 * time.Hour
+
+// 解散投票の既定値。環境変数 DISBAND_VOTE_TTL_HOURS / DISBAND_VOTE_COOLDOWN_HOURS / DISBAND_INACTIVE_DAYS で上書きできる
+const (
+	defaultDisbandVoteTTLHours      = 72
+	defaultDisbandVoteCooldownHours = 24
+	defaultDisbandInactiveDays      = 14
+)
+
+// ProposalCooldownError 前回の解散投票が不成立になってから間がなく、新しい投票を始められないことを表す
+type ProposalCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ProposalCooldownError) Error() string {
+	return fmt.Sprintf("proposal is in cooldown for %s", e.RetryAfter)
+}
+
+func disbandVoteTTL() time.Duration {
+	return time.Duration(envInt("DISBAND_VOTE_TTL_HOURS", defaultDisbandVoteTTLHours)) * time.Hour
+}
+
+func disbandVoteCooldown() time.Duration {
+	return time.Duration(envInt("DISBAND_VOTE_COOLDOWN_HOURS", defaultDisbandVoteCooldownHours)) * time.Hour
+}
+
+func disbandInactivePeriod() time.Duration {
+	return time.Duration(envInt("DISBAND_INACTIVE_DAYS", defaultDisbandInactiveDays)) * 24 * time.Hour
+}
+
+// envInt 正の整数の環境変数を読み取る。未設定・不正な値の場合は既定値を返す
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+type ProposalService struct {
+	db                *gorm.DB
+	membershipService *MembershipService
+}
+
+func NewProposalService(db *gorm.DB, membershipService *MembershipService) *ProposalService {
+	return &ProposalService{db: db, membershipService: membershipService}
+}
+
+// ProposalTally 提案の投票集計
+type ProposalTally struct {
+	EligibleCount int
+	ApproveCount  int
+	RejectCount   int
+	RequiredCount int
+	Inactive      bool // 解散提案で、チームの活動が途絶えているため過半数で可決できる
+}
+
+// RequiredProposalVotes 提案の種類ごとに可決に必要な賛成数を返す
+// 除名は RequiredRemovalVotes、設定変更はメンバーの過半数。解散は RequiredTeamDisbandVotes を使う
+func RequiredProposalVotes(proposalType string, memberCount int) int {
+	switch proposalType {
+	case "disband":
+		return RequiredDisbandVotes(memberCount)
+	case "remove_member":
+		return RequiredRemovalVotes(memberCount)
+	default:
+		return memberCount/2 + 1
+	}
+}
+
+// RequiredTeamDisbandVotes チームの解散条件に応じた解散に必要な投票数を返す
+// unanimous は常に全員、majority_when_inactive は通常 RequiredDisbandVotes、活動が途絶えていれば過半数
+func RequiredTeamDisbandVotes(team models.Team, memberCount int, inactive bool) int {
+	if team.DisbandThreshold == "unanimous" {
+		return memberCount
+	}
+	if inactive {
+		return memberCount/2 + 1
+	}
+	return RequiredDisbandVotes(memberCount)
+}
+
+// TeamInactive チームで DISBAND_INACTIVE_DAYS 日以上活動が記録されていないかを返す
+// 結成・開始から日が浅いチームは活動がなくても対象外
+func TeamInactive(tx *gorm.DB, team models.Team, now time.Time) (bool, error) {
+	since := now.Add(-disbandInactivePeriod())
+	base := team.CreatedAt
+	if team.StartedAt != nil {
+		base = *team.StartedAt
+	}
+	if base.After(since) {
+		return false, nil
+	}
+
+	var recentCount int64
+	if err := tx.Model(&models.Activity{}).
+		Where("team_id = ? AND started_at > ?", team.ID, since).
+		Count(&recentCount).Error; err != nil {
+		return false, fmt.Errorf("failed to count recent activities: %w", err)
+	}
+	return recentCount == 0, nil
+}
+
+// TallyProposal 現在のメンバー構成で提案の投票を集計する
+func TallyProposal(tx *gorm.DB, p models.TeamProposal) (ProposalTally, error) {
+	var memberCount int64
+	if err := tx.Model(&models.TeamMember{}).Where("team_id = ?", p.TeamID).Count(&memberCount).Error; err != nil {
+		return ProposalTally{}, fmt.Errorf("failed to count members: %w", err)
+	}
+
+	var votes []models.TeamProposalVote
+	if err := tx.Where("proposal_id = ?", p.ID).Find(&votes).Error; err != nil {
+		return ProposalTally{}, fmt.Errorf("failed to fetch votes: %w", err)
+	}
+
+	tally := ProposalTally{
+		EligibleCount: int(memberCount),
+		RequiredCount: RequiredProposalVotes(p.Type, int(memberCount)),
+	}
+	if p.Type == "remove_member" {
+		tally.EligibleCount--
+	}
+	if p.Type == "disband" {
+		var team models.Team
+		if err := tx.First(&team, "id = ?", p.TeamID).Error; err != nil {
+			return ProposalTally{}, fmt.Errorf("failed to fetch team: %w", err)
+		}
+		inactive, err := TeamInactive(tx, team, time.Now())
+		if err != nil {
+			return ProposalTally{}, err
+		}
+		tally.Inactive = inactive && team.DisbandThreshold != "unanimous"
+		tally.RequiredCount = RequiredTeamDisbandVotes(team, int(memberCount), inactive)
+	}
+	for _, v := range votes {
+		if v.Approve {
+			tally.ApproveCount++
+		} else {
+			tally.RejectCount++
+		}
+	}
+	return tally, nil
+}
+
+// CreateProposal 提案を作成し、提案者の賛成票を入れる
+// 解散提案はクールダウン中なら *ProposalCooldownError を返す
+func (s *ProposalService) CreateProposal(tx *gorm.DB, team models.Team, p *models.TeamProposal) error {
+	now := time.Now()
+	if p.Type == "disband" {
+		until, err := DisbandCooldownUntil(tx, team.ID)
+		if err != nil {
+			return err
+		}
+		if until != nil && until.After(now) {
+			return &ProposalCooldownError{RetryAfter: until.Sub(now)}
+		}
+	}
+
+	p.ID = utils.GenerateULID()
+	p.TeamID = team.ID
+	p.Status = "open"
+	if p.ExpiresAt == nil {
+		var ttl time.Duration
+		switch p.Type {
+		case "change_settings":
+			ttl = settingsProposalTTL
+		case "disband":
+			ttl = disbandVoteTTL()
+		}
+		if ttl > 0 {
+			expiresAt := now.Add(ttl)
+			p.ExpiresAt = &expiresAt
+		}
+	}
+	if err := tx.Create(p).Error; err != nil {
+		return fmt.Errorf("failed to create proposal: %w", err)
+	}
+	if err := s.Vote(tx, p, p.ProposedBy, true); err != nil {
+		return err
+	}
+
+	// 提案者の票だけで決まらなかった解散提案は、他のメンバーに投票を呼びかける
+	if p.Type == "disband" && p.Status == "open" {
+		var proposer models.User
+		tx.Select("name").First(&proposer, "id = ?", p.ProposedBy)
+		expires := ""
+		if p.ExpiresAt != nil {
+			expires = p.ExpiresAt.In(TeamLocation(team)).Format("2006-01-02 15:04")
+		}
+		return NotifyTeam(tx, team.ID, p.ProposedBy, "disband_vote_started", map[string]string{
+			"proposer": proposer.Name,
+			"team":     team.Name,
+			"expires":  expires,
+		})
+	}
+	return nil
+}
+
+// Vote 提案に投票し、可決・否決を判定する
+func (s *ProposalService) Vote(tx *gorm.DB, p *models.TeamProposal, userID string, approve bool) error {
+	if p.Status != "open" {
+		return ErrProposalNotOpen
+	}
+	if p.ExpiresAt != nil && time.Now().After(*p.ExpiresAt) {
+		if err := s.resolve(tx, p); err != nil {
+			return err
+		}
+		return ErrProposalNotOpen
+	}
+
+	var member models.TeamMember
+	if err := tx.Where("team_id = ? AND user_id = ?", p.TeamID, userID).First(&member).Error; err != nil {
+		return ErrNotEligible
+	}
+	if p.TargetUserID != nil && *p.TargetUserID == userID {
+		return ErrNotEligible
+	}
+
+	var existingCount int64
+	tx.Model(&models.TeamProposalVote{}).Where("proposal_id = ? AND user_id = ?", p.ID, userID).Count(&existingCount)
+	if existingCount > 0 {
+		return ErrAlreadyVoted
+	}
+
+	vote := models.TeamProposalVote{
+		ID:         utils.GenerateULID(),
+		ProposalID: p.ID,
+		TeamID:     p.TeamID,
+		UserID:     userID,
+		Approve:    approve,
+	}
+	if err := tx.Create(&vote).Error; err != nil {
+		return fmt.Errorf("failed to create vote: %w", err)
+	}
+
+	return s.resolve(tx, p)
+}
+
+// WithdrawVote 投票を取り消す。投票がなくなった提案は取り下げ扱いにする
+func (s *ProposalService) WithdrawVote(tx *gorm.DB, p *models.TeamProposal, userID string) error {
+	if p.Status != "open" {
+		return ErrProposalNotOpen
+	}
+	result := tx.Where("proposal_id = ? AND user_id = ?", p.ID, userID).Delete(&models.TeamProposalVote{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete vote: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrVoteNotFound
+	}
+
+	var remaining int64
+	tx.Model(&models.TeamProposalVote{}).Where("proposal_id = ?", p.ID).Count(&remaining)
+	if remaining == 0 {
+		return closeProposal(tx, p, "cancelled")
+	}
+	return nil
+}
+
+// Cancel 提案を取り下げる
+func (s *ProposalService) Cancel(tx *gorm.DB, p *models.TeamProposal) error {
+	if p.Status != "open" {
+		return ErrProposalNotOpen
+	}
+	return closeProposal(tx, p, "cancelled")
+}
+
+// Refresh 期限切れの提案を閉じる（参照時に呼び出す）
+func (s *ProposalService) Refresh(tx *gorm.DB, p *models.TeamProposal) error {
+	if !ProposalPastDeadline(*p, time.Now()) {
+		return nil
+	}
+	return s.resolve(tx, p)
+}
+
+// ProposalPastDeadline 受付中のまま投票期限を過ぎた提案か。締め切りは Refresh か定期処理で行う
+func ProposalPastDeadline(p models.TeamProposal, now time.Time) bool {
+	return p.Status == "open" && p.ExpiresAt != nil && !now.Before(*p.ExpiresAt)
+}
+
+// DisbandCooldownUntil 前回の解散提案が否決・期限切れ・取り下げになった場合、次の解散提案を始められる日時を返す
+func DisbandCooldownUntil(tx *gorm.DB, teamID string) (*time.Time, error) {
+	var last models.TeamProposal
+	err := tx.Where("team_id = ? AND type = ? AND status IN ?", teamID, "disband", []string{"rejected", "expired", "cancelled"}).
+		Order("resolved_at DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && last.ResolvedAt == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch last disband proposal: %w", err)
+	}
+	until := last.ResolvedAt.Add(disbandVoteCooldown())
+	return &until, nil
+}
+
+// ProposalExpiryResult 期限切れ提案の処理結果
+type ProposalExpiryResult struct {
+	Resolved    int   `json:"resolved"`
+	Expired     int   `json:"expired"`
+	PurgedVotes int64 `json:"purged_votes"`
+	Failed      int   `json:"failed"` // 締め切りに失敗した提案。次回の実行で再度処理する
+}
+
+// ExpireProposals 投票期限を過ぎた提案を締め切り、期限切れになった提案の投票を削除する
+func (s *ProposalService) ExpireProposals() (*ProposalExpiryResult, error) {
+	var proposals []models.TeamProposal
+	if err := s.db.Where("status = ? AND expires_at <= ?", "open", time.Now()).Order("created_at ASC").Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch expired proposals: %w", err)
+	}
+
+	result := &ProposalExpiryResult{}
+	for i := range proposals {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return s.resolve(tx, &proposals[i])
+		})
+		if err != nil {
+			// この提案は飛ばし、残りの提案の処理を続ける
+			log.Printf("[ExpireProposals] failed to resolve proposal %s: %v", proposals[i].ID, err)
+			result.Failed++
+			continue
+		}
+		result.Resolved++
+		if proposals[i].Status == "expired" {
+			result.Expired++
+		}
+	}
+
+	// 期限切れの提案の票は後から数えられないよう削除する
+	purge := s.db.Where("proposal_id IN (?)", s.db.Model(&models.TeamProposal{}).Select("id").Where("status = ?", "expired")).
+		Delete(&models.TeamProposalVote{})
+	if purge.Error != nil {
+		return nil, fmt.Errorf("failed to purge expired votes: %w", purge.Error)
+	}
+	result.PurgedVotes = purge.RowsAffected
+
+	return result, nil
+}
+
+func (s *ProposalService) resolve(tx *gorm.DB, p *models.TeamProposal) error {
+	return resolveProposal(tx, s.membershipService, p)
+}
+
+// resolveProposal 集計結果から提案を可決・否決・期限切れにする
+func resolveProposal(tx *gorm.DB, ms *MembershipService, p *models.TeamProposal) error {
+	if p.Status != "open" {
+		return nil
+	}
+
+	tally, err := TallyProposal(tx, *p)
+	if err != nil {
+		return err
+	}
+
+	if tally.ApproveCount >= tally.RequiredCount && tally.RequiredCount > 0 {
+		return executeProposal(tx, ms, p)
+	}
+	if p.ExpiresAt != nil && time.Now().After(*p.ExpiresAt) {
+		return closeProposal(tx, p, "expired")
+	}
+	// 残りの全員が賛成しても届かない場合は否決
+	undecided := tally.EligibleCount - tally.ApproveCount - tally.RejectCount
+	if undecided < 0 {
+		undecided = 0
+	}
+	if tally.ApproveCount+undecided < tally.RequiredCount {
+		return closeProposal(tx, p, "rejected")
+	}
+	return nil
+}
+
+// executeProposal 可決した提案を実行する
+// 解散・除名は即時、設定変更はactiveチームでは翌週の評価から反映する
+func executeProposal(tx *gorm.DB, ms *MembershipService, p *models.TeamProposal) error {
+	var team models.Team
+	if err := tx.First(&team, "id = ?", p.TeamID).Error; err != nil {
+		return fmt.Errorf("failed to fetch team: %w", err)
+	}
+
+	switch p.Type {
+	case "disband":
+		if err := closeProposal(tx, p, "applied"); err != nil {
+			return err
+		}
+		return ms.Disband(tx, team.ID)
+	case "remove_member":
+		if err := closeProposal(tx, p, "applied"); err != nil {
+			return err
+		}
+		if p.TargetUserID == nil {
+			return nil
+		}
+		_, err := ms.RemoveMember(tx, team, *p.TargetUserID, false)
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return RecordMembershipEvent(tx, team.ID, *p.TargetUserID, "removed", nil)
+	case "change_settings":
+		if team.Status != "active" {
+			if err := applySettings(tx, team, *p, 1); err != nil {
+				return err
+			}
+			return closeProposal(tx, p, "applied")
+		}
+		applyFrom := team.CurrentWeek + 1
+		p.ApplyFromWeek = &applyFrom
+		if err := tx.Model(p).Update("apply_from_week", applyFrom).Error; err != nil {
+			return fmt.Errorf("failed to update proposal: %w", err)
+		}
+		return closeProposal(tx, p, "passed")
+	}
+	return fmt.Errorf("unknown proposal type: %s", p.Type)
+}
+
+func closeProposal(tx *gorm.DB, p *models.TeamProposal, status string) error {
+	now := time.Now()
+	if err := tx.Model(p).Updates(map[string]interface{}{
+		"status":      status,
+		"resolved_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update proposal: %w", err)
+	}
+	p.Status = status
+	p.ResolvedAt = &now
+	return nil
+}
+
+// resolveOpenProposals メンバー構成が変わった後に、チームの投票中の提案を再判定する
+func resolveOpenProposals(tx *gorm.DB, ms *MembershipService, teamID string) error {
+	var proposals []models.TeamProposal
+	if err := tx.Where("team_id = ? AND status = ?", teamID, "open").Order("created_at ASC").Find(&proposals).Error; err != nil {
+		return fmt.Errorf("failed to fetch proposals: %w", err)
+	}
+	for i := range proposals {
+		var team models.Team
+		if err := tx.First(&team, "id = ?", teamID).Error; err != nil {
+			return fmt.Errorf("failed to fetch team: %w", err)
+		}
+		if team.Status != "forming" && team.Status != "active" {
+			return nil
+		}
+		// 先に実行した提案で閉じられている場合があるため読み直す
+		if err := tx.First(&proposals[i], "id = ?", proposals[i].ID).Error; err != nil {
+			return fmt.Errorf("failed to fetch proposal: %w", err)
+		}
+		if err := resolveProposal(tx, ms, &proposals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPassedSettings 可決済みの設定変更のうち、指定週から反映するものを適用する
+func applyPassedSettings(tx *gorm.DB, teamID string, week int) error {
+	var proposals []models.TeamProposal
+	if err := tx.Where("team_id = ? AND type = ? AND status = ? AND apply_from_week <= ?", teamID, "change_settings", "passed", week).
+		Order("resolved_at ASC").Find(&proposals).Error; err != nil {
+		return fmt.Errorf("failed to fetch passed proposals: %w", err)
+	}
+	for i := range proposals {
+		var team models.Team
+		if err := tx.First(&team, "id = ?", teamID).Error; err != nil {
+			return fmt.Errorf("failed to fetch team: %w", err)
+		}
+		if err := applySettings(tx, team, proposals[i], week); err != nil {
+			return err
+		}
+		if err := closeProposal(tx, &proposals[i], "applied"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySettings 設定変更をチームに反映し、目標の変更は effectiveFromWeek から有効な新しい版にする
+func applySettings(tx *gorm.DB, team models.Team, p models.TeamProposal, effectiveFromWeek int) error {
+	teamUpdates := map[string]interface{}{}
+	if p.NewStrictness != nil {
+		teamUpdates["strictness"] = *p.NewStrictness
+	}
+	if p.NewChallengeWeeks != nil {
+		teamUpdates["challenge_weeks"] = *p.NewChallengeWeeks
+	}
+	if p.NewDisbandThreshold != nil {
+		teamUpdates["disband_threshold"] = *p.NewDisbandThreshold
+	}
+	if p.NewReviewQuorum != nil {
+		teamUpdates["review_quorum"] = *p.NewReviewQuorum
+	}
+	if p.NewReviewWindowHours != nil {
+		teamUpdates["review_window_hours"] = *p.NewReviewWindowHours
+	}
+	if len(teamUpdates) > 0 {
+		if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(teamUpdates).Error; err != nil {
+			return fmt.Errorf("failed to update team settings: %w", err)
+		}
+	}
+
+	if p.NewTargetDistanceKM == nil && p.NewTargetVisitsPerWeek == nil && p.NewTargetMinDurationMin == nil {
+		return nil
+	}
+	var goal models.Goal
+	if err := tx.First(&goal, "team_id = ?", team.ID).Error; err != nil {
+		// 目標未設定のチームでは目標の変更を無視する
+		return nil
+	}
+	if p.NewTargetDistanceKM != nil {
+		goal.TargetDistanceKM = p.NewTargetDistanceKM
+	}
+	if p.NewTargetVisitsPerWeek != nil {
+		goal.TargetVisitsPerWeek = p.NewTargetVisitsPerWeek
+	}
+	if p.NewTargetMinDurationMin != nil {
+		goal.TargetMinDurationMin = p.NewTargetMinDurationMin
+	}
+	return RecordGoalChange(tx, &goal, effectiveFromWeek, nil, "proposal")
+}
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultReviewWindowHours 週の終わりからレビューを受け付ける時間の既定値
+	DefaultReviewWindowHours = 12
+	// MaxReviewWindowHours レビューを受け付ける時間の上限。週次評価がこれだけ遅れる
+	MaxReviewWindowHours = 48
+)
+
+// ReviewQuorumPolicies アクティビティの承認・否認が決まる票数の決め方
+// majority: 本人以外のメンバーの過半数 / any: 1票（以前の挙動） / all: 本人以外の全員
+var ReviewQuorumPolicies = []string{"majority", "any", "all"}
+
+// RejectionReasons 否認の理由。異議申し立てで争点を分けられるよう、否認には必ずいずれかを付ける
+var RejectionReasons = []string{"gps_anomaly", "not_exercise", "duplicate", "wrong_location", "too_short", "other"}
+
+// rejectionReasonLabels 通知に載せる否認理由の表記
+var rejectionReasonLabels = map[string][2]string{
+	"gps_anomaly":    {"GPSの記録が不自然", "suspicious GPS track"},
+	"not_exercise":   {"運動ではない", "not a workout"},
+	"duplicate":      {"重複した記録", "duplicate record"},
+	"wrong_location": {"登録外の場所", "wrong location"},
+	"too_short":      {"時間・距離が短すぎる", "too short"},
+	"other":          {"その他", "other"},
+}
+
+var (
+	ErrReviewWindowClosed     = errors.New("review window is closed")
+	ErrInvalidRejectionReason = errors.New("rejection requires a valid reason category")
+	ErrRejectionNeedsComment  = errors.New("rejection with reason other requires a comment")
+	ErrReviewOwnActivity      = errors.New("cannot review own activity")
+	ErrActivityNotCompleted   = errors.New("activity is not completed")
+)
+
+// ValidReviewQuorum review_quorum の値が有効か
+func ValidReviewQuorum(policy string) bool {
+	for _, p := range ReviewQuorumPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRejectionReason 否認理由が有効か
+func ValidRejectionReason(reason string) bool {
+	_, ok := rejectionReasonLabels[reason]
+	return ok
+}
+
+// ReviewQuorum レビュー対象者 eligible 人のうち、承認・否認が決まるのに必要な票数
+func ReviewQuorum(policy string, eligible int) int {
+	if eligible <= 1 {
+		return 1
+	}
+	switch policy {
+	case "any":
+		return 1
+	case "all":
+		return eligible
+	default:
+		return eligible/2 + 1
+	}
+}
+
+// ReviewWindow 週の終わりからレビューを受け付ける時間
+func ReviewWindow(team models.Team) time.Duration {
+	hours := team.ReviewWindowHours
+	if hours <= 0 {
+		hours = DefaultReviewWindowHours
+	}
+	if hours > MaxReviewWindowHours {
+		hours = MaxReviewWindowHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// WeekOf t が含まれる週番号。開始前のチームや開始前の時刻は1
+func WeekOf(team models.Team, t time.Time) int {
+	week := 1
+	for {
+		_, end, ok := WeekWindow(team, week)
+		if !ok || t.Before(end) {
+			return week
+		}
+		week++
+	}
+}
+
+// ReviewDeadline アクティビティのレビューを受け付ける期限。アクティビティを始めた週の終わり + ReviewWindow
+// 開始前のチームは期限なし
+func ReviewDeadline(team models.Team, activity models.Activity) *time.Time {
+	_, end, ok := WeekWindow(team, WeekOf(team, activity.StartedAt))
+	if !ok {
+		return nil
+	}
+	deadline := end.Add(ReviewWindow(team))
+	return &deadline
+}
+
+// ReviewTally アクティビティのレビューの集計
+type ReviewTally struct {
+	Policy     string
+	Eligible   int // 本人を除くメンバー数
+	Quorum     int
+	Approvals  int
+	Rejections int
+	Reasons    []string // 否認理由（重複なし）
+	Status     string   // pending / approved / rejected
+}
+
+// TallyReviews アクティビティへのレビューを今のメンバーで数える。抜けたメンバーの票は数えない。否認が必要数に届けば承認より優先する
+func TallyReviews(tx *gorm.DB, team models.Team, activity models.Activity) (ReviewTally, error) {
+	tally := ReviewTally{Policy: team.ReviewQuorum, Status: "pending"}
+	if !ValidReviewQuorum(tally.Policy) {
+		tally.Policy = "majority"
+	}
+
+	var memberIDs []string
+	if err := tx.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id <> ?", team.ID, activity.UserID).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		return tally, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	tally.Eligible = len(memberIDs)
+	tally.Quorum = ReviewQuorum(tally.Policy, tally.Eligible)
+	if len(memberIDs) == 0 {
+		return tally, nil
+	}
+
+	var reviews []models.ActivityReview
+	if err := tx.Where("activity_id = ? AND reviewer_id IN ?", activity.ID, memberIDs).
+		Order("created_at ASC").
+		Find(&reviews).Error; err != nil {
+		return tally, fmt.Errorf("failed to fetch reviews: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, r := range reviews {
+		switch r.Status {
+		case "approved":
+			tally.Approvals++
+		case "rejected":
+			tally.Rejections++
+			if r.ReasonCategory != "" && !seen[r.ReasonCategory] {
+				seen[r.ReasonCategory] = true
+				tally.Reasons = append(tally.Reasons, r.ReasonCategory)
+			}
+		}
+	}
+
+	switch {
+	case tally.Rejections >= tally.Quorum:
+		tally.Status = "rejected"
+	case tally.Approvals >= tally.Quorum:
+		tally.Status = "approved"
+	}
+	return tally, nil
+}
+
+// ReviewOutcomeFor 確定したレビュー結果。まだ確定していなければ nil
+func ReviewOutcomeFor(tx *gorm.DB, activityID string) (*models.ReviewOutcome, error) {
+	var outcome models.ReviewOutcome
+	err := tx.First(&outcome, "activity_id = ?", activityID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review outcome: %w", err)
+	}
+	return &outcome, nil
+}
+
+// ReviewInput レビューの投稿内容
+type ReviewInput struct {
+	Status         string // approved / rejected
+	ReasonCategory string
+	Comment        string
+}
+
+// RecordReview レビューを記録し、集計し直したアクティビティの review_status を反映する
+// 期限を過ぎたアクティビティや、週次評価で結果が確定したアクティビティにはレビューできない
+func RecordReview(tx *gorm.DB, team models.Team, activity *models.Activity, reviewerID string, in ReviewInput, now time.Time) (*models.ActivityReview, ReviewTally, error) {
+	if activity.UserID == reviewerID {
+		return nil, ReviewTally{}, ErrReviewOwnActivity
+	}
+	if activity.Status != "completed" {
+		return nil, ReviewTally{}, ErrActivityNotCompleted
+	}
+	if in.Status == "rejected" {
+		if !ValidRejectionReason(in.ReasonCategory) {
+			return nil, ReviewTally{}, ErrInvalidRejectionReason
+		}
+		if in.ReasonCategory == "other" && strings.TrimSpace(in.Comment) == "" {
+			return nil, ReviewTally{}, ErrRejectionNeedsComment
+		}
+	} else {
+		in.ReasonCategory = ""
+	}
+
+	// 同じアクティビティへの同時レビューで集計が食い違わないようアクティビティ行をロックする
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(activity, "id = ?", activity.ID).Error; err != nil {
+		return nil, ReviewTally{}, fmt.Errorf("failed to lock activity: %w", err)
+	}
+	if deadline := ReviewDeadline(team, *activity); deadline != nil && !now.Before(*deadline) {
+		return nil, ReviewTally{}, ErrReviewWindowClosed
+	}
+	if outcome, err := ReviewOutcomeFor(tx, activity.ID); err != nil {
+		return nil, ReviewTally{}, err
+	} else if outcome != nil {
+		return nil, ReviewTally{}, ErrReviewWindowClosed
+	}
+	// 異議申し立て後は申し立てへの投票で決める
+	if appeal, err := AppealFor(tx, activity.ID); err != nil {
+		return nil, ReviewTally{}, err
+	} else if appeal != nil {
+		return nil, ReviewTally{}, ErrActivityUnderAppeal
+	}
+
+	var review models.ActivityReview
+	err := tx.Where("activity_id = ? AND reviewer_id = ?", activity.ID, reviewerID).First(&review).Error
+	switch {
+	case err == nil:
+		review.Status = in.Status
+		review.ReasonCategory = in.ReasonCategory
+		review.Comment = in.Comment
+		if err := tx.Model(&review).Updates(map[string]interface{}{
+			"status":          in.Status,
+			"reason_category": in.ReasonCategory,
+			"comment":         in.Comment,
+		}).Error; err != nil {
+			return nil, ReviewTally{}, fmt.Errorf("failed to update review: %w", err)
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		review = models.ActivityReview{
+			ID:             utils.GenerateULID(),
+			ActivityID:     activity.ID,
+			ReviewerID:     reviewerID,
+			Status:         in.Status,
+			ReasonCategory: in.ReasonCategory,
+			Comment:        in.Comment,
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			return nil, ReviewTally{}, fmt.Errorf("failed to create review: %w", err)
+		}
+	default:
+		return nil, ReviewTally{}, fmt.Errorf("failed to fetch review: %w", err)
+	}
+
+	tally, err := TallyReviews(tx, team, *activity)
+	if err != nil {
+		return nil, ReviewTally{}, err
+	}
+	if err := applyReviewStatus(tx, activity, tally); err != nil {
+		return nil, ReviewTally{}, err
+	}
+	return &review, tally, nil
+}
+
+// applyReviewStatus 集計結果を review_status に反映する。初めて否認が決まったときは本人に知らせる
+func applyReviewStatus(tx *gorm.DB, activity *models.Activity, tally ReviewTally) error {
+	if activity.ReviewStatus == tally.Status {
+		return nil
+	}
+	wasRejected := activity.ReviewStatus == "rejected"
+	if err := tx.Model(activity).Update("review_status", tally.Status).Error; err != nil {
+		return fmt.Errorf("failed to update review status: %w", err)
+	}
+	activity.ReviewStatus = tally.Status
+	if tally.Status != "rejected" || wasRejected {
+		return nil
+	}
+
+	loc := utils.LoadLocation(UserTimezone(tx, activity.UserID))
+	reasonJa, reasonEn := rejectionReasonText(tally.Reasons)
+	return Notify(tx, activity.UserID, "activity_rejected", map[string]string{
+		"date":       activity.StartedAt.In(loc).Format("2006-01-02"),
+		"rejections": fmt.Sprint(tally.Rejections),
+		"reason_ja":  reasonJa,
+		"reason_en":  reasonEn,
+	}, activity.TeamID)
+}
+
+// rejectionReasonText 否認理由を通知用に並べる
+func rejectionReasonText(reasons []string) (ja, en string) {
+	jas := make([]string, 0, len(reasons))
+	ens := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		if label, ok := rejectionReasonLabels[r]; ok {
+			jas = append(jas, label[0])
+			ens = append(ens, label[1])
+		}
+	}
+	return strings.Join(jas, "・"), strings.Join(ens, ", ")
+}
+
+// SnapshotReviewOutcomes 週次評価の直前に、その週のアクティビティのレビュー結果を確定させる
+// 否認が必要数に届かなかったアクティビティは承認として扱い、review_status も確定した結果にそろえる
+// 申し立て中のアクティビティは否認のまま確定し、後で申し立てが通れば評価し直す
+func SnapshotReviewOutcomes(tx *gorm.DB, team models.Team, week int, now time.Time) error {
+	start, end, ok := WeekWindow(team, week)
+	if !ok {
+		return nil
+	}
+	var activities []models.Activity
+	if err := tx.Where("team_id = ? AND status = ? AND started_at >= ? AND started_at < ?", team.ID, "completed", start, end).
+		Find(&activities).Error; err != nil {
+		return fmt.Errorf("failed to fetch activities: %w", err)
+	}
+
+	for i := range activities {
+		activity := &activities[i]
+		existing, err := ReviewOutcomeFor(tx, activity.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		tally, err := TallyReviews(tx, team, *activity)
+		if err != nil {
+			return err
+		}
+		outcome := models.ReviewOutcome{
+			ID:               utils.GenerateULID(),
+			ActivityID:       activity.ID,
+			TeamID:           team.ID,
+			UserID:           activity.UserID,
+			WeekNumber:       week,
+			Outcome:          "approved",
+			Resolution:       "quorum",
+			Policy:           tally.Policy,
+			EligibleCount:    tally.Eligible,
+			Quorum:           tally.Quorum,
+			Approvals:        tally.Approvals,
+			Rejections:       tally.Rejections,
+			ReasonCategories: strings.Join(tally.Reasons, ","),
+			DecidedAt:        now,
+		}
+		switch tally.Status {
+		case "rejected":
+			outcome.Outcome = "rejected"
+		case "pending":
+			outcome.Resolution = "no_quorum"
+		}
+		// 評価前に異議申し立てが通ったアクティビティは承認で確定する
+		if appeal, err := AppealFor(tx, activity.ID); err != nil {
+			return err
+		} else if appeal != nil && appeal.Status == "overturned" {
+			outcome.Outcome = "approved"
+			outcome.Resolution = "appeal"
+		}
+		if err := tx.Create(&outcome).Error; err != nil {
+			return fmt.Errorf("failed to record review outcome: %w", err)
+		}
+		if activity.ReviewStatus != outcome.Outcome {
+			if err := tx.Model(activity).Update("review_status", outcome.Outcome).Error; err != nil {
+				return fmt.Errorf("failed to update review status: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ReviewerStat チーム内でのレビュアーの記録
+type ReviewerStat struct {
+	UserID          string
+	UserName        string
+	Eligible        int // レビューできた（結果が確定した他のメンバーの）アクティビティ数
+	Reviews         int
+	Approvals       int
+	Rejections      int
+	Upheld          int // 否認したアクティビティが否認で確定した数
+	Overturned      int // 否認したアクティビティが承認で確定した数
+	ReasonBreakdown map[string]int
+}
+
+// ReviewerStats チームの今のメンバーごとに、確定したアクティビティへのレビューの記録をまとめる
+func ReviewerStats(tx *gorm.DB, teamID string) ([]ReviewerStat, error) {
+	var members []models.TeamMember
+	if err := tx.Preload("User").Where("team_id = ?", teamID).Order("joined_at ASC").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	var outcomes []models.ReviewOutcome
+	if err := tx.Where("team_id = ?", teamID).Find(&outcomes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch review outcomes: %w", err)
+	}
+	byActivity := make(map[string]models.ReviewOutcome, len(outcomes))
+	activityIDs := make([]string, 0, len(outcomes))
+	for _, o := range outcomes {
+		byActivity[o.ActivityID] = o
+		activityIDs = append(activityIDs, o.ActivityID)
+	}
+	var reviews []models.ActivityReview
+	if len(activityIDs) > 0 {
+		if err := tx.Where("activity_id IN ?", activityIDs).Find(&reviews).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch reviews: %w", err)
+		}
+	}
+
+	stats := make([]ReviewerStat, len(members))
+	index := map[string]int{}
+	for i, m := range members {
+		stats[i] = ReviewerStat{UserID: m.UserID, UserName: m.User.Name, ReasonBreakdown: map[string]int{}}
+		index[m.UserID] = i
+		for _, o := range outcomes {
+			// 参加前のアクティビティはレビューできなかったので数えない
+			if o.UserID != m.UserID && !o.DecidedAt.Before(m.JoinedAt) {
+				stats[i].Eligible++
+			}
+		}
+	}
+	for _, r := range reviews {
+		i, ok := index[r.ReviewerID]
+		if !ok {
+			continue
+		}
+		s := &stats[i]
+		s.Reviews++
+		switch r.Status {
+		case "approved":
+			s.Approvals++
+		case "rejected":
+			s.Rejections++
+			if r.ReasonCategory != "" {
+				s.ReasonBreakdown[r.ReasonCategory]++
+			}
+			if byActivity[r.ActivityID].Outcome == "rejected" {
+				s.Upheld++
+			} else {
+				s.Overturned++
+			}
+		}
+	}
+	return stats, nil
+}
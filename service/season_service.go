@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+// FinishSeason チームを終了状態（completed / disbanded）にしてシーズンサマリーを書き出す
+// メンバーを削除する前に呼び出すこと
+func FinishSeason(tx *gorm.DB, teamID, status string) error {
+	now := time.Now()
+	if err := tx.Model(&models.Team{}).Where("id = ?", teamID).Updates(map[string]interface{}{
+		"status":   status,
+		"ended_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update team: %w", err)
+	}
+
+	if status == "disbanded" {
+		var disbanded models.Team
+		tx.First(&disbanded, "id = ?", teamID)
+		if err := EmitTeamEvent(tx, teamID, "team.disbanded", map[string]interface{}{
+			"team_name":   disbanded.Name,
+			"week_number": disbanded.CurrentWeek,
+			"hp":          disbanded.CurrentHP,
+			"ended_at":    now.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 投票中・反映待ちの提案は取り下げる
+	if err := tx.Model(&models.TeamProposal{}).
+		Where("team_id = ? AND status IN ?", teamID, []string{"open", "passed"}).
+		Updates(map[string]interface{}{"status": "cancelled", "resolved_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to cancel proposals: %w", err)
+	}
+
+	// 既にサマリーがあれば書き直さない
+	var existingCount int64
+	tx.Model(&models.SeasonSummary{}).Where("team_id = ?", teamID).Count(&existingCount)
+	if existingCount > 0 {
+		return nil
+	}
+
+	var team models.Team
+	if err := tx.First(&team, "id = ?", teamID).Error; err != nil {
+		return fmt.Errorf("failed to fetch team: %w", err)
+	}
+
+	var evaluations []models.WeeklyEvaluation
+	tx.Where("team_id = ?", teamID).Order("week_number ASC").Find(&evaluations)
+
+	var members []models.TeamMember
+	tx.Where("team_id = ?", teamID).Order("joined_at ASC").Find(&members)
+
+	summary := models.SeasonSummary{
+		ID:             utils.GenerateULID(),
+		TeamID:         team.ID,
+		TeamName:       team.Name,
+		ExerciseType:   team.ExerciseType,
+		Strictness:     team.Strictness,
+		Result:         status,
+		FinalHP:        team.CurrentHP,
+		MaxHP:          team.MaxHP,
+		ChallengeWeeks: team.ChallengeWeeks,
+		PreviousTeamID: team.PreviousTeamID,
+		StartedAt:      team.StartedAt,
+		EndedAt:        now,
+	}
+
+	// 終了時点のメンバーに加え、途中で抜けたメンバーも評価があれば成績に含める
+	stats := make(map[string]*models.SeasonMemberStat)
+	var order []string
+	addStat := func(userID, role string) *models.SeasonMemberStat {
+		if st, ok := stats[userID]; ok {
+			return st
+		}
+		st := &models.SeasonMemberStat{
+			ID:          utils.GenerateULID(),
+			SummaryID:   summary.ID,
+			TeamID:      team.ID,
+			UserID:      userID,
+			Role:        role,
+			InTeamAtEnd: role != "",
+		}
+		stats[userID] = st
+		order = append(order, userID)
+		return st
+	}
+	for _, m := range members {
+		addStat(m.UserID, m.Role)
+	}
+
+	weeks := make(map[int]bool)
+	for _, e := range evaluations {
+		weeks[e.WeekNumber] = true
+		st := addStat(e.UserID, "")
+		st.TotalDistanceKM += e.TotalDistanceKM
+		st.TotalVisits += e.TotalVisits
+		st.TotalDurationMin += e.TotalDurationMin
+		st.TotalHPChange += e.HPChange
+		// フリーズした週は評価週数に数えず、連続達成も途切れさせない
+		if e.Frozen {
+			st.WeeksFrozen++
+			continue
+		}
+		st.WeeksEvaluated++
+		if e.TargetMet {
+			st.WeeksMet++
+			st.FinalStreak++
+			if st.FinalStreak > st.LongestStreak {
+				st.LongestStreak = st.FinalStreak
+			}
+		} else {
+			st.FinalStreak = 0
+		}
+	}
+	summary.WeeksPlayed = len(weeks)
+
+	if err := tx.Create(&summary).Error; err != nil {
+		return fmt.Errorf("failed to create season summary: %w", err)
+	}
+	for _, userID := range order {
+		if err := tx.Create(stats[userID]).Error; err != nil {
+			return fmt.Errorf("failed to create season member stat: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"gorm.io/gorm"
+)
+
+// defaultFormingTeamTTLHours forming のまま放置されたチームを期限切れにするまでの時間の既定値。環境変数 FORMING_TEAM_TTL_HOURS で上書きできる
+const defaultFormingTeamTTLHours = 7 * 24
+
+// defaultDisbandedTeamRetentionHours 解散済みチームのメンバーと投票を残しておく時間の既定値。環境変数 DISBANDED_TEAM_RETENTION_HOURS で上書きできる
+// HPが尽きて解散したチームは、最後の週への異議申し立て（翌週末まで＋投票期間）が通ると再開するため、その間はメンバーを消さない
+const defaultDisbandedTeamRetentionHours = 14 * 24
+
+type TeamCleanupService struct {
+	db *gorm.DB
+}
+
+func NewTeamCleanupService(db *gorm.DB) *TeamCleanupService {
+	return &TeamCleanupService{db: db}
+}
+
+// FormingExpiryResult forming チームの期限切れ処理結果
+type FormingExpiryResult struct {
+	ExpiredTeams  int      `json:"expired_teams"`
+	NotifiedUsers int      `json:"notified_users"`
+	TeamIDs       []string `json:"team_ids"`
+}
+
+// DisbandedCleanupResult 解散済みチームのクリーンアップ結果
+type DisbandedCleanupResult struct {
+	CleanedTeams   int      `json:"cleaned_teams"`
+	DeletedVotes   int64    `json:"deleted_votes"`
+	DeletedMembers int64    `json:"deleted_members"`
+	TeamIDs        []string `json:"team_ids"`
+}
+
+func formingTeamTTL() time.Duration {
+	return time.Duration(envInt("FORMING_TEAM_TTL_HOURS", defaultFormingTeamTTLHours)) * time.Hour
+}
+
+func disbandedTeamRetention() time.Duration {
+	return time.Duration(envInt("DISBANDED_TEAM_RETENTION_HOURS", defaultDisbandedTeamRetentionHours)) * time.Hour
+}
+
+// ExpireFormingTeams 作成から FORMING_TEAM_TTL_HOURS 時間が経っても開始していない forming チームを expired にする
+// メンバーには通知を送り、他のチームに参加できるようメンバー登録を外す
+func (s *TeamCleanupService) ExpireFormingTeams() (*FormingExpiryResult, error) {
+	var teams []models.Team
+	if err := s.db.Where("status = ? AND created_at < ?", "forming", time.Now().Add(-formingTeamTTL())).
+		Find(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch forming teams: %w", err)
+	}
+
+	result := &FormingExpiryResult{TeamIDs: []string{}}
+	for _, team := range teams {
+		var notified int
+		var expired bool
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			notified, expired, err = expireFormingTeam(tx, team)
+			return err
+		})
+		if err != nil {
+			log.Printf("[ExpireFormingTeams] failed to expire team %s: %v", team.ID, err)
+			continue
+		}
+		if !expired {
+			continue
+		}
+		result.ExpiredTeams++
+		result.NotifiedUsers += notified
+		result.TeamIDs = append(result.TeamIDs, team.ID)
+	}
+	return result, nil
+}
+
+// expireFormingTeam チームを expired にしてメンバーへ通知し、通知したメンバー数を返す
+// 処理中に開始されて forming でなくなっていれば何もせず expired=false を返す
+func expireFormingTeam(tx *gorm.DB, team models.Team) (notified int, expired bool, err error) {
+	now := time.Now()
+	// 処理中に開始された場合は対象外
+	update := tx.Model(&models.Team{}).Where("id = ? AND status = ?", team.ID, "forming").
+		Updates(map[string]interface{}{"status": "expired", "ended_at": now})
+	if update.Error != nil {
+		return 0, false, fmt.Errorf("failed to update team: %w", update.Error)
+	}
+	if update.RowsAffected == 0 {
+		return 0, false, nil
+	}
+
+	if err := tx.Model(&models.TeamProposal{}).
+		Where("team_id = ? AND status IN ?", team.ID, []string{"open", "passed"}).
+		Updates(map[string]interface{}{"status": "cancelled", "resolved_at": now}).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to cancel proposals: %w", err)
+	}
+	if err := tx.Model(&models.InviteCode{}).
+		Where("team_id = ? AND revoked_at IS NULL", team.ID).
+		Update("revoked_at", now).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to revoke invite codes: %w", err)
+	}
+	if err := tx.Model(&models.JoinRequest{}).
+		Where("team_id = ? AND status = ?", team.ID, "pending").
+		Updates(map[string]interface{}{"status": "cancelled", "decided_at": now}).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to cancel join requests: %w", err)
+	}
+
+	var members []models.TeamMember
+	if err := tx.Where("team_id = ?", team.ID).Find(&members).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to fetch members: %w", err)
+	}
+	teamID := team.ID
+	for _, m := range members {
+		if err := Notify(tx, m.UserID, "team_expired", map[string]string{"team": team.Name}, &teamID); err != nil {
+			return 0, false, err
+		}
+	}
+
+	if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamProposalVote{}).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to delete votes: %w", err)
+	}
+	if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamMember{}).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to delete members: %w", err)
+	}
+	return len(members), true, nil
+}
+
+// CleanupDisbandedTeams 解散から DISBANDED_TEAM_RETENTION_HOURS 時間が経ったチームに残ったメンバーと提案への投票を削除する
+// 受付中の異議申し立てがあるチームは、通れば再開するため残しておく
+func (s *TeamCleanupService) CleanupDisbandedTeams() (*DisbandedCleanupResult, error) {
+	result := &DisbandedCleanupResult{TeamIDs: []string{}}
+	cutoff := time.Now().Add(-disbandedTeamRetention())
+
+	// メンバーか投票が残っている解散済みチームだけを対象にする
+	seen := make(map[string]bool)
+	for _, table := range []string{"team_members", "team_proposal_votes"} {
+		var ids []string
+		if err := s.db.Table(table).
+			Joins("JOIN teams ON teams.id = "+table+".team_id").
+			Where("teams.status = ? AND (teams.ended_at IS NULL OR teams.ended_at < ?)", "disbanded", cutoff).
+			Where("NOT EXISTS (?)", s.db.Model(&models.ActivityAppeal{}).Select("1").
+				Where("activity_appeals.team_id = teams.id AND activity_appeals.status = ?", "open")).
+			Distinct().Pluck(table+".team_id", &ids).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch disbanded teams: %w", err)
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				result.TeamIDs = append(result.TeamIDs, id)
+			}
+		}
+	}
+	if len(result.TeamIDs) == 0 {
+		return result, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		votes := tx.Where("team_id IN ?", result.TeamIDs).Delete(&models.TeamProposalVote{})
+		if votes.Error != nil {
+			return fmt.Errorf("failed to delete votes: %w", votes.Error)
+		}
+		result.DeletedVotes = votes.RowsAffected
+
+		members := tx.Where("team_id IN ?", result.TeamIDs).Delete(&models.TeamMember{})
+		if members.Error != nil {
+			return fmt.Errorf("failed to delete members: %w", members.Error)
+		}
+		result.DeletedMembers = members.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.CleanedTeams = len(result.TeamIDs)
+	return result, nil
+}
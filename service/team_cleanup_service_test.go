@@ -0,0 +1,231 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// argTime 引数のうち最初の時刻
+func argTime(t *testing.T, s fakeStmt) time.Time {
+	t.Helper()
+	for _, a := range s.Args {
+		if tm, ok := a.(time.Time); ok {
+			return tm
+		}
+	}
+	t.Fatalf("no time argument in %q", s.SQL)
+	return time.Time{}
+}
+
+// hasArg 引数に v が含まれるか
+func hasArg(s fakeStmt, v driver.Value) bool {
+	for _, a := range s.Args {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// assertCutoff 引数の時刻が before〜after の間に実行した time.Now().Add(-ago) か
+func assertCutoff(t *testing.T, s fakeStmt, before, after time.Time, ago time.Duration) {
+	t.Helper()
+	got := argTime(t, s)
+	if got.Before(before.Add(-ago)) || got.After(after.Add(-ago)) {
+		t.Errorf("cutoff = %v, want between %v and %v", got, before.Add(-ago), after.Add(-ago))
+	}
+}
+
+func TestExpireFormingTeams(t *testing.T) {
+	t.Setenv("FORMING_TEAM_TTL_HOURS", "48")
+	db, fake := newFakeGorm(t,
+		fakeRule{
+			Contains: `FROM "teams"`,
+			Columns:  []string{"id", "name", "status"},
+			Rows:     [][]driver.Value{{"team-1", "朝ランチーム", "forming"}},
+		},
+		fakeRule{
+			Contains: `SELECT * FROM "team_members"`,
+			Columns:  []string{"id", "team_id", "user_id", "role"},
+			Rows: [][]driver.Value{
+				{"m-1", "team-1", "user-1", "leader"},
+				{"m-2", "team-1", "user-2", "member"},
+			},
+		},
+	)
+
+	before := time.Now()
+	result, err := NewTeamCleanupService(db).ExpireFormingTeams()
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("ExpireFormingTeams() error = %v", err)
+	}
+
+	selects := fake.Executed(`FROM "teams"`)
+	if len(selects) != 1 {
+		t.Fatalf("team queries = %d, want 1", len(selects))
+	}
+	assertCutoff(t, selects[0], before, after, 48*time.Hour)
+
+	if result.ExpiredTeams != 1 || result.NotifiedUsers != 2 {
+		t.Errorf("result = %+v, want 1 expired team and 2 notified users", result)
+	}
+	if len(result.TeamIDs) != 1 || result.TeamIDs[0] != "team-1" {
+		t.Errorf("TeamIDs = %v, want [team-1]", result.TeamIDs)
+	}
+
+	updates := fake.Executed(`UPDATE "teams"`)
+	if len(updates) != 1 || !hasArg(updates[0], "expired") || !hasArg(updates[0], "forming") {
+		t.Fatalf("team updates = %+v, want one forming -> expired update", updates)
+	}
+
+	notifications := fake.Executed(`INSERT INTO "notifications"`)
+	if len(notifications) != 2 {
+		t.Fatalf("notifications = %d, want 2", len(notifications))
+	}
+	for i, userID := range []string{"user-1", "user-2"} {
+		if !hasArg(notifications[i], userID) || !hasArg(notifications[i], "team_expired") {
+			t.Errorf("notification %d args = %v, want team_expired for %s", i, notifications[i].Args, userID)
+		}
+	}
+
+	if len(fake.Executed(`DELETE FROM "team_members"`)) != 1 {
+		t.Error("members of the expired team were not removed")
+	}
+}
+
+func TestExpireFormingTeamsSkipsStartedTeam(t *testing.T) {
+	db, fake := newFakeGorm(t,
+		fakeRule{
+			Contains: `FROM "teams"`,
+			Columns:  []string{"id", "name", "status"},
+			Rows:     [][]driver.Value{{"team-1", "朝ランチーム", "forming"}},
+		},
+		// 取得後に開始されたため forming 条件の更新に当たらない
+		fakeRule{Contains: `UPDATE "teams"`, Affected: 0},
+		fakeRule{
+			Contains: `SELECT * FROM "team_members"`,
+			Columns:  []string{"id", "team_id", "user_id", "role"},
+			Rows:     [][]driver.Value{{"m-1", "team-1", "user-1", "leader"}},
+		},
+	)
+
+	result, err := NewTeamCleanupService(db).ExpireFormingTeams()
+	if err != nil {
+		t.Fatalf("ExpireFormingTeams() error = %v", err)
+	}
+	if result.ExpiredTeams != 0 || result.NotifiedUsers != 0 || len(result.TeamIDs) != 0 {
+		t.Errorf("result = %+v, want nothing expired", result)
+	}
+	if n := len(fake.Executed(`INSERT INTO "notifications"`)); n != 0 {
+		t.Errorf("notifications = %d, want 0", n)
+	}
+	if n := len(fake.Executed(`DELETE FROM "team_members"`)); n != 0 {
+		t.Errorf("member deletes = %d, want 0", n)
+	}
+}
+
+func TestExpireFormingTeamsContinuesAfterFailure(t *testing.T) {
+	db, fake := newFakeGorm(t,
+		fakeRule{
+			Contains: `FROM "teams"`,
+			Columns:  []string{"id", "name", "status"},
+			Rows: [][]driver.Value{
+				{"team-1", "朝ランチーム", "forming"},
+				{"team-2", "ジムチーム", "forming"},
+			},
+		},
+		fakeRule{Contains: `UPDATE "team_proposals"`, Err: errors.New("connection reset")},
+	)
+
+	result, err := NewTeamCleanupService(db).ExpireFormingTeams()
+	if err != nil {
+		t.Fatalf("ExpireFormingTeams() error = %v", err)
+	}
+	if result.ExpiredTeams != 0 {
+		t.Errorf("ExpiredTeams = %d, want 0", result.ExpiredTeams)
+	}
+	if n := len(fake.Executed(`UPDATE "teams"`)); n != 2 {
+		t.Errorf("team updates = %d, want both teams attempted", n)
+	}
+}
+
+func TestCleanupDisbandedTeams(t *testing.T) {
+	t.Setenv("DISBANDED_TEAM_RETENTION_HOURS", "24")
+	db, fake := newFakeGorm(t,
+		fakeRule{
+			Contains: `FROM "team_members" JOIN teams`,
+			Columns:  []string{"team_id"},
+			Rows:     [][]driver.Value{{"team-1"}, {"team-2"}},
+		},
+		fakeRule{
+			Contains: `FROM "team_proposal_votes" JOIN teams`,
+			Columns:  []string{"team_id"},
+			Rows:     [][]driver.Value{{"team-2"}, {"team-3"}},
+		},
+		fakeRule{Contains: `DELETE FROM "team_proposal_votes"`, Affected: 4},
+		fakeRule{Contains: `DELETE FROM "team_members"`, Affected: 5},
+	)
+
+	before := time.Now()
+	result, err := NewTeamCleanupService(db).CleanupDisbandedTeams()
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("CleanupDisbandedTeams() error = %v", err)
+	}
+
+	// 解散から保持期間が経ち、受付中の異議申し立てがないチームだけを対象にする
+	for _, table := range []string{"team_members", "team_proposal_votes"} {
+		selects := fake.Executed(`FROM "` + table + `" JOIN teams`)
+		if len(selects) != 1 {
+			t.Fatalf("%s queries = %d, want 1", table, len(selects))
+		}
+		if !hasArg(selects[0], "disbanded") {
+			t.Errorf("%s query args = %v, want disbanded status", table, selects[0].Args)
+		}
+		if !strings.Contains(selects[0].SQL, `NOT EXISTS (SELECT 1 FROM "activity_appeals"`) || !hasArg(selects[0], "open") {
+			t.Errorf("%s query = %q, want teams with an open appeal excluded", table, selects[0].SQL)
+		}
+		assertCutoff(t, selects[0], before, after, 24*time.Hour)
+	}
+
+	want := []string{"team-1", "team-2", "team-3"}
+	if len(result.TeamIDs) != len(want) {
+		t.Fatalf("TeamIDs = %v, want %v", result.TeamIDs, want)
+	}
+	for i, id := range want {
+		if result.TeamIDs[i] != id {
+			t.Errorf("TeamIDs[%d] = %s, want %s", i, result.TeamIDs[i], id)
+		}
+	}
+	if result.CleanedTeams != 3 || result.DeletedVotes != 4 || result.DeletedMembers != 5 {
+		t.Errorf("result = %+v, want 3 teams, 4 votes, 5 members", result)
+	}
+}
+
+func TestCleanupDisbandedTeamsDefaultRetention(t *testing.T) {
+	db, fake := newFakeGorm(t)
+
+	before := time.Now()
+	result, err := NewTeamCleanupService(db).CleanupDisbandedTeams()
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("CleanupDisbandedTeams() error = %v", err)
+	}
+
+	selects := fake.Executed(`FROM "team_members" JOIN teams`)
+	if len(selects) != 1 {
+		t.Fatalf("team_members queries = %d, want 1", len(selects))
+	}
+	assertCutoff(t, selects[0], before, after, defaultDisbandedTeamRetentionHours*time.Hour)
+
+	if result.CleanedTeams != 0 || len(result.TeamIDs) != 0 {
+		t.Errorf("result = %+v, want nothing cleaned", result)
+	}
+	if n := len(fake.Executed(`DELETE`)); n != 0 {
+		t.Errorf("deletes = %d, want 0 when no team is past the cutoff", n)
+	}
+}
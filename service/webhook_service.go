@@ -0,0 +1,550 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultWebhookMaxAttempts 配信を諦めるまでの送信回数（WEBHOOK_MAX_ATTEMPTS で変更可能）
+	defaultWebhookMaxAttempts = 8
+	// webhookRetryBase 1回目の再送までの待ち時間。以降は2倍ずつ延ばす
+	webhookRetryBase = 30 * time.Second
+	// webhookRetryMax 再送間隔の上限
+	webhookRetryMax = 6 * time.Hour
+	// webhookDispatchBatch 1回の配信処理で送る件数の上限
+	webhookDispatchBatch = 100
+	// webhookTimeout 1件の送信のタイムアウト
+	webhookTimeout = 10 * time.Second
+	// webhookClaimLease 配信処理が確保した配信を他の処理が拾わない時間。1回分を送り切れる長さにする
+	webhookClaimLease = webhookDispatchBatch*webhookTimeout + time.Minute
+	// webhookResponseBodyLimit 送信ログに残すレスポンス本文の長さ
+	webhookResponseBodyLimit = 1024
+	// webhookDisableAfter 連続でこの回数配信を諦めたWebhookは無効にする
+	webhookDisableAfter = 5
+	// MaxWebhooksPerTeam 1チームに登録できるWebhookの数
+	MaxWebhooksPerTeam = 5
+
+	// WebhookSignatureHeader 署名ヘッダー。値は "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookIDHeader        = "X-Webhook-Id"
+)
+
+// WebhookEvents 購読できるイベント
+var WebhookEvents = []string{
+	"activity.completed",
+	"review.posted",
+	"appeal.filed",
+	"appeal.resolved",
+	"evaluation.completed",
+	"hp.changed",
+	"team.disbanded",
+	"member.joined",
+}
+
+// webhookTestEvent テスト送信のイベント名。購読の有無に関わらず送る
+const webhookTestEvent = "webhook.test"
+
+var (
+	ErrInvalidWebhookURL   = errors.New("invalid webhook url")
+	ErrInvalidWebhookEvent = errors.New("invalid webhook event")
+	ErrTooManyWebhooks     = errors.New("too many webhooks")
+)
+
+func webhookMaxAttempts() int {
+	return envInt("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts)
+}
+
+// WebhookPayload 送信する本文
+type WebhookPayload struct {
+	ID         string      `json:"id"` // イベントID
+	Event      string      `json:"event"`
+	TeamID     string      `json:"team_id"`
+	OccurredAt string      `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// ValidateWebhookURL https のURLのみ受け付ける。開発環境（FLAVOR=dev）ではローカルの受信先に http で送れる
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if os.Getenv("FLAVOR") != "dev" {
+			return ErrInvalidWebhookURL
+		}
+		return nil
+	default:
+		return ErrInvalidWebhookURL
+	}
+	// 本番では内部ネットワークに向けたリクエストを送らない。ホスト名の場合は送信時に解決したIPで webhookDialControl が確かめる
+	host := u.Hostname()
+	if host == "localhost" {
+		return ErrInvalidWebhookURL
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedWebhookIP(ip) {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// errBlockedWebhookAddress 解決したIPが内部ネットワークだった
+var errBlockedWebhookAddress = errors.New("webhook address resolves to a non-public ip")
+
+// blockedWebhookIP Webhookで送ってはいけない宛先（ループバック・プライベート・リンクローカル・未指定・マルチキャスト）か
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// webhookDialControl 名前解決後の接続先IPを確かめ、内部ネットワークへの接続を拒む。開発環境（FLAVOR=dev）ではローカルの受信先を許す
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	if os.Getenv("FLAVOR") == "dev" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || blockedWebhookIP(ip) {
+		return fmt.Errorf("%w: %s", errBlockedWebhookAddress, host)
+	}
+	return nil
+}
+
+// newWebhookClient Webhook送信用のクライアント。接続時に宛先IPを検査し、リダイレクトには従わない（3xx は失敗として記録する）
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: webhookDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// プロキシ経由だと接続先の検査がプロキシに向いてしまうので使わない
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// NormalizeWebhookEvents イベント名を検証し、重複を除いてカンマ区切りにする
+func NormalizeWebhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", ErrInvalidWebhookEvent
+	}
+	seen := map[string]bool{}
+	var result []string
+	for _, e := range events {
+		valid := false
+		for _, known := range WebhookEvents {
+			if e == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return "", fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			result = append(result, e)
+		}
+	}
+	return strings.Join(result, ","), nil
+}
+
+// WebhookEventList 登録されているイベントの一覧
+func WebhookEventList(w models.TeamWebhook) []string {
+	events := []string{}
+	for _, e := range strings.Split(w.Events, ",") {
+		if e != "" {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// GenerateWebhookSecret 署名用のシークレットを作る
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// SignWebhook タイムスタンプと本文の署名。受信側は同じ計算をして定数時間で比較する
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature 署名が正しいか
+func VerifyWebhookSignature(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+// EmitTeamEvent チームのイベントを購読しているWebhookごとに配信キューへ積む
+// 呼び出し元のトランザクションで記録するため、ロールバックされたイベントは送られない
+func EmitTeamEvent(tx *gorm.DB, teamID, event string, data interface{}) error {
+	var webhooks []models.TeamWebhook
+	if err := tx.Where("team_id = ? AND active = ?", teamID, true).Find(&webhooks).Error; err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+
+	var targets []models.TeamWebhook
+	for _, w := range webhooks {
+		for _, e := range WebhookEventList(w) {
+			if e == event {
+				targets = append(targets, w)
+				break
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload := WebhookPayload{
+		ID:         utils.GenerateULID(),
+		Event:      event,
+		TeamID:     teamID,
+		OccurredAt: now.Format(time.RFC3339),
+		Data:       data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	for _, w := range targets {
+		delivery := models.WebhookDelivery{
+			ID:            utils.GenerateULID(),
+			WebhookID:     w.ID,
+			TeamID:        teamID,
+			EventID:       payload.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        "pending",
+			NextAttemptAt: now,
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return fmt.Errorf("failed to enqueue webhook: %w", err)
+		}
+	}
+	return nil
+}
+
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{db: db, client: newWebhookClient()}
+}
+
+// WebhookDispatchResult Webhookの配信結果
+type WebhookDispatchResult struct {
+	Delivered int `json:"delivered"`
+	Retrying  int `json:"retrying"`  // 失敗して再送を待っている
+	Dead      int `json:"dead"`      // 再送上限に達した
+	Cancelled int `json:"cancelled"` // Webhookが削除・無効化されていた
+	Errors    int `json:"errors"`    // 結果を記録できなかった。確保の期限が切れた後にもう一度送る
+}
+
+// DispatchDeliveries 送信時刻を過ぎたWebhookを送る。失敗したものは指数バックオフで再送を予約する
+// 送る分を短いトランザクションで確保してから、トランザクションの外で1件ずつ送って結果を記録する。
+// 途中でDBエラーが起きても送信済みの記録は巻き戻らず、記録できなかった配信は確保の期限切れ後にもう一度送られる
+func (s *WebhookService) DispatchDeliveries() (*WebhookDispatchResult, error) {
+	deliveries, err := s.claimDeliveries(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WebhookDispatchResult{}
+	for i := range deliveries {
+		d := &deliveries[i]
+		var webhook models.TeamWebhook
+		err := s.db.First(&webhook, "id = ? AND active = ?", d.WebhookID, true).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := s.db.Model(d).Update("status", "cancelled").Error; err != nil {
+				log.Printf("[DispatchDeliveries] failed to cancel delivery %s: %v", d.ID, err)
+				result.Errors++
+				continue
+			}
+			result.Cancelled++
+			continue
+		}
+		if err != nil {
+			log.Printf("[DispatchDeliveries] failed to fetch webhook %s: %v", d.WebhookID, err)
+			result.Errors++
+			continue
+		}
+
+		switch status, err := s.deliver(webhook, d); {
+		case err != nil:
+			log.Printf("[DispatchDeliveries] %v", err)
+			result.Errors++
+		case status == "delivered":
+			result.Delivered++
+		case status == "dead":
+			result.Dead++
+		default:
+			result.Retrying++
+		}
+	}
+	return result, nil
+}
+
+// claimDeliveries 送る配信を確保する。next_attempt_at を確保の期限まで進めておき、並行して動く配信処理が同じ配信を送らないようにする
+func (s *WebhookService) claimDeliveries(now time.Time) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("next_attempt_at ASC").
+			Limit(webhookDispatchBatch).
+			Find(&deliveries).Error; err != nil {
+			return fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]string, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		if err := tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(webhookClaimLease)).Error; err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// SendTestWebhook サンプルのペイロードをすぐに1回だけ送り、結果を送信ログに残す（再送はしない）
+func (s *WebhookService) SendTestWebhook(webhook models.TeamWebhook, sentBy string) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	payload := WebhookPayload{
+		ID:         utils.GenerateULID(),
+		Event:      webhookTestEvent,
+		TeamID:     webhook.TeamID,
+		OccurredAt: now.Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"message":    "これはテスト送信です / This is a test delivery",
+			"sent_by":    sentBy,
+			"webhook_id": webhook.ID,
+			"events":     WebhookEventList(webhook),
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	// 送信中に配信処理が拾わないよう、確保済みの状態で作る
+	delivery := models.WebhookDelivery{
+		ID:            utils.GenerateULID(),
+		WebhookID:     webhook.ID,
+		TeamID:        webhook.TeamID,
+		EventID:       payload.ID,
+		Event:         webhookTestEvent,
+		Payload:       string(body),
+		Status:        "pending",
+		NextAttemptAt: now.Add(webhookClaimLease),
+	}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	ok := s.attempt(webhook, &delivery)
+	delivery.Status = "delivered"
+	if !ok {
+		delivery.Status = "dead"
+	}
+	if err := s.recordAttempt(&delivery, nil); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&webhook).Update("last_delivery_at", time.Now()).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return &delivery, nil
+}
+
+// deliver 1件送り、結果に応じて状態と次回の送信時刻を記録する
+func (s *WebhookService) deliver(webhook models.TeamWebhook, d *models.WebhookDelivery) (string, error) {
+	ok := s.attempt(webhook, d)
+	now := time.Now()
+	webhookUpdates := map[string]interface{}{"last_delivery_at": now}
+
+	var nextAttemptAt *time.Time
+	switch {
+	case ok:
+		d.Status = "delivered"
+		if webhook.FailureCount > 0 {
+			webhookUpdates["failure_count"] = 0
+		}
+	case d.Attempts < webhookMaxAttempts():
+		log.Printf("[DispatchDeliveries] webhook %s delivery %s failed (attempt %d): %s", webhook.ID, d.ID, d.Attempts, d.LastError)
+		d.Status = "pending"
+		next := now.Add(webhookRetryDelay(d.Attempts))
+		nextAttemptAt = &next
+	default:
+		log.Printf("[DispatchDeliveries] webhook %s delivery %s failed (attempt %d): %s", webhook.ID, d.ID, d.Attempts, d.LastError)
+		d.Status = "dead"
+		// 届かない状態が続くWebhookは止める
+		webhookUpdates["failure_count"] = webhook.FailureCount + 1
+		if webhook.FailureCount+1 >= webhookDisableAfter {
+			webhookUpdates["active"] = false
+		}
+	}
+
+	if err := s.recordAttempt(d, nextAttemptAt); err != nil {
+		return "", err
+	}
+	if err := s.db.Model(&webhook).Updates(webhookUpdates).Error; err != nil {
+		return "", fmt.Errorf("failed to update webhook %s: %w", webhook.ID, err)
+	}
+	return d.Status, nil
+}
+
+// recordAttempt 送信の結果と配信の状態を1つの更新で記録する。nextAttemptAt があれば再送の時刻にする
+func (s *WebhookService) recordAttempt(d *models.WebhookDelivery, nextAttemptAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"response_status": d.ResponseStatus,
+		"response_body":   d.ResponseBody,
+		"last_error":      d.LastError,
+		"duration_ms":     d.DurationMS,
+		"delivered_at":    d.DeliveredAt,
+	}
+	if nextAttemptAt != nil {
+		d.NextAttemptAt = *nextAttemptAt
+		updates["next_attempt_at"] = *nextAttemptAt
+	}
+	if err := s.db.Model(d).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record webhook delivery %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+// attempt 署名を付けてPOSTし、試行回数と応答を d に書き込む。2xxが返れば true、失敗の理由は d.LastError に残る
+func (s *WebhookService) attempt(webhook models.TeamWebhook, d *models.WebhookDelivery) bool {
+	started := time.Now()
+	timestamp := strconv.FormatInt(started.Unix(), 10)
+	body := []byte(d.Payload)
+
+	sendErr := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "trihackathon-webhooks/1.0")
+		req.Header.Set(WebhookIDHeader, webhook.ID)
+		req.Header.Set(WebhookEventHeader, d.Event)
+		req.Header.Set(WebhookDeliveryHeader, d.ID)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, body))
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+		d.ResponseStatus = resp.StatusCode
+		d.ResponseBody = string(respBody)
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}()
+
+	now := time.Now()
+	d.Attempts++
+	d.DurationMS = int(now.Sub(started).Milliseconds())
+	d.LastError = ""
+	if sendErr != nil {
+		d.LastError = sendErr.Error()
+	} else {
+		d.DeliveredAt = &now
+	}
+	return sendErr == nil
+}
+
+// webhookRetryDelay attempts 回失敗した後、次に送るまでの待ち時間
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay
+}
+
+// EmitActivityCompleted アクティビティの完了を activity.completed として送る。チームに紐づかないアクティビティは送らない
+func EmitActivityCompleted(tx *gorm.DB, activity models.Activity) error {
+	if activity.TeamID == nil {
+		return nil
+	}
+	var user models.User
+	tx.Select("name").First(&user, "id = ?", activity.UserID)
+	data := map[string]interface{}{
+		"activity_id":   activity.ID,
+		"user_id":       activity.UserID,
+		"user_name":     user.Name,
+		"exercise_type": activity.ExerciseType,
+		"started_at":    activity.StartedAt.Format(time.RFC3339),
+		"duration_min":  activity.DurationMin,
+	}
+	if activity.EndedAt != nil {
+		data["ended_at"] = activity.EndedAt.Format(time.RFC3339)
+	}
+	if activity.ExerciseType == "running" {
+		data["distance_km"] = activity.DistanceKM
+	}
+	return EmitTeamEvent(tx, *activity.TeamID, "activity.completed", data)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/trihackathon/api/models"
+	"github.com/trihackathon/api/utils"
+	"gorm.io/gorm"
+)
+
+// StartPolicies チャレンジ開始と週の区切りの決め方
+// midnight: 開始操作の翌日0時から7日ごと / monday: 翌日0時に始まり、週は月曜0時で区切る（最初の週は日数に応じて目標を按分）
+var StartPolicies = []string{"midnight", "monday"}
+
+// ValidStartPolicy start_policy の値が有効か
+func ValidStartPolicy(policy string) bool {
+	for _, p := range StartPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamLocation 週の区切りや日付の集計に使うチームのタイムゾーン
+func TeamLocation(team models.Team) *time.Location {
+	return utils.LoadLocation(team.Timezone)
+}
+
+// UserTimezone ユーザーのタイムゾーン名。未登録・不正な値は DefaultTimezone
+func UserTimezone(tx *gorm.DB, userID string) string {
+	var user models.User
+	if err := tx.Select("timezone").First(&user, "id = ?", userID).Error; err != nil || !utils.ValidTimezone(user.Timezone) {
+		return utils.DefaultTimezone
+	}
+	return user.Timezone
+}
+
+// ChallengeStartAt now にチャレンジを開始したときの第1週の開始日時（翌日0時）
+func ChallengeStartAt(team models.Team, now time.Time) time.Time {
+	local := now.In(TeamLocation(team))
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location())
+}
+
+// WeekWindow チームの week 週目の評価期間 [start, end) を返す。開始前のチームは ok=false
+func WeekWindow(team models.Team, week int) (start, end time.Time, ok bool) {
+	if team.StartedAt == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	if week < 1 {
+		week = 1
+	}
+	startedAt := team.StartedAt.In(TeamLocation(team))
+
+	if team.StartPolicy != "monday" {
+		start = startedAt.AddDate(0, 0, (week-1)*7)
+		return start, start.AddDate(0, 0, 7), true
+	}
+
+	// 最初の月曜0時で第1週を区切り、以降は月曜から7日ごと
+	firstEnd := time.Date(startedAt.Year(), startedAt.Month(), startedAt.Day()+1, 0, 0, 0, 0, startedAt.Location())
+	for firstEnd.Weekday() != time.Monday {
+		firstEnd = firstEnd.AddDate(0, 0, 1)
+	}
+	if week == 1 {
+		return startedAt, firstEnd, true
+	}
+	start = firstEnd.AddDate(0, 0, (week-2)*7)
+	return start, start.AddDate(0, 0, 7), true
+}
+
+// TargetProration week 週目の目標に掛ける按分率。週の途中から参加したメンバーや短い第1週は、参加できる日数 / 7 になる
+func TargetProration(team models.Team, joinedAt time.Time, week int) float64 {
+	start, end, ok := WeekWindow(team, week)
+	if !ok {
+		return 1
+	}
+	if joinedAt.After(start) {
+		start = joinedAt
+	}
+	// チームの暦で数え、途中から始まる日も1日とする。最低でも1日分の目標は課す
+	loc := TeamLocation(team)
+	days := float64(utils.DaysBetween(start, end, loc))
+	if !end.Equal(utils.StartOfDay(end, loc)) {
+		days++
+	}
+	if days < 1 {
+		days = 1
+	}
+	if days >= 7 {
+		return 1
+	}
+	return math.Round(days/7*100) / 100
+}
+
+// DaysRemaining week 週目の残り日数（今日を含む）。チームの暦で数え、週が終わっていれば0
+func DaysRemaining(team models.Team, week int, now time.Time) int {
+	_, end, ok := WeekWindow(team, week)
+	if !ok || !now.Before(end) {
+		return 0
+	}
+	loc := TeamLocation(team)
+	days := utils.DaysBetween(now, end, loc)
+	if !end.Equal(utils.StartOfDay(end, loc)) {
+		days++
+	}
+	return days
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // テスト環境にタイムゾーンデータがなくても読み込めるようにする
+
+	"github.com/trihackathon/api/models"
+)
+
+const newYork = "America/New_York"
+
+// nyTime America/New_York の暦での日時
+func nyTime(t *testing.T, year int, month time.Month, day, hour, min int) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation(newYork)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", newYork, err)
+	}
+	return time.Date(year, month, day, hour, min, 0, 0, loc)
+}
+
+// startedTeam startedAt に開始したチーム
+func startedTeam(timezone, policy string, startedAt time.Time) models.Team {
+	return models.Team{Timezone: timezone, StartPolicy: policy, StartedAt: &startedAt}
+}
+
+func TestChallengeStartAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		now      time.Time
+		want     time.Time
+	}{
+		{
+			// 2026-03-08 は夏時間が始まり23時間の日。翌日0時（EST）から始める
+			name:     "day before dst start",
+			timezone: newYork,
+			now:      nyTime(t, 2026, 3, 7, 10, 0),
+			want:     time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "late on dst start day",
+			timezone: newYork,
+			now:      nyTime(t, 2026, 3, 8, 23, 30),
+			want:     time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			// 2026-11-01 は夏時間が終わり25時間の日
+			name:     "repeated hour at dst end",
+			timezone: newYork,
+			now:      time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), // 1:30 EST（2回目）
+			want:     time.Date(2026, 11, 2, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			// 不正なタイムゾーンは Asia/Tokyo の暦で扱う
+			name:     "invalid timezone falls back to tokyo",
+			timezone: "Not/A_Zone",
+			now:      time.Date(2026, 3, 7, 20, 0, 0, 0, time.UTC), // 3/8 5:00 JST
+			want:     time.Date(2026, 3, 8, 15, 0, 0, 0, time.UTC), // 3/9 0:00 JST
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChallengeStartAt(models.Team{Timezone: tt.timezone}, tt.now)
+			if !got.Equal(tt.want) {
+				t.Errorf("ChallengeStartAt() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWeekWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		team       models.Team
+		week       int
+		start, end time.Time
+		wantHours  float64
+	}{
+		{
+			name:      "midnight week containing the 23 hour day",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0)),
+			week:      1,
+			start:     nyTime(t, 2026, 3, 5, 0, 0),
+			end:       nyTime(t, 2026, 3, 12, 0, 0),
+			wantHours: 7*24 - 1,
+		},
+		{
+			name:      "midnight week after dst start",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0)),
+			week:      2,
+			start:     nyTime(t, 2026, 3, 12, 0, 0),
+			end:       nyTime(t, 2026, 3, 19, 0, 0),
+			wantHours: 7 * 24,
+		},
+		{
+			name:      "midnight week containing the 25 hour day",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 10, 29, 0, 0)),
+			week:      1,
+			start:     nyTime(t, 2026, 10, 29, 0, 0),
+			end:       nyTime(t, 2026, 11, 5, 0, 0),
+			wantHours: 7*24 + 1,
+		},
+		{
+			// 木曜に始めると第1週は月曜0時までの4日間（23時間の日を含む）
+			name:      "monday short first week across dst start",
+			team:      startedTeam(newYork, "monday", nyTime(t, 2026, 3, 5, 0, 0)),
+			week:      1,
+			start:     nyTime(t, 2026, 3, 5, 0, 0),
+			end:       nyTime(t, 2026, 3, 9, 0, 0),
+			wantHours: 4*24 - 1,
+		},
+		{
+			name:      "monday second week starts on monday",
+			team:      startedTeam(newYork, "monday", nyTime(t, 2026, 3, 5, 0, 0)),
+			week:      2,
+			start:     nyTime(t, 2026, 3, 9, 0, 0),
+			end:       nyTime(t, 2026, 3, 16, 0, 0),
+			wantHours: 7 * 24,
+		},
+		{
+			name:      "monday short first week across dst end",
+			team:      startedTeam(newYork, "monday", nyTime(t, 2026, 10, 29, 0, 0)),
+			week:      1,
+			start:     nyTime(t, 2026, 10, 29, 0, 0),
+			end:       nyTime(t, 2026, 11, 2, 0, 0),
+			wantHours: 4*24 + 1,
+		},
+		{
+			// 月曜に始めたときは第1週も7日間
+			name:      "monday start on a monday",
+			team:      startedTeam(newYork, "monday", nyTime(t, 2026, 3, 2, 0, 0)),
+			week:      1,
+			start:     nyTime(t, 2026, 3, 2, 0, 0),
+			end:       nyTime(t, 2026, 3, 9, 0, 0),
+			wantHours: 7*24 - 1,
+		},
+		{
+			// 不正なタイムゾーンは Asia/Tokyo の月曜0時で区切る
+			name:      "invalid timezone falls back to tokyo",
+			team:      startedTeam("Not/A_Zone", "monday", time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)), // 3/3(火) 0:00 JST
+			week:      1,
+			start:     time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 3, 8, 15, 0, 0, 0, time.UTC), // 3/9(月) 0:00 JST
+			wantHours: 6 * 24,
+		},
+		{
+			name:      "week below 1 is the first week",
+			team:      startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0)),
+			week:      0,
+			start:     nyTime(t, 2026, 3, 5, 0, 0),
+			end:       nyTime(t, 2026, 3, 12, 0, 0),
+			wantHours: 7*24 - 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := WeekWindow(tt.team, tt.week)
+			if !ok {
+				t.Fatal("WeekWindow() ok = false, want true")
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("WeekWindow() = [%v, %v), want [%v, %v)", start, end, tt.start, tt.end)
+			}
+			if h := end.Sub(start).Hours(); h != tt.wantHours {
+				t.Errorf("window length = %vh, want %vh", h, tt.wantHours)
+			}
+		})
+	}
+
+	if _, _, ok := WeekWindow(models.Team{Timezone: newYork}, 1); ok {
+		t.Error("WeekWindow() ok = true for a team that has not started")
+	}
+}
+
+func TestTargetProration(t *testing.T) {
+	tests := []struct {
+		name     string
+		team     models.Team
+		joinedAt time.Time
+		week     int
+		want     float64
+	}{
+		{
+			// 23時間の日を含んでも7日間は按分しない
+			name:     "midnight week across dst start",
+			team:     startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0)),
+			joinedAt: nyTime(t, 2026, 3, 1, 9, 0),
+			week:     1,
+			want:     1,
+		},
+		{
+			name:     "midnight week across dst end",
+			team:     startedTeam(newYork, "midnight", nyTime(t, 2026, 10, 29, 0, 0)),
+			joinedAt: nyTime(t, 2026, 10, 20, 9, 0),
+			week:     1,
+			want:     1,
+		},
+		{
+			name:     "monday short first week across dst start",
+			team:     startedTeam(newYork, "monday", nyTime(t, 2026, 3, 5, 0, 0)),
+			joinedAt: nyTime(t, 2026, 3, 1, 9, 0),
+			week:     1,
+			want:     0.57,
+		},
+		{
+			name:     "monday short first week across dst end",
+			team:     startedTeam(newYork, "monday", nyTime(t, 2026, 10, 29, 0, 0)),
+			joinedAt: nyTime(t, 2026, 10, 20, 9, 0),
+			week:     1,
+			want:     0.57,
+		},
+		{
+			name:     "monday full second week",
+			team:     startedTeam(newYork, "monday", nyTime(t, 2026, 3, 5, 0, 0)),
+			joinedAt: nyTime(t, 2026, 3, 1, 9, 0),
+			week:     2,
+			want:     1,
+		},
+		{
+			// 参加した日も1日と数える（水〜日の5日間）
+			name:     "joined mid week",
+			team:     startedTeam(newYork, "monday", nyTime(t, 2026, 3, 5, 0, 0)),
+			joinedAt: nyTime(t, 2026, 3, 11, 15, 0),
+			week:     2,
+			want:     0.71,
+		},
+		{
+			name:     "joined on the 23 hour day",
+			team:     startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0)),
+			joinedAt: nyTime(t, 2026, 3, 8, 12, 0),
+			week:     1,
+			want:     0.57,
+		},
+		{
+			name:     "joined on the 25 hour day",
+			team:     startedTeam(newYork, "midnight", nyTime(t, 2026, 10, 29, 0, 0)),
+			joinedAt: time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), // 1:30 EST（2回目）
+			week:     1,
+			want:     0.57,
+		},
+		{
+			// 最終日の途中から参加しても最低1日分
+			name:     "joined on the last day",
+			team:     startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0)),
+			joinedAt: nyTime(t, 2026, 3, 11, 23, 0),
+			week:     1,
+			want:     0.14,
+		},
+		{
+			name:     "not started",
+			team:     models.Team{Timezone: newYork, StartPolicy: "monday"},
+			joinedAt: nyTime(t, 2026, 3, 5, 0, 0),
+			week:     1,
+			want:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TargetProration(tt.team, tt.joinedAt, tt.week); got != tt.want {
+				t.Errorf("TargetProration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysRemaining(t *testing.T) {
+	marchTeam := startedTeam(newYork, "midnight", nyTime(t, 2026, 3, 5, 0, 0))
+	novemberTeam := startedTeam(newYork, "midnight", nyTime(t, 2026, 10, 29, 0, 0))
+	tests := []struct {
+		name string
+		team models.Team
+		week int
+		now  time.Time
+		want int
+	}{
+		{"first day", marchTeam, 1, nyTime(t, 2026, 3, 5, 0, 0), 7},
+		{"on the 23 hour day", marchTeam, 1, nyTime(t, 2026, 3, 8, 12, 0), 4},
+		{"just before dst start", marchTeam, 1, nyTime(t, 2026, 3, 8, 1, 59), 4},
+		{"last minute of the week", marchTeam, 1, nyTime(t, 2026, 3, 11, 23, 59), 1},
+		{"week ended", marchTeam, 1, nyTime(t, 2026, 3, 12, 0, 0), 0},
+		{"week not reached yet", marchTeam, 2, nyTime(t, 2026, 3, 8, 12, 0), 11},
+		{"first pass of the repeated hour", novemberTeam, 1, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), 4},
+		{"second pass of the repeated hour", novemberTeam, 1, time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), 4},
+		{"monday short first week", startedTeam(newYork, "monday", nyTime(t, 2026, 10, 29, 0, 0)), 1, nyTime(t, 2026, 10, 31, 8, 0), 2},
+		{"not started", models.Team{Timezone: newYork}, 1, nyTime(t, 2026, 3, 8, 12, 0), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysRemaining(tt.team, tt.week, tt.now); got != tt.want {
+				t.Errorf("DaysRemaining() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QRコード生成（バイトモード・誤り訂正レベルM・バージョン1〜10）
+// 招待リンク程度の短い文字列を外部ライブラリなしで画像化するための最小実装
+
+var ErrQRDataTooLong = errors.New("qr: data too long")
+
+// QRCode 生成済みQRコードのモジュール配列
+type QRCode struct {
+	Size    int
+	modules [][]bool
+}
+
+// qrVersionM 誤り訂正レベルMのブロック構成
+type qrVersionM struct {
+	ecPerBlock int
+	blocks     []int // 各ブロックのデータコード語数
+	alignment  []int
+}
+
+var qrVersionsM = []qrVersionM{
+	{},
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// EncodeQR 文字列をQRコードに符号化する
+func EncodeQR(text string) (*QRCode, error) {
+	data := []byte(text)
+
+	version := 0
+	for v := 1; v < len(qrVersionsM); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		capacity := 0
+		for _, n := range qrVersionsM[v].blocks {
+			capacity += n
+		}
+		if 4+countBits+len(data)*8 <= capacity*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrQRDataTooLong
+	}
+	spec := qrVersionsM[version]
+
+	codewords := qrDataCodewords(data, version, spec)
+	codewords = qrInterleave(codewords, spec)
+
+	q := newQRMatrix(version)
+	q.drawFunctionPatterns(spec.alignment)
+	q.drawCodewords(codewords)
+
+	// ペナルティが最小のマスクを選ぶ
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			bestMask, bestPenalty = mask, p
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(bestMask)
+	q.drawFormatBits(bestMask)
+
+	return &QRCode{Size: q.size, modules: q.modules}, nil
+}
+
+// Dark 指定座標のモジュールが暗か
+func (c *QRCode) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// PNG 1モジュールあたり scale ピクセルのPNG画像を返す（周囲4モジュールの余白付き）
+func (c *QRCode) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	const border = 4
+	dim := (c.Size + border*2) * scale
+	img := image.NewGray(image.Rect(0, 0, dim, dim))
+	for py := 0; py < dim; py++ {
+		for px := 0; px < dim; px++ {
+			v := color.Gray{Y: 0xFF}
+			if c.Dark(px/scale-border, py/scale-border) {
+				v = color.Gray{Y: 0x00}
+			}
+			img.SetGray(px, py, v)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG 1モジュールあたり scale 単位のSVG画像を返す（周囲4モジュールの余白付き）
+func (c *QRCode) SVG(scale int) string {
+	if scale < 1 {
+		scale = 1
+	}
+	const border = 4
+	dim := c.Size + border*2
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+border, y+border)
+			}
+		}
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" width="%d" height="%d" stroke="none">
+<rect width="100%%" height="100%%" fill="#FFFFFF"/>
+<path d="%s" fill="#000000"/>
+</svg>
+`, dim, dim, dim*scale, dim*scale, path.String())
+}
+
+// qrDataCodewords モード指示子・文字数・データ・パディングをコード語列にする
+func qrDataCodewords(data []byte, version int, spec qrVersionM) []byte {
+	capacity := 0
+	for _, n := range spec.blocks {
+		capacity += n
+	}
+
+	var bits []bool
+	appendBits := func(val uint32, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (val>>uint(i))&1 == 1)
+		}
+	}
+	appendBits(0x4, 4) // バイトモード
+	if version >= 10 {
+		appendBits(uint32(len(data)), 16)
+	} else {
+		appendBits(uint32(len(data)), 8)
+	}
+	for _, b := range data {
+		appendBits(uint32(b), 8)
+	}
+
+	// 終端パターンとバイト境界までのパディング
+	for i := 0; i < 4 && len(bits) < capacity*8; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	result := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << uint(7-j)
+			}
+		}
+		result = append(result, b)
+	}
+	for pad := byte(0xEC); len(result) < capacity; pad ^= 0xEC ^ 0x11 {
+		result = append(result, pad)
+	}
+	return result
+}
+
+// qrInterleave ブロックごとに誤り訂正コード語を付与してインターリーブする
+func qrInterleave(data []byte, spec qrVersionM) []byte {
+	divisor := qrRSDivisor(spec.ecPerBlock)
+
+	dataBlocks := make([][]byte, len(spec.blocks))
+	ecBlocks := make([][]byte, len(spec.blocks))
+	offset, maxLen := 0, 0
+	for i, n := range spec.blocks {
+		dataBlocks[i] = data[offset : offset+n]
+		ecBlocks[i] = qrRSRemainder(dataBlocks[i], divisor)
+		offset += n
+		if n > maxLen {
+			maxLen = n
+		}
+	}
+
+	var result []byte
+	for i := 0; i < maxLen; i++ {
+		for _, blk := range dataBlocks {
+			if i < len(blk) {
+				result = append(result, blk[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ecPerBlock; i++ {
+		for _, blk := range ecBlocks {
+			result = append(result, blk[i])
+		}
+	}
+	return result
+}
+
+func qrGFMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z <<= 1
+		if carry == 1 {
+			z ^= 0x1D
+		}
+		if (y>>uint(i))&1 == 1 {
+			z ^= x
+		}
+	}
+	return z
+}
+
+func qrRSDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrGFMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMul(root, 0x02)
+	}
+	return result
+}
+
+func qrRSRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= qrGFMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+type qrMatrix struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := version*4 + 17
+	q := &qrMatrix{version: version, size: size}
+	q.modules = make([][]bool, size)
+	q.isFunction = make([][]bool, size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.isFunction[i] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *qrMatrix) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *qrMatrix) drawFunctionPatterns(alignment []int) {
+	// タイミングパターン
+	for i := 0; i < q.size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	// 位置検出パターン（分離パターン込み）
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= q.size || y >= q.size {
+					continue
+				}
+				dist := qrMax(qrAbs(dx), qrAbs(dy))
+				q.setFunction(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	// 位置合わせパターン
+	last := len(alignment) - 1
+	for i, cx := range alignment {
+		for j, cy := range alignment {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.setFunction(cx+dx, cy+dy, qrMax(qrAbs(dx), qrAbs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// 形式情報の領域を確保
+	q.drawFormatBits(0)
+
+	// 型番情報（バージョン7以上）
+	if q.version >= 7 {
+		rem := q.version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := q.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 == 1
+			a, b := q.size-11+i%3, i/3
+			q.setFunction(a, b, dark)
+			q.setFunction(b, a, dark)
+		}
+	}
+}
+
+// drawFormatBits 誤り訂正レベルM（00）とマスク番号から形式情報を書き込む
+func (q *qrMatrix) drawFormatBits(mask int) {
+	data := mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>uint(i))&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	q.setFunction(8, q.size-8, true)
+}
+
+func (q *qrMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.isFunction[y][x] && i < len(data)*8 {
+					q.modules[y][x] = (data[i>>3]>>uint(7-(i&7)))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *qrMatrix) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty JIS X 0510 のマスク評価（連続・2x2ブロック・ファインダー類似・明暗比率）
+func (q *qrMatrix) penalty() int {
+	result := 0
+	get := func(x, y int, horizontal bool) bool {
+		if horizontal {
+			return q.modules[y][x]
+		}
+		return q.modules[x][y]
+	}
+
+	for _, horizontal := range []bool{true, false} {
+		for a := 0; a < q.size; a++ {
+			run := 1
+			for b := 1; b < q.size; b++ {
+				if get(b, a, horizontal) == get(b-1, a, horizontal) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				result += run - 2
+			}
+
+			// 1:1:3:1:1 パターンの片側に4モジュールの明領域
+			for b := 0; b+11 <= q.size; b++ {
+				pattern := [11]bool{}
+				for k := 0; k < 11; k++ {
+					pattern[k] = get(b+k, a, horizontal)
+				}
+				if pattern == [11]bool{true, false, true, true, true, false, true, false, false, false, false} ||
+					pattern == [11]bool{false, false, false, false, true, false, true, true, true, false, true} {
+					result += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (qrAbs(dark*20-total*10)+total-1)/total - 1
+	if k > 0 {
+		result += k * 10
+	}
+	return result
+}
+
+func qrAbs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func qrMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTimezone タイムゾーン未設定のユーザー・チームに使うIANAタイムゾーン
+const DefaultTimezone = "Asia/Tokyo"
+
+var locationCache sync.Map
+
+// ValidTimezone IANAタイムゾーン名として読み込めるか。"Local" のようなサーバー依存の値は受け付けない
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// LoadLocation IANAタイムゾーン名から *time.Location を返す。不正な値は DefaultTimezone として扱う
+func LoadLocation(name string) *time.Location {
+	if !ValidTimezone(name) {
+		name = DefaultTimezone
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	locationCache.Store(name, loc)
+	return loc
+}
+
+// StartOfDay loc での t の日付の0時
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// DaysBetween loc の暦で from の日付から to の日付まで何日あるか。夏時間で1日が23・25時間でも1日と数える
+func DaysBetween(from, to time.Time, loc *time.Location) int {
+	f := from.In(loc)
+	t := to.In(loc)
+	fromDate := time.Date(f.Year(), f.Month(), f.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
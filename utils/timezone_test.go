@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // テスト環境にタイムゾーンデータがなくても読み込めるようにする
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestValidTimezone(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"America/New_York", true},
+		{"Asia/Tokyo", true},
+		{"UTC", true},
+		{"", false},
+		{"Local", false},
+		{"Mars/Olympus_Mons", false},
+		{"america/new_york", false},
+	}
+	for _, tt := range tests {
+		if got := ValidTimezone(tt.name); got != tt.want {
+			t.Errorf("ValidTimezone(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"America/New_York", "America/New_York"},
+		{"Europe/London", "Europe/London"},
+		// 不正な値・サーバー依存の値は既定のタイムゾーンにする
+		{"", DefaultTimezone},
+		{"Local", DefaultTimezone},
+		{"Mars/Olympus_Mons", DefaultTimezone},
+		{"+09:00", DefaultTimezone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := LoadLocation(tt.name)
+			if loc.String() != tt.want {
+				t.Errorf("LoadLocation(%q) = %s, want %s", tt.name, loc, tt.want)
+			}
+			// 2回目はキャッシュから同じ値を返す
+			if again := LoadLocation(tt.name); again != loc {
+				t.Errorf("LoadLocation(%q) returned a different location on the second call", tt.name)
+			}
+		})
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{
+			// 2026-03-08 は 2時に夏時間が始まる。0時はまだ EST
+			name: "dst start day",
+			t:    time.Date(2026, 3, 8, 15, 0, 0, 0, ny),
+			want: time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day after dst start",
+			t:    time.Date(2026, 3, 9, 0, 30, 0, 0, ny),
+			want: time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			// 2026-11-01 は 2時に夏時間が終わる。0時はまだ EDT
+			name: "dst end day",
+			t:    time.Date(2026, 11, 1, 23, 0, 0, 0, ny),
+			want: time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "utc instant on the previous local date",
+			t:    time.Date(2026, 11, 2, 3, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartOfDay(tt.t, ny); !got.Equal(tt.want) {
+				t.Errorf("StartOfDay() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	tests := []struct {
+		name     string
+		from, to time.Time
+		loc      *time.Location
+		want     int
+	}{
+		{
+			name: "23 hour day at dst start",
+			from: time.Date(2026, 3, 8, 0, 0, 0, 0, ny),
+			to:   time.Date(2026, 3, 9, 0, 0, 0, 0, ny),
+			loc:  ny,
+			want: 1,
+		},
+		{
+			name: "25 hour day at dst end",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+			to:   time.Date(2026, 11, 2, 0, 0, 0, 0, ny),
+			loc:  ny,
+			want: 1,
+		},
+		{
+			name: "two weeks across dst start",
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, ny),
+			to:   time.Date(2026, 3, 15, 0, 0, 0, 0, ny),
+			loc:  ny,
+			want: 14,
+		},
+		{
+			name: "two weeks across dst end",
+			from: time.Date(2026, 10, 25, 0, 0, 0, 0, ny),
+			to:   time.Date(2026, 11, 8, 0, 0, 0, 0, ny),
+			loc:  ny,
+			want: 14,
+		},
+		{
+			name: "less than an hour across midnight",
+			from: time.Date(2026, 3, 7, 23, 30, 0, 0, ny),
+			to:   time.Date(2026, 3, 8, 0, 15, 0, 0, ny),
+			loc:  ny,
+			want: 1,
+		},
+		{
+			name: "repeated hour at dst end is the same day",
+			from: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), // 1:30 EDT
+			to:   time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), // 1:30 EST
+			loc:  ny,
+			want: 0,
+		},
+		{
+			name: "counted on the calendar of loc",
+			from: time.Date(2026, 3, 7, 14, 0, 0, 0, time.UTC), // 23:00 JST
+			to:   time.Date(2026, 3, 7, 16, 0, 0, 0, time.UTC), // 翌日 1:00 JST
+			loc:  tokyo,
+			want: 1,
+		},
+		{
+			name: "backwards",
+			from: time.Date(2026, 11, 3, 0, 0, 0, 0, ny),
+			to:   time.Date(2026, 10, 31, 0, 0, 0, 0, ny),
+			loc:  ny,
+			want: -3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysBetween(tt.from, tt.to, tt.loc); got != tt.want {
+				t.Errorf("DaysBetween() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -26,16 +26,27 @@ func GenerateULIDWithEntropy() string {
 }
 
 // GenerateInviteCode 6桁英数大文字のランダムコードを生成する
+// 剰余による偏りを避けるため、36の倍数（252）以上のバイトは捨てて引き直す
 func GenerateInviteCode() string {
 	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, 6)
-	if _, err := rand.Read(b); err != nil {
-		panic(err)
+	const limit = 256 - 256%len(chars)
+	code := make([]byte, 0, 6)
+	buf := make([]byte, 16)
+	for len(code) < 6 {
+		if _, err := rand.Read(buf); err != nil {
+			panic(err)
+		}
+		for _, v := range buf {
+			if int(v) >= limit {
+				continue
+			}
+			code = append(code, chars[int(v)%len(chars)])
+			if len(code) == 6 {
+				break
+			}
+		}
 	}
-	for i := range b {
-		b[i] = chars[int(b[i])%len(chars)]
-	}
-	return string(b)
+	return string(code)
 }
 
 // CalculateAge 生年月日と現在時刻から年齢を計算する